@@ -21,7 +21,9 @@ import (
 	"fmt"
 
 	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/history"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/command/writer"
 	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
 	"github.com/megaease/easemeshctl/cmd/client/util"
 	"github.com/megaease/easemeshctl/cmd/common"
@@ -50,6 +52,11 @@ func Run(cmd *cobra.Command, flag *flags.Apply) {
 		common.ExitWithErrorf("build visitor failed: %v", err)
 	}
 
+	w, err := writer.New(flag.AdminGlobal, flag.AdminHistory, flag.AdminAudit, flag.AdminPolicy)
+	if err != nil {
+		common.ExitWithError(err)
+	}
+
 	var errs []error
 	for _, vs := range vss {
 		err := vs.Visit(func(mo meta.MeshObject, e error) error {
@@ -57,9 +64,11 @@ func Run(cmd *cobra.Command, flag *flags.Apply) {
 				return errors.Wrap(e, "visit failed")
 			}
 
-			err := WrapApplierByMeshObject(mo, meshclient.New(flag.Server), flag.Timeout).Apply()
+			err := w.Write(history.ActionApply, mo, mo, func(client meshclient.MeshClient, _ meta.MeshObject) error {
+				return WrapApplierByMeshObject(mo, client, flag.Timeout).Apply()
+			})
 			if err != nil {
-				return fmt.Errorf("%s/%s applied failed: %s", mo.Kind(), mo.Name(), err)
+				return err
 			}
 
 			fmt.Printf("%s/%s applied successfully\n", mo.Kind(), mo.Name())
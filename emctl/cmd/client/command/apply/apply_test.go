@@ -67,6 +67,35 @@ func TestRunFail(t *testing.T) {
 	Run(cmd, flag)
 }
 
+func TestRunUnknownPrevious(t *testing.T) {
+	exitCode := 0
+	patch := monkey.Patch(os.Exit, func(code int) {
+		if code != 0 {
+			exitCode = code
+		}
+	})
+	defer patch.Unpatch()
+
+	flag := meshtesting.PrepareApplyFlags("__test_apply_unknown_previous_reactor", tenantSpec, t)
+
+	mutated := false
+	fake.NewResourceReactorBuilder(flag.Server).
+		AddReactor("get", "*", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, nil, errors.Errorf("mock an error")
+		}).
+		AddReactor("*", "*", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			mutated = true
+			return true, nil, nil
+		}).
+		Added()
+
+	cmd := &cobra.Command{}
+	Run(cmd, flag)
+	if mutated || exitCode == 0 {
+		t.Fatalf("apply should fail without mutation if the previous version is unknown")
+	}
+}
+
 var tenantSpec = `
 kind: Tenant
 apiVersion: mesh.megaease.com/v2alpha1
@@ -0,0 +1,221 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	"github.com/megaease/easemeshctl/cmd/common"
+	"github.com/megaease/easemeshctl/cmd/common/client"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	auditLogFileName = ".emctl/audit.jsonl"
+
+	// ResultSuccess is the result of a successful operation
+	ResultSuccess = "success"
+	// ResultFailure is the result of a failed operation
+	ResultFailure = "failure"
+
+	webhookTimeout = 5 * time.Second
+)
+
+type (
+	// Event is an audit event of a mutating operation issued by emctl
+	Event struct {
+		Timestamp  time.Time `json:"timestamp"`
+		User       string    `json:"user"`
+		Host       string    `json:"host"`
+		Server     string    `json:"server"`
+		Kind       string    `json:"kind"`
+		Name       string    `json:"name"`
+		Action     string    `json:"action"`
+		BeforeHash string    `json:"beforeHash,omitempty"`
+		AfterHash  string    `json:"afterHash,omitempty"`
+		Result     string    `json:"result"`
+		Error      string    `json:"error,omitempty"`
+	}
+
+	// Sink is the destination of audit events
+	Sink interface {
+		Write(event *Event) error
+	}
+
+	// Auditor records audit events to all its sinks
+	Auditor interface {
+		Record(action string, object, before, after meta.MeshObject, err error)
+	}
+
+	auditor struct {
+		server string
+		user   string
+		host   string
+		sinks  []Sink
+	}
+
+	fileSink struct {
+		mutex sync.Mutex
+		path  string
+	}
+
+	webhookSink struct {
+		url string
+	}
+)
+
+// New creates an Auditor writing events to the local JSONL file and the optional webhook
+func New(flag *flags.AdminAudit, server string) Auditor {
+	a := &auditor{
+		server: server,
+		user:   currentUser(),
+	}
+	a.host, _ = os.Hostname()
+
+	logFile := flag.AuditLogFile
+	if logFile == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			common.OutputErrorf("ignored: get user home dir failed: %v", err)
+		} else {
+			logFile = filepath.Join(homeDir, auditLogFileName)
+		}
+	}
+	if logFile != "" {
+		a.sinks = append(a.sinks, NewFileSink(logFile))
+	}
+
+	webhook := flag.AuditWebhook
+	if webhook == "" {
+		webhook = flags.GetAuditWebhook()
+	}
+	if webhook != "" {
+		a.sinks = append(a.sinks, NewWebhookSink(webhook))
+	}
+
+	return a
+}
+
+// NewFileSink creates a Sink appending events to a JSONL file
+func NewFileSink(path string) Sink {
+	return &fileSink{path: path}
+}
+
+// NewWebhookSink creates a Sink posting events to a webhook
+func NewWebhookSink(url string) Sink {
+	return &webhookSink{url: url}
+}
+
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return "unknown"
+	}
+	return u.Username
+}
+
+// Hash returns the hash of the content of the object, it returns empty for a nil object.
+func Hash(object meta.MeshObject) string {
+	if object == nil {
+		return ""
+	}
+
+	buff, err := yaml.Marshal(object)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(buff)
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *auditor) Record(action string, object, before, after meta.MeshObject, err error) {
+	event := &Event{
+		Timestamp:  time.Now(),
+		User:       a.user,
+		Host:       a.host,
+		Server:     a.server,
+		Kind:       object.Kind(),
+		Name:       object.Name(),
+		Action:     action,
+		BeforeHash: Hash(before),
+		AfterHash:  Hash(after),
+		Result:     ResultSuccess,
+	}
+	if err != nil {
+		event.Result = ResultFailure
+		event.Error = err.Error()
+	}
+
+	for _, sink := range a.sinks {
+		err := sink.Write(event)
+		if err != nil {
+			common.OutputErrorf("ignored: write audit event of %s/%s failed: %v", event.Kind, event.Name, err)
+		}
+	}
+}
+
+func (f *fileSink) Write(event *Event) error {
+	buff, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "marshal %+v to json failed", event)
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	err = os.MkdirAll(filepath.Dir(f.path), 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "create dir of %s failed", f.path)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "open file %s failed", f.path)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(buff, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "write file %s failed", f.path)
+	}
+
+	return nil
+}
+
+func (w *webhookSink) Write(event *Event) error {
+	_, err := client.NewHTTPJSON().
+		Post(w.url, event, webhookTimeout, nil).
+		HandleResponse(func(b []byte, statusCode int) (interface{}, error) {
+			if statusCode < 300 && statusCode >= 200 {
+				return nil, nil
+			}
+			return nil, errors.Errorf("call POST %s failed, return statuscode %d text %+v", w.url, statusCode, string(b))
+		})
+	return err
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+
+	"github.com/megaease/easemesh-api/v2alpha1"
+	"github.com/pkg/errors"
+	utiltesting "k8s.io/client-go/util/testing"
+)
+
+func TestAuditor(t *testing.T) {
+	dir, err := utiltesting.MkTmpdir("audit")
+	if err != nil {
+		t.Fatalf("mkdir tmpdir error: %s", err)
+	}
+
+	var webhookEvents []*Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := &Event{}
+		err := json.NewDecoder(r.Body).Decode(event)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		webhookEvents = append(webhookEvents, event)
+	}))
+	defer server.Close()
+
+	logFile := filepath.Join(dir, "audit.jsonl")
+	auditor := New(&flags.AdminAudit{AuditLogFile: logFile, AuditWebhook: server.URL}, "127.0.0.1:2381")
+
+	before := resource.ToTenant(&v2alpha1.Tenant{Name: "tenant-001", Description: "before"})
+	after := resource.ToTenant(&v2alpha1.Tenant{Name: "tenant-001", Description: "after"})
+	auditor.Record("apply", after, before, after, nil)
+	auditor.Record("delete", after, after, nil, errors.Errorf("mock delete error"))
+
+	file, err := os.Open(logFile)
+	if err != nil {
+		t.Fatalf("open audit log error: %s", err)
+	}
+	defer file.Close()
+
+	var events []*Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event := &Event{}
+		err := json.Unmarshal(scanner.Bytes(), event)
+		if err != nil {
+			t.Fatalf("unmarshal audit event error: %s", err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 2 || len(webhookEvents) != 2 {
+		t.Fatalf("expected 2 events in both sinks, but got %d and %d", len(events), len(webhookEvents))
+	}
+	if events[0].Result != ResultSuccess || events[0].BeforeHash == events[0].AfterHash {
+		t.Fatalf("unexpected event %+v", events[0])
+	}
+	if events[1].Result != ResultFailure || events[1].AfterHash != "" || events[1].Error == "" {
+		t.Fatalf("unexpected event %+v", events[1])
+	}
+	if events[1].Server != "127.0.0.1:2381" || events[1].Kind != resource.KindTenant || events[1].Name != "tenant-001" {
+		t.Fatalf("unexpected event %+v", events[1])
+	}
+}
+
+func TestWebhookSinkFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewWebhookSink(server.URL).Write(&Event{})
+	if err == nil {
+		t.Fatalf("write event to a failed webhook should fail")
+	}
+}
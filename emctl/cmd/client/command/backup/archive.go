@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	metadataFileName = "metadata.yaml"
+	objectsDirName   = "objects"
+	documentSplitter = "---\n"
+)
+
+type (
+	// Metadata describes a backup, it is the first file of the backup archive.
+	Metadata struct {
+		FormatVersion int            `yaml:"formatVersion"`
+		EmctlVersion  string         `yaml:"emctlVersion"`
+		Server        string         `yaml:"server"`
+		CreatedAt     time.Time      `yaml:"createdAt"`
+		Objects       []*ObjectCount `yaml:"objects"`
+	}
+
+	// ObjectCount is the number of backed up objects of a kind.
+	ObjectCount struct {
+		Kind  string `yaml:"kind"`
+		Count int    `yaml:"count"`
+	}
+
+	// group holds the objects of a kind.
+	group struct {
+		kind    string
+		objects []meta.MeshObject
+	}
+)
+
+// Total returns the number of all backed up objects.
+func (m *Metadata) Total() int {
+	total := 0
+	for _, c := range m.Objects {
+		total += c.Count
+	}
+	return total
+}
+
+// objectsFileName returns the name of the file holding the objects of a group in the archive,
+// the index keeps the files in the order to restore.
+func objectsFileName(index int, kind string) string {
+	return path.Join(objectsDirName, fmt.Sprintf("%03d-%s.yaml", index, kind))
+}
+
+// writeArchive writes the metadata and the objects into a gzipped tarball.
+func writeArchive(name string, metadata *Metadata, groups []*group) error {
+	err := os.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "create directory of %s failed", name)
+	}
+
+	buff := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buff)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	content, err := yaml.Marshal(metadata)
+	if err != nil {
+		return errors.Wrap(err, "marshal metadata failed")
+	}
+	err = writeFile(tarWriter, metadataFileName, content, metadata.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i, g := range groups {
+		if len(g.objects) == 0 {
+			continue
+		}
+
+		var documents []string
+		for _, object := range g.objects {
+			content, err := yaml.Marshal(object)
+			if err != nil {
+				return errors.Wrapf(err, "marshal %s/%s to yaml failed", object.Kind(), object.Name())
+			}
+			documents = append(documents, string(content))
+		}
+
+		err = writeFile(tarWriter, objectsFileName(i, g.kind),
+			[]byte(strings.Join(documents, documentSplitter)), metadata.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return errors.Wrap(err, "close tar writer failed")
+	}
+	err = gzipWriter.Close()
+	if err != nil {
+		return errors.Wrap(err, "close gzip writer failed")
+	}
+
+	err = ioutil.WriteFile(name, buff.Bytes(), 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "write %s failed", name)
+	}
+
+	return nil
+}
+
+func writeFile(w *tar.Writer, name string, content []byte, modTime time.Time) error {
+	err := w.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(content)),
+		ModTime: modTime,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "write header of %s failed", name)
+	}
+
+	_, err = w.Write(content)
+	if err != nil {
+		return errors.Wrapf(err, "write %s failed", name)
+	}
+
+	return nil
+}
+
+// readArchive reads the metadata and the files of objects from the backup archive,
+// if onlyMetadata is true, it stops after reading the metadata.
+func readArchive(name string, onlyMetadata bool) (*Metadata, map[string][]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "open %s failed", name)
+	}
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "%s is not a backup of EaseMesh", name)
+	}
+	defer gzipReader.Close()
+
+	var metadata *Metadata
+	files := map[string][]byte{}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "read %s failed", name)
+		}
+
+		content, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "read %s in %s failed", header.Name, name)
+		}
+
+		if header.Name == metadataFileName {
+			metadata = &Metadata{}
+			err = yaml.Unmarshal(content, metadata)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "unmarshal metadata of %s failed", name)
+			}
+			if onlyMetadata {
+				break
+			}
+			continue
+		}
+
+		if path.Dir(header.Name) == objectsDirName {
+			files[path.Base(header.Name)] = content
+		}
+	}
+
+	if metadata == nil {
+		return nil, nil, errors.Errorf("%s is not a backup of EaseMesh: %s not found", name, metadataFileName)
+	}
+
+	return metadata, files, nil
+}
+
+// sortedNames returns the names of the files of objects in the order to restore.
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
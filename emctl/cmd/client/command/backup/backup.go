@@ -0,0 +1,316 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/apply"
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/get"
+	"github.com/megaease/easemeshctl/cmd/client/command/history"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/command/writer"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	"github.com/megaease/easemeshctl/cmd/client/util"
+	"github.com/megaease/easemeshctl/cmd/common"
+	"github.com/megaease/easemeshctl/pkg/version"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	backupDirName  = ".emctl/backups"
+	backupFileExt  = ".tar.gz"
+	formatVersion  = 1
+	fileTimeLayout = "20060102-150405"
+)
+
+// kinds are the kinds of backed up objects in the order to restore, the custom resources
+// follow their kinds. The service instances are registered by the running sidecars,
+// so they are not backed up.
+var kinds = []string{
+	resource.KindMeshController,
+	resource.KindTenant,
+	resource.KindService,
+	resource.KindLoadBalance,
+	resource.KindResilience,
+	resource.KindMock,
+	resource.KindObservabilityMetrics,
+	resource.KindObservabilityTracings,
+	resource.KindObservabilityOutputServer,
+	resource.KindServiceCanary,
+	resource.KindIngress,
+	resource.KindHTTPRouteGroup,
+	resource.KindTrafficTarget,
+	resource.KindCustomResourceKind,
+}
+
+// DefaultDir returns the default directory of backups.
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "get user home dir failed")
+	}
+
+	return filepath.Join(homeDir, backupDirName), nil
+}
+
+func backupDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+
+	dir, err := DefaultDir()
+	if err != nil {
+		common.ExitWithError(err)
+	}
+	return dir
+}
+
+// Create is the entrypoint of the emctl backup create sub command
+func Create(cmd *cobra.Command, flag *flags.Backup) {
+	if flag.Server == "" {
+		flag.Server = flags.GetServerAddress()
+	}
+
+	now := time.Now()
+	name := flag.Output
+	if name == "" {
+		name = filepath.Join(backupDir(flag.BackupDir), "easemesh-"+now.Format(fileTimeLayout)+backupFileExt)
+	}
+
+	metadata, err := create(meshclient.New(flag.Server), flag.Server, flag.Timeout, name, now)
+	if err != nil {
+		common.ExitWithError(err)
+	}
+
+	for _, c := range metadata.Objects {
+		fmt.Printf("%s: %d\n", c.Kind, c.Count)
+	}
+	fmt.Printf("backup %s created with %d objects\n", name, metadata.Total())
+}
+
+// Restore is the entrypoint of the emctl backup restore sub command
+func Restore(cmd *cobra.Command, flag *flags.Backup) {
+	if flag.Server == "" {
+		flag.Server = flags.GetServerAddress()
+	}
+
+	cmdArgs := cmd.Flags().Args()
+	if len(cmdArgs) != 1 {
+		common.ExitWithErrorf("invalid command args: support <backup file>")
+	}
+
+	// NOTE: A backup listed by emctl backup list could be restored by its name.
+	name := cmdArgs[0]
+	if _, err := os.Stat(name); os.IsNotExist(err) && !strings.ContainsRune(name, os.PathSeparator) {
+		name = filepath.Join(backupDir(flag.BackupDir), name)
+	}
+
+	w, err := writer.New(flag.AdminGlobal, flag.AdminHistory, flag.AdminAudit, flag.AdminPolicy)
+	if err != nil {
+		common.ExitWithError(err)
+	}
+
+	metadata, err := restore(w, name)
+	if err != nil {
+		common.ExitWithError(err)
+	}
+
+	fmt.Printf("backup %s created at %s restored with %d objects\n",
+		name, metadata.CreatedAt.Format(time.RFC3339), metadata.Total())
+}
+
+// List is the entrypoint of the emctl backup list sub command
+func List(cmd *cobra.Command, flag *flags.Backup) {
+	dir := backupDir(flag.BackupDir)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		common.ExitWithErrorf("read %s failed: %v", dir, err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Created", "Emctl Version", "Server", "Objects"})
+	table.SetBorder(false)
+	table.SetRowLine(false)
+	table.SetColumnSeparator("")
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderLine(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), backupFileExt) {
+			continue
+		}
+
+		metadata, _, err := readArchive(filepath.Join(dir, entry.Name()), true)
+		if err != nil {
+			common.OutputErrorf("ignored: %v", err)
+			continue
+		}
+
+		count++
+		table.Append([]string{
+			entry.Name(),
+			metadata.CreatedAt.Format(time.RFC3339),
+			metadata.EmctlVersion,
+			metadata.Server,
+			strconv.Itoa(metadata.Total()),
+		})
+	}
+
+	if count == 0 {
+		fmt.Println("No backup")
+		return
+	}
+
+	table.Render()
+}
+
+// create snapshots all objects of the EaseMesh through its API, and writes them into the backup.
+func create(client meshclient.MeshClient, server string, timeout time.Duration, name string, now time.Time) (*Metadata, error) {
+	var groups []*group
+	for _, kind := range kinds {
+		objects, err := list(client, kind, timeout)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, &group{kind: kind, objects: objects})
+
+		if kind != resource.KindCustomResourceKind {
+			continue
+		}
+		for _, object := range objects {
+			customResources, err := list(client, object.Name(), timeout)
+			if err != nil {
+				return nil, err
+			}
+			groups = append(groups, &group{kind: object.Name(), objects: customResources})
+		}
+	}
+
+	metadata := &Metadata{
+		FormatVersion: formatVersion,
+		EmctlVersion:  version.RELEASE,
+		Server:        server,
+		CreatedAt:     now,
+	}
+	for _, g := range groups {
+		metadata.Objects = append(metadata.Objects, &ObjectCount{Kind: g.kind, Count: len(g.objects)})
+	}
+
+	err := writeArchive(name, metadata, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func list(client meshclient.MeshClient, kind string, timeout time.Duration) ([]meta.MeshObject, error) {
+	object, err := resource.NewObjectCreator().NewFromKind(meta.VersionKind{Kind: kind})
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := get.WrapGetterByMeshObject(object, client, timeout).Get()
+	if meshclient.IsNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "list %s failed", kind)
+	}
+
+	return objects, nil
+}
+
+// restore applies the objects in the backup in order, the existed objects are updated,
+// so it works in both a fresh installed EaseMesh and the one being backed up.
+func restore(w *writer.Writer, name string) (*Metadata, error) {
+	metadata, files, err := readArchive(name, false)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.FormatVersion > formatVersion {
+		return nil, errors.Errorf("format version %d of %s is not supported, upgrade emctl to restore it",
+			metadata.FormatVersion, name)
+	}
+
+	dir, err := ioutil.TempDir("", "emctl-restore")
+	if err != nil {
+		return nil, errors.Wrap(err, "create temp dir failed")
+	}
+	defer os.RemoveAll(dir)
+
+	var errs []error
+	for _, fileName := range sortedNames(files) {
+		filePath := filepath.Join(dir, fileName)
+		err := ioutil.WriteFile(filePath, files[fileName], 0o600)
+		if err != nil {
+			return nil, errors.Wrapf(err, "write %s failed", filePath)
+		}
+
+		vss, err := util.NewVisitorBuilder().
+			FilenameParam(&util.FilenameOptions{Filenames: []string{filePath}}).
+			Do()
+		if err != nil {
+			return nil, errors.Wrapf(err, "build visitor of %s failed", fileName)
+		}
+
+		for _, vs := range vss {
+			err := vs.Visit(func(mo meta.MeshObject, e error) error {
+				if e != nil {
+					return errors.Wrap(e, "visit failed")
+				}
+
+				err := w.Write(history.ActionRestore, mo, mo, func(client meshclient.MeshClient, _ meta.MeshObject) error {
+					return apply.WrapApplierByMeshObject(mo, client, w.Timeout()).Apply()
+				})
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("%s/%s restored successfully\n", mo.Kind(), mo.Name())
+				return nil
+			})
+
+			common.OutputError(err)
+
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Errorf("restoring resources has errors occurred")
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient/fake"
+	"github.com/megaease/easemeshctl/cmd/client/command/writer"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	meshtesting "github.com/megaease/easemeshctl/cmd/client/testing"
+
+	"github.com/megaease/easemesh-api/v2alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/structpb"
+	utiltesting "k8s.io/client-go/util/testing"
+)
+
+func TestBackup(t *testing.T) {
+	schema, err := structpb.NewStruct(map[string]interface{}{"type": "object"})
+	if err != nil {
+		t.Fatalf("create schema failed: %v", err)
+	}
+
+	reactorType := "__test_backup_reactor"
+	var restored []string
+	fake.NewResourceReactorBuilder(reactorType).
+		AddReactor("list", resource.KindTenant, "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, []meta.MeshObject{
+				resource.ToTenant(&v2alpha1.Tenant{Name: "tenant-001", Description: "tenant 001"}),
+				resource.ToTenant(&v2alpha1.Tenant{Name: "tenant-002", Description: "tenant 002"}),
+			}, nil
+		}).
+		AddReactor("list", resource.KindCustomResourceKind, "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, []meta.MeshObject{
+				resource.ToCustomResourceKind(&v2alpha1.CustomResourceKind{Name: "Widget", JsonSchema: schema}),
+			}, nil
+		}).
+		AddReactor("list", "Widget", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, []meta.MeshObject{
+				resource.ToCustomResource(map[string]interface{}{"name": "widget-001", "kind": "Widget", "size": 1}),
+			}, nil
+		}).
+		AddReactor("list", "*", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, nil, nil
+		}).
+		AddReactor("get", "*", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			restored = append(restored, action.GetVersionKind().Kind)
+			return true, nil, nil
+		}).Added()
+	client := meshclient.NewFakeClient(reactorType)
+
+	dir, err := utiltesting.MkTmpdir("backup")
+	if err != nil {
+		t.Fatalf("mkdir tmpdir error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "easemesh"+backupFileExt)
+	now := time.Now().Round(time.Second)
+	metadata, err := create(client, "127.0.0.1:2381", time.Second, name, now)
+	if err != nil {
+		t.Fatalf("create backup failed: %v", err)
+	}
+	if metadata.Total() != 4 {
+		t.Fatalf("expect 4 objects, but got %d", metadata.Total())
+	}
+
+	read, files, err := readArchive(name, false)
+	if err != nil {
+		t.Fatalf("read backup failed: %v", err)
+	}
+	if !read.CreatedAt.Equal(now) || read.Total() != 4 || read.FormatVersion != formatVersion {
+		t.Fatalf("unexpected metadata %+v", read)
+	}
+	names := sortedNames(files)
+	if len(names) != 3 || !strings.HasSuffix(names[0], resource.KindTenant+".yaml") ||
+		!strings.HasSuffix(names[2], "Widget.yaml") {
+		t.Fatalf("unexpected files %v", names)
+	}
+
+	backupFlags := meshtesting.PrepareBackupFlags(reactorType, t)
+	w, err := writer.New(backupFlags.AdminGlobal, backupFlags.AdminHistory, backupFlags.AdminAudit, backupFlags.AdminPolicy)
+	if err != nil {
+		t.Fatalf("create writer failed: %v", err)
+	}
+	_, err = restore(w, name)
+	if err != nil {
+		t.Fatalf("restore backup failed: %v", err)
+	}
+	// Every restored object is looked up for its history before it is written.
+	expected := []string{
+		resource.KindTenant, resource.KindTenant, resource.KindTenant, resource.KindTenant,
+		resource.KindCustomResourceKind, resource.KindCustomResourceKind, "Widget", "Widget",
+	}
+	if strings.Join(restored, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expect restored %v, but got %v", expected, restored)
+	}
+
+	List(&cobra.Command{}, &flags.Backup{BackupDir: dir})
+}
+
+func TestRestoreInvalidBackup(t *testing.T) {
+	dir, err := utiltesting.MkTmpdir("backup")
+	if err != nil {
+		t.Fatalf("mkdir tmpdir error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "invalid"+backupFileExt)
+	err = writeArchive(name, &Metadata{FormatVersion: formatVersion + 1}, nil)
+	if err != nil {
+		t.Fatalf("write backup failed: %v", err)
+	}
+
+	backupFlags := meshtesting.PrepareBackupFlags("__test_backup_invalid_reactor", t)
+	w, err := writer.New(backupFlags.AdminGlobal, backupFlags.AdminHistory, backupFlags.AdminAudit, backupFlags.AdminPolicy)
+	if err != nil {
+		t.Fatalf("create writer failed: %v", err)
+	}
+	_, err = restore(w, name)
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Fatalf("expect unsupported format version error, but got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+
+	"github.com/pkg/errors"
+)
+
+// Runner runs the command and returns its combined output.
+type Runner func(name string, args ...string) ([]byte, error)
+
+// ExecRunner runs the command by os/exec.
+func ExecRunner(name string, args ...string) ([]byte, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return out, errors.Wrapf(err, "%s %s: %s", name, strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// Create pulls the images of the installation by docker, and saves them with the manifest
+// of their digests into the directory of the bundle.
+func Create(createFlags *flags.BundleCreate, run Runner) (*Manifest, error) {
+	err := os.MkdirAll(createFlags.Dir, 0o755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create directory %s", createFlags.Dir)
+	}
+
+	m := &Manifest{Images: images(createFlags)}
+	var sources []string
+	for _, image := range m.Images {
+		if !contains(sources, image.Source) {
+			fmt.Printf("pulling %s\n", image.Source)
+			_, err = run("docker", "pull", image.Source)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, image.Source)
+		}
+
+		image.Digest, err = digest(run, image.Source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Printf("saving %d images into %s\n", len(sources), filepath.Join(createFlags.Dir, ImagesFileName))
+	_, err = run("docker", append([]string{"save", "-o", filepath.Join(createFlags.Dir, ImagesFileName)}, sources...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, m.Save(createFlags.Dir)
+}
+
+// Push loads the images of the bundle by docker, and pushes them to the private registry,
+// the manifest records the registry and the digests of the pushed images.
+func Push(pushFlags *flags.BundlePush, run Runner) (*Manifest, error) {
+	if pushFlags.ImageRegistryURL == "" {
+		return nil, errors.New("the private image registry URL is required")
+	}
+
+	m, err := LoadManifest(pushFlags.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = run("docker", "load", "-i", filepath.Join(pushFlags.Dir, ImagesFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []string
+	for _, image := range m.Images {
+		target := pushFlags.ImageRegistryURL + "/" + image.Image
+		if !contains(targets, target) {
+			fmt.Printf("pushing %s\n", target)
+			_, err = run("docker", "tag", image.Source, target)
+			if err != nil {
+				return nil, err
+			}
+			_, err = run("docker", "push", target)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, target)
+		}
+
+		// NOTE: The digest may change since docker compresses the loaded layers again.
+		image.Digest, err = digest(run, target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.Registry = pushFlags.ImageRegistryURL
+	return m, m.Save(pushFlags.Dir)
+}
+
+// digest returns the digest of the image in its repository.
+func digest(run Runner, image string) (string, error) {
+	out, err := run("docker", "image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	if err != nil {
+		return "", err
+	}
+
+	var repoDigests []string
+	err = json.Unmarshal(out, &repoDigests)
+	if err != nil {
+		return "", errors.Wrapf(err, "unmarshal digests of image %s", image)
+	}
+
+	// NOTE: Docker omits the default registry in the repository digests.
+	registry, name := splitImage(image)
+	repos := []string{registry + "/" + repository(name)}
+	if registry == flags.DefaultImageRegistryURL {
+		repos = append(repos, repository(name))
+	}
+	for _, repoDigest := range repoDigests {
+		parts := strings.SplitN(repoDigest, "@", 2)
+		if len(parts) == 2 && contains(repos, parts[0]) {
+			return parts[1], nil
+		}
+	}
+	return "", errors.Errorf("digest of image %s not found in %v", image, repoDigests)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bundle
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+
+	"github.com/spf13/cobra"
+)
+
+// fakeDocker records the docker commands, the digest of an image is derived from its repository.
+type fakeDocker struct {
+	commands []string
+}
+
+func (d *fakeDocker) run(name string, args ...string) ([]byte, error) {
+	d.commands = append(d.commands, name+" "+strings.Join(args, " "))
+	if args[0] != "image" {
+		return nil, nil
+	}
+
+	image := args[len(args)-1]
+	registry, name := splitImage(image)
+	repo := registry + "/" + repository(name)
+	if registry == flags.DefaultImageRegistryURL {
+		repo = repository(name)
+	}
+	return []byte(fmt.Sprintf(`["%s@sha256:%x"]`, repo, len(image))), nil
+}
+
+func (d *fakeDocker) count(prefix string) int {
+	n := 0
+	for _, c := range d.commands {
+		if strings.HasPrefix(c, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCreatePushAndApply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	createFlags := &flags.BundleCreate{}
+	createFlags.AttachCmd(&cobra.Command{})
+	createFlags.Dir = dir
+
+	docker := &fakeDocker{}
+	m, err := Create(createFlags, docker.run)
+	if err != nil {
+		t.Fatalf("create bundle failed: %v", err)
+	}
+	if len(m.Images) != 7 {
+		t.Fatalf("expect 7 images, but got %d", len(m.Images))
+	}
+	// NOTE: The sidecar is the same image as Easegress.
+	if docker.count("docker pull") != 6 || docker.count("docker save") != 1 {
+		t.Fatalf("unexpected docker commands: %v", docker.commands)
+	}
+	for _, image := range m.Images {
+		if image.Digest == "" {
+			t.Fatalf("expect digest of image %s", image.Name)
+		}
+		if image.Name == imageRBACProxy && image.Source != flags.DefaultRBACProxyImage {
+			t.Fatalf("unexpected source of rbac proxy: %s", image.Source)
+		}
+	}
+
+	install := &flags.Install{}
+	install.AttachCmd(&cobra.Command{})
+	loaded, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("load manifest failed: %v", err)
+	}
+	err = loaded.Apply(install)
+	if err == nil || !strings.Contains(err.Error(), "emctl bundle push") {
+		t.Fatalf("expect not pushed error, but got %v", err)
+	}
+
+	docker = &fakeDocker{}
+	_, err = Push(&flags.BundlePush{ImageRegistryURL: "registry.example.com:5000", Dir: dir}, docker.run)
+	if err != nil {
+		t.Fatalf("push bundle failed: %v", err)
+	}
+	if docker.count("docker load") != 1 || docker.count("docker push") != 6 {
+		t.Fatalf("unexpected docker commands: %v", docker.commands)
+	}
+
+	loaded, err = LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("load manifest failed: %v", err)
+	}
+	err = loaded.Apply(install)
+	if err != nil {
+		t.Fatalf("apply bundle failed: %v", err)
+	}
+	if install.ImageRegistryURL != "registry.example.com:5000" ||
+		!strings.HasPrefix(install.EasegressImage, flags.DefaultEasegressImage+"@sha256:") ||
+		!strings.HasPrefix(install.SidecarImage, flags.DefaultSidecarImage+"@sha256:") ||
+		!strings.HasPrefix(install.RBACProxyImage, "registry.example.com:5000/kubebuilder/kube-rbac-proxy:v0.5.0@sha256:") {
+		t.Fatalf("unexpected install flags: %+v", install)
+	}
+
+	coreDNS := &flags.CoreDNS{}
+	err = loaded.ApplyCoreDNS(coreDNS)
+	if err != nil || coreDNS.ImageRegistryURL != "registry.example.com:5000" ||
+		!strings.HasPrefix(coreDNS.Image, flags.DefaultCoreDNSImage+"@sha256:") {
+		t.Fatalf("unexpected coredns flags: %+v, %v", coreDNS, err)
+	}
+}
+
+func TestSplitImage(t *testing.T) {
+	for image, want := range map[string][2]string{
+		"megaease/easegress:easemesh":               {flags.DefaultImageRegistryURL, "megaease/easegress:easemesh"},
+		"gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0": {"gcr.io", "kubebuilder/kube-rbac-proxy:v0.5.0"},
+		"localhost/coredns":                         {"localhost", "coredns"},
+		"registry:5000/coredns@sha256:1":            {"registry:5000", "coredns@sha256:1"},
+	} {
+		registry, name := splitImage(image)
+		if registry != want[0] || name != want[1] {
+			t.Fatalf("split %s: expect %v, but got %s %s", image, want, registry, name)
+		}
+	}
+}
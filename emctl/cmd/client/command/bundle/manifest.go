@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bundle
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ManifestFileName is the file name of the manifest in the bundle.
+	ManifestFileName = "manifest.yaml"
+	// ImagesFileName is the file name of the saved images in the bundle.
+	ImagesFileName = "images.tar"
+
+	// The names of the images of the installation.
+	imageEasegress        = "easegress"
+	imageOperator         = "operator"
+	imageRBACProxy        = "rbac-proxy"
+	imageShadowService    = "shadowservice-controller"
+	imageSidecar          = "sidecar"
+	imageAgentInitializer = "agent-initializer"
+	imageCoreDNS          = "coredns"
+)
+
+type (
+	// Manifest describes the images in the bundle.
+	Manifest struct {
+		// Registry is the private registry the images are pushed to, it's empty
+		// before pushing.
+		Registry string   `yaml:"registry,omitempty"`
+		Images   []*Image `yaml:"images"`
+	}
+
+	// Image is an image in the bundle.
+	Image struct {
+		Name string `yaml:"name"`
+		// Image is the image name without the registry.
+		Image string `yaml:"image"`
+		// Source is the image pulled to create the bundle.
+		Source string `yaml:"source"`
+		// Digest pins the image, it's the one in the private registry after pushing.
+		Digest string `yaml:"digest"`
+	}
+)
+
+// images returns the images referred by the installation, the operator and CoreDNS.
+func images(b *flags.BundleCreate) []*Image {
+	withRegistry := func(name, image string) *Image {
+		return &Image{Name: name, Image: image, Source: b.ImageRegistryURL + "/" + image}
+	}
+
+	// NOTE: The image of the RBAC proxy includes its registry.
+	registry, rbacProxyImage := splitImage(b.RBACProxyImage)
+	return []*Image{
+		withRegistry(imageEasegress, b.EasegressImage),
+		withRegistry(imageOperator, b.EaseMeshOperatorImage),
+		{Name: imageRBACProxy, Image: rbacProxyImage, Source: registry + "/" + rbacProxyImage},
+		withRegistry(imageShadowService, b.ShadowServiceControllerImage),
+		withRegistry(imageSidecar, b.SidecarImage),
+		withRegistry(imageAgentInitializer, b.AgentInitializerImage),
+		withRegistry(imageCoreDNS, b.CoreDNSImage),
+	}
+}
+
+// LoadManifest loads the manifest of the bundle in the directory.
+func LoadManifest(dir string) (*Manifest, error) {
+	buff, err := ioutil.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return nil, errors.Wrap(err, "read manifest of bundle")
+	}
+
+	m := &Manifest{}
+	err = yaml.Unmarshal(buff, m)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal manifest of bundle")
+	}
+	return m, nil
+}
+
+// Save writes the manifest into the directory of the bundle.
+func (m *Manifest) Save(dir string) error {
+	buff, err := yaml.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "marshal manifest of bundle")
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, ManifestFileName), buff, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "write manifest of bundle")
+	}
+	return nil
+}
+
+// pinned returns the image name pinned by the digest, without the registry.
+func (m *Manifest) pinned(name string) (string, error) {
+	if m.Registry == "" {
+		return "", errors.New("the bundle is not pushed to a private registry, push it by: emctl bundle push")
+	}
+
+	for _, image := range m.Images {
+		if image.Name == name {
+			return image.Image + "@" + image.Digest, nil
+		}
+	}
+	return "", errors.Errorf("image %s not found in the bundle", name)
+}
+
+// Apply rewrites the images of the installation to the ones in the private registry
+// pinned by the digests.
+func (m *Manifest) Apply(install *flags.Install) error {
+	for name, field := range map[string]*string{
+		imageEasegress:        &install.EasegressImage,
+		imageOperator:         &install.EaseMeshOperatorImage,
+		imageShadowService:    &install.ShadowServiceControllerImage,
+		imageSidecar:          &install.SidecarImage,
+		imageAgentInitializer: &install.AgentInitializerImage,
+	} {
+		image, err := m.pinned(name)
+		if err != nil {
+			return err
+		}
+		*field = image
+	}
+
+	image, err := m.pinned(imageRBACProxy)
+	if err != nil {
+		return err
+	}
+	install.RBACProxyImage = m.Registry + "/" + image
+	install.ImageRegistryURL = m.Registry
+
+	return nil
+}
+
+// ApplyCoreDNS rewrites the image of CoreDNS to the one in the private registry
+// pinned by the digest.
+func (m *Manifest) ApplyCoreDNS(coreDNS *flags.CoreDNS) error {
+	image, err := m.pinned(imageCoreDNS)
+	if err != nil {
+		return err
+	}
+
+	coreDNS.Image = image
+	coreDNS.ImageRegistryURL = m.Registry
+	return nil
+}
+
+// splitImage splits the image into its registry and the name, the default registry
+// is returned if there's none.
+func splitImage(image string) (string, string) {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[0], parts[1]
+	}
+	return flags.DefaultImageRegistryURL, image
+}
+
+// repository returns the image without the tag and the digest.
+func repository(image string) string {
+	if i := strings.Index(image, "@"); i != -1 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
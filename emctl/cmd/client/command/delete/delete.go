@@ -21,7 +21,9 @@ import (
 	"fmt"
 
 	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/history"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/command/writer"
 	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
 	"github.com/megaease/easemeshctl/cmd/client/util"
 	"github.com/megaease/easemeshctl/cmd/common"
@@ -69,6 +71,11 @@ func Run(cmd *cobra.Command, flag *flags.Delete) {
 		common.ExitWithErrorf("build visitor failed: %s", err)
 	}
 
+	w, err := writer.New(flag.AdminGlobal, flag.AdminHistory, flag.AdminAudit, &flags.AdminPolicy{})
+	if err != nil {
+		common.ExitWithError(err)
+	}
+
 	var errs []error
 	for _, vs := range vss {
 		err := vs.Visit(func(mo meta.MeshObject, e error) error {
@@ -76,9 +83,11 @@ func Run(cmd *cobra.Command, flag *flags.Delete) {
 				return errors.Wrap(e, "visit failed")
 			}
 
-			err := WrapDeleterByMeshObject(mo, meshclient.New(flag.Server), flag.Timeout).Delete()
+			err := w.Write(history.ActionDelete, mo, nil, func(client meshclient.MeshClient, _ meta.MeshObject) error {
+				return WrapDeleterByMeshObject(mo, client, flag.Timeout).Delete()
+			})
 			if err != nil {
-				return errors.Wrapf(err, "%s/%s deleted failed", mo.Kind(), mo.Name())
+				return err
 			}
 
 			fmt.Printf("%s/%s deleted successfully\n", mo.Kind(), mo.Name())
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flags
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// PodAntiAffinityPreferred spreads the replicas of a component across nodes if possible.
+	PodAntiAffinityPreferred = "preferred"
+	// PodAntiAffinityRequired never schedules two replicas of a component on the same node.
+	PodAntiAffinityRequired = "required"
+)
+
+type (
+	// Components holds the resources and the scheduling of the installed components,
+	// which can only be specified in the spec file.
+	Components struct {
+		ControlPlane  *Component `json:"controlPlane,omitempty"`
+		Operator      *Component `json:"operator,omitempty"`
+		Ingress       *Component `json:"ingress,omitempty"`
+		ShadowService *Component `json:"shadowService,omitempty"`
+		CoreDNS       *Component `json:"coreDNS,omitempty"`
+	}
+
+	// Component holds the resources and the scheduling of the pods of a component,
+	// the empty fields keep the defaults of the installation.
+	Component struct {
+		Resources                 *v1.ResourceRequirements      `json:"resources,omitempty"`
+		Tolerations               []v1.Toleration               `json:"tolerations,omitempty"`
+		Affinity                  *v1.Affinity                  `json:"affinity,omitempty"`
+		PodAntiAffinity           string                        `json:"podAntiAffinity,omitempty"`
+		PriorityClassName         string                        `json:"priorityClassName,omitempty"`
+		PodDisruptionBudget       *PodDisruptionBudget          `json:"podDisruptionBudget,omitempty"`
+		TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	}
+
+	// PodDisruptionBudget holds the disruption budget of the pods of a component.
+	PodDisruptionBudget struct {
+		MinAvailable   *intstr.IntOrString `json:"minAvailable,omitempty"`
+		MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	}
+)
+
+// UnmarshalYAML decodes the components with the Kubernetes field names, since
+// the Kubernetes types inside can't be decoded by yaml.v2 directly.
+func (c *Components) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw interface{}
+	err := unmarshal(&raw)
+	if err != nil {
+		return err
+	}
+
+	buff, err := yaml.Marshal(raw)
+	if err != nil {
+		return errors.Wrap(err, "marshal components")
+	}
+
+	type plain Components
+	p := plain{}
+	err = k8syaml.UnmarshalStrict(buff, &p)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal components")
+	}
+
+	*c = Components(p)
+	return c.Validate()
+}
+
+// Validate validates the components.
+func (c *Components) Validate() error {
+	for name, component := range map[string]*Component{
+		"controlPlane":  c.ControlPlane,
+		"operator":      c.Operator,
+		"ingress":       c.Ingress,
+		"shadowService": c.ShadowService,
+		"coreDNS":       c.CoreDNS,
+	} {
+		if component == nil {
+			continue
+		}
+
+		switch component.PodAntiAffinity {
+		case "", PodAntiAffinityPreferred, PodAntiAffinityRequired:
+		default:
+			return errors.Errorf("invalid podAntiAffinity %s of %s (support %s, %s)",
+				component.PodAntiAffinity, name, PodAntiAffinityPreferred, PodAntiAffinityRequired)
+		}
+
+		pdb := component.PodDisruptionBudget
+		if pdb != nil && (pdb.MinAvailable == nil) == (pdb.MaxUnavailable == nil) {
+			return errors.Errorf("podDisruptionBudget of %s must specify one of minAvailable and maxUnavailable", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package flags
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestUnmarshalComponents(t *testing.T) {
+	spec := `
+easemeshoperatorreplicas: 2
+components:
+  controlPlane:
+    resources:
+      requests:
+        cpu: 500m
+        memory: 2Gi
+    tolerations:
+    - key: dedicated
+      operator: Equal
+      value: infra
+      effect: NoSchedule
+    podAntiAffinity: required
+    priorityClassName: system-cluster-critical
+    podDisruptionBudget:
+      maxUnavailable: 1
+    topologySpreadConstraints:
+    - maxSkew: 1
+      topologyKey: topology.kubernetes.io/zone
+      whenUnsatisfiable: ScheduleAnyway
+  coreDNS:
+    podDisruptionBudget:
+      minAvailable: 50%
+`
+	install := &Install{}
+	err := yaml.Unmarshal([]byte(spec), install)
+	if err != nil {
+		t.Fatalf("unmarshal spec failed: %v", err)
+	}
+
+	if install.EaseMeshOperatorReplicas != 2 {
+		t.Fatalf("expected operator replicas 2, got %d", install.EaseMeshOperatorReplicas)
+	}
+
+	controlPlane := install.Components.ControlPlane
+	if controlPlane == nil {
+		t.Fatalf("expected the control plane component")
+	}
+	if cpu := controlPlane.Resources.Requests[v1.ResourceCPU]; cpu.String() != "500m" {
+		t.Fatalf("expected cpu request 500m, got %s", cpu.String())
+	}
+	if len(controlPlane.Tolerations) != 1 || controlPlane.Tolerations[0].Effect != v1.TaintEffectNoSchedule {
+		t.Fatalf("unexpected tolerations %+v", controlPlane.Tolerations)
+	}
+	if controlPlane.PodAntiAffinity != PodAntiAffinityRequired {
+		t.Fatalf("expected pod anti-affinity %s, got %s", PodAntiAffinityRequired, controlPlane.PodAntiAffinity)
+	}
+	if controlPlane.PodDisruptionBudget.MaxUnavailable.IntValue() != 1 {
+		t.Fatalf("expected maxUnavailable 1, got %s", controlPlane.PodDisruptionBudget.MaxUnavailable.String())
+	}
+	if len(controlPlane.TopologySpreadConstraints) != 1 {
+		t.Fatalf("unexpected topology spread constraints %+v", controlPlane.TopologySpreadConstraints)
+	}
+
+	if install.Components.CoreDNS.PodDisruptionBudget.MinAvailable.String() != "50%" {
+		t.Fatalf("expected minAvailable 50%%, got %s", install.Components.CoreDNS.PodDisruptionBudget.MinAvailable.String())
+	}
+	if install.Components.Operator != nil {
+		t.Fatalf("expected no operator component, got %+v", install.Components.Operator)
+	}
+}
+
+func TestUnmarshalInvalidComponents(t *testing.T) {
+	for _, spec := range []string{
+		"components:\n  ingress:\n    podAntiAffinity: always\n",
+		"components:\n  ingress:\n    podDisruptionBudget: {}\n",
+		"components:\n  ingress:\n    podDisruptionBudget:\n      minAvailable: 1\n      maxUnavailable: 1\n",
+		"components:\n  ingress:\n    unknown: true\n",
+	} {
+		err := yaml.Unmarshal([]byte(spec), &Install{})
+		if err == nil {
+			t.Fatalf("expected error of spec:\n%s", spec)
+		}
+	}
+}
@@ -18,11 +18,14 @@
 package flags
 
 import (
+	"strings"
 	"time"
 
 	"github.com/megaease/easemeshctl/cmd/client/command/rcfile"
 	"github.com/megaease/easemeshctl/cmd/common"
+	"github.com/megaease/easemeshctl/pkg/version"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 )
@@ -55,12 +58,18 @@ const (
 	// DefaultMeshControlPlaneCheckHealthzMaxTime is a duration that installation wait for checking the control plane service status
 	DefaultMeshControlPlaneCheckHealthzMaxTime = 60
 
-	// DefaultMeshControlPlaneStorageClassName is a storage class name of persistent volume used by control plane service
-	DefaultMeshControlPlaneStorageClassName = "easemesh-storage"
+	// DefaultLocalPVStorageClassName is the storage class created by --provision-local-pv if no storage class is specified
+	DefaultLocalPVStorageClassName = "easemesh-storage"
 
 	// DefaultMeshControlPlanePersistVolumeCapacity is the default capacity of persistent volume needed by control plane service
 	DefaultMeshControlPlanePersistVolumeCapacity = "3Gi" // 3 Gib
 
+	// DefaultMeshControlPlanePersistVolumeName is the default name prefix of the local persistent volumes provisioned by emctl
+	DefaultMeshControlPlanePersistVolumeName = "easemesh-control-plane-pv"
+
+	// DefaultMeshControlPlanePersistVolumeHostPath is the default host path of the local persistent volumes provisioned by emctl
+	DefaultMeshControlPlanePersistVolumeHostPath = "/opt/easemesh"
+
 	// DefaultMeshRegistryType is default registry type of the EaseMesh
 	DefaultMeshRegistryType = "eureka"
 
@@ -73,13 +82,16 @@ const (
 	// DefaultMeshIngressServicePort is default port listened by the Easegress acted as an ingress role
 	DefaultMeshIngressServicePort = 19527
 
+	// DefaultInstanceDrainGracePeriod is the default duration waiting for in-flight traffic of a draining instance
+	DefaultInstanceDrainGracePeriod = 30 * time.Second
+
 	// DefaultWaitControlPlaneSeconds is the default wait control plane ready elapse, in seconds (intall command)
 	DefaultWaitControlPlaneSeconds = 3
 
 	// MeshControlPlanePVNameHelpStr is a text described name of persistent volume
-	MeshControlPlanePVNameHelpStr = "The name of PersistentVolume for EaseMesh control plane storage"
+	MeshControlPlanePVNameHelpStr = "The name prefix of PersistentVolumes provisioned by --provision-local-pv for EaseMesh control plane storage"
 	// MeshControlPlanePVHostPathHelpStr is a text described local path of persistent volume
-	MeshControlPlanePVHostPathHelpStr = "The host path of the PersistentVolume for EaseMesh control plane storage"
+	MeshControlPlanePVHostPathHelpStr = "The host path of the PersistentVolumes provisioned by --provision-local-pv for EaseMesh control plane storage"
 	// MeshControlPlanePVCapacityHelpStr is a text described capacity of persistent volume
 	MeshControlPlanePVCapacityHelpStr = "The capacity of the PersistentVolume for EaseMesh control plane storage"
 
@@ -96,7 +108,10 @@ const (
 	`
 	// MeshControlPlanePVNotExistedHelpStr is a text described the persistent volume that doesn't exist
 	MeshControlPlanePVNotExistedHelpStr = `EaseMesh control plane needs PersistentVolume to store data.
-You need to create PersistentVolume in advance and specify its storageClassName as the value of --mesh-storage-class-name.
+You can specify a StorageClass provisioning volumes dynamically as the value of --mesh-storage-class-name,
+or an empty value for the default StorageClass of the cluster.
+For a test cluster, --provision-local-pv creates the PersistentVolumes with host paths on the nodes.
+Otherwise, you need to create PersistentVolume in advance and specify its storageClassName as the value of --mesh-storage-class-name.
 
 You can create PersistentVolume by the following definition:
 
@@ -116,18 +131,87 @@ spec:
     path: {/opt/easemesh/}
     type: "DirectoryOrCreate"`
 
-	// DefaultEasegressImage is default name of Easegress docker image
-	DefaultEasegressImage = "megaease/easegress:easemesh"
-	// DefaultEaseMeshOperatorImage is default name of the operator docker image
-	DefaultEaseMeshOperatorImage = "megaease/easemesh-operator:latest"
-	// DefaultShadowServiceControllerImage is default name of the shadow service docker image
-	DefaultShadowServiceControllerImage = "megaease/easemesh-shadowservice-controller:latest"
 	// DefaultImageRegistryURL is default registry url
 	DefaultImageRegistryURL = "docker.io"
+	// DefaultBundleDir is the default directory of the air-gapped installation bundle
+	DefaultBundleDir = "easemesh-bundle"
 	// DefaultImagePullPolicy is default image pull policy.
 	DefaultImagePullPolicy = v1.PullIfNotPresent
+
+	// CleanWhenFailedStage cleans the resources of the failed stage only when installation failed
+	CleanWhenFailedStage = "stage"
+	// CleanWhenFailedAll cleans the resources of all stages when installation failed
+	CleanWhenFailedAll = "all"
+	// CleanWhenFailedNone keeps all resources when installation failed
+	CleanWhenFailedNone = "none"
+
+	// DefaultUpgradeWaitTimeout is the default duration waiting for an upgraded pod or component to be ready
+	DefaultUpgradeWaitTimeout = 5 * time.Minute
+
+	// WebhookCertModeCA signs the webhook certificate by a CA generated by emctl, the operator rotates
+	// the certificate and the CA before expiry and keeps the caBundle of the webhook up to date
+	WebhookCertModeCA = "ca"
+	// WebhookCertModeCSR signs the webhook certificate by the kubernetes.io/kubelet-serving signer of the cluster
+	WebhookCertModeCSR = "csr"
+	// WebhookCertModeCertManager issues the webhook certificate by cert-manager, which renews it and injects the caBundle
+	WebhookCertModeCertManager = "cert-manager"
+	// DefaultWebhookCertMode is the default mode managing the certificate of the webhook
+	DefaultWebhookCertMode = WebhookCertModeCA
+
+	// DefaultStatusCertExpiryWarning is the default duration before the expiry of the webhook certificate to warn
+	DefaultStatusCertExpiryWarning = 30 * 24 * time.Hour
+
+	// DefaultChartName is the default name of the exported Helm chart
+	DefaultChartName = "easemesh"
+	// DefaultChartVersion is the default version of the exported Helm chart
+	DefaultChartVersion = "0.1.0"
+	// DefaultChartProvisionerImage is the default image of the job provisioning the mesh controller in the Helm chart
+	DefaultChartProvisionerImage = "curlimages/curl:7.79.1"
+)
+
+// The default images are the components of the release of emctl pinned by their digests,
+// so the installs of a release are reproducible. They are empty if the manifest of the
+// components misses them, which fails the installation by CheckImages.
+var (
+	// DefaultEasegressImage is default name of Easegress docker image
+	DefaultEasegressImage = releasedImage(version.ComponentEasegress)
+	// DefaultEaseMeshOperatorImage is default name of the operator docker image
+	DefaultEaseMeshOperatorImage = releasedImage(version.ComponentEaseMeshOperator)
+	// DefaultShadowServiceControllerImage is default name of the shadow service docker image
+	DefaultShadowServiceControllerImage = releasedImage(version.ComponentShadowServiceController)
+	// DefaultSidecarImage is default name of the sidecar docker image injected by the operator
+	DefaultSidecarImage = releasedImage(version.ComponentSidecar)
+	// DefaultAgentInitializerImage is default name of the agent initializer docker image injected by the operator
+	DefaultAgentInitializerImage = releasedImage(version.ComponentAgentInitializer)
+	// DefaultRBACProxyImage is default image of the RBAC proxy of the operator, including its registry
+	DefaultRBACProxyImage = releasedImage(version.ComponentRBACProxy)
+	// DefaultCoreDNSImage is default name of the EaseMesh dedicated CoreDNS docker image
+	DefaultCoreDNSImage = releasedImage(version.ComponentCoreDNS)
 )
 
+// releasedImage returns the image of the component of the release of emctl,
+// it's empty if the component is not found.
+func releasedImage(name string) string {
+	image, err := version.CurrentImage(name)
+	if err != nil {
+		return ""
+	}
+	return image
+}
+
+// checkImage fails if the image of the component is empty, with the reason why
+// the default one of the release of emctl is missing.
+func checkImage(name, image string) error {
+	if image != "" {
+		return nil
+	}
+	_, err := version.CurrentImage(name)
+	if err != nil {
+		return errors.Wrapf(err, "no image of %s", name)
+	}
+	return errors.Errorf("no image of %s", name)
+}
+
 type (
 	// OperationGlobal is global option for emctl
 	OperationGlobal struct {
@@ -142,7 +226,8 @@ type (
 		ImageRegistryURL string
 		ImagePullPolicy  string
 
-		CleanWhenFailed bool
+		CleanWhenFailed string
+		Resume          bool
 
 		// Easegress Control Plane params
 		EasegressImage                string
@@ -160,6 +245,7 @@ type (
 		MeshControlPlanePersistVolumeCapacity string
 		MeshControlPlaneCheckHealthzMaxTime   int
 		MeshControlPlaneNodeSelectors         map[string]string
+		ProvisionLocalPV                      bool
 
 		MeshIngressReplicas    int
 		MeshIngressServicePort int32
@@ -175,10 +261,49 @@ type (
 		// EaseMesh Operator params
 		EaseMeshOperatorImage    string
 		EaseMeshOperatorReplicas int
+		WebhookCertMode          string
+		RBACProxyImage           string
+		SidecarImage             string
+		AgentInitializerImage    string
+
+		// Bundle is the directory of the air-gapped installation bundle pinning the images.
+		Bundle string
+
+		// Resources and scheduling of the components, which are only in the spec file
+		Components Components
 
 		SpecFile string
 
 		WaitControlPlaneTimeoutInSeconds int
+
+		Render       bool
+		RenderOutput string
+	}
+
+	// ExportChart holds the options for exporting the installation as a Helm chart.
+	ExportChart struct {
+		*Install
+
+		Output           string
+		ChartVersion     string
+		ProvisionerImage string
+	}
+
+	// Upgrade holds the options for upgrading the installed EaseMesh in place.
+	Upgrade struct {
+		*Install
+
+		DryRun      bool
+		WaitTimeout time.Duration
+	}
+
+	// Status holds the options for the health report of the EaseMesh infrastructure.
+	Status struct {
+		*OperationGlobal
+		*AdminGlobal
+
+		OutputFormat      string
+		CertExpiryWarning time.Duration
 	}
 
 	// CoreDNS holds the options for installing EaseMesh-version CoreDNS.
@@ -188,7 +313,40 @@ type (
 		ImagePullPolicy string
 		Image           string
 		DNSDomain       string
-		CleanWhenFailed bool
+		CleanWhenFailed string
+		Restore         bool
+		Render          bool
+		RenderOutput    string
+
+		// ImageRegistryURL prefixes the image if it's not empty.
+		ImageRegistryURL string
+		Bundle           string
+
+		// SpecFile is the spec file of the installation, whose CoreDNS component is used.
+		SpecFile  string
+		Component *Component
+	}
+
+	// BundleCreate holds the options for creating the air-gapped installation bundle,
+	// the images of the installation are collected.
+	BundleCreate struct {
+		*Install
+
+		CoreDNSImage string
+		Dir          string
+	}
+
+	// BundlePush holds the options for pushing the bundle to the private registry.
+	BundlePush struct {
+		ImageRegistryURL string
+		Dir              string
+	}
+
+	// Version holds the options for the version sub command.
+	Version struct {
+		Components   bool
+		Release      string
+		OutputFormat string
 	}
 
 	// Reset holds the option for the EaseMesh resest sub command
@@ -198,6 +356,16 @@ type (
 		AddOns    []string
 	}
 
+	// AddOn holds the options for the emctl addon sub commands
+	AddOn struct {
+		*OperationGlobal
+	}
+
+	// AddOnInstall holds the options for the emctl addon install sub command
+	AddOnInstall struct {
+		*Install
+	}
+
 	// AdminGlobal holds the option for all the EaseMesh admin command
 	AdminGlobal struct {
 		Server  string
@@ -210,16 +378,59 @@ type (
 		Recursive bool
 	}
 
+	// AdminHistory holds the option for the revision history of the EaseMesh resources
+	AdminHistory struct {
+		HistoryDir string
+	}
+
+	// AdminAudit holds the option for the audit log of the mutating operations
+	AdminAudit struct {
+		AuditLogFile string
+		AuditWebhook string
+	}
+
+	// AdminPolicy holds the option for the policies checked before writing the EaseMesh resources
+	AdminPolicy struct {
+		PolicyDir string
+	}
+
 	// Apply holds the option for the apply sub command
 	Apply struct {
 		*AdminGlobal
 		*AdminFileInput
+		*AdminHistory
+		*AdminAudit
+		*AdminPolicy
 	}
 
 	// Delete holds the option for the emctl delete sub command
 	Delete struct {
 		*AdminGlobal
 		*AdminFileInput
+		*AdminHistory
+		*AdminAudit
+	}
+
+	// History holds the option for the emctl history sub command
+	History struct {
+		*AdminGlobal
+		*AdminHistory
+		Revision int
+	}
+
+	// Rollback holds the option for the emctl rollback sub command
+	Rollback struct {
+		*AdminGlobal
+		*AdminHistory
+		*AdminAudit
+		*AdminPolicy
+		ToRevision int
+	}
+
+	// Lint holds the option for the emctl lint sub command
+	Lint struct {
+		*AdminFileInput
+		OutputFormat string
 	}
 
 	// Get holds the option for the emctl get sub command
@@ -227,6 +438,24 @@ type (
 		*AdminGlobal
 		OutputFormat string
 	}
+
+	// Instance holds the option for the emctl instance sub commands
+	Instance struct {
+		*AdminGlobal
+		*AdminAudit
+		Wait        bool
+		GracePeriod time.Duration
+	}
+
+	// Backup holds the option for the emctl backup sub commands
+	Backup struct {
+		*AdminGlobal
+		*AdminHistory
+		*AdminAudit
+		*AdminPolicy
+		BackupDir string
+		Output    string
+	}
 )
 
 // GetServerAddress return global server address configuration
@@ -244,20 +473,110 @@ func GetServerAddress() string {
 	return rc.Server
 }
 
+// loadRCFile loads the rcfile, it returns an empty one if the rcfile is unavailable
+func loadRCFile() *rcfile.RCFile {
+	rc, err := rcfile.New()
+	if err != nil {
+		return &rcfile.RCFile{}
+	}
+
+	err = rc.Unmarshal()
+	if err != nil {
+		return &rcfile.RCFile{}
+	}
+	return rc
+}
+
+// GetAuditWebhook return global audit webhook configuration
+func GetAuditWebhook() string {
+	return loadRCFile().AuditWebhook
+}
+
+// GetPolicyDir return global policy directory configuration
+func GetPolicyDir() string {
+	return loadRCFile().PolicyDir
+}
+
+// GetAddOnDir return global add-on manifests directory configuration
+func GetAddOnDir() string {
+	return loadRCFile().AddOnDir
+}
+
 // AttachCmd attaches options for installation of coredns.
 func (c *CoreDNS) AttachCmd(cmd *cobra.Command) {
 	c.OperationGlobal = &OperationGlobal{}
 	c.OperationGlobal.AttachCmd(cmd)
 
-	cmd.Flags().BoolVar(&c.CleanWhenFailed, "clean-when-failed", true, "Clean resources when installation failed")
+	attachCleanWhenFailedCmd(cmd, &c.CleanWhenFailed)
 	cmd.Flags().IntVar(&c.Replicas, "replicas", 1, "CoreDNS replicas")
 	cmd.Flags().StringVar(&c.ImagePullPolicy, "image-pull-policy", string(DefaultImagePullPolicy), "Image pull policy.")
 	cmd.Flags().StringVar(&c.DNSDomain, "dns-domain", "", "DNS Domain for Corefile of CoreDNS, default is the value of ClusterConfiguration.networking.dnsDomain in ConfigMap kube-system/kubeadm-config")
-	cmd.Flags().StringVar(&c.Image, "image", "megaease/coredns:latest", "CoreDNS image name")
+	cmd.Flags().StringVar(&c.Image, "image", DefaultCoreDNSImage, "CoreDNS image name")
+	cmd.Flags().StringVar(&c.ImageRegistryURL, "image-registry-url", "", "Image registry URL of CoreDNS, the image is used as it is if it's empty")
+	cmd.Flags().StringVar(&c.Bundle, "bundle", "", "A directory of the air-gapped installation bundle pushed by emctl bundle push, whose CoreDNS image is used")
+	cmd.Flags().StringVarP(&c.SpecFile, "file", "f", "", "A yaml file specifying the install params, whose components.coreDNS is applied")
+	cmd.Flags().BoolVar(&c.Restore, "restore", false, "Restore the original CoreDNS backed up in ConfigMap kube-system/coredns instead of installing")
+	cmd.Flags().BoolVar(&c.Render, "render", false, "Render manifests of CoreDNS instead of applying them to the cluster, --dns-domain is required")
+	cmd.Flags().StringVarP(&c.RenderOutput, "output", "o", "-", "A directory to write the rendered manifests with a kustomization.yaml, or - for stdout")
+}
+
+func attachCleanWhenFailedCmd(cmd *cobra.Command, value *string) {
+	cmd.Flags().StringVar(value, "clean-when-failed", CleanWhenFailedStage, "Resources to clean when installation failed (support stage, all, none)")
+	cmd.Flags().Lookup("clean-when-failed").NoOptDefVal = CleanWhenFailedAll
+}
+
+// CleanWhenFailedMode returns the validated value of --clean-when-failed.
+func (i *Install) CleanWhenFailedMode() (string, error) {
+	return cleanWhenFailedMode(i.CleanWhenFailed)
+}
+
+// CleanWhenFailedMode returns the validated value of --clean-when-failed.
+func (c *CoreDNS) CleanWhenFailedMode() (string, error) {
+	return cleanWhenFailedMode(c.CleanWhenFailed)
+}
+
+// cleanWhenFailedMode validates the value of --clean-when-failed, the boolean values
+// are accepted for the spec files written before it supports the granularity.
+func cleanWhenFailedMode(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case CleanWhenFailedStage, CleanWhenFailedAll, CleanWhenFailedNone:
+		return strings.ToLower(value), nil
+	case "true":
+		return CleanWhenFailedAll, nil
+	case "false":
+		return CleanWhenFailedNone, nil
+	default:
+		return "", errors.Errorf("unsupported value of --clean-when-failed: %s, support %s, %s, %s", value,
+			CleanWhenFailedStage, CleanWhenFailedAll, CleanWhenFailedNone)
+	}
+}
+
+// CheckImage fails if the image of CoreDNS is empty.
+func (c *CoreDNS) CheckImage() error {
+	return checkImage(version.ComponentCoreDNS, c.Image)
 }
 
 // AttachCmd attaches options for installation sub command
 func (i *Install) AttachCmd(cmd *cobra.Command) {
+	i.attachComponentCmd(cmd)
+	cmd.Flags().BoolVar(&i.OnlyAddOn, "only-add-on", false, "Only install add-ons")
+	attachCleanWhenFailedCmd(cmd, &i.CleanWhenFailed)
+	cmd.Flags().StringVar(&i.Bundle, "bundle", "", "A directory of the air-gapped installation bundle pushed by emctl bundle push, whose images pinned by digests are used")
+	cmd.Flags().BoolVar(&i.Resume, "resume", false, "Resume the installation by skipping the stages completed in the previous one")
+	cmd.Flags().IntVar(&i.WaitControlPlaneTimeoutInSeconds, "wait-control-plane-seconds", DefaultWaitControlPlaneSeconds, "Wait control plane ready timeout in seconds")
+	cmd.Flags().BoolVar(&i.Render, "render", false, "Render manifests of the installation instead of applying them to the cluster")
+	cmd.Flags().StringVarP(&i.RenderOutput, "output", "o", "-", "A directory to write the rendered manifests with a kustomization.yaml, or - for stdout")
+	cmd.Flags().BoolVar(&i.ProvisionLocalPV, "provision-local-pv", false,
+		"Create a StorageClass and PersistentVolumes with host paths on the nodes for the control plane, "+
+			"for the clusters without dynamic provisioning")
+	cmd.Flags().StringVar(&i.MeshControlPlanePersistVolumeName, "mesh-control-plane-pv-name", DefaultMeshControlPlanePersistVolumeName,
+		MeshControlPlanePVNameHelpStr)
+	cmd.Flags().StringVar(&i.MeshControlPlanePersistVolumeHostPath, "mesh-control-plane-pv-host-path", DefaultMeshControlPlanePersistVolumeHostPath,
+		MeshControlPlanePVHostPathHelpStr)
+}
+
+// attachComponentCmd attaches options describing the installed components.
+func (i *Install) attachComponentCmd(cmd *cobra.Command) {
 	i.OperationGlobal = &OperationGlobal{}
 	i.OperationGlobal.AttachCmd(cmd)
 	cmd.Flags().IntVar(&i.EgClientPort, "mesh-control-plane-client-port", DefaultMeshClientPort, "Mesh control plane client port for remote accessing")
@@ -271,9 +590,11 @@ func (i *Install) AttachCmd(cmd *cobra.Command) {
 	cmd.Flags().IntVar(&i.EgServicePeerPort, "mesh-control-plane-service-peer-port", DefaultMeshPeerPort, "Port of Easegress cluster peer")
 	cmd.Flags().IntVar(&i.EgServiceAdminPort, "mesh-control-plane-service-admin-port", DefaultMeshAdminPort, "Port of Easegress admin address")
 
-	cmd.Flags().StringVar(&i.MeshControlPlaneStorageClassName, "mesh-storage-class-name", DefaultMeshControlPlaneStorageClassName, "Mesh storage class name")
+	cmd.Flags().StringVar(&i.MeshControlPlaneStorageClassName, "mesh-storage-class-name", "",
+		"Mesh storage class name, the default StorageClass of the cluster is used if it's empty, "+
+			"or "+DefaultLocalPVStorageClassName+" created with --provision-local-pv")
 	cmd.Flags().StringVar(&i.MeshControlPlanePersistVolumeCapacity, "mesh-control-plane-pv-capacity", DefaultMeshControlPlanePersistVolumeCapacity,
-		MeshControlPlanePVNotExistedHelpStr)
+		MeshControlPlanePVCapacityHelpStr)
 	cmd.Flags().StringToStringVar(&i.MeshControlPlaneNodeSelectors, "mesh-control-plane-node-selectors", map[string]string{}, "Mesh control plane node selectors")
 
 	cmd.Flags().Int32Var(&i.MeshIngressServicePort, "mesh-ingress-service-port", DefaultMeshIngressServicePort, "Port of mesh ingress controller")
@@ -281,20 +602,117 @@ func (i *Install) AttachCmd(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&i.EaseMeshRegistryType, "registry-type", DefaultMeshRegistryType, MeshRegistryTypeHelpStr)
 	cmd.Flags().IntVar(&i.HeartbeatInterval, "heartbeat-interval", DefaultHeartbeatInterval, "Heartbeat interval for mesh service")
 
-	cmd.Flags().StringVar(&i.ImageRegistryURL, "image-registry-url", DefaultImageRegistryURL, "Image registry URL")
+	i.attachImageCmd(cmd)
 	cmd.Flags().StringVar(&i.ImagePullPolicy, "image-pull-policy", string(DefaultImagePullPolicy), "Image pull policy (support Always, IfNotPresent, Never)")
-	cmd.Flags().StringVar(&i.EasegressImage, "easegress-image", DefaultEasegressImage, "Easegress image name")
-	cmd.Flags().StringVar(&i.EaseMeshOperatorImage, "easemesh-operator-image", DefaultEaseMeshOperatorImage, "Mesh operator image name")
 
 	cmd.Flags().IntVar(&i.EasegressControlPlaneReplicas, "easemesh-control-plane-replicas", DefaultMeshControlPlaneReplicas, "Mesh control plane replicas")
 	cmd.Flags().IntVar(&i.MeshIngressReplicas, "easemesh-ingress-replicas", DefaultMeshIngressReplicas, "Mesh ingress controller replicas")
-	cmd.Flags().BoolVar(&i.OnlyAddOn, "only-add-on", false, "Only install add-ons")
-	cmd.Flags().StringArrayVar(&i.AddOns, "add-ons", []string{}, "Names of add-ons to be installed (support shadowservice)")
-	cmd.Flags().StringVar(&i.ShadowServiceControllerImage, "shadowservice-controller-image", DefaultShadowServiceControllerImage, "Shadow service controller image name")
+	cmd.Flags().StringArrayVar(&i.AddOns, "add-ons", []string{}, "Names of add-ons to be installed (see emctl addon list)")
 	cmd.Flags().IntVar(&i.EaseMeshOperatorReplicas, "easemesh-operator-replicas", DefaultMeshOperatorReplicas, "Mesh operator controller replicas")
+	cmd.Flags().StringVar(&i.WebhookCertMode, "webhook-cert-mode", DefaultWebhookCertMode,
+		"The mode managing the certificate of the operator webhook (support ca, csr, cert-manager)")
 	cmd.Flags().StringVarP(&i.SpecFile, "file", "f", "", "A yaml file specifying the install params")
-	cmd.Flags().BoolVar(&i.CleanWhenFailed, "clean-when-failed", true, "Clean resources when installation failed")
-	cmd.Flags().IntVar(&i.WaitControlPlaneTimeoutInSeconds, "wait-control-plane-seconds", DefaultWaitControlPlaneSeconds, "Wait control plane ready timeout in seconds")
+}
+
+// attachImageCmd attaches the options of the images of the installation.
+func (i *Install) attachImageCmd(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&i.ImageRegistryURL, "image-registry-url", DefaultImageRegistryURL, "Image registry URL")
+	cmd.Flags().StringVar(&i.EasegressImage, "easegress-image", DefaultEasegressImage, "Easegress image name")
+	cmd.Flags().StringVar(&i.EaseMeshOperatorImage, "easemesh-operator-image", DefaultEaseMeshOperatorImage, "Mesh operator image name")
+	cmd.Flags().StringVar(&i.ShadowServiceControllerImage, "shadowservice-controller-image", DefaultShadowServiceControllerImage, "Shadow service controller image name")
+	cmd.Flags().StringVar(&i.RBACProxyImage, "rbac-proxy-image", DefaultRBACProxyImage, "RBAC proxy image of the operator, including its registry")
+	cmd.Flags().StringVar(&i.SidecarImage, "sidecar-image", DefaultSidecarImage, "Sidecar image name injected by the operator")
+	cmd.Flags().StringVar(&i.AgentInitializerImage, "agent-initializer-image", DefaultAgentInitializerImage, "Agent initializer image name injected by the operator")
+}
+
+// StorageClassName returns the storage class of the volumes of the control plane,
+// the empty one is the default storage class of the cluster, unless the storage
+// class of the local persistent volumes is created by emctl.
+func (i *Install) StorageClassName() string {
+	if i.MeshControlPlaneStorageClassName == "" && i.ProvisionLocalPV {
+		return DefaultLocalPVStorageClassName
+	}
+	return i.MeshControlPlaneStorageClassName
+}
+
+// CheckImages fails if any image of the components is empty, the defaults of the
+// release of emctl are missing if the manifest of the components misses them.
+func (i *Install) CheckImages() error {
+	for _, c := range []struct{ name, image string }{
+		{version.ComponentEasegress, i.EasegressImage},
+		{version.ComponentEaseMeshOperator, i.EaseMeshOperatorImage},
+		{version.ComponentShadowServiceController, i.ShadowServiceControllerImage},
+		{version.ComponentRBACProxy, i.RBACProxyImage},
+		{version.ComponentSidecar, i.SidecarImage},
+		{version.ComponentAgentInitializer, i.AgentInitializerImage},
+	} {
+		if err := checkImage(c.name, c.image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttachCmd attaches options for creating the bundle
+func (b *BundleCreate) AttachCmd(cmd *cobra.Command) {
+	b.Install = &Install{}
+	b.Install.attachImageCmd(cmd)
+	cmd.Flags().StringVar(&b.CoreDNSImage, "coredns-image", DefaultCoreDNSImage, "EaseMesh dedicated CoreDNS image name")
+	cmd.Flags().StringVarP(&b.Dir, "dir", "d", DefaultBundleDir, "A directory to write the bundle")
+}
+
+// AttachCmd attaches options for pushing the bundle
+func (b *BundlePush) AttachCmd(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&b.ImageRegistryURL, "image-registry-url", "", "The private image registry URL to push the images to")
+	cmd.Flags().StringVarP(&b.Dir, "dir", "d", DefaultBundleDir, "The directory of the bundle")
+}
+
+// AttachCmd attaches options for the version sub command.
+func (v *Version) AttachCmd(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&v.Components, "components", false, "Show the images of the components pinned by the release")
+	cmd.Flags().StringVar(&v.Release, "release", "", "The release of the components to show (default the release of emctl)")
+	cmd.Flags().StringVarP(&v.OutputFormat, "output", "o", "table", "Output format of the components (support table, yaml, json)")
+}
+
+// AttachCmd attaches options for addon sub commands
+func (a *AddOn) AttachCmd(cmd *cobra.Command) {
+	a.OperationGlobal = &OperationGlobal{}
+	a.OperationGlobal.AttachCmd(cmd)
+}
+
+// AttachCmd attaches options for the addon install sub command, the add-ons
+// read the options of the components they depend on.
+func (a *AddOnInstall) AttachCmd(cmd *cobra.Command) {
+	a.Install = &Install{}
+	a.Install.attachComponentCmd(cmd)
+}
+
+// AttachCmd attaches options for the export-chart sub command of install
+func (e *ExportChart) AttachCmd(cmd *cobra.Command) {
+	e.Install = &Install{}
+	e.Install.attachComponentCmd(cmd)
+	cmd.Flags().StringVarP(&e.Output, "output", "o", DefaultChartName, "A directory to write the Helm chart")
+	cmd.Flags().StringVar(&e.ChartVersion, "chart-version", DefaultChartVersion, "Version of the Helm chart")
+	cmd.Flags().StringVar(&e.ProvisionerImage, "provisioner-image", DefaultChartProvisionerImage,
+		"Image of the hook job which provisions the mesh controller after the control plane is running")
+}
+
+// AttachCmd attaches options for upgrade sub command
+func (u *Upgrade) AttachCmd(cmd *cobra.Command) {
+	u.Install = &Install{}
+	u.Install.attachComponentCmd(cmd)
+	cmd.Flags().BoolVar(&u.DryRun, "dry-run", false, "Only show the difference between the deployed and the target components")
+	cmd.Flags().DurationVar(&u.WaitTimeout, "wait-timeout", DefaultUpgradeWaitTimeout, "Max duration waiting for an upgraded pod or component to be ready")
+}
+
+// AttachCmd attaches options for status sub command
+func (s *Status) AttachCmd(cmd *cobra.Command) {
+	s.OperationGlobal = &OperationGlobal{}
+	s.OperationGlobal.AttachCmd(cmd)
+	s.AdminGlobal = &AdminGlobal{}
+	s.AdminGlobal.AttachCmd(cmd)
+	cmd.Flags().StringVarP(&s.OutputFormat, "output", "o", "text", "Output format (support text, json)")
+	cmd.Flags().DurationVar(&s.CertExpiryWarning, "cert-expiry-warning", DefaultStatusCertExpiryWarning, "A duration before the expiry of the webhook certificate to warn")
 }
 
 // AttachCmd attaches options for reset sub command
@@ -323,6 +741,22 @@ func (a *AdminFileInput) AttachCmd(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&a.Recursive, "recursive", "r", true, "Whether to recursively iterate all sub-directories and files of the location")
 }
 
+// AttachCmd attaches history options for base administrator command
+func (a *AdminHistory) AttachCmd(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&a.HistoryDir, "history-dir", "", "A directory to store the revision history of the EaseMesh resources (default $HOME/.emctl/history)")
+}
+
+// AttachCmd attaches audit options for base administrator command
+func (a *AdminAudit) AttachCmd(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&a.AuditLogFile, "audit-log-file", "", "A JSONL file to record audit events of mutating operations (default $HOME/.emctl/audit.jsonl)")
+	cmd.Flags().StringVar(&a.AuditWebhook, "audit-webhook", "", "A webhook URL to post audit events of mutating operations (default auditWebhook in rcfile)")
+}
+
+// AttachCmd attaches policy options for base administrator command
+func (a *AdminPolicy) AttachCmd(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&a.PolicyDir, "policy-dir", "", "A directory contained policies checked before writing resources (default policyDir in rcfile)")
+}
+
 // AttachCmd attaches options for apply sub command
 func (a *Apply) AttachCmd(cmd *cobra.Command) {
 	a.AdminGlobal = &AdminGlobal{}
@@ -330,6 +764,15 @@ func (a *Apply) AttachCmd(cmd *cobra.Command) {
 
 	a.AdminFileInput = &AdminFileInput{}
 	a.AdminFileInput.AttachCmd(cmd)
+
+	a.AdminHistory = &AdminHistory{}
+	a.AdminHistory.AttachCmd(cmd)
+
+	a.AdminAudit = &AdminAudit{}
+	a.AdminAudit.AttachCmd(cmd)
+
+	a.AdminPolicy = &AdminPolicy{}
+	a.AdminPolicy.AttachCmd(cmd)
 }
 
 // AttachCmd attaches options for delete sub command
@@ -339,6 +782,40 @@ func (d *Delete) AttachCmd(cmd *cobra.Command) {
 
 	d.AdminFileInput = &AdminFileInput{}
 	d.AdminFileInput.AttachCmd(cmd)
+
+	d.AdminHistory = &AdminHistory{}
+	d.AdminHistory.AttachCmd(cmd)
+
+	d.AdminAudit = &AdminAudit{}
+	d.AdminAudit.AttachCmd(cmd)
+}
+
+// AttachCmd attaches options for history sub command
+func (h *History) AttachCmd(cmd *cobra.Command) {
+	h.AdminGlobal = &AdminGlobal{}
+	h.AdminGlobal.AttachCmd(cmd)
+
+	h.AdminHistory = &AdminHistory{}
+	h.AdminHistory.AttachCmd(cmd)
+
+	cmd.Flags().IntVar(&h.Revision, "revision", 0, "Show the details of a specific revision")
+}
+
+// AttachCmd attaches options for rollback sub command
+func (r *Rollback) AttachCmd(cmd *cobra.Command) {
+	r.AdminGlobal = &AdminGlobal{}
+	r.AdminGlobal.AttachCmd(cmd)
+
+	r.AdminHistory = &AdminHistory{}
+	r.AdminHistory.AttachCmd(cmd)
+
+	r.AdminAudit = &AdminAudit{}
+	r.AdminAudit.AttachCmd(cmd)
+
+	r.AdminPolicy = &AdminPolicy{}
+	r.AdminPolicy.AttachCmd(cmd)
+
+	cmd.Flags().IntVar(&r.ToRevision, "to-revision", 0, "The revision to roll back to, the resource will be restored to the version before that revision")
 }
 
 // AttachCmd attaches options for get sub command
@@ -348,3 +825,41 @@ func (g *Get) AttachCmd(cmd *cobra.Command) {
 
 	cmd.Flags().StringVarP(&g.OutputFormat, "output", "o", "table", "Output format (support table, yaml, json)")
 }
+
+// AttachCmd attaches options for lint sub command
+func (l *Lint) AttachCmd(cmd *cobra.Command) {
+	l.AdminFileInput = &AdminFileInput{}
+	l.AdminFileInput.AttachCmd(cmd)
+
+	cmd.Flags().StringVarP(&l.OutputFormat, "output", "o", "text", "Output format (support text, json, sarif)")
+}
+
+// AttachCmd attaches options for instance sub commands
+func (i *Instance) AttachCmd(cmd *cobra.Command) {
+	i.AdminGlobal = &AdminGlobal{}
+	i.AdminGlobal.AttachCmd(cmd)
+
+	i.AdminAudit = &AdminAudit{}
+	i.AdminAudit.AttachCmd(cmd)
+
+	cmd.Flags().BoolVar(&i.Wait, "wait", true, "Wait the grace period after the instance is out of service (only for drain)")
+	cmd.Flags().DurationVar(&i.GracePeriod, "grace-period", DefaultInstanceDrainGracePeriod, "A duration to wait for in-flight traffic of the instance after it is out of service (only for drain)")
+}
+
+// AttachCmd attaches options for backup sub commands
+func (b *Backup) AttachCmd(cmd *cobra.Command) {
+	b.AdminGlobal = &AdminGlobal{}
+	b.AdminGlobal.AttachCmd(cmd)
+
+	b.AdminHistory = &AdminHistory{}
+	b.AdminHistory.AttachCmd(cmd)
+
+	b.AdminAudit = &AdminAudit{}
+	b.AdminAudit.AttachCmd(cmd)
+
+	b.AdminPolicy = &AdminPolicy{}
+	b.AdminPolicy.AttachCmd(cmd)
+
+	cmd.Flags().StringVar(&b.BackupDir, "backup-dir", "", "A directory to store the backups of the EaseMesh resources (default $HOME/.emctl/backups)")
+	cmd.Flags().StringVarP(&b.Output, "output", "o", "", "A file to write the backup to (only for create, default a timestamped file in the backup directory)")
+}
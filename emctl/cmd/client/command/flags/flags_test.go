@@ -69,3 +69,98 @@ func TestInstallFlag(t *testing.T) {
 	a := Install{}
 	a.AttachCmd(cmd)
 }
+
+func TestInstanceFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	i := Instance{}
+	i.AttachCmd(cmd)
+}
+
+func TestBackupFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	b := Backup{}
+	b.AttachCmd(cmd)
+}
+
+func TestHistoryFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	h := History{}
+	h.AttachCmd(cmd)
+}
+
+func TestRollbackFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	r := Rollback{}
+	r.AttachCmd(cmd)
+}
+
+func TestExportChartFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	e := ExportChart{}
+	e.AttachCmd(cmd)
+	if cmd.Flags().Lookup("render") != nil || cmd.Flags().Lookup("registry-type") == nil {
+		t.Fatalf("export-chart should only take the component flags of install")
+	}
+}
+
+func TestAddOnFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	a := AddOn{}
+	a.AttachCmd(cmd)
+
+	cmd = &cobra.Command{}
+	i := AddOnInstall{}
+	i.AttachCmd(cmd)
+}
+
+func TestUpgradeFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	u := Upgrade{}
+	u.AttachCmd(cmd)
+}
+
+func TestStatusFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	s := Status{}
+	s.AttachCmd(cmd)
+}
+
+func TestLintFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	l := Lint{}
+	l.AttachCmd(cmd)
+}
+
+func TestCleanWhenFailedFlag(t *testing.T) {
+	installCmd, coreDNSCmd := &cobra.Command{}, &cobra.Command{}
+	i, c := Install{}, CoreDNS{}
+	i.AttachCmd(installCmd)
+	c.AttachCmd(coreDNSCmd)
+
+	for _, cmd := range []*cobra.Command{installCmd, coreDNSCmd} {
+		for value, expected := range map[string]string{
+			"": CleanWhenFailedAll, "stage": CleanWhenFailedStage, "none": CleanWhenFailedNone, "false": CleanWhenFailedNone,
+		} {
+			arg := "--clean-when-failed"
+			if value != "" {
+				arg += "=" + value
+			}
+			if err := cmd.ParseFlags([]string{arg}); err != nil {
+				t.Fatalf("parse %s failed: %v", arg, err)
+			}
+
+			mode, err := i.CleanWhenFailedMode()
+			if cmd == coreDNSCmd {
+				mode, err = c.CleanWhenFailedMode()
+			}
+			if err != nil || mode != expected {
+				t.Fatalf("expect %s of %s, but got %s, %v", expected, arg, mode, err)
+			}
+		}
+	}
+
+	c.CleanWhenFailed = "always"
+	if _, err := c.CleanWhenFailedMode(); err == nil {
+		t.Fatalf("expect unsupported value error")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package history
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// Run is the entrypoint of the emctl history sub command
+func Run(cmd *cobra.Command, flag *flags.History) {
+	cmdArgs := cmd.Flags().Args()
+	if len(cmdArgs) != 2 {
+		common.ExitWithErrorf("invalid command args: support <resource kind> <resource name>")
+	}
+	kind, name := cmdArgs[0], cmdArgs[1]
+
+	if flag.Server == "" {
+		flag.Server = flags.GetServerAddress()
+	}
+
+	store, err := NewLocalStore(flag.HistoryDir, flag.Server)
+	if err != nil {
+		common.ExitWithErrorf("create history store failed: %v", err)
+	}
+
+	if flag.Revision != 0 {
+		revision, err := store.Get(kind, name, flag.Revision)
+		if err != nil {
+			common.ExitWithError(err)
+		}
+		printRevision(revision)
+		return
+	}
+
+	revisions, err := store.List(kind, name)
+	if err != nil {
+		common.ExitWithError(err)
+	}
+	if len(revisions) == 0 {
+		fmt.Println("No revision")
+		return
+	}
+
+	printRevisions(revisions)
+}
+
+func printRevisions(revisions []*Revision) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Revision", "Action", "Author", "Timestamp"})
+	table.SetBorder(false)
+	table.SetRowLine(false)
+	table.SetColumnSeparator("")
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderLine(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	for _, revision := range revisions {
+		table.Append([]string{
+			strconv.Itoa(revision.Revision),
+			revision.Action,
+			revision.Author,
+			revision.Timestamp.Format(time.RFC3339),
+		})
+	}
+
+	table.Render()
+}
+
+func printRevision(revision *Revision) {
+	fmt.Printf("Revision:  %d\n", revision.Revision)
+	fmt.Printf("Resource:  %s/%s\n", revision.Kind, revision.Name)
+	fmt.Printf("Action:    %s\n", revision.Action)
+	fmt.Printf("Author:    %s\n", revision.Author)
+	fmt.Printf("Timestamp: %s\n", revision.Timestamp.Format(time.RFC3339))
+	fmt.Printf("Diff:\n%s", revision.Diff)
+}
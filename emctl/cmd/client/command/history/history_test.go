@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package history
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient/fake"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+
+	"github.com/megaease/easemesh-api/v2alpha1"
+	"github.com/spf13/cobra"
+	utiltesting "k8s.io/client-go/util/testing"
+)
+
+func prepareStore(t *testing.T) (string, Store) {
+	dir, err := utiltesting.MkTmpdir("history")
+	if err != nil {
+		t.Fatalf("mkdir tmpdir error: %s", err)
+	}
+	store, err := NewLocalStore(dir, "127.0.0.1:2381")
+	if err != nil {
+		t.Fatalf("create store error: %s", err)
+	}
+	return dir, store
+}
+
+func TestDiff(t *testing.T) {
+	diff := Diff("a\nb\nc\n", "a\nc\nd\n")
+	expected := "  a\n- b\n  c\n+ d\n"
+	if diff != expected {
+		t.Fatalf("expected diff %q, but got %q", expected, diff)
+	}
+
+	if Diff("", "") != "" {
+		t.Fatalf("diff of empty texts should be empty")
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	_, store := prepareStore(t)
+
+	err := store.Save(&Revision{Kind: resource.KindService, Name: "service-001", Action: ActionApply})
+	if err != nil {
+		t.Fatalf("save revision error: %s", err)
+	}
+	err = store.Save(&Revision{
+		Kind:    resource.KindService,
+		Name:    "service-001",
+		Action:  ActionDelete,
+		Existed: true,
+		Object:  []byte("kind: Service\n"),
+	})
+	if err != nil {
+		t.Fatalf("save revision error: %s", err)
+	}
+
+	revisions, err := store.List("service", "service-001")
+	if err != nil {
+		t.Fatalf("list revisions error: %s", err)
+	}
+	if len(revisions) != 2 || revisions[0].Revision != 1 || revisions[1].Revision != 2 {
+		t.Fatalf("expected revisions 1 and 2, but got %+v", revisions)
+	}
+	if string(revisions[1].Object) != "kind: Service\n" {
+		t.Fatalf("unexpected object of revision 2: %s", revisions[1].Object)
+	}
+
+	_, err = store.Get(resource.KindService, "service-001", 3)
+	if err == nil {
+		t.Fatalf("get a nonexistent revision should fail")
+	}
+
+	revisions, err = store.List(resource.KindService, "service-002")
+	if err != nil || len(revisions) != 0 {
+		t.Fatalf("list revisions of a resource without history should be empty")
+	}
+
+	otherStore, err := NewLocalStore(store.(*localStore).dir, "127.0.0.2:2381")
+	if err != nil {
+		t.Fatalf("create store error: %s", err)
+	}
+	revisions, err = otherStore.List(resource.KindService, "service-001")
+	if err != nil || len(revisions) != 0 {
+		t.Fatalf("revisions of another server should be separated, but got %+v", revisions)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	reactorType := "__test_history_reactor"
+	fake.NewResourceReactorBuilder(reactorType).
+		AddReactor("get", resource.KindTenant, "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, []meta.MeshObject{resource.ToTenant(&v2alpha1.Tenant{Name: "tenant-001", Description: "previous"})}, nil
+		}).
+		AddReactor("*", "*", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, nil, nil
+		}).Added()
+
+	_, store := prepareStore(t)
+	recorder := NewRecorder(store, meshclient.NewFakeClient(reactorType), time.Second)
+
+	current := resource.ToTenant(&v2alpha1.Tenant{Name: "tenant-001", Description: "current"})
+	previous, err := recorder.Previous(current)
+	if err != nil || previous == nil {
+		t.Fatalf("get previous version should succeed, but %v", err)
+	}
+
+	err = recorder.Record(ActionApply, current, previous, current)
+	if err != nil {
+		t.Fatalf("record revision error: %s", err)
+	}
+
+	revision, err := store.Get(resource.KindTenant, "tenant-001", 1)
+	if err != nil {
+		t.Fatalf("get revision error: %s", err)
+	}
+	if !revision.Existed || !strings.Contains(revision.Diff, "+   description: current") {
+		t.Fatalf("unexpected revision %+v", revision)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.ParseFlags([]string{resource.KindTenant, "tenant-001"})
+	historyFlags := func(revision int) *flags.History {
+		return &flags.History{
+			AdminGlobal:  &flags.AdminGlobal{Server: store.(*localStore).server},
+			AdminHistory: &flags.AdminHistory{HistoryDir: store.(*localStore).dir},
+			Revision:     revision,
+		}
+	}
+	Run(cmd, historyFlags(0))
+	Run(cmd, historyFlags(1))
+}
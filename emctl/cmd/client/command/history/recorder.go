@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package history
+
+import (
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/get"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ActionApply is the action of emctl apply
+	ActionApply = "apply"
+	// ActionDelete is the action of emctl delete
+	ActionDelete = "delete"
+	// ActionRollback is the action of emctl rollback
+	ActionRollback = "rollback"
+	// ActionRestore is the action of emctl backup restore
+	ActionRestore = "restore"
+)
+
+// Recorder records revisions of the mesh resources mutated by emctl
+type Recorder struct {
+	store   Store
+	client  meshclient.MeshClient
+	timeout time.Duration
+}
+
+// NewRecorder creates a Recorder
+func NewRecorder(store Store, client meshclient.MeshClient, timeout time.Duration) *Recorder {
+	return &Recorder{
+		store:   store,
+		client:  client,
+		timeout: timeout,
+	}
+}
+
+// Previous gets the version of the object in the control plane before
+// it is mutated, it returns nil if the object doesn't exist.
+func (r *Recorder) Previous(object meta.MeshObject) (meta.MeshObject, error) {
+	objects, err := get.WrapGetterByMeshObject(object, r.client, r.timeout).Get()
+	if meshclient.IsNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get previous version of %s/%s", object.Kind(), object.Name())
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	return objects[0], nil
+}
+
+// Record records a revision of the object, current is nil if the object is deleted.
+func (r *Recorder) Record(action string, object, previous, current meta.MeshObject) error {
+	previousBuff, err := marshal(previous)
+	if err != nil {
+		return err
+	}
+	currentBuff, err := marshal(current)
+	if err != nil {
+		return err
+	}
+
+	return r.store.Save(&Revision{
+		Kind:      object.Kind(),
+		Name:      object.Name(),
+		Action:    action,
+		Author:    Author(),
+		Timestamp: time.Now(),
+		Diff:      Diff(string(previousBuff), string(currentBuff)),
+		Existed:   previous != nil,
+		Object:    previousBuff,
+	})
+}
+
+func marshal(object meta.MeshObject) ([]byte, error) {
+	if object == nil {
+		return nil, nil
+	}
+
+	buff, err := yaml.Marshal(object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal %s/%s to yaml failed", object.Kind(), object.Name())
+	}
+
+	return buff, nil
+}
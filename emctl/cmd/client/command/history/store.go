@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package history
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	historyDirName = ".emctl/history"
+
+	revisionFileExt       = ".yaml"
+	revisionObjectFileExt = ".object.yaml"
+)
+
+type (
+	// Revision is a record of a mutation of a mesh resource, it holds the
+	// object version before the mutation.
+	Revision struct {
+		Revision  int       `yaml:"revision"`
+		Kind      string    `yaml:"kind"`
+		Name      string    `yaml:"name"`
+		Action    string    `yaml:"action"`
+		Author    string    `yaml:"author"`
+		Timestamp time.Time `yaml:"timestamp"`
+		Diff      string    `yaml:"diff,omitempty"`
+
+		// Existed is false if the object did not exist before the mutation.
+		Existed bool `yaml:"existed"`
+		// Object is the previous object in YAML format, it is stored in a dedicated file.
+		Object []byte `yaml:"-"`
+	}
+
+	// Store stores the revisions of mesh resources.
+	Store interface {
+		// Save saves the revision and assigns a new revision number to it.
+		Save(revision *Revision) error
+		// List lists all revisions of the resource in ascending order.
+		List(kind, name string) ([]*Revision, error)
+		// Get gets a specific revision of the resource.
+		Get(kind, name string, revision int) (*Revision, error)
+		// ObjectPath returns the path of the file holding the object of the revision.
+		ObjectPath(kind, name string, revision int) string
+	}
+
+	localStore struct {
+		dir    string
+		server string
+	}
+)
+
+// DefaultDir returns the default directory of the local history store.
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "get user home dir failed")
+	}
+
+	return filepath.Join(homeDir, historyDirName), nil
+}
+
+// NewLocalStore creates a Store which keeps revisions of the control plane server
+// in a local directory, if dir is empty, the DefaultDir is used.
+// The revisions are kept per server, in case of mixing the histories of the
+// resources with the same kind and name in different control planes.
+func NewLocalStore(dir, server string) (Store, error) {
+	if dir == "" {
+		var err error
+		dir, err = DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &localStore{dir: dir, server: server}, nil
+}
+
+// Author returns the identity of current user in format user@host.
+func Author() string {
+	name := "unknown"
+	u, err := user.Current()
+	if err == nil {
+		name = u.Username
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return name
+	}
+
+	return name + "@" + host
+}
+
+func (s *localStore) resourceDir(kind, name string) string {
+	return filepath.Join(s.dir, url.PathEscape(s.server), strings.ToLower(kind), url.PathEscape(name))
+}
+
+func (s *localStore) revisionPath(kind, name string, revision int) string {
+	return filepath.Join(s.resourceDir(kind, name), strconv.Itoa(revision)+revisionFileExt)
+}
+
+func (s *localStore) ObjectPath(kind, name string, revision int) string {
+	return filepath.Join(s.resourceDir(kind, name), strconv.Itoa(revision)+revisionObjectFileExt)
+}
+
+func (s *localStore) revisionNumbers(kind, name string) ([]int, error) {
+	files, err := ioutil.ReadDir(s.resourceDir(kind, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read history of %s/%s failed", kind, name)
+	}
+
+	var numbers []int
+	for _, f := range files {
+		fileName := f.Name()
+		if strings.HasSuffix(fileName, revisionObjectFileExt) {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimSuffix(fileName, revisionFileExt))
+		if err != nil {
+			continue
+		}
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	return numbers, nil
+}
+
+func (s *localStore) Save(revision *Revision) error {
+	numbers, err := s.revisionNumbers(revision.Kind, revision.Name)
+	if err != nil {
+		return err
+	}
+
+	revision.Revision = 1
+	if len(numbers) != 0 {
+		revision.Revision = numbers[len(numbers)-1] + 1
+	}
+
+	err = os.MkdirAll(s.resourceDir(revision.Kind, revision.Name), 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "create history dir of %s/%s failed", revision.Kind, revision.Name)
+	}
+
+	if revision.Existed {
+		objectPath := s.ObjectPath(revision.Kind, revision.Name, revision.Revision)
+		err = ioutil.WriteFile(objectPath, revision.Object, 0o644)
+		if err != nil {
+			return errors.Wrapf(err, "write file %s failed", objectPath)
+		}
+	}
+
+	buff, err := yaml.Marshal(revision)
+	if err != nil {
+		return errors.Wrapf(err, "marshal %+v to yaml failed", revision)
+	}
+
+	revisionPath := s.revisionPath(revision.Kind, revision.Name, revision.Revision)
+	err = ioutil.WriteFile(revisionPath, buff, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "write file %s failed", revisionPath)
+	}
+
+	return nil
+}
+
+func (s *localStore) List(kind, name string) ([]*Revision, error) {
+	numbers, err := s.revisionNumbers(kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*Revision, 0, len(numbers))
+	for _, number := range numbers {
+		revision, err := s.Get(kind, name, number)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+func (s *localStore) Get(kind, name string, number int) (*Revision, error) {
+	revisionPath := s.revisionPath(kind, name, number)
+	buff, err := ioutil.ReadFile(revisionPath)
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("revision %d of %s/%s not found", number, kind, name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read file %s failed", revisionPath)
+	}
+
+	revision := &Revision{}
+	err = yaml.Unmarshal(buff, revision)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal %s to yaml failed", buff)
+	}
+
+	if revision.Existed {
+		objectPath := s.ObjectPath(kind, name, number)
+		revision.Object, err = ioutil.ReadFile(objectPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read file %s failed", objectPath)
+		}
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/audit"
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Action is a lifecycle operation applied to a service instance
+type Action string
+
+const (
+	// ActionDrain cordons the instance, then waits a grace period for its in-flight traffic
+	ActionDrain Action = "drain"
+	// ActionCordon takes the instance out of the load balancing
+	ActionCordon Action = "cordon"
+
+	waitStatusInterval = time.Second
+)
+
+// Run is the entrypoint of the emctl instance sub commands
+func Run(cmd *cobra.Command, action Action, flag *flags.Instance) {
+	if flag.Server == "" {
+		flag.Server = flags.GetServerAddress()
+	}
+
+	cmdArgs := cmd.Flags().Args()
+	if len(cmdArgs) != 1 {
+		common.ExitWithErrorf("invalid command args: support <service name>/<instance id>")
+	}
+
+	instance := &resource.ServiceInstance{
+		MeshResource: resource.NewServiceInstanceResource(resource.DefaultAPIVersion, cmdArgs[0]),
+	}
+	serviceName, instanceID, err := instance.ParseName()
+	if err != nil {
+		common.ExitWithError(err)
+	}
+
+	l := &lifecycle{
+		client:  meshclient.New(flag.Server),
+		timeout: flag.Timeout,
+	}
+
+	switch action {
+	case ActionCordon:
+		err = l.cordon(serviceName, instanceID)
+	case ActionDrain:
+		err = l.drain(serviceName, instanceID, flag.Wait, flag.GracePeriod)
+	default:
+		err = errors.Errorf("unknown action %s", action)
+	}
+	audit.New(flag.AdminAudit, flag.Server).Record(string(action), instance, nil, nil, err)
+	if err != nil {
+		common.ExitWithErrorf("%s/%s %s failed: %v", resource.KindServiceInstance, instance.Name(), action, err)
+	}
+
+	fmt.Printf("%s/%s %s successfully\n", resource.KindServiceInstance, instance.Name(), pastTense(action))
+}
+
+func pastTense(action Action) string {
+	if action == ActionDrain {
+		return "drained"
+	}
+	return string(action) + "ed"
+}
+
+type lifecycle struct {
+	client  meshclient.MeshClient
+	timeout time.Duration
+}
+
+// cordon offlines the instance by the control plane, whose DELETE of the instance is the
+// offline API: it marks the instance OUT_OF_SERVICE and keeps it until the sidecar registers
+// again, such as the pod restarts. It fails if the instance is not kept, so an instance is
+// never de-registered in the name of cordoning.
+// NOTE: The control plane has no API to put an offlined instance back to UP, so there is
+// no uncordon.
+func (l *lifecycle) cordon(serviceName, instanceID string) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), l.timeout)
+	defer cancelFunc()
+
+	err := l.client.V2Alpha1().ServiceInstance().Delete(ctx, serviceName, instanceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.client.V2Alpha1().ServiceInstance().Get(ctx, serviceName, instanceID)
+	if meshclient.IsNotFoundError(err) {
+		return errors.Errorf("service instance %s/%s is de-registered instead of out of service", serviceName, instanceID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "get service instance %s/%s", serviceName, instanceID)
+	}
+
+	return nil
+}
+
+// drain cordons the instance, then waits until the control plane reports it
+// out of service, and waits the grace period for its in-flight traffic.
+// NOTE: The control plane doesn't expose the in-flight traffic of an instance,
+// so the grace period is a fixed duration instead of watching the traffic.
+func (l *lifecycle) drain(serviceName, instanceID string, wait bool, gracePeriod time.Duration) error {
+	err := l.cordon(serviceName, instanceID)
+	if err != nil {
+		return err
+	}
+
+	if !wait {
+		return nil
+	}
+
+	err = l.waitStatus(serviceName, instanceID, resource.ServiceInstanceStatusOutOfService)
+	if err != nil {
+		return err
+	}
+
+	if gracePeriod > 0 {
+		fmt.Printf("waiting grace period %s for in-flight traffic of %s/%s\n", gracePeriod, serviceName, instanceID)
+		time.Sleep(gracePeriod)
+	}
+
+	return nil
+}
+
+func (l *lifecycle) waitStatus(serviceName, instanceID, status string) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), l.timeout)
+	defer cancelFunc()
+
+	for {
+		instance, err := l.client.V2Alpha1().ServiceInstance().Get(ctx, serviceName, instanceID)
+		if err != nil {
+			return errors.Wrapf(err, "get service instance %s/%s", serviceName, instanceID)
+		}
+		if instance.Spec != nil && instance.Spec.Status == status {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("wait status of service instance %s/%s to be %s timeout", serviceName, instanceID, status)
+		case <-time.After(waitStatusInterval):
+		}
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient/fake"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+
+	"bou.ke/monkey"
+	"github.com/megaease/easemesh-api/v2alpha1"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func prepareInstanceFlags(server string) *flags.Instance {
+	return &flags.Instance{
+		AdminGlobal: &flags.AdminGlobal{Server: server, Timeout: time.Second},
+		AdminAudit:  &flags.AdminAudit{AuditLogFile: filepath.Join(os.TempDir(), "emctl-instance-test-audit.jsonl")},
+		Wait:        true,
+	}
+}
+
+func TestInstanceRun(t *testing.T) {
+	reactorType := "__test_instance_reactor"
+	fake.NewResourceReactorBuilder(reactorType).
+		AddReactor("*", resource.KindServiceInstance, "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			instance := resource.ToServiceInstance(&v2alpha1.ServiceInstance{
+				ServiceName: "service-001",
+				InstanceID:  "instance-001",
+				Status:      resource.ServiceInstanceStatusOutOfService,
+			})
+			return true, []meta.MeshObject{instance}, nil
+		}).Added()
+
+	for _, action := range []Action{ActionDrain, ActionCordon} {
+		cmd := &cobra.Command{}
+		cmd.ParseFlags([]string{"service-001/instance-001"})
+		Run(cmd, action, prepareInstanceFlags(reactorType))
+	}
+}
+
+func TestInstanceRunFail(t *testing.T) {
+	exitCode := 0
+	patch := monkey.Patch(os.Exit, func(code int) {
+		if code != 0 {
+			exitCode = code
+		}
+	})
+	defer patch.Unpatch()
+
+	reactorType := "__test_instance_reactor_fail"
+	fake.NewResourceReactorBuilder(reactorType).
+		AddReactor("*", "*", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, nil, errors.Errorf("mock instance error")
+		}).Added()
+
+	cmd := &cobra.Command{}
+	cmd.ParseFlags([]string{"service-001/instance-001"})
+	Run(cmd, ActionDrain, prepareInstanceFlags(reactorType))
+	if exitCode == 0 {
+		t.Fatalf("drain should fail")
+	}
+
+	exitCode = 0
+	cmd = &cobra.Command{}
+	cmd.ParseFlags([]string{"invalid-instance-name"})
+	Run(cmd, ActionCordon, prepareInstanceFlags(reactorType))
+	if exitCode == 0 {
+		t.Fatalf("cordon with an invalid name should fail")
+	}
+}
+
+func TestDrainWaitTimeout(t *testing.T) {
+	reactorType := "__test_instance_reactor_up"
+	fake.NewResourceReactorBuilder(reactorType).
+		AddReactor("*", resource.KindServiceInstance, "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			instance := resource.ToServiceInstance(&v2alpha1.ServiceInstance{
+				ServiceName: "service-001",
+				InstanceID:  "instance-001",
+				Status:      resource.ServiceInstanceStatusUp,
+			})
+			return true, []meta.MeshObject{instance}, nil
+		}).Added()
+
+	l := &lifecycle{client: meshclient.NewFakeClient(reactorType), timeout: 10 * time.Millisecond}
+	err := l.drain("service-001", "instance-001", true, 0)
+	if err == nil {
+		t.Fatalf("drain should time out when the instance is still up")
+	}
+}
+
+func TestCordonDeregistered(t *testing.T) {
+	reactorType := "__test_instance_reactor_deregistered"
+	fake.NewResourceReactorBuilder(reactorType).
+		AddReactor("*", resource.KindServiceInstance, "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, nil, nil
+		}).Added()
+
+	l := &lifecycle{client: meshclient.NewFakeClient(reactorType), timeout: 10 * time.Millisecond}
+	err := l.cordon("service-001", "instance-001")
+	if err == nil {
+		t.Fatalf("cordon should fail when the instance is de-registered")
+	}
+}
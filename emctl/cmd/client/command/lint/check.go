@@ -0,0 +1,290 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/megaease/easemesh-api/v2alpha1"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+)
+
+const (
+	ruleInvalidResource             = "invalid-resource"
+	ruleServiceWithoutResilience    = "service-without-resilience"
+	ruleRetryWithoutTimeout         = "retry-without-timeout"
+	ruleUnreachableCircuitBreaker   = "unreachable-circuit-breaker"
+	ruleTimeLimiterShorterThanRetry = "timelimiter-shorter-than-retry"
+	ruleMockEnabled                 = "mock-enabled"
+	ruleDuplicateName               = "duplicate-name"
+	ruleUnknownField                = "unknown-field"
+	ruleUnsupportedLocalityPolicy   = "unsupported-locality-policy"
+)
+
+type (
+	check struct {
+		rule        string
+		description string
+		fn          func(docs []*document) []*Finding
+	}
+
+	// resilienceSpec is a resilience configuration which could be defined
+	// in a Service or in a standalone Resilience.
+	resilienceSpec struct {
+		doc  *document
+		spec *v2alpha1.Resilience
+	}
+)
+
+// checks are all checks run by lint, invalid resources are reported while loading.
+var checks = []*check{
+	{
+		rule:        ruleServiceWithoutResilience,
+		description: "Service has no resilience configuration",
+		fn:          checkServiceWithoutResilience,
+	},
+	{
+		rule:        ruleRetryWithoutTimeout,
+		description: "Retry is configured without a TimeLimiter",
+		fn:          checkRetryWithoutTimeout,
+	},
+	{
+		rule:        ruleUnreachableCircuitBreaker,
+		description: "CircuitBreaker thresholds can never be reached",
+		fn:          checkUnreachableCircuitBreaker,
+	},
+	{
+		rule:        ruleTimeLimiterShorterThanRetry,
+		description: "TimeLimiter timeout is shorter than the retry backoff",
+		fn:          checkTimeLimiterShorterThanRetry,
+	},
+	{
+		rule:        ruleMockEnabled,
+		description: "Mock is left enabled",
+		fn:          checkMockEnabled,
+	},
+	{
+		rule:        ruleDuplicateName,
+		description: "Resources with the same kind and name are defined more than once",
+		fn:          checkDuplicateName,
+	},
+	{
+		rule:        ruleUnknownField,
+		description: "Fields unknown to the resource are silently ignored",
+		fn:          checkUnknownField,
+	},
+	{
+		rule:        ruleUnsupportedLocalityPolicy,
+		description: "Locality policies are not carried out by the sidecar yet",
+		fn:          checkUnsupportedLocalityPolicy,
+	},
+}
+
+func ruleDescription(rule string) string {
+	if rule == ruleInvalidResource {
+		return "Resource can't be decoded or validated"
+	}
+	for _, c := range checks {
+		if c.rule == rule {
+			return c.description
+		}
+	}
+	return rule
+}
+
+func resilienceSpecs(docs []*document) []*resilienceSpec {
+	var specs []*resilienceSpec
+	for _, doc := range docs {
+		switch object := doc.object.(type) {
+		case *resource.Service:
+			if object.Spec != nil && object.Spec.Resilience != nil {
+				specs = append(specs, &resilienceSpec{doc: doc, spec: object.Spec.Resilience})
+			}
+		case *resource.Resilience:
+			if object.Spec != nil {
+				specs = append(specs, &resilienceSpec{doc: doc, spec: object.Spec})
+			}
+		}
+	}
+	return specs
+}
+
+func checkServiceWithoutResilience(docs []*document) []*Finding {
+	configured := map[string]bool{}
+	for _, rs := range resilienceSpecs(docs) {
+		configured[rs.doc.object.Name()] = true
+	}
+
+	var findings []*Finding
+	for _, doc := range docs {
+		if _, ok := doc.object.(*resource.Service); !ok {
+			continue
+		}
+		if configured[doc.object.Name()] {
+			continue
+		}
+		findings = append(findings, doc.finding(ruleServiceWithoutResilience, LevelWarning, "",
+			"service %s has no resilience configuration", doc.object.Name()))
+	}
+
+	return findings
+}
+
+func checkRetryWithoutTimeout(docs []*document) []*Finding {
+	var findings []*Finding
+	for _, rs := range resilienceSpecs(docs) {
+		if rs.spec.Retry == nil {
+			continue
+		}
+		if rs.spec.TimeLimiter != nil && rs.spec.TimeLimiter.Timeout != "" {
+			continue
+		}
+		findings = append(findings, rs.doc.finding(ruleRetryWithoutTimeout, LevelWarning, "retry",
+			"retry of %s is configured without a timeLimiter, a hanging call is never retried", rs.doc.object.Name()))
+	}
+
+	return findings
+}
+
+func checkUnreachableCircuitBreaker(docs []*document) []*Finding {
+	var findings []*Finding
+	for _, rs := range resilienceSpecs(docs) {
+		cb := rs.spec.CircuitBreaker
+		if cb == nil {
+			continue
+		}
+
+		report := func(format string, a ...interface{}) {
+			findings = append(findings, rs.doc.finding(ruleUnreachableCircuitBreaker, LevelWarning, "circuitBreaker",
+				"circuitBreaker of %s never opens: %s", rs.doc.object.Name(), fmt.Sprintf(format, a...)))
+		}
+
+		if cb.FailureRateThreshold > 100 {
+			report("failureRateThreshold %d is greater than 100%%", cb.FailureRateThreshold)
+		}
+		if cb.SlowCallRateThreshold > 100 {
+			report("slowCallRateThreshold %d is greater than 100%%", cb.SlowCallRateThreshold)
+		}
+		if cb.SlidingWindowType != "TIME_BASED" && cb.SlidingWindowSize != 0 &&
+			cb.MinimumNumberOfCalls > cb.SlidingWindowSize {
+			report("minimumNumberOfCalls %d is greater than slidingWindowSize %d",
+				cb.MinimumNumberOfCalls, cb.SlidingWindowSize)
+		}
+
+		if cb.SlowCallRateThreshold != 0 && rs.spec.TimeLimiter != nil {
+			slow, err1 := time.ParseDuration(cb.SlowCallDurationThreshold)
+			timeout, err2 := time.ParseDuration(rs.spec.TimeLimiter.Timeout)
+			if err1 == nil && err2 == nil && slow >= timeout {
+				report("slowCallDurationThreshold %s is not shorter than the timeLimiter timeout %s",
+					cb.SlowCallDurationThreshold, rs.spec.TimeLimiter.Timeout)
+			}
+		}
+	}
+
+	return findings
+}
+
+func checkTimeLimiterShorterThanRetry(docs []*document) []*Finding {
+	var findings []*Finding
+	for _, rs := range resilienceSpecs(docs) {
+		if rs.spec.Retry == nil || rs.spec.TimeLimiter == nil {
+			continue
+		}
+
+		wait, err1 := time.ParseDuration(rs.spec.Retry.WaitDuration)
+		timeout, err2 := time.ParseDuration(rs.spec.TimeLimiter.Timeout)
+		if err1 != nil || err2 != nil || timeout >= wait {
+			continue
+		}
+
+		findings = append(findings, rs.doc.finding(ruleTimeLimiterShorterThanRetry, LevelWarning, "timeLimiter",
+			"timeLimiter timeout %s of %s is shorter than the retry waitDuration %s, retries are cut off by the timeout",
+			rs.spec.TimeLimiter.Timeout, rs.doc.object.Name(), rs.spec.Retry.WaitDuration))
+	}
+
+	return findings
+}
+
+func checkMockEnabled(docs []*document) []*Finding {
+	var findings []*Finding
+	for _, doc := range docs {
+		var mock *v2alpha1.Mock
+		switch object := doc.object.(type) {
+		case *resource.Service:
+			if object.Spec != nil {
+				mock = object.Spec.Mock
+			}
+		case *resource.Mock:
+			mock = object.Spec
+		}
+
+		if mock == nil || !mock.Enabled {
+			continue
+		}
+		findings = append(findings, doc.finding(ruleMockEnabled, LevelWarning, "enabled",
+			"mock of %s is enabled, the real service is not called", doc.object.Name()))
+	}
+
+	return findings
+}
+
+func checkUnsupportedLocalityPolicy(docs []*document) []*Finding {
+	var findings []*Finding
+	for _, doc := range docs {
+		lb, ok := doc.object.(*resource.LoadBalance)
+		if !ok || lb.Spec == nil || !lb.Spec.IsLocality() {
+			continue
+		}
+		findings = append(findings, doc.finding(ruleUnsupportedLocalityPolicy, LevelWarning, "policy",
+			"policy %s of %s is not carried out by the sidecar yet", lb.Spec.Policy, lb.Name()))
+	}
+
+	return findings
+}
+
+func checkDuplicateName(docs []*document) []*Finding {
+	first := map[string]*document{}
+
+	var findings []*Finding
+	for _, doc := range docs {
+		key := doc.object.Kind() + "/" + doc.object.Name()
+		prev, exists := first[key]
+		if !exists {
+			first[key] = doc
+			continue
+		}
+		findings = append(findings, doc.finding(ruleDuplicateName, LevelError, "name",
+			"%s is already defined in %s:%d", key, prev.file, prev.line))
+	}
+
+	return findings
+}
+
+func checkUnknownField(docs []*document) []*Finding {
+	var findings []*Finding
+	for _, doc := range docs {
+		for _, field := range unknownFields(doc.raw, reflect.TypeOf(doc.object), "") {
+			findings = append(findings, doc.finding(ruleUnknownField, LevelWarning, field.key,
+				"unknown field %s is ignored", field.path))
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,301 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	"github.com/megaease/easemeshctl/cmd/client/util"
+	"github.com/megaease/easemeshctl/cmd/client/valid"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	yamljsontool "github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type (
+	// Level is the severity of a finding, the values are the same as SARIF.
+	Level string
+
+	// Finding is a problem found in the configuration files.
+	Finding struct {
+		Rule    string `json:"rule"`
+		Level   Level  `json:"level"`
+		Message string `json:"message"`
+		File    string `json:"file"`
+		Line    int    `json:"line"`
+		Kind    string `json:"kind,omitempty"`
+		Name    string `json:"name,omitempty"`
+	}
+
+	// document is a YAML document in a configuration file.
+	document struct {
+		file  string
+		line  int
+		lines []string
+		raw   map[string]interface{}
+
+		object meta.MeshObject
+	}
+)
+
+const (
+	// LevelError means the configuration is wrong.
+	LevelError Level = "error"
+	// LevelWarning means the configuration violates a best practice.
+	LevelWarning Level = "warning"
+)
+
+// Run is the entrypoint of the emctl lint subcommand
+func Run(cmd *cobra.Command, flag *flags.Lint) {
+	if flag.YamlFile == "" {
+		common.ExitWithErrorf("no resource specified")
+	}
+
+	findings, err := Lint(flag.YamlFile, flag.Recursive)
+	if err != nil {
+		common.ExitWithErrorf("lint %s failed: %v", flag.YamlFile, err)
+	}
+
+	err = newPrinter(flag.OutputFormat).print(os.Stdout, findings)
+	if err != nil {
+		common.ExitWithErrorf("print findings failed: %v", err)
+	}
+
+	errCount := 0
+	for _, finding := range findings {
+		if finding.Level == LevelError {
+			errCount++
+		}
+	}
+	if errCount > 0 {
+		common.ExitWithErrorf("%d errors found", errCount)
+	}
+}
+
+// Lint checks all configuration files in the location without contacting the server.
+func Lint(location string, recursive bool) ([]*Finding, error) {
+	files, err := expandFiles(location, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*document
+	var findings []*Finding
+	for _, file := range files {
+		fileDocs, fileFindings, err := loadDocuments(file)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, fileDocs...)
+		findings = append(findings, fileFindings...)
+	}
+
+	for _, check := range checks {
+		findings = append(findings, check.fn(docs)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+
+	return findings, nil
+}
+
+func expandFiles(location string, recursive bool) ([]string, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") || location == "-" {
+		return nil, errors.Errorf("lint only supports files and directories")
+	}
+
+	var files []string
+	err := filepath.Walk(location, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if fi.IsDir() {
+			if path != location && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if path != location && !hasExtension(path) {
+			return nil
+		}
+
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func hasExtension(path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range util.FileExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// loadDocuments splits the file into documents and decodes them into mesh objects,
+// the documents failed to be decoded are reported as findings.
+func loadDocuments(file string) ([]*document, []*Finding, error) {
+	buff, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "read file %s failed", file)
+	}
+
+	var docs []*document
+	var findings []*Finding
+	for _, doc := range splitDocuments(file, buff) {
+		content := []byte(strings.Join(doc.lines, "\n"))
+		if len(bytes.TrimSpace(content)) == 0 {
+			continue
+		}
+
+		jsonBuff, err := yamljsontool.YAMLToJSON(content)
+		if err != nil {
+			findings = append(findings, doc.finding(ruleInvalidResource, LevelError, "", "invalid YAML: %v", err))
+			continue
+		}
+		if bytes.Equal(bytes.TrimSpace(jsonBuff), []byte("null")) {
+			continue
+		}
+
+		err = json.Unmarshal(jsonBuff, &doc.raw)
+		if err != nil {
+			findings = append(findings, doc.finding(ruleInvalidResource, LevelError, "", "document is not an object: %v", err))
+			continue
+		}
+
+		object, err := decodeObject(jsonBuff)
+		if err != nil {
+			findings = append(findings, doc.finding(ruleInvalidResource, LevelError, "", "%v", err))
+			continue
+		}
+		doc.object = object
+
+		docs = append(docs, doc)
+	}
+
+	return docs, findings, nil
+}
+
+// splitDocuments splits the content by the YAML document separator,
+// JSON files are treated as a single document.
+func splitDocuments(file string, buff []byte) []*document {
+	doc := &document{file: file, line: 1}
+	if filepath.Ext(file) == ".json" {
+		doc.lines = strings.Split(string(buff), "\n")
+		return []*document{doc}
+	}
+
+	docs := []*document{doc}
+	scanner := bufio.NewScanner(bytes.NewReader(buff))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if line == "---" || strings.HasPrefix(line, "--- ") {
+			doc = &document{file: file, line: lineNumber + 1}
+			docs = append(docs, doc)
+			continue
+		}
+		doc.lines = append(doc.lines, line)
+	}
+
+	return docs
+}
+
+func decodeObject(jsonBuff []byte) (meta.MeshObject, error) {
+	vk := meta.VersionKind{}
+	err := json.Unmarshal(jsonBuff, &vk)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal data to resource.VersionKind failed")
+	}
+
+	object, err := resource.NewObjectCreator().NewFromKind(vk)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(jsonBuff, object)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal data to MeshObject failed")
+	}
+
+	vr := valid.Validate(object)
+	if !vr.Valid() {
+		return nil, errors.Errorf("%s/%s is invalid:\n%s", object.Kind(), object.Name(), vr)
+	}
+
+	return object, nil
+}
+
+// lineOf returns the line number of the first key in the document,
+// it returns the first line of the document if the key is not found.
+func (d *document) lineOf(key string) int {
+	if key == "" {
+		return d.line
+	}
+
+	for i, line := range d.lines {
+		line = strings.TrimLeft(strings.TrimSpace(line), "- \"")
+		if strings.HasPrefix(line, key+":") || strings.HasPrefix(line, key+"\":") {
+			return d.line + i
+		}
+	}
+
+	return d.line
+}
+
+func (d *document) finding(rule string, level Level, key string, format string, a ...interface{}) *Finding {
+	finding := &Finding{
+		Rule:    rule,
+		Level:   level,
+		Message: fmt.Sprintf(format, a...),
+		File:    d.file,
+		Line:    d.lineOf(key),
+	}
+	if d.object != nil {
+		finding.Kind, finding.Name = d.object.Kind(), d.object.Name()
+	}
+	return finding
+}
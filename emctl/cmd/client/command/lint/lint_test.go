@@ -0,0 +1,236 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const serviceYAML = `apiVersion: mesh.megaease.com/v2alpha1
+kind: Service
+metadata:
+  name: order
+spec:
+  registerTenant: shop
+  sidecar:
+    discoveryType: eureka
+    address: "127.0.0.1"
+    ingressPort: 13001
+    ingressProtocol: http
+    egressPort: 13002
+    egressProtocol: http
+  mock:
+    enabled: true
+---
+apiVersion: mesh.megaease.com/v2alpha1
+kind: Service
+metadata:
+  name: payment
+spec:
+  registerTenant: shop
+  sidecar:
+    discoveryType: eureka
+    address: "127.0.0.1"
+    ingressPort: 13001
+    ingressProtocol: http
+    egressPort: 13002
+    egressProtocol: http
+  loadbalancer:
+    policy: random
+`
+
+const resilienceYAML = `apiVersion: mesh.megaease.com/v2alpha1
+kind: Resilience
+metadata:
+  name: order
+spec:
+  retry:
+    maxAttempts: 3
+    waitDuration: 2s
+  timeLimiter:
+    timeout: 1s
+  circuitBreaker:
+    slidingWindowType: COUNT_BASED
+    failureRateThreshold: 50
+    slidingWindowSize: 10
+    minimumNumberOfCalls: 20
+---
+apiVersion: mesh.megaease.com/v2alpha1
+kind: Resilience
+metadata:
+  name: payment
+spec:
+  retry:
+    maxAttempts: 3
+    waitDuration: 500ms
+---
+apiVersion: mesh.megaease.com/v2alpha1
+kind: Resilience
+metadata:
+  name: payment
+spec:
+  timeLimiter:
+    timeout: 1s
+`
+
+const loadBalanceYAML = `apiVersion: mesh.megaease.com/v2alpha1
+kind: LoadBalance
+metadata:
+  name: payment
+spec:
+  policy: localityRoundRobin
+`
+
+func prepareFiles(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "emctl-lint")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	files := map[string]string{
+		"service.yaml":     serviceYAML,
+		"resilience.yaml":  resilienceYAML,
+		"loadbalance.yaml": loadBalanceYAML,
+		"README.md":        "not a configuration",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("write %s failed: %v", name, err)
+		}
+	}
+
+	return dir
+}
+
+func TestLint(t *testing.T) {
+	dir := prepareFiles(t)
+
+	findings, err := Lint(dir, true)
+	if err != nil {
+		t.Fatalf("lint failed: %v", err)
+	}
+
+	rules := map[string]int{}
+	for _, f := range findings {
+		rules[f.Rule]++
+	}
+
+	expected := map[string]int{
+		ruleMockEnabled:                 1,
+		ruleUnknownField:                1,
+		ruleTimeLimiterShorterThanRetry: 1,
+		ruleUnreachableCircuitBreaker:   1,
+		ruleRetryWithoutTimeout:         1,
+		ruleDuplicateName:               1,
+		ruleUnsupportedLocalityPolicy:   1,
+	}
+	for rule, count := range expected {
+		if rules[rule] != count {
+			t.Errorf("expect %d findings of %s, but got %d: %+v", count, rule, rules[rule], findings)
+		}
+	}
+	if rules[ruleServiceWithoutResilience] != 0 {
+		t.Errorf("expect no findings of %s", ruleServiceWithoutResilience)
+	}
+
+	for _, f := range findings {
+		if f.Rule == ruleUnknownField && f.Line != 30 {
+			t.Errorf("expect unknown field at line 30, but got %d", f.Line)
+		}
+		if f.Rule == ruleDuplicateName && f.Line != 29 {
+			t.Errorf("expect duplicate name at line 29, but got %d", f.Line)
+		}
+	}
+}
+
+func TestLintServiceWithoutResilience(t *testing.T) {
+	dir := prepareFiles(t)
+	os.Remove(filepath.Join(dir, "resilience.yaml"))
+
+	findings, err := Lint(filepath.Join(dir, "service.yaml"), false)
+	if err != nil {
+		t.Fatalf("lint failed: %v", err)
+	}
+
+	count := 0
+	for _, f := range findings {
+		if f.Rule == ruleServiceWithoutResilience {
+			count++
+		}
+	}
+	if count != 2 {
+		t.Fatalf("expect 2 services without resilience, but got %d", count)
+	}
+}
+
+func TestLintInvalidResource(t *testing.T) {
+	dir := prepareFiles(t)
+	err := ioutil.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte("kind: Service\nmetadata:\n  name: x\nspec:\n  registerTenant: shop\n"), 0644)
+	if err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	findings, err := Lint(dir, true)
+	if err != nil {
+		t.Fatalf("lint failed: %v", err)
+	}
+
+	for _, f := range findings {
+		if f.Rule == ruleInvalidResource && f.Level == LevelError {
+			return
+		}
+	}
+	t.Fatalf("expect invalid resource finding, but got %+v", findings)
+}
+
+func TestPrinter(t *testing.T) {
+	findings := []*Finding{{
+		Rule:    ruleMockEnabled,
+		Level:   LevelWarning,
+		Message: "mock of order is enabled",
+		File:    "service.yaml",
+		Line:    14,
+	}}
+
+	for _, format := range []string{"text", "json", "sarif"} {
+		buff := &bytes.Buffer{}
+		err := newPrinter(format).print(buff, findings)
+		if err != nil {
+			t.Fatalf("print %s failed: %v", format, err)
+		}
+		if format != "text" && !json.Valid(buff.Bytes()) {
+			t.Fatalf("expect valid json of %s, but got %s", format, buff)
+		}
+	}
+
+	buff := &bytes.Buffer{}
+	newPrinter("sarif").print(buff, findings)
+	log := &sarifLog{}
+	json.Unmarshal(buff.Bytes(), log)
+	if len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 ||
+		log.Runs[0].Results[0].Locations[0].PhysicalLocation.Region.StartLine != 14 {
+		t.Fatalf("unexpected sarif output: %s", buff)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/megaease/easemeshctl/cmd/common"
+)
+
+type (
+	printer struct {
+		outputFormat string
+	}
+
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     Level           `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           sarifRegion           `json:"region"`
+	}
+
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+
+	sarifRegion struct {
+		StartLine int `json:"startLine"`
+	}
+)
+
+func newPrinter(outputFormat string) *printer {
+	return &printer{outputFormat: outputFormat}
+}
+
+func (p *printer) print(w io.Writer, findings []*Finding) error {
+	switch p.outputFormat {
+	case "text":
+		return p.printText(w, findings)
+	case "json":
+		return p.printJSON(w, findings)
+	case "sarif":
+		return p.printSARIF(w, findings)
+	default:
+		common.ExitWithErrorf("unsupported output format: %s", p.outputFormat)
+	}
+	return nil
+}
+
+func (p *printer) printText(w io.Writer, findings []*Finding) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No problem found")
+		return err
+	}
+
+	for _, f := range findings {
+		_, err := fmt.Fprintf(w, "%s:%d: %s: %s [%s]\n", f.File, f.Line, f.Level, f.Message, f.Rule)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *printer) printJSON(w io.Writer, findings []*Finding) error {
+	if findings == nil {
+		findings = []*Finding{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(findings)
+}
+
+func (p *printer) printSARIF(w io.Writer, findings []*Finding) error {
+	rules := []sarifRule{{
+		ID:               ruleInvalidResource,
+		ShortDescription: sarifMessage{Text: ruleDescription(ruleInvalidResource)},
+	}}
+	for _, c := range checks {
+		rules = append(rules, sarifRule{ID: c.rule, ShortDescription: sarifMessage{Text: c.description}})
+	}
+
+	results := []sarifResult{}
+	for _, f := range findings {
+		results = append(results, sarifResult{
+			RuleID:  f.Rule,
+			Level:   f.Level,
+			Message: sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(f.File)},
+					Region:           sarifRegion{StartLine: f.Line},
+				},
+			}},
+		})
+	}
+
+	log := &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "emctl lint",
+				InformationURI: "https://github.com/megaease/easemesh",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
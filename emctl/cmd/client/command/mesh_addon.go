@@ -0,0 +1,317 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/addon"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/installation"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	// NOTE: The add-ons shipped with emctl register themselves to the add-on registry.
+	_ "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/shadowservice"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var loadAddOnsOnce sync.Once
+
+// AddOnCmd invokes addon sub command entrypoint
+func AddOnCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "addon",
+		Short:   "Manage add-ons of the EaseMesh",
+		Example: "emctl addon list | emctl addon install shadowservice | emctl addon status",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			loadAddOns()
+		},
+	}
+
+	cmd.AddCommand(addOnListCmd(), addOnInstallCmd(), addOnUninstallCmd(), addOnStatusCmd())
+
+	return cmd
+}
+
+func addOnListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "List add-ons supported by emctl",
+		Example: "emctl addon list",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			table := newAddOnTable([]string{"Name", "Version", "Dependencies", "Description"})
+			for _, a := range addon.List() {
+				table.Append([]string{a.Name, a.Version, strings.Join(a.Dependencies, ","), a.Description})
+			}
+			table.Render()
+		},
+	}
+}
+
+func addOnInstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "install <add-on name>...",
+		Short:   "Install add-ons with their dependencies into the installed EaseMesh",
+		Example: "emctl addon install shadowservice --shadowservice-controller-image megaease/easemesh-shadowservice-controller:v1.0.0",
+	}
+
+	flags := &flags.AddOnInstall{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if flags.SpecFile != "" {
+			buff, err := ioutil.ReadFile(flags.SpecFile)
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+
+			err = yaml.Unmarshal(buff, flags.Install)
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+		}
+		installAddOns(cmd, flags, uniqueAddOn(append(args, flags.AddOns...)))
+	}
+
+	return cmd
+}
+
+func addOnUninstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "uninstall <add-on name>...",
+		Short:   "Uninstall add-ons from the EaseMesh",
+		Example: "emctl addon uninstall shadowservice",
+	}
+
+	flags := &flags.AddOn{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		uninstallAddOns(cmd, flags, uniqueAddOn(args))
+	}
+
+	return cmd
+}
+
+func addOnStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "status [add-on name]...",
+		Short:   "Show status of add-ons of the EaseMesh",
+		Example: "emctl addon status shadowservice",
+	}
+
+	flags := &flags.AddOn{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		addOnStatus(cmd, flags, uniqueAddOn(args))
+	}
+
+	return cmd
+}
+
+func installAddOns(cmd *cobra.Command, flags *flags.AddOnInstall, names []string) {
+	if len(names) == 0 {
+		common.ExitWithErrorf("nothing to install")
+	}
+
+	stages, err := addOnInstallStages(names)
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+
+	context := newInstallContext(cmd, flags.Install)
+	state, err := installation.LoadState(context)
+	if err != nil {
+		common.ExitWithErrorf("load installation state failed: %v", err)
+	}
+	// NOTE: The installed dependencies are skipped, but the requested add-ons are installed again.
+	state.Reset(names...)
+
+	install := installation.NewResumable(state, stages...)
+	err = install.DoInstallStage(context)
+	if err != nil {
+		install.ClearFailedStage(context)
+		common.ExitWithErrorf("install add-ons error: %s\nthe installed add-ons are kept, "+
+			"run `emctl addon install` again after fixing it", err)
+	}
+
+	fmt.Println("Done.")
+}
+
+func uninstallAddOns(cmd *cobra.Command, flags *flags.AddOn, names []string) {
+	if len(names) == 0 {
+		common.ExitWithErrorf("nothing to uninstall")
+	}
+
+	context := newOperationContext(cmd, flags.OperationGlobal)
+	state, err := installation.LoadState(context)
+	if err != nil {
+		common.ExitWithErrorf("load installation state failed: %v", err)
+	}
+
+	uninstalling := map[string]bool{}
+	for _, name := range names {
+		uninstalling[name] = true
+	}
+	var kept []string
+	for _, name := range addon.Names() {
+		if state.Completed(name) && !uninstalling[name] {
+			kept = append(kept, name)
+		}
+	}
+	for _, name := range names {
+		if dependents := addon.Dependents(name, kept); len(dependents) != 0 {
+			common.ExitWithErrorf("add-on %s is required by %s, uninstall them together",
+				name, strings.Join(dependents, ","))
+		}
+	}
+
+	clearFuncs, err := addOnClearFuncs(names)
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+	for _, f := range clearFuncs {
+		err := f(context)
+		if err != nil {
+			common.OutputErrorf("ignored a uninstalling add-on error %s", err)
+		}
+	}
+
+	err = installation.ForgetStages(context, names...)
+	if err != nil {
+		common.OutputErrorf("ignored a reseting installation state error %s", err)
+	}
+
+	fmt.Println("Done.")
+}
+
+func addOnStatus(cmd *cobra.Command, flags *flags.AddOn, names []string) {
+	addOns := addon.List()
+	if len(names) != 0 {
+		addOns = nil
+		for _, name := range names {
+			a, exists := addon.Get(name)
+			if !exists {
+				common.ExitWithErrorf("unknown add-on name: %s", name)
+			}
+			addOns = append(addOns, a)
+		}
+	}
+
+	context := newOperationContext(cmd, flags.OperationGlobal)
+	state, err := installation.LoadState(context)
+	if err != nil {
+		common.ExitWithErrorf("load installation state failed: %v", err)
+	}
+
+	table := newAddOnTable([]string{"Name", "Version", "Installed", "Status"})
+	for _, a := range addOns {
+		installed, status := "-", "-"
+		if state.Completed(a.Name) {
+			installed = state.CompletedAt(a.Name)
+			if a.Status != nil {
+				status, err = a.Status(context)
+				if err != nil {
+					status = fmt.Sprintf("unhealthy: %v", err)
+				}
+			}
+		}
+		table.Append([]string{a.Name, a.Version, installed, status})
+	}
+	table.Render()
+}
+
+// addOnClearFuncs returns the functions clearing the add-ons, every add-on is cleared
+// before its dependencies.
+func addOnClearFuncs(names []string) ([]installation.ClearFunc, error) {
+	loadAddOns()
+	ordered, err := addon.Resolve(addon.Names())
+	if err != nil {
+		return nil, err
+	}
+
+	clearing := map[string]bool{}
+	for _, name := range names {
+		if _, exists := addon.Get(name); !exists {
+			return nil, fmt.Errorf("unknown add-on name: %s", name)
+		}
+		clearing[strings.ToLower(name)] = true
+	}
+
+	var clearFuncs []installation.ClearFunc
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if clearing[ordered[i].Name] {
+			clearFuncs = append(clearFuncs, installation.ClearFunc(ordered[i].Clear))
+		}
+	}
+	return clearFuncs, nil
+}
+
+func newOperationContext(cmd *cobra.Command, operationGlobal *flags.OperationGlobal) *installbase.StageContext {
+	kubeClient, clientConfig, err := installbase.NewKubernetesClient()
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+
+	apiExtensionClient, err := installbase.NewKubernetesAPIExtensionsClient()
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+
+	return &installbase.StageContext{
+		Cmd:                 cmd,
+		ClientConfig:        clientConfig,
+		Client:              kubeClient,
+		Flags:               &flags.Install{OperationGlobal: operationGlobal},
+		APIExtensionsClient: apiExtensionClient,
+	}
+}
+
+// loadAddOns registers the add-ons defined by the manifests in the add-on directory
+// of the rcfile or $HOME/.emctl/addons, next to the add-ons shipped with emctl.
+func loadAddOns() {
+	loadAddOnsOnce.Do(func() {
+		err := addon.Load(flags.GetAddOnDir())
+		if err != nil {
+			common.ExitWithErrorf("load add-ons failed: %v", err)
+		}
+	})
+}
+
+func newAddOnTable(header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetBorder(false)
+	table.SetRowLine(false)
+	table.SetColumnSeparator("")
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderLine(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	return table
+}
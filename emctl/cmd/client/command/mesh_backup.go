@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"github.com/megaease/easemeshctl/cmd/client/command/backup"
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+
+	"github.com/spf13/cobra"
+)
+
+// BackupCmd invokes backup sub command entrypoint
+func BackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "backup",
+		Short:   "Back up and restore resources of easemesh",
+		Example: "emctl backup create | emctl backup list | emctl backup restore easemesh-20211001-120000.tar.gz",
+	}
+
+	cmd.AddCommand(
+		backupActionCmd("create", backup.Create,
+			"Snapshot all resources of easemesh into a backup file",
+			"emctl backup create -o easemesh.tar.gz"),
+		backupActionCmd("restore <backup file>", backup.Restore,
+			"Restore resources of easemesh from a backup file",
+			"emctl backup restore easemesh.tar.gz"),
+		backupActionCmd("list", backup.List,
+			"List backups in the backup directory",
+			"emctl backup list"),
+	)
+
+	return cmd
+}
+
+func backupActionCmd(use string, run func(*cobra.Command, *flags.Backup), short, example string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   short,
+		Example: example,
+	}
+
+	flags := &flags.Backup{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		run(cmd, flags)
+	}
+
+	return cmd
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/bundle"
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/spf13/cobra"
+)
+
+// BundleCmd invokes bundle sub command entrypoint
+func BundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "bundle",
+		Short:   "Manage the air-gapped installation bundle of the EaseMesh",
+		Example: "emctl bundle create -d easemesh-bundle | emctl bundle push -d easemesh-bundle --image-registry-url registry.example.com",
+	}
+
+	cmd.AddCommand(bundleCreateCmd(), bundlePushCmd())
+
+	return cmd
+}
+
+func bundleCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "create",
+		Short:   "Pull the images of the EaseMesh by docker and save them with their digests into a bundle",
+		Example: "emctl bundle create -d easemesh-bundle --easegress-image megaease/easegress:v1.4.0",
+	}
+
+	flags := &flags.BundleCreate{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		m, err := bundle.Create(flags, bundle.ExecRunner)
+		if err != nil {
+			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+		}
+		fmt.Printf("Bundle of %d images created in %s\n", len(m.Images), flags.Dir)
+	}
+
+	return cmd
+}
+
+func bundlePushCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "push",
+		Short:   "Load the images of the bundle by docker and push them to the private registry",
+		Example: "emctl bundle push -d easemesh-bundle --image-registry-url registry.example.com",
+	}
+
+	flags := &flags.BundlePush{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		m, err := bundle.Push(flags, bundle.ExecRunner)
+		if err != nil {
+			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+		}
+		fmt.Printf("Bundle of %d images pushed to %s, install it by: emctl install --bundle %s\n",
+			len(m.Images), m.Registry, flags.Dir)
+	}
+
+	return cmd
+}
@@ -33,4 +33,12 @@ func TestCmd(t *testing.T) {
 	GetCmd()
 	InstallCmd()
 	ResetCmd()
+	InstanceCmd()
+	HistoryCmd()
+	RollbackCmd()
+	LintCmd()
+	UpgradeCmd()
+	StatusCmd()
+	BackupCmd()
+	AddOnCmd()
 }
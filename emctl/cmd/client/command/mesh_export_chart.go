@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/addon"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/chart"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+func exportChartCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "export-chart",
+		Short:   "Export the installation of the EaseMesh as a Helm chart",
+		Example: "emctl install export-chart -o easemesh --easemesh-control-plane-replicas 5 --add-ons shadowservice",
+		Args:    cobra.NoArgs,
+	}
+
+	flags := &flags.ExportChart{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if flags.SpecFile != "" {
+			buff, err := ioutil.ReadFile(flags.SpecFile)
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+
+			err = yaml.Unmarshal(buff, flags.Install)
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+		}
+		exportChart(cmd, flags)
+	}
+
+	return cmd
+}
+
+func exportChart(cmd *cobra.Command, flags *flags.ExportChart) {
+	loadAddOns()
+	// NOTE: The dependencies of the add-ons are enabled in the values too.
+	enabled, err := addon.Resolve(uniqueAddOn(flags.AddOns))
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+	flags.AddOns = nil
+	for _, a := range enabled {
+		flags.AddOns = append(flags.AddOns, a.Name)
+	}
+
+	var addOns []*chart.AddOn
+	for _, a := range addon.List() {
+		addOns = append(addOns, &chart.AddOn{Name: a.Name, Stage: a.Stage(), Notes: a.ChartNotes})
+	}
+
+	err = chart.Export(cmd, flags, coreInstallStages(), addOns)
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+
+	fmt.Printf("Helm chart is exported to %s\n", flags.Output)
+}
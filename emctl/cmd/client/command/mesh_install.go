@@ -21,9 +21,12 @@ import (
 	stdcontext "context"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
 
+	"github.com/megaease/easemeshctl/cmd/client/command/bundle"
 	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/addon"
 	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/controlpanel"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/coredns"
@@ -31,7 +34,6 @@ import (
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/ingresscontroller"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/installation"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/operator"
-	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/shadowservice"
 	"github.com/megaease/easemeshctl/cmd/client/command/rcfile"
 	"github.com/megaease/easemeshctl/cmd/common"
 
@@ -50,6 +52,7 @@ func InstallCmd() *cobra.Command {
 		Example: "emctl install coredns --clean-when-failed",
 	}
 	cmd.AddCommand(coredns.CoreDNSCmd())
+	cmd.AddCommand(exportChartCmd())
 
 	flags := &flags.Install{}
 	flags.AttachCmd(cmd)
@@ -68,6 +71,15 @@ func InstallCmd() *cobra.Command {
 				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
 			}
 		}
+		if flags.Bundle != "" {
+			m, err := bundle.LoadManifest(flags.Bundle)
+			if err == nil {
+				err = m.Apply(flags)
+			}
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+		}
 		install(cmd, flags)
 	}
 
@@ -90,7 +102,119 @@ func uniqueAddOn(addOns []string) []string {
 }
 
 func install(cmd *cobra.Command, flags *flags.Install) {
-	var err error
+	err := flags.CheckImages()
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+
+	context := newInstallContext(cmd, flags)
+
+	var stages []installation.InstallStage
+	if !flags.OnlyAddOn {
+		stages = append(stages, coreInstallStages()...)
+	}
+
+	addOnStages, err := addOnInstallStages(flags.AddOns)
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+	stages = append(stages, addOnStages...)
+	if flags.OnlyAddOn && len(stages) == 0 {
+		common.ExitWithErrorf("nothing to install")
+	}
+
+	if context.Rendering() {
+		render(context, stages)
+		return
+	}
+
+	cleanWhenFailed, err := flags.CleanWhenFailedMode()
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+
+	state := installation.NewState()
+	if flags.Resume {
+		state, err = installation.LoadState(context)
+		if err != nil {
+			common.ExitWithErrorf("load installation state failed: %v", err)
+		}
+	}
+
+	install := installation.NewResumable(state, stages...)
+
+	err = install.DoInstallStage(context)
+	if err != nil {
+		installFailed(context, install, cleanWhenFailed, err)
+	}
+
+	postInstall(context)
+
+	fmt.Println("Done.")
+}
+
+func installFailed(context *installbase.StageContext, install installation.Installation, cleanWhenFailed string, err error) {
+	hint := fmt.Sprintf("run `emctl status --mesh-namespace %s` for a health report", context.Flags.MeshNamespace)
+
+	switch cleanWhenFailed {
+	case flags.CleanWhenFailedAll:
+		install.ClearResource(context)
+		clearErr := installation.ClearState(context)
+		if clearErr != nil {
+			common.OutputErrorf("ignored: clear installation state failed: %v", clearErr)
+		}
+	case flags.CleanWhenFailedStage:
+		install.ClearFailedStage(context)
+	}
+	if cleanWhenFailed != flags.CleanWhenFailedAll {
+		hint += ", and `emctl install --resume` with the same flags to continue from the failed stage"
+	}
+
+	common.ExitWithErrorf("install mesh infrastructure error: %s\n%s", err, hint)
+}
+
+// coreInstallStages returns the stages installing the infrastructure components of the EaseMesh.
+func coreInstallStages() []installation.InstallStage {
+	return []installation.InstallStage{
+		installation.Wrap("crd", crd.PreCheck, crd.Deploy, crd.Clear, crd.DescribePhase),
+		installation.Wrap("control-plane", controlpanel.PreCheck, controlpanel.Deploy, controlpanel.Clear, controlpanel.DescribePhase),
+		installation.Wrap("operator", operator.PreCheck, operator.Deploy, operator.Clear, operator.DescribePhase),
+		installation.Wrap("ingress-controller", ingresscontroller.PreCheck, ingresscontroller.Deploy, ingresscontroller.Clear, ingresscontroller.DescribePhase),
+	}
+}
+
+// addOnInstallStages returns the stages installing the add-ons with their dependencies.
+func addOnInstallStages(names []string) ([]installation.InstallStage, error) {
+	loadAddOns()
+	addOns, err := addon.Resolve(uniqueAddOn(names))
+	if err != nil {
+		return nil, err
+	}
+
+	var stages []installation.InstallStage
+	for _, a := range addOns {
+		stages = append(stages, a.Stage())
+	}
+	return stages, nil
+}
+
+func newInstallContext(cmd *cobra.Command, flags *flags.Install) *installbase.StageContext {
+	if flags.Render {
+		recorder := installbase.NewRecorder()
+		kubeClient, apiExtensionClient, err := installbase.NewRecordingClients(recorder)
+		if err != nil {
+			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+		}
+
+		return &installbase.StageContext{
+			Flags:               flags,
+			Client:              kubeClient,
+			Cmd:                 cmd,
+			APIExtensionsClient: apiExtensionClient,
+			Recorder:            recorder,
+		}
+	}
+
 	kubeClient, clientConfig, err := installbase.NewKubernetesClient()
 	if err != nil {
 		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
@@ -101,51 +225,32 @@ func install(cmd *cobra.Command, flags *flags.Install) {
 		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
 	}
 
-	context := &installbase.StageContext{
+	return &installbase.StageContext{
 		Flags:               flags,
 		ClientConfig:        clientConfig,
 		Client:              kubeClient,
 		Cmd:                 cmd,
 		APIExtensionsClient: apiExtensionClient,
 	}
+}
 
-	// TODO: currently, we install add-ons in the 'emctl instll' command, but we need to use a seperated
-	// command for add-ons for better add-on management
-	var stages []installation.InstallStage
-	if !flags.OnlyAddOn {
-		stages = append(stages,
-			installation.Wrap(crd.PreCheck, crd.Deploy, crd.Clear, crd.DescribePhase),
-			installation.Wrap(controlpanel.PreCheck, controlpanel.Deploy, controlpanel.Clear, controlpanel.DescribePhase),
-			installation.Wrap(operator.PreCheck, operator.Deploy, operator.Clear, operator.DescribePhase),
-			installation.Wrap(ingresscontroller.PreCheck, ingresscontroller.Deploy, ingresscontroller.Clear, ingresscontroller.DescribePhase),
-		)
-	}
-
-	for _, addon := range uniqueAddOn(flags.AddOns) {
-		switch addon {
-		case "shadowservice":
-			stages = append(stages, installation.Wrap(shadowservice.PreCheck, shadowservice.Deploy, shadowservice.Clear, shadowservice.DescribePhase))
-		default:
-			common.ExitWithErrorf("unknown add-on name: %s", addon)
-		}
-	}
-	if flags.OnlyAddOn && len(stages) == 0 {
-		common.ExitWithErrorf("nothing to install")
+// render runs the stages against the recording clients and writes the manifests.
+func render(context *installbase.StageContext, stages []installation.InstallStage) {
+	// NOTE: The progress of stages goes to stderr, in case of the manifests are written to stdout.
+	context.Progress = os.Stderr
+	err := installation.New(stages...).DoInstallStage(context)
+	if err != nil {
+		common.ExitWithErrorf("render mesh infrastructure error: %s", err)
 	}
 
-	install := installation.New(stages...)
-
-	err = install.DoInstallStage(context)
+	err = context.Recorder.WriteManifests(context.Flags.RenderOutput)
 	if err != nil {
-		if flags.CleanWhenFailed {
-			install.ClearResource(context)
-		}
-		common.ExitWithErrorf("install mesh infrastructure error: %s", err)
+		common.ExitWithErrorf("write rendered manifests error: %s", err)
 	}
 
-	postInstall(context)
-
-	fmt.Println("Done.")
+	if context.Flags.RenderOutput != installbase.RenderToStdout {
+		fmt.Printf("Manifests are rendered to %s\n", context.Flags.RenderOutput)
+	}
 }
 
 func postInstall(context *installbase.StageContext) {
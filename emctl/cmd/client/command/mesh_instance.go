@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package command
+
+import (
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/instance"
+
+	"github.com/spf13/cobra"
+)
+
+// InstanceCmd invokes instance sub command entrypoint
+func InstanceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "instance",
+		Short:   "Manage lifecycle of service instances of easemesh",
+		Example: "emctl instance drain service-001/instance-001",
+	}
+
+	cmd.AddCommand(
+		instanceActionCmd(instance.ActionDrain,
+			"Cordon a service instance and wait a grace period for its in-flight traffic",
+			"emctl instance drain service-001/instance-001 --grace-period 30s"),
+		instanceActionCmd(instance.ActionCordon,
+			"Take a service instance out of load balancing without waiting",
+			"emctl instance cordon service-001/instance-001"),
+	)
+
+	return cmd
+}
+
+func instanceActionCmd(action instance.Action, short, example string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     string(action) + " <service name>/<instance id>",
+		Short:   short,
+		Example: example,
+	}
+
+	flags := &flags.Instance{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		instance.Run(cmd, action, flags)
+	}
+
+	return cmd
+}
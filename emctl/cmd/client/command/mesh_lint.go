@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/lint"
+
+	"github.com/spf13/cobra"
+)
+
+// LintCmd invokes lint sub command entrypoint
+func LintCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "lint",
+		Short:   "Check the EaseMesh configuration files against best practices without contacting the server",
+		Long:    "",
+		Example: "emctl lint -f configs/ -o sarif",
+	}
+
+	flags := &flags.Lint{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		lint.Run(cmd, flags)
+	}
+
+	return cmd
+}
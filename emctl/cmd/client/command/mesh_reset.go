@@ -19,68 +19,62 @@ package command
 
 import (
 	"github.com/megaease/easemeshctl/cmd/client/command/flags"
-	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/addon"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/controlpanel"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/coredns"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/crd"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/ingresscontroller"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/installation"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/operator"
-	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/shadowservice"
 	"github.com/megaease/easemeshctl/cmd/common"
 
 	"github.com/spf13/cobra"
 )
 
 func reset(cmd *cobra.Command, resetFlags *flags.Reset) {
-	kubeClient, clientConfig, err := installbase.NewKubernetesClient()
-	if err != nil {
-		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
-	}
-
-	apiExtensionClient, err := installbase.NewKubernetesAPIExtensionsClient()
-	if err != nil {
-		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
-	}
-
 	var clearFuncs []installation.ClearFunc
 	if resetFlags.OnlyAddOn {
-		for _, addon := range uniqueAddOn(resetFlags.AddOns) {
-			switch addon {
-			case "shadowservice":
-				clearFuncs = append(clearFuncs, shadowservice.Clear)
-			default:
-				common.ExitWithErrorf("unknown add-on name: %s", addon)
-			}
+		addOnClears, err := addOnClearFuncs(uniqueAddOn(resetFlags.AddOns))
+		if err != nil {
+			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
 		}
-		if len(clearFuncs) == 0 {
+		if len(addOnClears) == 0 {
 			common.ExitWithErrorf("nothing to reset")
 		}
+		clearFuncs = addOnClears
 	} else {
 		// clear everything
-		clearFuncs = []installation.ClearFunc{
-			shadowservice.Clear,
+		addOnClears, err := addOnClearFuncs(addon.Names())
+		if err != nil {
+			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+		}
+		clearFuncs = append(addOnClears,
 			ingresscontroller.Clear,
 			operator.Clear,
 			controlpanel.Clear,
 			crd.Clear,
-		}
+		)
 	}
 
-	stageContext := installbase.StageContext{
-		Cmd:                 cmd,
-		ClientConfig:        clientConfig,
-		Client:              kubeClient,
-		Flags:               &flags.Install{OperationGlobal: resetFlags.OperationGlobal},
-		APIExtensionsClient: apiExtensionClient,
-		ClearFuncs:          nil,
-	}
+	stageContext := newOperationContext(cmd, resetFlags.OperationGlobal)
 
 	for _, f := range clearFuncs {
-		err := f(&stageContext)
+		err := f(stageContext)
 		if err != nil {
 			common.OutputErrorf("ignored a reseting resource error %s", err)
 		}
 	}
+
+	// NOTE: The stages of reset components are installed again by a resumed installation.
+	var err error
+	if resetFlags.OnlyAddOn {
+		err = installation.ForgetStages(stageContext, uniqueAddOn(resetFlags.AddOns)...)
+	} else {
+		err = installation.ClearState(stageContext)
+	}
+	if err != nil {
+		common.OutputErrorf("ignored a reseting installation state error %s", err)
+	}
 }
 
 // ResetCmd invoke reset sub command entrypoint
@@ -98,6 +92,7 @@ func ResetCmd() *cobra.Command {
 	cmd.Run = func(cmd *cobra.Command, args []string) {
 		reset(cmd, flags)
 	}
+	cmd.AddCommand(coredns.ResetCmd())
 
 	return cmd
 }
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package command
+
+import (
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/rollback"
+
+	"github.com/spf13/cobra"
+)
+
+// RollbackCmd invokes rollback sub command entrypoint
+func RollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rollback",
+		Short:   "Roll back a resource of easemesh to a revision",
+		Example: "emctl rollback resilience service-001 --to-revision 2",
+	}
+
+	flags := &flags.Rollback{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		rollback.Run(cmd, flags)
+	}
+
+	return cmd
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"io/ioutil"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/addon"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/upgrade"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// coreComponentNames are the names of the core install stages, which are upgraded in the same order.
+var coreComponentNames = []string{"custom resource definitions", "control plane", "operator", "ingress controller"}
+
+// UpgradeCmd is the entrypoint of the emctl upgrade
+func UpgradeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "upgrade",
+		Short:   "Upgrade infrastructure components of the EaseMesh in place",
+		Long:    "",
+		Example: "emctl upgrade --easegress-image megaease/easegress:v1.4.0 --dry-run",
+		Args:    cobra.NoArgs,
+	}
+
+	flags := &flags.Upgrade{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if flags.SpecFile != "" {
+			buff, err := ioutil.ReadFile(flags.SpecFile)
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+
+			err = yaml.Unmarshal(buff, flags.Install)
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+		}
+
+		err := upgrade.Run(cmd, flags, upgradeComponents)
+		if err != nil {
+			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+		}
+	}
+
+	return cmd
+}
+
+// upgradeComponents returns the components of the target spec in the upgrade order.
+func upgradeComponents(target *flags.Install) []*upgrade.Component {
+	var components []*upgrade.Component
+	for i, stage := range coreInstallStages() {
+		components = append(components, &upgrade.Component{Name: coreComponentNames[i], Stage: stage})
+	}
+
+	loadAddOns()
+	addOns, err := addon.Resolve(uniqueAddOn(target.AddOns))
+	if err != nil {
+		common.ExitWithErrorf("upgrade failed: %v", err)
+	}
+	for _, a := range addOns {
+		components = append(components, &upgrade.Component{Name: "add-on " + a.Name, Stage: a.Stage()})
+	}
+
+	return components
+}
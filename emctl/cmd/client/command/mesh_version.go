@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/common"
+	"github.com/megaease/easemeshctl/pkg/version"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// VersionCmd invokes the version sub command entrypoint
+func VersionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "version",
+		Short:   "Show the version of emctl and the components of its release",
+		Example: "emctl version --components",
+		Args:    cobra.NoArgs,
+	}
+
+	flags := &flags.Version{}
+	flags.AttachCmd(cmd)
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if !flags.Components {
+			fmt.Println(version.Long)
+			return
+		}
+
+		err := printComponents(flags)
+		if err != nil {
+			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+		}
+	}
+
+	return cmd
+}
+
+func printComponents(flags *flags.Version) error {
+	var release *version.ComponentsRelease
+	if flags.Release == "" {
+		var err error
+		release, err = version.CurrentComponents()
+		if err != nil {
+			return err
+		}
+	} else {
+		manifest, err := version.Components()
+		if err != nil {
+			return err
+		}
+		release, err = manifest.Release(flags.Release)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch flags.OutputFormat {
+	case "table":
+		fmt.Printf("%s\n\nComponents of release %s:\n", version.Long, release.Release)
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Component", "Image", "Digest"})
+		table.SetBorder(false)
+		table.SetRowLine(false)
+		table.SetColumnSeparator("")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetHeaderLine(false)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAutoWrapText(false)
+		for _, c := range release.Components {
+			digest := c.Digest
+			if digest == "" {
+				digest = "<unpinned>"
+			}
+			table.Append([]string{c.Name, c.Image, digest})
+		}
+		table.Render()
+	case "yaml":
+		buff, err := yaml.Marshal(release)
+		if err != nil {
+			return errors.Wrap(err, "marshal components")
+		}
+		fmt.Print(string(buff))
+	case "json":
+		buff, err := json.MarshalIndent(release, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "marshal components")
+		}
+		fmt.Println(string(buff))
+	default:
+		return errors.Errorf("unsupported output format %s, expecting table, yaml or json", flags.OutputFormat)
+	}
+
+	return nil
+}
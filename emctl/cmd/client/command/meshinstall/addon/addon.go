@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package addon
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/installation"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// AddOn is the manifest of an add-on of the EaseMesh, it's installed after the
+	// infrastructure components, as a stage named after the add-on.
+	AddOn struct {
+		Name        string
+		Version     string
+		Description string
+		// Dependencies are the names of add-ons which must be installed before it.
+		Dependencies []string
+
+		PreCheck      installation.HookFunc
+		Deploy        installation.InstallFunc
+		Clear         installation.HookFunc
+		DescribePhase installation.DescribeFunc
+
+		// Status reports the health of the installed add-on, it's optional.
+		Status func(*installbase.StageContext) (string, error)
+		// ChartNotes are the notes of the add-on in the exported Helm chart,
+		// for the installation which can't be completed by the chart.
+		ChartNotes string
+	}
+
+	registry struct {
+		sync.RWMutex
+		addOns map[string]*AddOn
+	}
+)
+
+var defaultRegistry = &registry{addOns: map[string]*AddOn{}}
+
+// Register registers the add-on, the add-ons shipped with emctl register themselves
+// in the init function of their packages. It panics if the name is registered twice.
+func Register(a *AddOn) {
+	err := defaultRegistry.register(a)
+	if err != nil {
+		panic(err.Error())
+	}
+}
+
+func (r *registry) register(a *AddOn) error {
+	r.Lock()
+	defer r.Unlock()
+
+	name := strings.ToLower(a.Name)
+	if name == "" {
+		return errors.New("add-on name is empty")
+	}
+	if a.Deploy == nil || a.Clear == nil {
+		return errors.Errorf("add-on %s must have Deploy and Clear", name)
+	}
+	if _, exists := r.addOns[name]; exists {
+		return errors.Errorf("add-on %s is registered twice", name)
+	}
+
+	a.Name = name
+	r.addOns[name] = a
+	return nil
+}
+
+// Get returns the registered add-on of the name.
+func Get(name string) (*AddOn, bool) {
+	defaultRegistry.RLock()
+	defer defaultRegistry.RUnlock()
+
+	a, exists := defaultRegistry.addOns[strings.ToLower(name)]
+	return a, exists
+}
+
+// List returns all registered add-ons sorted by their names.
+func List() []*AddOn {
+	defaultRegistry.RLock()
+	defer defaultRegistry.RUnlock()
+
+	var addOns []*AddOn
+	for _, a := range defaultRegistry.addOns {
+		addOns = append(addOns, a)
+	}
+	sort.Slice(addOns, func(i, j int) bool { return addOns[i].Name < addOns[j].Name })
+	return addOns
+}
+
+// Names returns the names of all registered add-ons.
+func Names() []string {
+	var names []string
+	for _, a := range List() {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// Stage returns the install stage of the add-on.
+func (a *AddOn) Stage() installation.InstallStage {
+	preCheck := a.PreCheck
+	if preCheck == nil {
+		preCheck = func(*installbase.StageContext) error { return nil }
+	}
+	describe := a.DescribePhase
+	if describe == nil {
+		describe = func(*installbase.StageContext, installbase.InstallPhase) string { return "" }
+	}
+
+	return installation.Wrap(a.Name, preCheck, a.Deploy, a.Clear, describe)
+}
+
+// Resolve returns the add-ons of the names with their dependencies, every add-on
+// follows its dependencies, so they can be installed in order.
+func Resolve(names []string) ([]*AddOn, error) {
+	var resolved []*AddOn
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		name = strings.ToLower(name)
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return errors.Errorf("circular dependency of add-ons: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		a, exists := Get(name)
+		if !exists {
+			if len(path) == 0 {
+				return errors.Errorf("unknown add-on name: %s", name)
+			}
+			return errors.Errorf("unknown add-on name: %s, which is a dependency of %s", name, path[len(path)-1])
+		}
+
+		visiting[name] = true
+		for _, dependency := range a.Dependencies {
+			err := visit(dependency, append(path, name))
+			if err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+
+		visited[name] = true
+		resolved = append(resolved, a)
+		return nil
+	}
+
+	for _, name := range names {
+		err := visit(name, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// Dependents returns the names of add-ons among the candidates, which depend on the add-on directly.
+func Dependents(name string, candidates []string) []string {
+	var dependents []string
+	for _, candidate := range candidates {
+		a, exists := Get(candidate)
+		if !exists {
+			continue
+		}
+		for _, dependency := range a.Dependencies {
+			if strings.EqualFold(dependency, name) {
+				dependents = append(dependents, a.Name)
+				break
+			}
+		}
+	}
+	return dependents
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package addon
+
+import (
+	"strings"
+	"testing"
+
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+)
+
+func noop(*installbase.StageContext) error { return nil }
+
+func register(name string, dependencies ...string) {
+	Register(&AddOn{Name: name, Dependencies: dependencies, Deploy: noop, Clear: noop})
+}
+
+func init() {
+	register("log-shipper")
+	register("Canary-Analyzer", "metrics", "log-shipper")
+	register("metrics", "log-shipper")
+	register("cycle-a", "cycle-b")
+	register("cycle-b", "cycle-a")
+	register("broken", "missing")
+}
+
+func names(addOns []*AddOn) string {
+	var result []string
+	for _, a := range addOns {
+		result = append(result, a.Name)
+	}
+	return strings.Join(result, ",")
+}
+
+func TestRegister(t *testing.T) {
+	if _, exists := Get("canary-analyzer"); !exists {
+		t.Fatalf("expect canary-analyzer registered in lower case")
+	}
+	if _, exists := Get("CANARY-ANALYZER"); !exists {
+		t.Fatalf("expect add-on got case-insensitively")
+	}
+	if List()[0].Name != "broken" {
+		t.Fatalf("expect add-ons sorted, but got %v", Names())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expect panic when an add-on is registered twice")
+		}
+	}()
+	register("metrics")
+}
+
+func TestResolve(t *testing.T) {
+	resolved, err := Resolve([]string{"canary-analyzer", "metrics"})
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if got := names(resolved); got != "log-shipper,metrics,canary-analyzer" {
+		t.Fatalf("expect dependencies first, but got %s", got)
+	}
+
+	_, err = Resolve([]string{"cycle-a"})
+	if err == nil || !strings.Contains(err.Error(), "cycle-a -> cycle-b -> cycle-a") {
+		t.Fatalf("expect circular dependency error, but got %v", err)
+	}
+
+	_, err = Resolve([]string{"broken"})
+	if err == nil || !strings.Contains(err.Error(), "dependency of broken") {
+		t.Fatalf("expect unknown dependency error, but got %v", err)
+	}
+
+	_, err = Resolve([]string{"unknown"})
+	if err == nil || !strings.Contains(err.Error(), "unknown add-on name: unknown") {
+		t.Fatalf("expect unknown add-on error, but got %v", err)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	dependents := Dependents("log-shipper", []string{"metrics", "canary-analyzer", "unknown"})
+	if strings.Join(dependents, ",") != "metrics,canary-analyzer" {
+		t.Fatalf("unexpected dependents %v", dependents)
+	}
+}
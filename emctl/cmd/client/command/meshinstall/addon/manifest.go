@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package addon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	yamljsontool "github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const addOnDirName = ".emctl/addons"
+
+type (
+	// Manifest is an add-on defined by a file instead of code, which deploys its
+	// Kubernetes objects in order and deletes them in reverse order. The namespaced
+	// objects without a namespace are deployed into the namespace of the EaseMesh.
+	Manifest struct {
+		Name         string                   `json:"name"`
+		Version      string                   `json:"version"`
+		Description  string                   `json:"description"`
+		Dependencies []string                 `json:"dependencies"`
+		ChartNotes   string                   `json:"chartNotes"`
+		Objects      []map[string]interface{} `json:"objects"`
+	}
+)
+
+// DefaultDir returns the default directory of the add-on manifests.
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "get user home dir failed")
+	}
+
+	return filepath.Join(homeDir, addOnDirName), nil
+}
+
+// Load registers the add-ons defined by the manifest file, or by all YAML files in the
+// directory. The empty path loads the default directory, which is skipped if it's absent.
+func Load(path string) error {
+	if path == "" {
+		dir, err := DefaultDir()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil
+		}
+		path = dir
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "stat %s failed", path)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return errors.Wrapf(err, "list %s failed", path)
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+
+	for _, file := range files {
+		m, err := loadManifest(file)
+		if err != nil {
+			return err
+		}
+
+		err = defaultRegistry.register(m.addOn())
+		if err != nil {
+			return errors.Wrapf(err, "register add-on of %s failed", file)
+		}
+	}
+
+	return nil
+}
+
+func loadManifest(file string) (*Manifest, error) {
+	buff, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s failed", file)
+	}
+
+	m := &Manifest{}
+	err = yamljsontool.Unmarshal(buff, m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal %s failed", file)
+	}
+
+	if m.Name == "" {
+		return nil, errors.Errorf("add-on name of %s is empty", file)
+	}
+	if len(m.Objects) == 0 {
+		return nil, errors.Errorf("add-on %s of %s has no objects", m.Name, file)
+	}
+	for i, object := range m.Objects {
+		u := &unstructured.Unstructured{Object: object}
+		if u.GetAPIVersion() == "" || u.GetKind() == "" || u.GetName() == "" {
+			return nil, errors.Errorf("object %d of add-on %s in %s must have apiVersion, kind and metadata.name",
+				i, m.Name, file)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Manifest) addOn() *AddOn {
+	return &AddOn{
+		Name:          m.Name,
+		Version:       m.Version,
+		Description:   m.Description,
+		Dependencies:  m.Dependencies,
+		Deploy:        m.deploy,
+		Clear:         m.clear,
+		DescribePhase: m.describePhase,
+		Status:        m.status,
+		ChartNotes:    m.ChartNotes,
+	}
+}
+
+// objects returns the objects of the add-on with their resources, the objects
+// are copied since they are modified by deploying.
+func (m *Manifest) objects(ctx *installbase.StageContext) ([]*unstructured.Unstructured, []string, error) {
+	resolve := installbase.NewKindResolver(ctx)
+	var objects []*unstructured.Unstructured
+	var resources []string
+	for _, object := range m.Objects {
+		u := (&unstructured.Unstructured{Object: object}).DeepCopy()
+		resource, clusterScoped, err := resolve(u.GetAPIVersion(), u.GetKind())
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "object %s of add-on %s", u.GetName(), m.Name)
+		}
+		switch {
+		case clusterScoped:
+			u.SetNamespace("")
+		case u.GetNamespace() == "":
+			u.SetNamespace(ctx.Flags.MeshNamespace)
+		}
+		objects = append(objects, u)
+		resources = append(resources, resource)
+	}
+	return objects, resources, nil
+}
+
+func (m *Manifest) deploy(ctx *installbase.StageContext) error {
+	objects, resources, err := m.objects(ctx)
+	if err != nil {
+		return err
+	}
+	for i, object := range objects {
+		err = installbase.DeployCustomResource(object, resources[i], ctx.Client)
+		if err != nil {
+			return errors.Wrapf(err, "deploy %s %s of add-on %s failed", object.GetKind(), object.GetName(), m.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) clear(ctx *installbase.StageContext) error {
+	objects, resources, err := m.objects(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for i := len(objects) - 1; i >= 0; i-- {
+		object := objects[i]
+		err = installbase.DeleteCustomResource(ctx.Client, object.GetAPIVersion(), resources[i],
+			object.GetNamespace(), object.GetName())
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("delete %s %s failed: %v", object.GetKind(), object.GetName(), err))
+		}
+	}
+	if len(errs) != 0 {
+		return errors.Errorf("clear add-on %s: %s", m.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (m *Manifest) describePhase(ctx *installbase.StageContext, phase installbase.InstallPhase) string {
+	switch phase {
+	case installbase.BeginPhase:
+		return fmt.Sprintf("Begin to install add-on %s with %d objects", m.Name, len(m.Objects))
+	case installbase.EndPhase:
+		return fmt.Sprintf("\nAdd-on %s deployed successfully", m.Name)
+	}
+	return ""
+}
+
+// status reports the readiness of the workloads of the add-on, the other objects
+// only need to exist.
+func (m *Manifest) status(ctx *installbase.StageContext) (string, error) {
+	objects, resources, err := m.objects(ctx)
+	if err != nil {
+		return "", err
+	}
+	workloads, ready := 0, 0
+	for i, object := range objects {
+		got, err := installbase.GetCustomResource(ctx.Client, object.GetAPIVersion(), resources[i],
+			object.GetNamespace(), object.GetName())
+		if err != nil {
+			return "", errors.Wrapf(err, "get %s %s failed", object.GetKind(), object.GetName())
+		}
+
+		var desired, available int64
+		switch got.GetKind() {
+		case "Deployment", "StatefulSet":
+			desired, _, _ = unstructured.NestedInt64(got.Object, "spec", "replicas")
+			available, _, _ = unstructured.NestedInt64(got.Object, "status", "readyReplicas")
+		case "DaemonSet":
+			desired, _, _ = unstructured.NestedInt64(got.Object, "status", "desiredNumberScheduled")
+			available, _, _ = unstructured.NestedInt64(got.Object, "status", "numberReady")
+		default:
+			continue
+		}
+
+		workloads++
+		if available >= desired {
+			ready++
+		}
+	}
+
+	status := fmt.Sprintf("%d/%d workloads ready", ready, workloads)
+	if ready != workloads {
+		return status, errors.Errorf("workloads of add-on %s are not ready: %s", m.Name, status)
+	}
+	return status, nil
+}
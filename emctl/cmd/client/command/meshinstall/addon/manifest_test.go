@@ -0,0 +1,201 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package addon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testManifest = `
+name: Log-Collector
+version: v0.1.0
+description: collect logs of the sidecars
+dependencies: [log-shipper]
+objects:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: log-collector
+  data:
+    level: info
+- apiVersion: rbac.authorization.k8s.io/v1
+  kind: ClusterRole
+  metadata:
+    name: log-collector
+    namespace: default
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: log-collector
+  spec:
+    replicas: 2
+`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emctl-addon")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "log-collector.yaml"), []byte(testManifest), 0644)
+	if err != nil {
+		t.Fatalf("write manifest failed: %v", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a manifest"), 0644)
+	if err != nil {
+		t.Fatalf("write readme failed: %v", err)
+	}
+
+	err = Load(dir)
+	if err != nil {
+		t.Fatalf("load add-ons failed: %v", err)
+	}
+	a, exists := Get("log-collector")
+	if !exists || a.Version != "v0.1.0" || strings.Join(a.Dependencies, ",") != "log-shipper" {
+		t.Fatalf("unexpected add-on %+v", a)
+	}
+	resolved, err := Resolve([]string{"log-collector"})
+	if err != nil || names(resolved) != "log-shipper,log-collector" {
+		t.Fatalf("expect dependencies first, but got %s, %v", names(resolved), err)
+	}
+
+	err = Load(filepath.Join(dir, "log-collector.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "registered twice") {
+		t.Fatalf("expect registered twice error, but got %v", err)
+	}
+
+	recorder := installbase.NewRecorder()
+	client, _, err := installbase.NewRecordingClients(recorder)
+	if err != nil {
+		t.Fatalf("new recording clients failed: %v", err)
+	}
+	ctx := &installbase.StageContext{
+		Client:   client,
+		Recorder: recorder,
+		Flags:    &flags.Install{OperationGlobal: &flags.OperationGlobal{MeshNamespace: "easemesh"}},
+	}
+
+	err = a.Deploy(ctx)
+	if err != nil {
+		t.Fatalf("deploy add-on failed: %v", err)
+	}
+	manifests := recorder.Manifests()
+	if len(manifests) != 3 {
+		t.Fatalf("expect 3 objects, but got %d", len(manifests))
+	}
+	for _, manifest := range manifests {
+		metadata, _ := manifest["metadata"].(map[string]interface{})
+		namespace, _ := metadata["namespace"].(string)
+		if (manifest["kind"] == "ClusterRole") != (namespace == "") {
+			t.Fatalf("unexpected namespace of %+v", manifest)
+		}
+	}
+
+	status, err := a.Status(ctx)
+	if err == nil || status != "0/1 workloads ready" {
+		t.Fatalf("expect deployment not ready, but got %s, %v", status, err)
+	}
+
+	err = a.Clear(ctx)
+	if err != nil || len(recorder.Manifests()) != 0 {
+		t.Fatalf("expect objects deleted, but got %v, %v", recorder.Manifests(), err)
+	}
+}
+
+func TestObjectsResolveKinds(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Fake.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "networking.k8s.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "ingresses", Kind: "Ingress", Namespaced: true},
+				{Name: "ingressclasses", Kind: "IngressClass"},
+			},
+		},
+	}
+	ctx := &installbase.StageContext{
+		Client: client,
+		Flags:  &flags.Install{OperationGlobal: &flags.OperationGlobal{MeshNamespace: "easemesh"}},
+	}
+
+	m := &Manifest{
+		Name: "ingress",
+		Objects: []map[string]interface{}{
+			{"apiVersion": "networking.k8s.io/v1", "kind": "Ingress", "metadata": map[string]interface{}{"name": "web"}},
+			{"apiVersion": "networking.k8s.io/v1", "kind": "IngressClass",
+				"metadata": map[string]interface{}{"name": "web", "namespace": "default"}},
+		},
+	}
+	objects, resources, err := m.objects(ctx)
+	if err != nil {
+		t.Fatalf("resolve kinds failed: %v", err)
+	}
+	if strings.Join(resources, ",") != "ingresses,ingressclasses" {
+		t.Fatalf("unexpected resources %v", resources)
+	}
+	if objects[0].GetNamespace() != "easemesh" || objects[1].GetNamespace() != "" {
+		t.Fatalf("unexpected namespaces %s, %s", objects[0].GetNamespace(), objects[1].GetNamespace())
+	}
+
+	m.Objects = append(m.Objects, map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1", "kind": "NetworkPolicy", "metadata": map[string]interface{}{"name": "web"},
+	})
+	_, _, err = m.objects(ctx)
+	if err == nil || !strings.Contains(err.Error(), "NetworkPolicy") {
+		t.Fatalf("expect unknown kind error, but got %v", err)
+	}
+
+	ctx.Recorder = installbase.NewRecorder()
+	_, _, err = m.objects(ctx)
+	if err == nil || !strings.Contains(err.Error(), "not supported in rendering") {
+		t.Fatalf("expect kind unsupported in rendering error, but got %v", err)
+	}
+}
+
+func TestLoadInvalidManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emctl-addon")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "invalid.yml")
+	err = ioutil.WriteFile(name, []byte("name: invalid\nobjects:\n- apiVersion: v1\n  kind: ConfigMap\n"), 0644)
+	if err != nil {
+		t.Fatalf("write manifest failed: %v", err)
+	}
+
+	err = Load(name)
+	if err == nil || !strings.Contains(err.Error(), "metadata.name") {
+		t.Fatalf("expect invalid object error, but got %v", err)
+	}
+	if _, exists := Get("invalid"); exists {
+		t.Fatalf("expect invalid add-on not registered")
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package installbase
+
+import (
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplyComponent applies the resources and the scheduling of the component to the pod template,
+// the resources are applied to the container with the given name. The pod anti-affinity and
+// the topology spread constraints without a label selector select the pods by their labels.
+func ApplyComponent(template *v1.PodTemplateSpec, containerName string, component *flags.Component) {
+	if component == nil {
+		return
+	}
+
+	if component.Resources != nil {
+		for i := range template.Spec.Containers {
+			if template.Spec.Containers[i].Name == containerName {
+				template.Spec.Containers[i].Resources = *component.Resources.DeepCopy()
+			}
+		}
+	}
+
+	if len(component.Tolerations) != 0 {
+		template.Spec.Tolerations = append([]v1.Toleration{}, component.Tolerations...)
+	}
+
+	if component.Affinity != nil {
+		template.Spec.Affinity = component.Affinity.DeepCopy()
+	}
+
+	if component.PodAntiAffinity != "" {
+		if template.Spec.Affinity == nil {
+			template.Spec.Affinity = &v1.Affinity{}
+		}
+		if template.Spec.Affinity.PodAntiAffinity == nil {
+			template.Spec.Affinity.PodAntiAffinity = &v1.PodAntiAffinity{}
+		}
+
+		antiAffinity := template.Spec.Affinity.PodAntiAffinity
+		term := v1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: template.Labels},
+			TopologyKey:   v1.LabelHostname,
+		}
+		switch component.PodAntiAffinity {
+		case flags.PodAntiAffinityRequired:
+			antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+				antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+		default:
+			antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+				antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+				v1.WeightedPodAffinityTerm{Weight: 100, PodAffinityTerm: term})
+		}
+	}
+
+	if component.PriorityClassName != "" {
+		template.Spec.PriorityClassName = component.PriorityClassName
+	}
+
+	for _, constraint := range component.TopologySpreadConstraints {
+		constraint := *constraint.DeepCopy()
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = &metav1.LabelSelector{MatchLabels: template.Labels}
+		}
+		template.Spec.TopologySpreadConstraints = append(template.Spec.TopologySpreadConstraints, constraint)
+	}
+}
+
+// PodDisruptionBudgetSpec returns the PodDisruptionBudget of the component selecting the pods
+// by the labels, it returns nil if the component has no disruption budget.
+func PodDisruptionBudgetSpec(name string, labels map[string]string, component *flags.Component) *policyv1.PodDisruptionBudget {
+	if component == nil || component.PodDisruptionBudget == nil {
+		return nil
+	}
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: labels},
+			MinAvailable:   component.PodDisruptionBudget.MinAvailable,
+			MaxUnavailable: component.PodDisruptionBudget.MaxUnavailable,
+		},
+	}
+}
+
+// PodDisruptionBudgetInstallFunc returns the install function deploying the PodDisruptionBudget
+// of the component, which does nothing if the component has no disruption budget.
+func PodDisruptionBudgetInstallFunc(name, namespace string, labels map[string]string, component *flags.Component) InstallFunc {
+	pdb := PodDisruptionBudgetSpec(name, labels, component)
+
+	return func(ctx *StageContext) error {
+		if pdb == nil {
+			return nil
+		}
+
+		err := DeployPodDisruptionBudget(pdb, ctx.Client, namespace)
+		if err != nil {
+			return errors.Wrapf(err, "deploy pod disruption budget %s failed", pdb.Name)
+		}
+		return nil
+	}
+}
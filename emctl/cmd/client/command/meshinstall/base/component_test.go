@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package installbase
+
+import (
+	"testing"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestApplyComponent(t *testing.T) {
+	labels := map[string]string{"app": "test"}
+	template := &v1.PodTemplateSpec{}
+	template.Labels = labels
+	template.Spec.Containers = []v1.Container{{Name: "proxy"}, {Name: "main"}}
+
+	ApplyComponent(template, "main", nil)
+	if template.Spec.Affinity != nil || template.Spec.Containers[1].Resources.Limits != nil {
+		t.Fatalf("expected the template untouched without the component")
+	}
+
+	ApplyComponent(template, "main", &flags.Component{
+		Resources: &v1.ResourceRequirements{
+			Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+		Tolerations:       []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpExists}},
+		PodAntiAffinity:   flags.PodAntiAffinityPreferred,
+		PriorityClassName: "high",
+		TopologySpreadConstraints: []v1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       v1.LabelTopologyZone,
+			WhenUnsatisfiable: v1.DoNotSchedule,
+		}},
+	})
+
+	if template.Spec.Containers[0].Resources.Limits != nil {
+		t.Fatalf("expected resources of the other container untouched")
+	}
+	if memory := template.Spec.Containers[1].Resources.Limits[v1.ResourceMemory]; memory.String() != "1Gi" {
+		t.Fatalf("expected memory limit 1Gi, got %s", memory.String())
+	}
+	if len(template.Spec.Tolerations) != 1 || template.Spec.PriorityClassName != "high" {
+		t.Fatalf("unexpected tolerations %+v and priority class %s",
+			template.Spec.Tolerations, template.Spec.PriorityClassName)
+	}
+
+	terms := template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	if len(terms) != 1 || terms[0].PodAffinityTerm.LabelSelector.MatchLabels["app"] != "test" ||
+		terms[0].PodAffinityTerm.TopologyKey != v1.LabelHostname {
+		t.Fatalf("unexpected pod anti-affinity %+v", terms)
+	}
+
+	constraints := template.Spec.TopologySpreadConstraints
+	if len(constraints) != 1 || constraints[0].LabelSelector.MatchLabels["app"] != "test" {
+		t.Fatalf("unexpected topology spread constraints %+v", constraints)
+	}
+}
+
+func TestPodDisruptionBudgetInstallFunc(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := &StageContext{Client: client}
+	labels := map[string]string{"app": "test"}
+
+	err := PodDisruptionBudgetInstallFunc("test", "easemesh", labels, &flags.Component{})(ctx)
+	if err != nil {
+		t.Fatalf("deploy pod disruption budget failed: %v", err)
+	}
+	pdbs, _ := client.PolicyV1().PodDisruptionBudgets("easemesh").List(requestContext(), metav1.ListOptions{})
+	if len(pdbs.Items) != 0 {
+		t.Fatalf("expected no pod disruption budget, got %d", len(pdbs.Items))
+	}
+
+	minAvailable := intstr.FromInt(2)
+	component := &flags.Component{
+		PodDisruptionBudget: &flags.PodDisruptionBudget{MinAvailable: &minAvailable},
+	}
+	for i := 0; i < 2; i++ {
+		err = PodDisruptionBudgetInstallFunc("test", "easemesh", labels, component)(ctx)
+		if err != nil {
+			t.Fatalf("deploy pod disruption budget failed: %v", err)
+		}
+	}
+
+	pdb, err := client.PolicyV1().PodDisruptionBudgets("easemesh").Get(requestContext(), "test", getOptions())
+	if err != nil {
+		t.Fatalf("get pod disruption budget failed: %v", err)
+	}
+	if pdb.Spec.MinAvailable.IntValue() != 2 || pdb.Spec.Selector.MatchLabels["app"] != "test" {
+		t.Fatalf("unexpected pod disruption budget %+v", pdb.Spec)
+	}
+
+	err = DeletePolicyV1Resources(client, "poddisruptionbudgets", "easemesh", "test")
+	if err != nil {
+		t.Fatalf("delete pod disruption budget failed: %v", err)
+	}
+}
@@ -19,6 +19,8 @@ package installbase
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"path"
 	"sort"
 	"strings"
@@ -102,6 +104,14 @@ type (
 		CertDir              string   `yaml:"cert-dir" jsonschema:"required"`
 		CertName             string   `yaml:"cert-name" jsonschema:"required"`
 		KeyName              string   `yaml:"key-name" jsonschema:"required"`
+
+		// The mode managing the webhook certificate, the operator rotates it in the ca mode.
+		WebhookCertMode       string `yaml:"webhook-cert-mode" jsonschema:"omitempty"`
+		CertSecretName        string `yaml:"cert-secret-name" jsonschema:"omitempty"`
+		CertSecretNamespace   string `yaml:"cert-secret-namespace" jsonschema:"omitempty"`
+		MutatingWebhookName   string `yaml:"mutating-webhook-name" jsonschema:"omitempty"`
+		ValidatingWebhookName string `yaml:"validating-webhook-name" jsonschema:"omitempty"`
+
 		// The image name of the injecting sidecar
 		SidecarImageName string `yaml:"sidecar-image-name" jsonschema:"required"`
 
@@ -130,6 +140,13 @@ type (
 		CoreDNSFlags        *flags.CoreDNS
 		APIExtensionsClient apiextensions.Interface
 		ClearFuncs          []func(*StageContext) error
+
+		// Recorder records the objects instead of applying them when rendering,
+		// it's nil in a real installation.
+		Recorder *Recorder
+
+		// Progress is where the stages report their progress, it's stdout if nil.
+		Progress io.Writer
 	}
 
 	// InstallFunc is the type of function for installation.
@@ -141,6 +158,20 @@ func (fn InstallFunc) Deploy(ctx *StageContext) error {
 	return fn(ctx)
 }
 
+// Rendering returns whether the installation is rendering manifests,
+// in which case nothing in the cluster could be waited or accessed.
+func (ctx *StageContext) Rendering() bool {
+	return ctx.Recorder != nil
+}
+
+// Out returns the writer of the progress of stages.
+func (ctx *StageContext) Out() io.Writer {
+	if ctx.Progress == nil {
+		return os.Stdout
+	}
+	return ctx.Progress
+}
+
 // ControlPlanePodName returns the pod name of control plane.
 func ControlPlanePodName(index int) string {
 	return fmt.Sprintf("%s-%d", ControlPlaneStatefulSetName, index)
@@ -197,6 +228,8 @@ func ControlPlanePeerURLs(ctx *StageContext) []string {
 	for _, peerURL := range initCluster {
 		peerURLs = append(peerURLs, peerURL)
 	}
+	// NOTE: Keep the rendered config stable, in case of the upgrade reports changes of it.
+	sort.Strings(peerURLs)
 
 	return peerURLs
 }
@@ -63,6 +63,8 @@ const (
 	ControlPlaneStatefulSetAdminPortName = "admin-port"
 	// ControlPlanePVCName is the name of persisten volume claim control plane.
 	ControlPlanePVCName = "control-plane-pvc"
+	// ControlPlaneLocalPVLabelKey is the label key of the storage class and the local persistent volumes provisioned by emctl.
+	ControlPlaneLocalPVLabelKey = "mesh.megaease.com/control-plane-local-pv"
 
 	// --- Control Plane Service related.
 
@@ -101,6 +103,21 @@ const (
 	OperatorSecretCertFileName = "cert.pem"
 	// OperatorSecretKeyFileName is the key filename of admission control of operator deployment.
 	OperatorSecretKeyFileName = "key.pem"
+	// OperatorSecretCAFileName is the filename of the CA signing the cert of admission control of operator deployment.
+	OperatorSecretCAFileName = "ca.pem"
+	// OperatorSecretCAKeyFileName is the filename of the key of the CA.
+	OperatorSecretCAKeyFileName = "ca-key.pem"
+	// OperatorSecretCABundleFileName is the filename of the CA bundle of the mutating webhook,
+	// which keeps the previous CA during its rotation.
+	OperatorSecretCABundleFileName = "ca-bundle.pem"
+	// OperatorCertManagerCertFileName is the cert filename of the secret issued by cert-manager.
+	OperatorCertManagerCertFileName = "tls.crt"
+	// OperatorCertManagerKeyFileName is the key filename of the secret issued by cert-manager.
+	OperatorCertManagerKeyFileName = "tls.key"
+	// OperatorIssuerName is the name of the cert-manager Issuer of the webhook certificate.
+	OperatorIssuerName = "easemesh-operator-selfsigned-issuer"
+	// OperatorCertificateName is the name of the cert-manager Certificate of the webhook.
+	OperatorCertificateName = "easemesh-operator-cert"
 	// OperatorCmd is the command of operator.
 	OperatorCmd = "/manager"
 	// OperatorArgs is the args of operator.
@@ -116,17 +133,35 @@ const (
 	OperatorMutatingWebhookName = "easemesh-operator-mutating-webhook"
 	// OperatorMutatingWebhookPath is the path of admission control of operator deployment.
 	OperatorMutatingWebhookPath = "/mutate"
+	// OperatorLocalityWebhookPath is the path of admission control of operator deployment copying the node locality to pods.
+	OperatorLocalityWebhookPath = "/locality"
+	// OperatorValidatingWebhookName is the name of validating-webhook of admission control of operator deployment.
+	OperatorValidatingWebhookName = "easemesh-operator-validating-webhook"
+	// OperatorValidatingWebhookPath is the path of validating admission control of operator deployment.
+	OperatorValidatingWebhookPath = "/validate"
 	// OperatorMutatingWebhookPortName is the name of mutating webhook port of admission control of operator deployment.
 	OperatorMutatingWebhookPortName = "mutate-port"
 	// OperatorMutatingWebhookPort is the port of adminssion control of operator deployment.
 	OperatorMutatingWebhookPort = 9090
 
+	// --- Installation related.
+
+	// InstallationStateConfigMapName is the name of config map persisting the progress of installation.
+	InstallationStateConfigMapName = "easemesh-installation-state"
+
+	// --- Custom resource definitions related.
+
+	// MeshDeploymentCRDName is the name of CustomResourceDefinition of MeshDeployment.
+	MeshDeploymentCRDName = "meshdeployments.mesh.megaease.com"
+	// MeshFederationCRDName is the name of CustomResourceDefinition of MeshFederation.
+	MeshFederationCRDName = "meshfederations.mesh.megaease.com"
+	// MeshStatefulSetCRDName is the name of CustomResourceDefinition of MeshStatefulSet.
+	MeshStatefulSetCRDName = "meshstatefulsets.mesh.megaease.com"
+	// MeshDaemonSetCRDName is the name of CustomResourceDefinition of MeshDaemonSet.
+	MeshDaemonSetCRDName = "meshdaemonsets.mesh.megaease.com"
+
 	// --- Operator injection related.
 
-	// SidecarImageName is the imaget name of sidecar.
-	SidecarImageName = "megaease/easegress:easemesh"
-	// AgentInitializerImageName is the image name of agent initializer.
-	AgentInitializerImageName = "megaease/easeagent-initializer:latest"
 	// AgentLog4jConfigName is the file name of log4j config of agent.
 	AgentLog4jConfigName = "log4j2.xml"
 
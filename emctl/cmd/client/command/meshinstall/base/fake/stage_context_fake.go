@@ -59,6 +59,9 @@ func NewStageContextForApply(client kubernetes.Interface,
 			// EaseMesh Operator params
 			EaseMeshOperatorImage:    "megaease/easemesh-operator",
 			EaseMeshOperatorReplicas: 1,
+			RBACProxyImage:           "gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0",
+			SidecarImage:             "megaease/easegress:easemesh",
+			AgentInitializerImage:    "megaease/easeagent-initializer:latest",
 			SpecFile:                 "",
 
 			OperationGlobal: &flags.OperationGlobal{
@@ -30,6 +30,7 @@ import (
 	extensionfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8yaml "k8s.io/apimachinery/pkg/util/yaml"
@@ -330,6 +331,55 @@ func TestDeleteCRDResource(t *testing.T) {
 	}
 }
 
+func TestDeployAndDeleteCustomResourceObject(t *testing.T) {
+	recorder := NewRecorder()
+	client, _, err := NewRecordingClients(recorder)
+	if err != nil {
+		t.Fatalf("new recording clients failed: %v", err)
+	}
+
+	object := &unstructured.Unstructured{}
+	object.SetAPIVersion("cert-manager.io/v1")
+	object.SetKind("Issuer")
+	object.SetNamespace("easemesh")
+	object.SetName("issuer")
+	object.Object["spec"] = map[string]interface{}{"selfSigned": map[string]interface{}{}}
+
+	// NOTE: The second deploy updates the existing one.
+	for i := 0; i < 2; i++ {
+		err = DeployCustomResource(object, "issuers", client)
+		if err != nil {
+			t.Fatalf("deploy custom resource failed: %v", err)
+		}
+	}
+
+	manifests := recorder.Manifests()
+	if len(manifests) != 1 || manifests[0]["kind"] != "Issuer" {
+		t.Fatalf("expected one Issuer, got %v", manifests)
+	}
+
+	got, err := GetCustomResource(client, "cert-manager.io/v1", "issuers", "easemesh", "issuer")
+	if err != nil {
+		t.Fatalf("get custom resource failed: %v", err)
+	}
+	if got.GetKind() != "Issuer" || got.GetName() != "issuer" {
+		t.Fatalf("expected the Issuer, got %v", got.Object)
+	}
+
+	err = DeleteCustomResource(client, "cert-manager.io/v1", "issuers", "easemesh", "issuer")
+	if err != nil {
+		t.Fatalf("delete custom resource failed: %v", err)
+	}
+	if len(recorder.Manifests()) != 0 {
+		t.Fatalf("expected no manifests after deletion")
+	}
+
+	_, err = GetCustomResource(client, "cert-manager.io/v1", "issuers", "easemesh", "issuer")
+	if !k8serr.IsNotFound(err) {
+		t.Fatalf("expected not found after deletion, got %v", err)
+	}
+}
+
 func TestDeleteCertificateV1Resource(t *testing.T) {
 	client := prepareClientForTest()
 	err := DeleteCertificateV1Beta1Resources(client, "na", "easemesh", "easemesh-control-plane")
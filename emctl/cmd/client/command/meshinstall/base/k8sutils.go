@@ -22,12 +22,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/megaease/easemeshctl/cmd/common"
 
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	appsV1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -36,8 +38,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -363,6 +368,115 @@ func DeployMutatingWebhookConfig(mutatingWebhookConfig *admissionregv1.MutatingW
 	return deployResource(createFn, updateFn)
 }
 
+// DeployValidatingWebhookConfig creates or updates ValidatingWebhookConfiguration.
+func DeployValidatingWebhookConfig(validatingWebhookConfig *admissionregv1.ValidatingWebhookConfiguration, clientSet kubernetes.Interface, namespace string) error {
+	createFn := func() error {
+		_, err := clientSet.AdmissionregistrationV1().ValidatingWebhookConfigurations().
+			Create(requestContext(), validatingWebhookConfig, createOptions())
+		return err
+	}
+
+	updateFn := func() error {
+		oldObject, err := clientSet.AdmissionregistrationV1().ValidatingWebhookConfigurations().
+			Get(requestContext(), validatingWebhookConfig.Name, getOptions())
+		if err != nil {
+			return err
+		}
+
+		err = adaptReplaceObject(oldObject, validatingWebhookConfig)
+		if err != nil {
+			return err
+		}
+
+		_, err = clientSet.AdmissionregistrationV1().ValidatingWebhookConfigurations().
+			Update(requestContext(), validatingWebhookConfig, updateOptions())
+		return err
+	}
+
+	return deployResource(createFn, updateFn)
+}
+
+// DeployPodDisruptionBudget creates or updates PodDisruptionBudget.
+func DeployPodDisruptionBudget(pdb *policyv1.PodDisruptionBudget, clientSet kubernetes.Interface, namespace string) error {
+	createFn := func() error {
+		_, err := clientSet.PolicyV1().PodDisruptionBudgets(namespace).
+			Create(requestContext(), pdb, createOptions())
+		return err
+	}
+
+	updateFn := func() error {
+		oldObject, err := clientSet.PolicyV1().PodDisruptionBudgets(namespace).
+			Get(requestContext(), pdb.Name, getOptions())
+		if err != nil {
+			return err
+		}
+
+		err = adaptReplaceObject(oldObject, pdb)
+		if err != nil {
+			return err
+		}
+
+		_, err = clientSet.PolicyV1().PodDisruptionBudgets(namespace).
+			Update(requestContext(), pdb, updateOptions())
+		return err
+	}
+
+	return deployResource(createFn, updateFn)
+}
+
+// DeployCustomResource creates or updates the custom resource, whose API is located by its
+// apiVersion and the plural resource name, since there is no typed client for it.
+func DeployCustomResource(object *unstructured.Unstructured, resource string, clientSet kubernetes.Interface) error {
+	restClient := clientSet.Discovery().RESTClient()
+	path := customResourcePath(object.GetAPIVersion(), object.GetNamespace(), resource)
+
+	createFn := func() error {
+		body, err := object.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		return restClient.Post().AbsPath(path).Body(body).Do(requestContext()).Error()
+	}
+
+	updateFn := func() error {
+		raw, err := restClient.Get().AbsPath(path, object.GetName()).Do(requestContext()).Raw()
+		if err != nil {
+			return err
+		}
+
+		oldObject := &unstructured.Unstructured{}
+		err = oldObject.UnmarshalJSON(raw)
+		if err != nil {
+			return err
+		}
+
+		err = adaptReplaceObject(oldObject, object)
+		if err != nil {
+			return err
+		}
+
+		body, err := object.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		return restClient.Put().AbsPath(path, object.GetName()).Body(body).Do(requestContext()).Error()
+	}
+
+	return deployResource(createFn, updateFn)
+}
+
+func customResourcePath(apiVersion, namespace, resource string) string {
+	// NOTE: The resources of the core group such as v1 are served under /api.
+	prefix := "/apis"
+	if !strings.Contains(apiVersion, "/") {
+		prefix = "/api"
+	}
+	if namespace == "" {
+		return fmt.Sprintf("%s/%s/%s", prefix, apiVersion, resource)
+	}
+	return fmt.Sprintf("%s/%s/namespaces/%s/%s", prefix, apiVersion, namespace, resource)
+}
+
 // ListPersistentVolume lists persistent volumes.
 func ListPersistentVolume(clientSet kubernetes.Interface) (*v1.PersistentVolumeList, error) {
 	return clientSet.CoreV1().PersistentVolumes().List(requestContext(), metav1.ListOptions{})
@@ -638,7 +752,13 @@ func DeleteRbacV1Resources(client kubernetes.Interface, resources, namespace, na
 // DeleteAdmissionregV1Resources deletes resources within group AdmissionregV1.
 func DeleteAdmissionregV1Resources(client kubernetes.Interface, resources, namespace, name string) error {
 	// NOTE: RESTClinet can't find mutatingwebhookconfigurations resource.
-	err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().Delete(requestContext(), name, metav1.DeleteOptions{})
+	var err error
+	switch resources {
+	case "validatingwebhookconfigurations":
+		err = client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Delete(requestContext(), name, metav1.DeleteOptions{})
+	default:
+		err = client.AdmissionregistrationV1().MutatingWebhookConfigurations().Delete(requestContext(), name, metav1.DeleteOptions{})
+	}
 	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
@@ -655,6 +775,68 @@ func DeleteCertificateV1Beta1Resources(client kubernetes.Interface, resources, n
 	return nil
 }
 
+// DeletePolicyV1Resources deletes resources within group PolicyV1.
+func DeletePolicyV1Resources(client kubernetes.Interface, resources, namespace, name string) error {
+	err := client.PolicyV1().PodDisruptionBudgets(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// KindResolver resolves the plural resource of the kind and whether it's cluster-scoped.
+type KindResolver func(apiVersion, kind string) (string, bool, error)
+
+// NewKindResolver creates a KindResolver by the discovery of the cluster,
+// the kinds unknown to the cluster are rejected instead of being guessed.
+func NewKindResolver(ctx *StageContext) KindResolver {
+	if ctx.Rendering() {
+		return func(apiVersion, kind string) (string, bool, error) {
+			return renderedResourceOfKind(kind)
+		}
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(ctx.Client.Discovery()))
+	return func(apiVersion, kind string) (string, bool, error) {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return "", false, err
+		}
+
+		mapping, err := mapper.RESTMapping(gv.WithKind(kind).GroupKind(), gv.Version)
+		if err != nil {
+			return "", false, fmt.Errorf("resolve kind %s of %s failed: %v", kind, apiVersion, err)
+		}
+		return mapping.Resource.Resource, mapping.Scope.Name() == meta.RESTScopeNameRoot, nil
+	}
+}
+
+// GetCustomResource gets the custom resource located by its apiVersion and the plural resource name.
+func GetCustomResource(client kubernetes.Interface, apiVersion, resource, namespace, name string) (*unstructured.Unstructured, error) {
+	raw, err := client.Discovery().RESTClient().Get().AbsPath(customResourcePath(apiVersion, namespace, resource), name).
+		Do(requestContext()).Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	object := &unstructured.Unstructured{}
+	err = object.UnmarshalJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// DeleteCustomResource deletes the custom resource located by its apiVersion and the plural resource name.
+func DeleteCustomResource(client kubernetes.Interface, apiVersion, resource, namespace, name string) error {
+	err := client.Discovery().RESTClient().Delete().AbsPath(customResourcePath(apiVersion, namespace, resource), name).
+		Do(context.Background()).Error()
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
 // DeleteCRDResource deletes resources within group CustomResourceDefinitions.
 func DeleteCRDResource(client apiextensions.Interface, name string) error {
 	err := client.ApiextensionsV1().CustomResourceDefinitions().Delete(context.Background(), name, metav1.DeleteOptions{})
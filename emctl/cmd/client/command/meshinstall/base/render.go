@@ -0,0 +1,533 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package installbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	yamljsontool "github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// RenderToStdout is the output of rendering which writes manifests to stdout.
+	RenderToStdout = "-"
+
+	renderHost = "http://emctl.render.local"
+
+	kustomizationFileName = "kustomization.yaml"
+)
+
+// resourceKinds maps resources of the installed objects to their kinds.
+var resourceKinds = map[string]string{
+	"namespaces":                      "Namespace",
+	"configmaps":                      "ConfigMap",
+	"secrets":                         "Secret",
+	"services":                        "Service",
+	"serviceaccounts":                 "ServiceAccount",
+	"pods":                            "Pod",
+	"persistentvolumes":               "PersistentVolume",
+	"persistentvolumeclaims":          "PersistentVolumeClaim",
+	"nodes":                           "Node",
+	"deployments":                     "Deployment",
+	"statefulsets":                    "StatefulSet",
+	"daemonsets":                      "DaemonSet",
+	"roles":                           "Role",
+	"rolebindings":                    "RoleBinding",
+	"clusterroles":                    "ClusterRole",
+	"clusterrolebindings":             "ClusterRoleBinding",
+	"mutatingwebhookconfigurations":   "MutatingWebhookConfiguration",
+	"validatingwebhookconfigurations": "ValidatingWebhookConfiguration",
+	"customresourcedefinitions":       "CustomResourceDefinition",
+	"certificatesigningrequests":      "CertificateSigningRequest",
+	"storageclasses":                  "StorageClass",
+	"poddisruptionbudgets":            "PodDisruptionBudget",
+	"priorityclasses":                 "PriorityClass",
+	"issuers":                         "Issuer",
+	"certificates":                    "Certificate",
+}
+
+// clusterScopedResources are the resources of the installed objects which
+// don't belong to any namespace.
+var clusterScopedResources = map[string]bool{
+	"namespaces":                      true,
+	"persistentvolumes":               true,
+	"nodes":                           true,
+	"clusterroles":                    true,
+	"clusterrolebindings":             true,
+	"mutatingwebhookconfigurations":   true,
+	"validatingwebhookconfigurations": true,
+	"customresourcedefinitions":       true,
+	"certificatesigningrequests":      true,
+	"storageclasses":                  true,
+	"priorityclasses":                 true,
+}
+
+// renderedResourceOfKind returns the plural resource of the kind known to emctl and
+// whether it's cluster-scoped, nothing could be discovered while rendering.
+func renderedResourceOfKind(kind string) (string, bool, error) {
+	for resource, k := range resourceKinds {
+		if k == kind {
+			return resource, clusterScopedResources[resource], nil
+		}
+	}
+	return "", false, errors.Errorf("kind %s is not supported in rendering", kind)
+}
+
+type (
+	// Recorder is a fake Kubernetes API server working as the transport of clients,
+	// it records the objects written by the installation instead of applying them.
+	Recorder struct {
+		mutex   sync.Mutex
+		keys    []string
+		objects map[string]map[string]interface{}
+	}
+
+	// requestPath is the parsed path of a Kubernetes API request.
+	requestPath struct {
+		apiVersion  string
+		namespace   string
+		resource    string
+		name        string
+		subresource string
+	}
+)
+
+// NewRecorder creates a Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{objects: map[string]map[string]interface{}{}}
+}
+
+// NewRecordingClients creates Kubernetes clients whose requests are served by the recorder.
+func NewRecordingClients(recorder *Recorder) (kubernetes.Interface, apiextensions.Interface, error) {
+	config := &rest.Config{
+		Host:      renderHost,
+		Transport: recorder,
+		QPS:       1000,
+		Burst:     1000,
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	apiExtensionsClient, err := apiextensions.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return kubeClient, apiExtensionsClient, nil
+}
+
+func parseRequestPath(path string) (*requestPath, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	rp := &requestPath{}
+	switch {
+	case len(segments) >= 3 && segments[0] == "api":
+		rp.apiVersion = segments[1]
+		segments = segments[2:]
+	case len(segments) >= 4 && segments[0] == "apis":
+		rp.apiVersion = segments[1] + "/" + segments[2]
+		segments = segments[3:]
+	default:
+		return nil, errors.Errorf("unsupported path %s", path)
+	}
+
+	if len(segments) >= 3 && segments[0] == "namespaces" {
+		rp.namespace = segments[1]
+		segments = segments[2:]
+	}
+
+	rp.resource = segments[0]
+	if len(segments) >= 2 {
+		rp.name = segments[1]
+	}
+	if len(segments) >= 3 {
+		rp.subresource = segments[2]
+	}
+
+	return rp, nil
+}
+
+func (rp *requestPath) kind() string {
+	kind, exists := resourceKinds[rp.resource]
+	if exists {
+		return kind
+	}
+	// NOTE: Fallback for resources we don't know, it's only used in responses.
+	return strings.Title(strings.TrimSuffix(rp.resource, "s"))
+}
+
+func (rp *requestPath) key(name string) string {
+	return strings.Join([]string{rp.apiVersion, rp.resource, rp.namespace, name}, "/")
+}
+
+// RoundTrip serves the request with the recorded objects.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	rp, err := parseRequestPath(req.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.Method == http.MethodGet && rp.name == "":
+		return response(req, http.StatusOK, map[string]interface{}{
+			"apiVersion": rp.apiVersion,
+			"kind":       rp.kind() + "List",
+			"metadata":   map[string]interface{}{},
+			"items":      []interface{}{},
+		})
+	case req.Method == http.MethodGet:
+		object, exists := r.objects[rp.key(rp.name)]
+		if !exists {
+			return statusResponse(req, rp, http.StatusNotFound, "NotFound", "not found")
+		}
+		return response(req, http.StatusOK, object)
+	case req.Method == http.MethodPost || req.Method == http.MethodPut:
+		if rp.subresource != "" {
+			return statusResponse(req, rp, http.StatusMethodNotAllowed, "MethodNotAllowed",
+				fmt.Sprintf("subresource %s is not supported in rendering", rp.subresource))
+		}
+
+		object, err := readObject(req)
+		if err != nil {
+			return nil, err
+		}
+		object["apiVersion"], object["kind"] = rp.apiVersion, rp.kind()
+
+		metadata, _ := object["metadata"].(map[string]interface{})
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+			object["metadata"] = metadata
+		}
+		// NOTE: The namespace of objects is usually given by the path only,
+		// the manifests need it to be applied to the right namespace, while
+		// the cluster-scoped objects must not carry any namespace.
+		switch {
+		case rp.namespace != "":
+			metadata["namespace"] = rp.namespace
+		case clusterScopedResources[rp.resource]:
+			delete(metadata, "namespace")
+		}
+
+		name := rp.name
+		if name == "" {
+			name, _ = metadata["name"].(string)
+		}
+
+		key := rp.key(name)
+		_, exists := r.objects[key]
+		if req.Method == http.MethodPost && exists {
+			rp.name = name
+			return statusResponse(req, rp, http.StatusConflict, "AlreadyExists", "already exists")
+		}
+		if !exists {
+			r.keys = append(r.keys, key)
+		}
+		r.objects[key] = object
+
+		if req.Method == http.MethodPost {
+			return response(req, http.StatusCreated, object)
+		}
+		return response(req, http.StatusOK, object)
+	case req.Method == http.MethodPatch:
+		if rp.subresource != "" {
+			return statusResponse(req, rp, http.StatusMethodNotAllowed, "MethodNotAllowed",
+				fmt.Sprintf("subresource %s is not supported in rendering", rp.subresource))
+		}
+
+		key := rp.key(rp.name)
+		object, exists := r.objects[key]
+		if !exists {
+			return statusResponse(req, rp, http.StatusNotFound, "NotFound", "not found")
+		}
+
+		patched, supported, err := patchObject(req, rp, object)
+		if !supported {
+			return statusResponse(req, rp, http.StatusUnsupportedMediaType, "UnsupportedMediaType", err.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+		// NOTE: The identity of the object is given by the path, the patch can't change it.
+		patched["apiVersion"], patched["kind"] = rp.apiVersion, rp.kind()
+		if metadata, ok := patched["metadata"].(map[string]interface{}); ok {
+			metadata["name"] = rp.name
+			if rp.namespace != "" {
+				metadata["namespace"] = rp.namespace
+			}
+		}
+		r.objects[key] = patched
+
+		return response(req, http.StatusOK, patched)
+	case req.Method == http.MethodDelete:
+		key := rp.key(rp.name)
+		delete(r.objects, key)
+		for i, k := range r.keys {
+			if k == key {
+				r.keys = append(r.keys[:i], r.keys[i+1:]...)
+				break
+			}
+		}
+		return statusResponse(req, rp, http.StatusOK, "", "")
+	default:
+		return statusResponse(req, rp, http.StatusMethodNotAllowed, "MethodNotAllowed",
+			fmt.Sprintf("method %s is not supported in rendering", req.Method))
+	}
+}
+
+// patchObject patches the copy of the object by the patch in the body, and reports
+// whether the type of the patch is supported.
+func patchObject(req *http.Request, rp *requestPath, object map[string]interface{}) (map[string]interface{}, bool, error) {
+	if req.Body == nil {
+		return nil, true, errors.Errorf("empty body of %s %s", req.Method, req.URL.Path)
+	}
+	defer req.Body.Close()
+
+	patch, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	original, err := json.Marshal(object)
+	if err != nil {
+		return nil, true, err
+	}
+
+	var patched []byte
+	switch patchType := types.PatchType(req.Header.Get("Content-Type")); patchType {
+	case types.JSONPatchType:
+		var jsonPatch jsonpatch.Patch
+		jsonPatch, err = jsonpatch.DecodePatch(patch)
+		if err == nil {
+			patched, err = jsonPatch.Apply(original)
+		}
+	case types.MergePatchType:
+		patched, err = jsonpatch.MergePatch(original, patch)
+	case types.StrategicMergePatchType:
+		// NOTE: The strategic merge patch needs the struct of the kind, which
+		// the API server doesn't support for custom resources either.
+		dataStruct, newErr := kubescheme.Scheme.New(schema.FromAPIVersionAndKind(rp.apiVersion, rp.kind()))
+		if newErr != nil {
+			return nil, false, errors.Errorf("strategic merge patch of %s is not supported in rendering", rp.resource)
+		}
+		patched, err = strategicpatch.StrategicMergePatch(original, patch, dataStruct)
+	default:
+		return nil, false, errors.Errorf("patch type %s is not supported in rendering", patchType)
+	}
+	if err != nil {
+		return nil, true, errors.Wrapf(err, "patch %s %s failed", rp.resource, rp.name)
+	}
+
+	result := map[string]interface{}{}
+	err = json.Unmarshal(patched, &result)
+	if err != nil {
+		return nil, true, errors.Wrapf(err, "unmarshal patched %s %s failed", rp.resource, rp.name)
+	}
+
+	return result, true, nil
+}
+
+func readObject(req *http.Request) (map[string]interface{}, error) {
+	if req.Body == nil {
+		return nil, errors.Errorf("empty body of %s %s", req.Method, req.URL.Path)
+	}
+	defer req.Body.Close()
+
+	buff, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	object := map[string]interface{}{}
+	err = json.Unmarshal(buff, &object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal body of %s %s failed", req.Method, req.URL.Path)
+	}
+
+	return object, nil
+}
+
+func response(req *http.Request, statusCode int, body interface{}) (*http.Response, error) {
+	buff, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(buff)),
+		Request:    req,
+	}, nil
+}
+
+func statusResponse(req *http.Request, rp *requestPath, statusCode int, reason, message string) (*http.Response, error) {
+	status := "Success"
+	if statusCode >= http.StatusBadRequest {
+		status = "Failure"
+		message = fmt.Sprintf("%s %q %s", rp.resource, rp.name, message)
+	}
+
+	return response(req, statusCode, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Status",
+		"metadata":   map[string]interface{}{},
+		"status":     status,
+		"message":    message,
+		"reason":     reason,
+		"details": map[string]interface{}{
+			"name": rp.name,
+			"kind": rp.resource,
+		},
+		"code": statusCode,
+	})
+}
+
+// Manifests returns the recorded objects in the order of creation, the
+// fields filled by the server and the status are removed.
+func (r *Recorder) Manifests() []map[string]interface{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var manifests []map[string]interface{}
+	for _, key := range r.keys {
+		object := map[string]interface{}{}
+		for k, v := range r.objects[key] {
+			object[k] = v
+		}
+		delete(object, "status")
+
+		if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+			trimmed := map[string]interface{}{}
+			for k, v := range metadata {
+				if v != nil {
+					trimmed[k] = v
+				}
+			}
+			delete(trimmed, "resourceVersion")
+			object["metadata"] = trimmed
+		}
+
+		manifests = append(manifests, object)
+	}
+
+	return manifests
+}
+
+// WriteManifests writes the recorded objects as multi-document YAML to stdout
+// if the output is "-", or writes every object to its own file in the output
+// directory along with a kustomization.yaml.
+func (r *Recorder) WriteManifests(output string) error {
+	if output == RenderToStdout {
+		return r.writeDocuments(os.Stdout)
+	}
+
+	err := os.MkdirAll(output, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "create directory %s failed", output)
+	}
+
+	var resources []string
+	for _, manifest := range r.Manifests() {
+		buff, err := manifestToYAML(manifest)
+		if err != nil {
+			return err
+		}
+
+		filename := manifestFileName(manifest)
+		err = ioutil.WriteFile(filepath.Join(output, filename), buff, 0644)
+		if err != nil {
+			return errors.Wrapf(err, "write %s failed", filename)
+		}
+		resources = append(resources, filename)
+	}
+
+	kustomization, err := yamljsontool.Marshal(map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal kustomization failed")
+	}
+
+	return ioutil.WriteFile(filepath.Join(output, kustomizationFileName), kustomization, 0644)
+}
+
+func (r *Recorder) writeDocuments(w io.Writer) error {
+	for _, manifest := range r.Manifests() {
+		buff, err := manifestToYAML(manifest)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "---\n%s", buff)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func manifestToYAML(manifest map[string]interface{}) ([]byte, error) {
+	buff, err := yamljsontool.Marshal(manifest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal %s to yaml failed", manifestFileName(manifest))
+	}
+	return buff, nil
+}
+
+// manifestFileName returns kind-name.yaml for the cluster-scoped objects and
+// kind-namespace-name.yaml for the namespaced ones, so the objects with the same
+// name in different namespaces don't overwrite each other.
+func manifestFileName(manifest map[string]interface{}) string {
+	kind, _ := manifest["kind"].(string)
+	namespace, name := "", ""
+	if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+		namespace, _ = metadata["namespace"].(string)
+		name, _ = metadata["name"].(string)
+	}
+	if namespace == "" {
+		return strings.ToLower(fmt.Sprintf("%s-%s.yaml", kind, name))
+	}
+	return strings.ToLower(fmt.Sprintf("%s-%s-%s.yaml", kind, namespace, name))
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package installbase
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder()
+	client, _, err := NewRecordingClients(recorder)
+	if err != nil {
+		t.Fatalf("new recording clients failed: %v", err)
+	}
+
+	_, err = client.CoreV1().Secrets("easemesh").Get(requestContext(), "secret", getOptions())
+	if !k8serr.IsNotFound(err) {
+		t.Fatalf("expect not found error, but got %v", err)
+	}
+
+	err = DeployNamespace(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "easemesh"}}, client)
+	if err != nil {
+		t.Fatalf("deploy namespace failed: %v", err)
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "easemesh"},
+		Data:       map[string]string{"key": "value"},
+	}
+	err = DeployConfigMap(configMap, client, "easemesh")
+	if err != nil {
+		t.Fatalf("deploy configmap failed: %v", err)
+	}
+
+	// NOTE: Deploying again goes through get and update.
+	configMap.Data["key"] = "new-value"
+	err = DeployConfigMap(configMap, client, "easemesh")
+	if err != nil {
+		t.Fatalf("redeploy configmap failed: %v", err)
+	}
+
+	got, err := client.CoreV1().ConfigMaps("easemesh").Get(requestContext(), "config", getOptions())
+	if err != nil {
+		t.Fatalf("get configmap failed: %v", err)
+	}
+	if got.Data["key"] != "new-value" {
+		t.Fatalf("expect updated configmap, but got %+v", got.Data)
+	}
+
+	_, err = client.CoreV1().ConfigMaps("easemesh").Patch(requestContext(), "config", types.MergePatchType,
+		[]byte(`{"data":{"patched":"true"}}`), metav1.PatchOptions{})
+	if err != nil {
+		t.Fatalf("merge patch configmap failed: %v", err)
+	}
+	_, err = client.CoreV1().ConfigMaps("easemesh").Patch(requestContext(), "config", types.StrategicMergePatchType,
+		[]byte(`{"metadata":{"labels":{"app":"config"}}}`), metav1.PatchOptions{})
+	if err != nil {
+		t.Fatalf("strategic merge patch configmap failed: %v", err)
+	}
+	got, err = client.CoreV1().ConfigMaps("easemesh").Patch(requestContext(), "config", types.JSONPatchType,
+		[]byte(`[{"op":"remove","path":"/data/patched"}]`), metav1.PatchOptions{})
+	if err != nil {
+		t.Fatalf("json patch configmap failed: %v", err)
+	}
+	if got.Data["key"] != "new-value" || got.Data["patched"] != "" || got.Labels["app"] != "config" || got.Namespace != "easemesh" {
+		t.Fatalf("unexpected patched configmap %+v", got)
+	}
+	_, err = client.CoreV1().ConfigMaps("easemesh").Patch(requestContext(), "config", types.ApplyPatchType,
+		[]byte(`{}`), metav1.PatchOptions{})
+	if !k8serr.IsUnsupportedMediaType(err) {
+		t.Fatalf("expect unsupported media type error, but got %v", err)
+	}
+	_, err = client.CoreV1().Secrets("easemesh").Patch(requestContext(), "secret", types.MergePatchType,
+		[]byte(`{}`), metav1.PatchOptions{})
+	if !k8serr.IsNotFound(err) {
+		t.Fatalf("expect not found error, but got %v", err)
+	}
+
+	// NOTE: The cluster-scoped objects are built with the namespace sometimes.
+	clusterRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: "easemesh"}}
+	err = DeployClusterRole(clusterRole, client)
+	if err != nil {
+		t.Fatalf("deploy cluster role failed: %v", err)
+	}
+
+	pods, err := client.CoreV1().Pods("easemesh").List(requestContext(), metav1.ListOptions{})
+	if err != nil || len(pods.Items) != 0 {
+		t.Fatalf("expect empty pod list, but got %v, %v", pods, err)
+	}
+
+	manifests := recorder.Manifests()
+	if len(manifests) != 3 {
+		t.Fatalf("expect 3 manifests, but got %d", len(manifests))
+	}
+	if manifests[0]["kind"] != "Namespace" || manifests[1]["kind"] != "ConfigMap" || manifests[1]["apiVersion"] != "v1" {
+		t.Fatalf("unexpected manifests: %+v", manifests)
+	}
+	metadata, _ := manifests[1]["metadata"].(map[string]interface{})
+	if metadata["namespace"] != "easemesh" {
+		t.Fatalf("expect namespace of configmap, but got %+v", metadata)
+	}
+	metadata, _ = manifests[2]["metadata"].(map[string]interface{})
+	if _, exists := metadata["namespace"]; exists || manifests[2]["kind"] != "ClusterRole" {
+		t.Fatalf("expect cluster role without namespace, but got %+v", manifests[2])
+	}
+
+	buff := &bytes.Buffer{}
+	err = recorder.writeDocuments(buff)
+	if err != nil {
+		t.Fatalf("write documents failed: %v", err)
+	}
+	if strings.Count(buff.String(), "---\n") != 3 || strings.Contains(buff.String(), "creationTimestamp: null") {
+		t.Fatalf("unexpected documents:\n%s", buff)
+	}
+
+	dir, err := ioutil.TempDir("", "emctl-render")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = recorder.WriteManifests(dir)
+	if err != nil {
+		t.Fatalf("write manifests failed: %v", err)
+	}
+	for _, name := range []string{"namespace-easemesh.yaml", "configmap-easemesh-config.yaml", "clusterrole-operator.yaml", kustomizationFileName} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("expect file %s: %v", name, err)
+		}
+	}
+}
+
+func TestParseRequestPath(t *testing.T) {
+	rp, err := parseRequestPath("/apis/apps/v1/namespaces/easemesh/statefulsets/control-plane/status")
+	if err != nil {
+		t.Fatalf("parse path failed: %v", err)
+	}
+	if rp.apiVersion != "apps/v1" || rp.namespace != "easemesh" || rp.resource != "statefulsets" ||
+		rp.name != "control-plane" || rp.subresource != "status" || rp.kind() != "StatefulSet" {
+		t.Fatalf("unexpected parsed path: %+v", rp)
+	}
+
+	rp, err = parseRequestPath("/api/v1/namespaces/easemesh")
+	if err != nil || rp.resource != "namespaces" || rp.name != "easemesh" || rp.namespace != "" {
+		t.Fatalf("unexpected parsed path: %+v, %v", rp, err)
+	}
+
+	_, err = parseRequestPath("/healthz")
+	if err == nil {
+		t.Fatalf("expect error of unsupported path")
+	}
+}
@@ -0,0 +1,333 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chart
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/installation"
+
+	yamljsontool "github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	templatesDir = "templates"
+	crdsDir      = "crds"
+
+	webhookTemplateName = "webhook.yaml"
+	helpersTemplateName = "_helpers.tpl"
+	notesTemplateName   = "NOTES.txt"
+
+	secretDataPlaceholder = "__EMCTL_CHART_WEBHOOK_SECRET_DATA__"
+	caBundlePlaceholder   = "__EMCTL_CHART_WEBHOOK_CA_BUNDLE__"
+
+	// NOTE: They are the same as the validity of the certificates issued by the operator stage.
+	caValidityDays          = 5 * 365
+	servingCertValidityDays = 365
+	selfSignedValidityDays  = 10 * 365
+)
+
+// webhookCertPreamble prepares the data of the webhook secret and the CA bundle of the
+// webhook configurations at installing, the secret and the webhook configurations are
+// in the same template to share them. The existing secret is reused, so upgrading the
+// release doesn't replace the certificate trusted by the API server. Otherwise it's
+// generated in the way of the cert mode, while cert-manager issues it by itself.
+func webhookCertPreamble(certMode string) string {
+	var generate string
+	switch certMode {
+	case flags.WebhookCertModeCertManager:
+		return ""
+	case flags.WebhookCertModeCA:
+		generate = fmt.Sprintf(`{{- $ca := genCA "easemesh-operator-ca" %[1]d }}
+{{- $cert := genSignedCert $cn nil $dnsNames %[2]d $ca }}
+{{- $webhookData = dict "%[3]s" ($ca.Cert | b64enc) "%[4]s" ($ca.Key | b64enc) "%[5]s" ($ca.Cert | b64enc) "%[6]s" ($cert.Cert | b64enc) "%[7]s" ($cert.Key | b64enc) }}
+`, caValidityDays, servingCertValidityDays,
+			installbase.OperatorSecretCAFileName, installbase.OperatorSecretCAKeyFileName, installbase.OperatorSecretCABundleFileName,
+			installbase.OperatorSecretCertFileName, installbase.OperatorSecretKeyFileName)
+	default:
+		// NOTE: No cluster signer is available in Helm, the certificate signs itself like rendering.
+		generate = fmt.Sprintf(`{{- $cert := genSelfSignedCert $cn nil $dnsNames %[1]d }}
+{{- $webhookData = dict "%[2]s" ($cert.Cert | b64enc) "%[3]s" ($cert.Key | b64enc) }}
+`, selfSignedValidityDays, installbase.OperatorSecretCertFileName, installbase.OperatorSecretKeyFileName)
+	}
+
+	return fmt.Sprintf(`{{- $cn := printf "%[1]s.%%s.svc" .Release.Namespace }}
+{{- $dnsNames := list "%[1]s" (printf "%[1]s.%%s" .Release.Namespace) $cn }}
+{{- $webhookData := dict }}
+{{- $secret := lookup "v1" "Secret" .Release.Namespace "%[2]s" }}
+{{- if $secret }}
+{{- $webhookData = $secret.data }}
+{{- else }}
+%[3]s{{- end }}
+{{- $caBundle := index $webhookData "%[4]s" | default (index $webhookData "%[5]s") }}
+`, installbase.OperatorServiceName, installbase.OperatorSecretName, generate,
+		installbase.OperatorSecretCABundleFileName, installbase.OperatorSecretCertFileName)
+}
+
+// helpersTemplate generates the initial cluster of control plane by the replicas.
+var helpersTemplate = fmt.Sprintf(`{{/* The initial cluster of the control plane. */}}
+{{- define "easemesh.initialCluster" -}}
+{{- $members := list -}}
+{{- range $i := until (int .Values.%[1]s) -}}
+{{- $name := printf "%[2]s-%%d" $i -}}
+{{- $members = append $members (printf "%%s=http://%%s.%[3]s.%%s:%%v" $name $name $.Release.Namespace $.Values.controlPlane.peerPort) -}}
+{{- end -}}
+{{- join "," $members -}}
+{{- end -}}
+`, controlPlaneReplicasPath, installbase.ControlPlaneStatefulSetName, installbase.ControlPlaneHeadlessServiceName)
+
+type (
+	// AddOn is an add-on which is installed if it's enabled in the values of the chart.
+	AddOn struct {
+		Name  string
+		Stage installation.InstallStage
+		// Notes is shown after the release is installed with the add-on enabled.
+		Notes string
+	}
+
+	// manifest is a rendered object with the condition to template it.
+	manifest struct {
+		object map[string]interface{}
+		addOn  string
+	}
+)
+
+// Export renders the stages into a Helm chart, the values of the chart come from the install flags.
+// The output of stages is full of placeholders, the add-ons leave their notes in the chart instead.
+func Export(cmd *cobra.Command, exportFlags *flags.ExportChart, stages []installation.InstallStage, addOns []*AddOn) error {
+	p := newPlaceholders()
+	installFlags := p.install(exportFlags.Install)
+
+	recorder := installbase.NewRecorder()
+	kubeClient, apiExtensionsClient, err := installbase.NewRecordingClients(recorder)
+	if err != nil {
+		return err
+	}
+
+	context := &installbase.StageContext{
+		Cmd:                 cmd,
+		Flags:               installFlags,
+		Client:              kubeClient,
+		APIExtensionsClient: apiExtensionsClient,
+		Recorder:            recorder,
+	}
+	p.literal(installbase.ControlPlaneInitialClusterStr(context), `{{ include "easemesh.initialCluster" . }}`)
+
+	var manifests []*manifest
+	rendered, err := installation.Render(context, stages...)
+	if err != nil {
+		return err
+	}
+	for _, objects := range rendered {
+		for _, object := range objects {
+			manifests = append(manifests, &manifest{object: object})
+		}
+	}
+
+	for _, addOn := range addOns {
+		enabled := false
+		for _, name := range exportFlags.AddOns {
+			if strings.EqualFold(name, addOn.Name) {
+				enabled = true
+			}
+		}
+		p.setValue(addOnEnabledPath(addOn.Name), enabled)
+
+		rendered, err := installation.Render(context, addOn.Stage)
+		if err != nil {
+			return err
+		}
+		for _, object := range rendered[0] {
+			manifests = append(manifests, &manifest{object: object, addOn: addOn.Name})
+		}
+	}
+
+	provisionerImage := p.str("meshController.provisionerImage", exportFlags.ProvisionerImage)
+	for _, m := range meshControllerManifests(installFlags, provisionerImage) {
+		manifests = append(manifests, &manifest{object: m})
+	}
+
+	return writeChart(exportFlags, p, manifests, notes(addOns))
+}
+
+func notes(addOns []*AddOn) string {
+	notes := "EaseMesh is installed in the namespace {{ .Release.Namespace }}.\n"
+	for _, addOn := range addOns {
+		if addOn.Notes != "" {
+			notes += fmt.Sprintf("{{- if .Values.%s }}\n\n%s\n{{- end }}\n", addOnEnabledPath(addOn.Name), addOn.Notes)
+		}
+	}
+	return notes
+}
+
+func addOnEnabledPath(name string) string {
+	return fmt.Sprintf("addOns.%s.enabled", strings.ToLower(name))
+}
+
+func writeChart(exportFlags *flags.ExportChart, p *placeholders, manifests []*manifest, notes string) error {
+	output := exportFlags.Output
+	for _, dir := range []string{output, filepath.Join(output, templatesDir), filepath.Join(output, crdsDir)} {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return errors.Wrapf(err, "create directory %s failed", dir)
+		}
+	}
+
+	chartFile, err := yaml.Marshal(yaml.MapSlice{
+		{Key: "apiVersion", Value: "v2"},
+		{Key: "name", Value: filepath.Base(filepath.Clean(output))},
+		{Key: "description", Value: "The infrastructure components of the EaseMesh"},
+		{Key: "type", Value: "application"},
+		{Key: "version", Value: exportFlags.ChartVersion},
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal Chart.yaml failed")
+	}
+
+	valuesFile, err := yaml.Marshal(p.values)
+	if err != nil {
+		return errors.Wrap(err, "marshal values.yaml failed")
+	}
+
+	files := map[string][]byte{
+		"Chart.yaml":  chartFile,
+		"values.yaml": valuesFile,
+		filepath.Join(templatesDir, helpersTemplateName): []byte(helpersTemplate),
+		filepath.Join(templatesDir, notesTemplateName):   []byte(notes),
+	}
+
+	webhook := []string{webhookCertPreamble(exportFlags.WebhookCertMode)}
+	for _, m := range manifests {
+		kind, _ := m.object["kind"].(string)
+		if kind == "Namespace" {
+			// NOTE: The namespace is created by helm install --create-namespace.
+			continue
+		}
+		adaptObject(m.object)
+
+		buff, err := yamljsontool.Marshal(m.object)
+		if err != nil {
+			return errors.Wrapf(err, "marshal %s to yaml failed", fileName(m.object))
+		}
+
+		content := p.substitute(string(buff))
+		if kind == "CustomResourceDefinition" {
+			// NOTE: The CRDs are installed by Helm ahead of templates, and they are not templated.
+			files[filepath.Join(crdsDir, fileName(m.object))] = []byte(content)
+			continue
+		}
+
+		content = substituteWebhookCert(content)
+		if m.addOn != "" {
+			content = fmt.Sprintf("{{- if .Values.%s }}\n%s{{- end }}\n", addOnEnabledPath(m.addOn), content)
+		}
+
+		if isWebhookObject(m.object) {
+			webhook = append(webhook, "---\n"+content)
+			continue
+		}
+		files[filepath.Join(templatesDir, fileName(m.object))] = []byte(content)
+	}
+	files[filepath.Join(templatesDir, webhookTemplateName)] = []byte(strings.Join(webhook, ""))
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = ioutil.WriteFile(filepath.Join(output, name), files[name], 0644)
+		if err != nil {
+			return errors.Wrapf(err, "write %s failed", name)
+		}
+	}
+
+	return nil
+}
+
+// adaptObject replaces the fields which can't be substituted as text with placeholders.
+func adaptObject(object map[string]interface{}) {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		delete(annotations, v1.LastAppliedConfigAnnotation)
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+
+	switch {
+	case object["kind"] == "StatefulSet" && metadata["name"] == installbase.ControlPlaneStatefulSetName:
+		if spec, ok := object["spec"].(map[string]interface{}); ok {
+			spec["replicas"] = controlPlaneReplicasPlaceholder
+		}
+	case object["kind"] == "Secret" && isWebhookObject(object):
+		object["data"] = secretDataPlaceholder
+	case (object["kind"] == "MutatingWebhookConfiguration" || object["kind"] == "ValidatingWebhookConfiguration") &&
+		isWebhookObject(object):
+		webhooks, _ := object["webhooks"].([]interface{})
+		for _, webhook := range webhooks {
+			webhook, _ := webhook.(map[string]interface{})
+			// NOTE: The caBundle is injected by cert-manager in mode cert-manager.
+			clientConfig, _ := webhook["clientConfig"].(map[string]interface{})
+			if _, exists := clientConfig["caBundle"]; exists {
+				clientConfig["caBundle"] = caBundlePlaceholder
+			}
+		}
+	}
+}
+
+func isWebhookObject(object map[string]interface{}) bool {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	switch object["kind"] {
+	case "Secret":
+		return metadata["name"] == installbase.OperatorSecretName
+	case "MutatingWebhookConfiguration":
+		return metadata["name"] == installbase.OperatorMutatingWebhookName
+	case "ValidatingWebhookConfiguration":
+		return metadata["name"] == installbase.OperatorValidatingWebhookName
+	}
+	return false
+}
+
+func substituteWebhookCert(content string) string {
+	return strings.NewReplacer(
+		"data: "+secretDataPlaceholder, "data:\n  {{- toYaml $webhookData | nindent 2 }}",
+		caBundlePlaceholder, "{{ $caBundle }}",
+	).Replace(content)
+}
+
+func fileName(object map[string]interface{}) string {
+	kind, _ := object["kind"].(string)
+	name := ""
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		name, _ = metadata["name"].(string)
+	}
+	return strings.ToLower(fmt.Sprintf("%s-%s.yaml", kind, name))
+}
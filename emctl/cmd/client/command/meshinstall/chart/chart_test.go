@@ -0,0 +1,239 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/controlpanel"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/crd"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/installation"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/operator"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/shadowservice"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// helmFuncs are stubs of the functions provided by Helm, for parsing the templates.
+var helmFuncs = template.FuncMap{}
+
+func init() {
+	for _, name := range []string{"include", "toYaml", "nindent", "genCA", "genSignedCert", "genSelfSignedCert",
+		"b64enc", "list", "until", "int", "append", "join", "printf", "lookup", "dict", "default"} {
+		helmFuncs[name] = func(...interface{}) interface{} { return nil }
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	p := newPlaceholders()
+	port := p.number("controlPlane.peerPort", 2380)
+	image := p.str("controlPlane.image", "megaease/easegress:easemesh")
+
+	manifest := strings.Join([]string{
+		"image: " + image,
+		"url: http://a:" + strconv.Itoa(port) + ":" + strconv.Itoa(port) + "/" + strconv.Itoa(port+10),
+		"spec:",
+		"  nodeSelector:",
+		"    " + nodeSelectorPlaceholder + ": " + nodeSelectorPlaceholder,
+	}, "\n")
+
+	expected := strings.Join([]string{
+		"image: {{ .Values.controlPlane.image }}",
+		"url: http://a:{{ .Values.controlPlane.peerPort }}:{{ .Values.controlPlane.peerPort }}/" + strconv.Itoa(port+10),
+		"spec:",
+		"  {{- with .Values.controlPlane.nodeSelector }}",
+		"  nodeSelector:",
+		"    {{- toYaml . | nindent 4 }}",
+		"  {{- end }}",
+	}, "\n")
+
+	got := p.substitute(manifest)
+	if got != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	p := newPlaceholders()
+	p.setValue("controlPlane.image", "easegress")
+	p.setValue("operator.replicas", 1)
+	p.setValue("controlPlane.replicas", 3)
+	p.setValue("controlPlane.image", "easegress:latest")
+
+	buff, err := yaml.Marshal(p.values)
+	if err != nil {
+		t.Fatalf("marshal values failed: %v", err)
+	}
+
+	expected := "controlPlane:\n  image: easegress:latest\n  replicas: 3\noperator:\n  replicas: 1\n"
+	if string(buff) != expected {
+		t.Fatalf("expect:\n%s\nbut got:\n%s", expected, buff)
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emctl-chart")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := &cobra.Command{}
+	exportFlags := &flags.ExportChart{}
+	exportFlags.AttachCmd(cmd)
+	cmd.Flags().Parse([]string{
+		"-o", filepath.Join(dir, "easemesh"),
+		"--easemesh-control-plane-replicas", "5",
+		"--registry-type", "consul",
+		"--add-ons", "shadowservice",
+	})
+
+	stages := []installation.InstallStage{
+		installation.Wrap("crd", crd.PreCheck, crd.Deploy, crd.Clear, crd.DescribePhase),
+		installation.Wrap("control-plane", controlpanel.PreCheck, controlpanel.Deploy, controlpanel.Clear, controlpanel.DescribePhase),
+		installation.Wrap("operator", operator.PreCheck, operator.Deploy, operator.Clear, operator.DescribePhase),
+	}
+	addOns := []*AddOn{{
+		Name:  "shadowservice",
+		Stage: installation.Wrap("shadowservice", shadowservice.PreCheck, shadowservice.Deploy, shadowservice.Clear, shadowservice.DescribePhase),
+		Notes: "notes of shadowservice",
+	}}
+
+	err = Export(cmd, exportFlags, stages, addOns)
+	if err != nil {
+		t.Fatalf("export chart failed: %v", err)
+	}
+
+	chartDir := filepath.Join(dir, "easemesh")
+	values := map[string]interface{}{}
+	buff, err := ioutil.ReadFile(filepath.Join(chartDir, "values.yaml"))
+	if err != nil {
+		t.Fatalf("read values failed: %v", err)
+	}
+	err = yaml.Unmarshal(buff, &values)
+	if err != nil {
+		t.Fatalf("unmarshal values failed: %v", err)
+	}
+	controlPlane, _ := values["controlPlane"].(map[interface{}]interface{})
+	meshController, _ := values["meshController"].(map[interface{}]interface{})
+	if controlPlane["replicas"] != 5 || meshController["registryType"] != "consul" {
+		t.Fatalf("unexpected values: %+v", values)
+	}
+
+	templates, err := filepath.Glob(filepath.Join(chartDir, templatesDir, "*"))
+	if err != nil || len(templates) == 0 {
+		t.Fatalf("expect templates, but got %v, %v", templates, err)
+	}
+	for _, name := range templates {
+		buff, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s failed: %v", name, err)
+		}
+		if strings.Contains(string(buff), "__EMCTL_CHART") {
+			t.Fatalf("placeholder left in %s:\n%s", name, buff)
+		}
+		_, err = template.New(name).Funcs(helmFuncs).Parse(string(buff))
+		if err != nil {
+			t.Fatalf("parse %s failed: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{
+		filepath.Join(crdsDir, "customresourcedefinition-meshdeployments.mesh.megaease.com.yaml"),
+		filepath.Join(templatesDir, webhookTemplateName),
+		filepath.Join(templatesDir, "statefulset-easemesh-control-plane.yaml"),
+	} {
+		_, err := os.Stat(filepath.Join(chartDir, name))
+		if err != nil {
+			t.Fatalf("expect file %s: %v", name, err)
+		}
+	}
+
+	buff, err = ioutil.ReadFile(filepath.Join(chartDir, templatesDir, "statefulset-easemesh-control-plane.yaml"))
+	if err != nil {
+		t.Fatalf("read statefulset failed: %v", err)
+	}
+	for _, expected := range []string{
+		"replicas: {{ .Values.controlPlane.replicas }}",
+		`{{ include "easemesh.initialCluster" . }}`,
+		"storage: {{ .Values.controlPlane.persistentVolumeCapacity }}",
+	} {
+		if !strings.Contains(string(buff), expected) {
+			t.Fatalf("expect %s in statefulset, but got:\n%s", expected, buff)
+		}
+	}
+}
+
+func TestExportWebhookCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emctl-chart")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, c := range []struct {
+		certMode   string
+		expected   []string
+		unexpected []string
+	}{
+		{flags.WebhookCertModeCA, []string{"lookup", "genCA", "caBundle: {{ $caBundle }}", "toYaml $webhookData"}, []string{"genSelfSignedCert"}},
+		{flags.WebhookCertModeCSR, []string{"lookup", "genSelfSignedCert", "caBundle: {{ $caBundle }}"}, []string{"genCA"}},
+		{flags.WebhookCertModeCertManager, []string{"cert-manager.io/inject-ca-from"}, []string{"lookup", "genCA", "genSelfSignedCert", "caBundle"}},
+	} {
+		cmd := &cobra.Command{}
+		exportFlags := &flags.ExportChart{}
+		exportFlags.AttachCmd(cmd)
+		cmd.Flags().Parse([]string{"-o", filepath.Join(dir, c.certMode), "--webhook-cert-mode", c.certMode})
+
+		stages := []installation.InstallStage{
+			installation.Wrap("operator", operator.PreCheck, operator.Deploy, operator.Clear, operator.DescribePhase),
+		}
+		err = Export(cmd, exportFlags, stages, nil)
+		if err != nil {
+			t.Fatalf("export chart in mode %s failed: %v", c.certMode, err)
+		}
+
+		name := filepath.Join(dir, c.certMode, templatesDir, webhookTemplateName)
+		buff, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s failed: %v", name, err)
+		}
+		_, err = template.New(name).Funcs(helmFuncs).Parse(string(buff))
+		if err != nil {
+			t.Fatalf("parse %s failed: %v", name, err)
+		}
+		for _, expected := range c.expected {
+			if !strings.Contains(string(buff), expected) {
+				t.Fatalf("expect %s in mode %s, but got:\n%s", expected, c.certMode, buff)
+			}
+		}
+		for _, unexpected := range c.unexpected {
+			if strings.Contains(string(buff), unexpected) {
+				t.Fatalf("unexpected %s in mode %s:\n%s", unexpected, c.certMode, buff)
+			}
+		}
+	}
+}
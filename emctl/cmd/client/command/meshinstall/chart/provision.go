@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chart
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	meshControllerConfigMapName = "easemesh-mesh-controller-config"
+	meshControllerConfigKey     = "mesh-controller.yaml"
+	meshControllerJobName       = "easemesh-mesh-controller-provision"
+	meshControllerConfigPath    = "/etc/easemesh"
+)
+
+// provisionScript posts the mesh controller to the control plane until it's accepted,
+// 409 means the mesh controller has been provisioned.
+const provisionScript = `until code=$(curl -s -o /dev/null -w '%%{http_code}' -X POST --data-binary @%s/%s %s) &&
+  { [ "$code" -lt 400 ] || [ "$code" = 409 ]; }; do
+  echo "provision mesh controller failed: $code, retry in 5 seconds"
+  sleep 5
+done`
+
+// meshControllerManifests returns the objects provisioning the mesh controller, which is done by
+// emctl after the control plane is running in the installation, and by a post-install hook in the chart.
+func meshControllerManifests(installFlags *flags.Install, provisionerImage string) []map[string]interface{} {
+	config := installbase.MeshControllerConfig{
+		Name:              installbase.MeshControllerName,
+		Kind:              flags.MeshControllerKind,
+		RegistryType:      installFlags.EaseMeshRegistryType,
+		HeartbeatInterval: strconv.Itoa(installFlags.HeartbeatInterval) + "s",
+		IngressPort:       installFlags.MeshIngressServicePort,
+		APIPort:           installbase.MeshControllerAPIPort,
+	}
+	// NOTE: Marshaling the struct of basic fields never fails.
+	configBody, _ := yaml.Marshal(config)
+
+	url := fmt.Sprintf("http://%s.%s:%d%s", installbase.ControlPlanePlubicServiceName,
+		installFlags.MeshNamespace, installFlags.EgAdminPort, installbase.ObjectsURL)
+	configMap := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      meshControllerConfigMapName,
+			"namespace": installFlags.MeshNamespace,
+		},
+		"data": map[string]interface{}{
+			meshControllerConfigKey: string(configBody),
+		},
+	}
+
+	job := map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":      meshControllerJobName,
+			"namespace": installFlags.MeshNamespace,
+			"annotations": map[string]interface{}{
+				"helm.sh/hook":               "post-install,post-upgrade",
+				"helm.sh/hook-delete-policy": "before-hook-creation,hook-succeeded",
+			},
+		},
+		"spec": map[string]interface{}{
+			"backoffLimit":          3,
+			"activeDeadlineSeconds": 600,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"restartPolicy": "OnFailure",
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":    "provisioner",
+							"image":   provisionerImage,
+							"command": []interface{}{"sh", "-c", fmt.Sprintf(provisionScript, meshControllerConfigPath, meshControllerConfigKey, url)},
+							"volumeMounts": []interface{}{
+								map[string]interface{}{
+									"name":      "config",
+									"mountPath": meshControllerConfigPath,
+								},
+							},
+						},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{
+							"name": "config",
+							"configMap": map[string]interface{}{
+								"name": meshControllerConfigMapName,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return []map[string]interface{}{configMap, job}
+}
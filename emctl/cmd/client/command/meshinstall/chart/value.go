@@ -0,0 +1,254 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chart
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// numberPlaceholderBase is the start of the placeholders of integer values,
+	// they are large enough not to be confused with numbers in the specs.
+	numberPlaceholderBase = 64300
+
+	nodeSelectorPlaceholder = "__EMCTL_CHART_NODE_SELECTOR__"
+	nodeSelectorPath        = "controlPlane.nodeSelector"
+
+	controlPlaneReplicasPlaceholder = "__EMCTL_CHART_CONTROL_PLANE_REPLICAS__"
+	controlPlaneReplicasPath        = "controlPlane.replicas"
+
+	namespaceTemplate = "{{ .Release.Namespace }}"
+)
+
+type (
+	// substitution replaces a placeholder in the rendered manifests with a template expression.
+	substitution struct {
+		placeholder string
+		number      bool
+		expression  string
+	}
+
+	// placeholders renders the install flags to placeholders, and records
+	// the substitutions and default values of the chart.
+	placeholders struct {
+		substitutions []*substitution
+		values        yaml.MapSlice
+		strings       int
+		numbers       int
+	}
+)
+
+func newPlaceholders() *placeholders {
+	return &placeholders{}
+}
+
+// valueExpression returns the template expression referring to the value path.
+func valueExpression(path string) string {
+	return fmt.Sprintf("{{ .Values.%s }}", path)
+}
+
+// str returns the placeholder of a string value and records the default value.
+func (p *placeholders) str(path string, value string) string {
+	p.strings++
+	placeholder := fmt.Sprintf("__EMCTL_CHART_VALUE_%d__", p.strings)
+	p.substitutions = append(p.substitutions, &substitution{
+		placeholder: placeholder,
+		expression:  valueExpression(path),
+	})
+	p.setValue(path, value)
+	return placeholder
+}
+
+// number returns the placeholder of an integer value and records the default value.
+func (p *placeholders) number(path string, value int) int {
+	p.numbers++
+	placeholder := numberPlaceholderBase + p.numbers
+	p.substitutions = append(p.substitutions, &substitution{
+		placeholder: fmt.Sprintf("%d", placeholder),
+		number:      true,
+		expression:  valueExpression(path),
+	})
+	p.setValue(path, value)
+	return placeholder
+}
+
+// literal replaces the text with the expression, it's used for the placeholders
+// composed by the specs, so it must be added before the placeholders inside it.
+func (p *placeholders) literal(text, expression string) {
+	p.substitutions = append([]*substitution{{placeholder: text, expression: expression}}, p.substitutions...)
+}
+
+// quantity returns the placeholder of a resource quantity value and records the default value.
+func (p *placeholders) quantity(path string, value string) string {
+	p.numbers++
+	placeholder := fmt.Sprintf("%dMi", numberPlaceholderBase+p.numbers)
+	p.substitutions = append(p.substitutions, &substitution{
+		placeholder: placeholder,
+		expression:  valueExpression(path),
+	})
+	p.setValue(path, value)
+	return placeholder
+}
+
+// setValue sets the default value at the dot-separated path, in the order of setting.
+func (p *placeholders) setValue(path string, value interface{}) {
+	p.values = setValue(p.values, strings.Split(path, "."), value)
+}
+
+func setValue(values yaml.MapSlice, keys []string, value interface{}) yaml.MapSlice {
+	for i, item := range values {
+		if item.Key != keys[0] {
+			continue
+		}
+		if len(keys) == 1 {
+			values[i].Value = value
+		} else {
+			child, _ := item.Value.(yaml.MapSlice)
+			values[i].Value = setValue(child, keys[1:], value)
+		}
+		return values
+	}
+
+	if len(keys) == 1 {
+		return append(values, yaml.MapItem{Key: keys[0], Value: value})
+	}
+	return append(values, yaml.MapItem{Key: keys[0], Value: setValue(nil, keys[1:], value)})
+}
+
+// install returns a copy of the install flags whose chart values are replaced with placeholders.
+func (p *placeholders) install(original *flags.Install) *flags.Install {
+	install := *original
+	operationGlobal := *original.OperationGlobal
+	install.OperationGlobal = &operationGlobal
+
+	// NOTE: The namespace is decided by the release.
+	install.MeshNamespace = "__EMCTL_CHART_NAMESPACE__"
+	p.substitutions = append(p.substitutions, &substitution{
+		placeholder: install.MeshNamespace,
+		expression:  namespaceTemplate,
+	})
+
+	install.ImageRegistryURL = p.str("image.registry", original.ImageRegistryURL)
+	install.ImagePullPolicy = p.str("image.pullPolicy", original.ImagePullPolicy)
+
+	install.EasegressImage = p.str("controlPlane.image", original.EasegressImage)
+	// NOTE: The initial cluster of control plane is generated by the replicas, so a single
+	// replica is rendered, and the replicas are substituted in the manifests.
+	p.setValue(controlPlaneReplicasPath, original.EasegressControlPlaneReplicas)
+	p.substitutions = append(p.substitutions, &substitution{
+		placeholder: controlPlaneReplicasPlaceholder,
+		expression:  valueExpression(controlPlaneReplicasPath),
+	})
+	install.EasegressControlPlaneReplicas = 1
+	install.EgClientPort = p.number("controlPlane.clientPort", original.EgClientPort)
+	install.EgPeerPort = p.number("controlPlane.peerPort", original.EgPeerPort)
+	install.EgAdminPort = p.number("controlPlane.adminPort", original.EgAdminPort)
+	install.MeshControlPlaneStorageClassName = p.str("controlPlane.storageClassName", original.MeshControlPlaneStorageClassName)
+	install.MeshControlPlanePersistVolumeCapacity = p.quantity("controlPlane.persistentVolumeCapacity",
+		original.MeshControlPlanePersistVolumeCapacity)
+	p.setValue(nodeSelectorPath, nodeSelectorValue(original.MeshControlPlaneNodeSelectors))
+	install.MeshControlPlaneNodeSelectors = map[string]string{nodeSelectorPlaceholder: nodeSelectorPlaceholder}
+
+	install.EaseMeshOperatorImage = p.str("operator.image", original.EaseMeshOperatorImage)
+	install.EaseMeshOperatorReplicas = p.number("operator.replicas", original.EaseMeshOperatorReplicas)
+	install.RBACProxyImage = p.str("operator.rbacProxyImage", original.RBACProxyImage)
+	install.SidecarImage = p.str("operator.sidecarImage", original.SidecarImage)
+	install.AgentInitializerImage = p.str("operator.agentInitializerImage", original.AgentInitializerImage)
+
+	install.MeshIngressReplicas = p.number("ingressController.replicas", original.MeshIngressReplicas)
+	install.MeshIngressServicePort = int32(p.number("ingressController.servicePort", int(original.MeshIngressServicePort)))
+
+	install.EaseMeshRegistryType = p.str("meshController.registryType", original.EaseMeshRegistryType)
+	install.HeartbeatInterval = p.number("meshController.heartbeatInterval", original.HeartbeatInterval)
+
+	install.ShadowServiceControllerImage = p.str("addOns.shadowservice.image", original.ShadowServiceControllerImage)
+
+	return &install
+}
+
+// nodeSelectorValue returns the node selectors in a stable order.
+func nodeSelectorValue(nodeSelectors map[string]string) yaml.MapSlice {
+	keys := make([]string, 0, len(nodeSelectors))
+	for key := range nodeSelectors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	value := yaml.MapSlice{}
+	for _, key := range keys {
+		value = append(value, yaml.MapItem{Key: key, Value: nodeSelectors[key]})
+	}
+	return value
+}
+
+// substitute replaces the placeholders in the rendered manifest with template expressions.
+func (p *placeholders) substitute(manifest string) string {
+	for _, s := range p.substitutions {
+		if !s.number {
+			manifest = strings.ReplaceAll(manifest, s.placeholder, s.expression)
+		}
+	}
+
+	// NOTE: The numbers are matched in whole, and replaced until nothing changes,
+	// because adjacent matches share the separator between them.
+	for _, s := range p.substitutions {
+		if !s.number {
+			continue
+		}
+		re := regexp.MustCompile(`(^|[^0-9])` + s.placeholder + `([^0-9]|$)`)
+		for {
+			replaced := re.ReplaceAllString(manifest, "${1}"+s.expression+"${2}")
+			if replaced == manifest {
+				break
+			}
+			manifest = replaced
+		}
+	}
+
+	return substituteNodeSelector(manifest)
+}
+
+// substituteNodeSelector replaces the placeholder of node selectors with the block of the value.
+func substituteNodeSelector(manifest string) string {
+	lines := strings.Split(manifest, "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.TrimSpace(line) != nodeSelectorPlaceholder+": "+nodeSelectorPlaceholder ||
+			len(result) == 0 || strings.TrimSpace(result[len(result)-1]) != "nodeSelector:" {
+			result = append(result, line)
+			continue
+		}
+
+		key := result[len(result)-1]
+		indent := key[:len(key)-len(strings.TrimLeft(key, " "))]
+		result = append(result[:len(result)-1],
+			fmt.Sprintf("%s{{- with .Values.%s }}", indent, nodeSelectorPath),
+			key,
+			fmt.Sprintf("%s  {{- toYaml . | nindent %d }}", indent, len(indent)+2),
+			fmt.Sprintf("%s{{- end }}", indent),
+		)
+	}
+	return strings.Join(result, "\n")
+}
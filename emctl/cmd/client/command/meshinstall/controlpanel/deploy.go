@@ -22,7 +22,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/megaease/easemeshctl/cmd/client/command/flags"
 	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
 	"github.com/megaease/easemeshctl/cmd/common"
 	"github.com/megaease/easemeshctl/cmd/common/client"
@@ -30,16 +29,17 @@ import (
 
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Deploy will deploy resource of control panel
 func Deploy(ctx *installbase.StageContext) error {
 	installFuncs := []installbase.InstallFunc{
 		namespaceSpec(ctx),
+		localPVSpec(ctx),
 		configMapSpec(ctx),
 		serviceSpec(ctx),
 		statefulsetSpec(ctx),
+		podDisruptionBudgetSpec(ctx),
 	}
 
 	err := installbase.BatchDeployResources(ctx, installFuncs)
@@ -47,6 +47,10 @@ func Deploy(ctx *installbase.StageContext) error {
 		return errors.Wrap(err, "deploy mesh control panel resource")
 	}
 
+	if ctx.Rendering() {
+		return nil
+	}
+
 	err = checkEasegressControlPlaneStatus(ctx)
 	if err != nil {
 		return errors.Wrap(err, "check mesh control panel status")
@@ -61,45 +65,8 @@ func Deploy(ctx *installbase.StageContext) error {
 
 // PreCheck will check prerequisite for installing control plane
 func PreCheck(context *installbase.StageContext) error {
-	var err error
-
-	// 1. check available PersistentVolume
-	pvList, err := installbase.ListPersistentVolume(context.Client)
-	if err != nil {
-		return err
-	}
-
-	availablePVCount := 0
-	quantity := resource.MustParse(context.Flags.MeshControlPlanePersistVolumeCapacity)
-	boundedPVCSuffixes := []string{}
-	for i := 0; i < context.Flags.EasegressControlPlaneReplicas; i++ {
-		boundedPVCSuffixes = append(boundedPVCSuffixes, fmt.Sprintf("%s-%d", installbase.ControlPlaneStatefulSetName, i))
-	}
-	for _, pv := range pvList.Items {
-		if pv.Status.Phase == v1.VolumeAvailable &&
-			pv.Spec.StorageClassName == context.Flags.MeshControlPlaneStorageClassName &&
-			pv.Spec.Capacity.Storage().Cmp(quantity) >= 0 &&
-			checkPVAccessModes(v1.ReadWriteOnce, &pv) {
-			availablePVCount++
-		} else if pv.Status.Phase == v1.VolumeBound {
-			// If PV already bound to PVC of EaseMesh controlpanel
-			// we regarded it as availablePVCount
-			for _, pvNameSuffix := range boundedPVCSuffixes {
-				if pv.Spec.ClaimRef.Kind == "PersistentVolumeClaim" &&
-					pv.Spec.ClaimRef.Namespace == context.Flags.MeshNamespace &&
-					strings.HasSuffix(pv.Spec.ClaimRef.Name, pvNameSuffix) {
-					availablePVCount++
-					break
-				}
-			}
-		}
-	}
-
-	if availablePVCount < context.Flags.EasegressControlPlaneReplicas {
-		return errors.Errorf(flags.MeshControlPlanePVNotExistedHelpStr)
-	}
-
-	return nil
+	// 1. check the storage of the control plane
+	return checkStorage(context)
 }
 
 // Clear will clear all installed resource about control panel
@@ -114,10 +81,16 @@ func Clear(context *installbase.StageContext) error {
 		{"configmaps", installbase.ControlPlaneConfigMapName},
 	}
 
+	policyV1Resources := [][]string{
+		{"poddisruptionbudgets", installbase.ControlPlaneStatefulSetName},
+	}
+
 	clearEaseMeshControlPlaneProvision(context.Cmd, context.Client, context.Flags)
 
+	installbase.DeleteResources(context.Client, policyV1Resources, context.Flags.MeshNamespace, installbase.DeletePolicyV1Resources)
 	installbase.DeleteResources(context.Client, statefulsetResource, context.Flags.MeshNamespace, installbase.DeleteStatefulsetResource)
 	installbase.DeleteResources(context.Client, coreV1Resources, context.Flags.MeshNamespace, installbase.DeleteCoreV1Resource)
+	clearLocalPV(context)
 
 	return nil
 }
@@ -156,7 +129,7 @@ func checkEasegressControlPlaneStatus(ctx *installbase.StageContext) error {
 		return errors.Wrap(err, "get mesh control plane entrypoint failed")
 	}
 
-	fmt.Printf("control plane endpoints: %+v\n", entrypoints)
+	fmt.Fprintf(ctx.Out(), "control plane endpoints: %+v\n", entrypoints)
 
 	timeOutPerTry := ctx.Flags.MeshControlPlaneCheckHealthzMaxTime / len(entrypoints)
 
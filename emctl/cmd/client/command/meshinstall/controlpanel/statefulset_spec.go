@@ -31,13 +31,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const controlPlaneContainerName = "easegress"
+
 type statefulsetSpecFunc func(ctx *installbase.StageContext) *appsV1.StatefulSet
 
 func statefulsetSpec(ctx *installbase.StageContext) installbase.InstallFunc {
-	statefulSet := statefulsetPVCSpec(
-		statefulsetContainerSpec(
-			baseStatefulSetSpec(
-				initialStatefulSetSpec(nil))))(ctx)
+	statefulSet := statefulsetComponentSpec(
+		statefulsetPVCSpec(
+			statefulsetContainerSpec(
+				baseStatefulSetSpec(
+					initialStatefulSetSpec(nil)))))(ctx)
 
 	return func(ctx *installbase.StageContext) error {
 		err := installbase.DeployStatefulset(statefulSet, ctx.Client, ctx.Flags.MeshNamespace)
@@ -91,7 +94,10 @@ func statefulsetPVCSpec(fn statefulsetSpecFunc) statefulsetSpecFunc {
 		pvc := v1.PersistentVolumeClaim{}
 		pvc.Name = installbase.ControlPlanePVCName
 		pvc.Spec.AccessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}
-		pvc.Spec.StorageClassName = &ctx.Flags.MeshControlPlaneStorageClassName
+		// NOTE: The claim without the storage class name takes the default storage class of the cluster.
+		if storageClassName := ctx.Flags.StorageClassName(); storageClassName != "" {
+			pvc.Spec.StorageClassName = &storageClassName
+		}
 
 		pvc.Spec.Resources.Requests = v1.ResourceList{
 			v1.ResourceStorage: resource.MustParse(ctx.Flags.MeshControlPlanePersistVolumeCapacity),
@@ -104,7 +110,7 @@ func statefulsetPVCSpec(fn statefulsetSpecFunc) statefulsetSpecFunc {
 func statefulsetContainerSpec(fn statefulsetSpecFunc) statefulsetSpecFunc {
 	return func(ctx *installbase.StageContext) *appsV1.StatefulSet {
 		spec := fn(ctx)
-		container, err := installbase.AcceptContainerVisitor("easegress",
+		container, err := installbase.AcceptContainerVisitor(controlPlaneContainerName,
 			ctx.Flags.ImageRegistryURL+"/"+ctx.Flags.EasegressImage,
 			v1.PullPolicy(ctx.Flags.ImagePullPolicy),
 			newContainerVisistor(ctx))
@@ -118,6 +124,19 @@ func statefulsetContainerSpec(fn statefulsetSpecFunc) statefulsetSpecFunc {
 	}
 }
 
+func statefulsetComponentSpec(fn statefulsetSpecFunc) statefulsetSpecFunc {
+	return func(ctx *installbase.StageContext) *appsV1.StatefulSet {
+		spec := fn(ctx)
+		installbase.ApplyComponent(&spec.Spec.Template, controlPlaneContainerName, ctx.Flags.Components.ControlPlane)
+		return spec
+	}
+}
+
+func podDisruptionBudgetSpec(ctx *installbase.StageContext) installbase.InstallFunc {
+	return installbase.PodDisruptionBudgetInstallFunc(installbase.ControlPlaneStatefulSetName,
+		ctx.Flags.MeshNamespace, meshControlPlaneLabel(), ctx.Flags.Components.ControlPlane)
+}
+
 type containerVisitor struct {
 	ctx *installbase.StageContext
 }
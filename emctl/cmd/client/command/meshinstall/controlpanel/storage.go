@@ -0,0 +1,328 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controlpanel
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// noProvisioner is the provisioner of the storage classes without dynamic provisioning.
+	noProvisioner = "kubernetes.io/no-provisioner"
+	// defaultStorageClassAnnotation marks the default storage class of the cluster.
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	// hostnameLabelKey is the well-known label of the node name.
+	hostnameLabelKey = "kubernetes.io/hostname"
+)
+
+func localPVLabels() map[string]string {
+	return map[string]string{installbase.ControlPlaneLocalPVLabelKey: "true"}
+}
+
+// checkStorage checks whether the volumes of the control plane can be provisioned.
+func checkStorage(ctx *installbase.StageContext) error {
+	if ctx.Flags.ProvisionLocalPV {
+		_, err := localPVNodes(ctx)
+		return err
+	}
+
+	dynamic, err := dynamicProvisioning(ctx)
+	if err != nil {
+		return err
+	}
+	if dynamic {
+		return nil
+	}
+
+	return checkAvailablePV(ctx)
+}
+
+// dynamicProvisioning returns whether the storage class provisions volumes dynamically,
+// the empty name stands for the default storage class of the cluster.
+func dynamicProvisioning(ctx *installbase.StageContext) (bool, error) {
+	name := ctx.Flags.StorageClassName()
+	if name == "" {
+		storageClasses, err := ctx.Client.StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return false, errors.Wrap(err, "list storage classes")
+		}
+		for _, sc := range storageClasses.Items {
+			if sc.Annotations[defaultStorageClassAnnotation] == "true" {
+				return sc.Provisioner != noProvisioner, nil
+			}
+		}
+		return false, errors.Errorf("no default StorageClass in the cluster, specify one by --mesh-storage-class-name\n%s",
+			flags.MeshControlPlanePVNotExistedHelpStr)
+	}
+
+	// NOTE: The PersistentVolumes created in advance may refer to a storage class which doesn't exist.
+	sc, err := ctx.Client.StorageV1().StorageClasses().Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "get storage class %s", name)
+	}
+	return sc.Provisioner != noProvisioner, nil
+}
+
+func checkAvailablePV(ctx *installbase.StageContext) error {
+	pvList, err := installbase.ListPersistentVolume(ctx.Client)
+	if err != nil {
+		return err
+	}
+
+	availablePVCount := 0
+	quantity := resource.MustParse(ctx.Flags.MeshControlPlanePersistVolumeCapacity)
+	boundedPVCSuffixes := []string{}
+	for i := 0; i < ctx.Flags.EasegressControlPlaneReplicas; i++ {
+		boundedPVCSuffixes = append(boundedPVCSuffixes, fmt.Sprintf("%s-%d", installbase.ControlPlaneStatefulSetName, i))
+	}
+	for _, pv := range pvList.Items {
+		if pv.Status.Phase == v1.VolumeAvailable &&
+			pv.Spec.StorageClassName == ctx.Flags.StorageClassName() &&
+			pv.Spec.Capacity.Storage().Cmp(quantity) >= 0 &&
+			checkPVAccessModes(v1.ReadWriteOnce, &pv) {
+			availablePVCount++
+		} else if pv.Status.Phase == v1.VolumeBound {
+			// If PV already bound to PVC of EaseMesh controlpanel
+			// we regarded it as availablePVCount
+			for _, pvNameSuffix := range boundedPVCSuffixes {
+				if pv.Spec.ClaimRef.Kind == "PersistentVolumeClaim" &&
+					pv.Spec.ClaimRef.Namespace == ctx.Flags.MeshNamespace &&
+					strings.HasSuffix(pv.Spec.ClaimRef.Name, pvNameSuffix) {
+					availablePVCount++
+					break
+				}
+			}
+		}
+	}
+
+	if availablePVCount < ctx.Flags.EasegressControlPlaneReplicas {
+		return errors.Errorf(flags.MeshControlPlanePVNotExistedHelpStr)
+	}
+
+	return nil
+}
+
+// localPVNodes returns the nodes which the control plane can be scheduled to, sorted by their names.
+func localPVNodes(ctx *installbase.StageContext) ([]v1.Node, error) {
+	nodes, err := ctx.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list nodes")
+	}
+
+	var tolerations []v1.Toleration
+	if ctx.Flags.Components.ControlPlane != nil {
+		tolerations = ctx.Flags.Components.ControlPlane.Tolerations
+	}
+
+	var schedulable []v1.Node
+	for _, node := range nodes.Items {
+		if nodeSchedulable(&node, ctx.Flags.MeshControlPlaneNodeSelectors, tolerations) {
+			schedulable = append(schedulable, node)
+		}
+	}
+	if len(schedulable) == 0 {
+		return nil, errors.Errorf("no ready and schedulable node matches the node selectors %v with taints tolerated by the control plane for the local PersistentVolumes",
+			ctx.Flags.MeshControlPlaneNodeSelectors)
+	}
+
+	sort.Slice(schedulable, func(i, j int) bool { return schedulable[i].Name < schedulable[j].Name })
+	return schedulable, nil
+}
+
+// nodeSchedulable returns if the control plane with the tolerations can be scheduled to the node.
+func nodeSchedulable(node *v1.Node, selectors map[string]string, tolerations []v1.Toleration) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for k, v := range selectors {
+		if node.Labels[k] != v {
+			return false
+		}
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != v1.TaintEffectNoSchedule && taint.Effect != v1.TaintEffectNoExecute {
+			continue
+		}
+		if !taintTolerated(taint, tolerations) {
+			return false
+		}
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func taintTolerated(taint *v1.Taint, tolerations []v1.Toleration) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// localPVSpec creates the storage class and a PersistentVolume with the host path per replica of
+// the control plane, the volumes spread over the schedulable nodes.
+func localPVSpec(ctx *installbase.StageContext) installbase.InstallFunc {
+	return func(ctx *installbase.StageContext) error {
+		if !ctx.Flags.ProvisionLocalPV {
+			return nil
+		}
+		if ctx.Rendering() {
+			return errors.New("--provision-local-pv needs the nodes of the cluster, it can't be rendered")
+		}
+
+		nodes, err := localPVNodes(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = createIfNotExists(ctx.Client.StorageV1().StorageClasses().Create(context.TODO(),
+			localStorageClass(ctx), metav1.CreateOptions{}))
+		if err != nil {
+			return errors.Wrapf(err, "create storage class %s", ctx.Flags.StorageClassName())
+		}
+
+		for i := 0; i < ctx.Flags.EasegressControlPlaneReplicas; i++ {
+			pv := localPV(ctx, i, &nodes[i%len(nodes)])
+			err = createIfNotExists(ctx.Client.CoreV1().PersistentVolumes().Create(context.TODO(), pv, metav1.CreateOptions{}))
+			if err != nil {
+				return errors.Wrapf(err, "create persistent volume %s", pv.Name)
+			}
+		}
+
+		return nil
+	}
+}
+
+func createIfNotExists(_ interface{}, err error) error {
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func localStorageClass(ctx *installbase.StageContext) *storagev1.StorageClass {
+	reclaimPolicy := v1.PersistentVolumeReclaimRetain
+	// NOTE: Binding waits for the scheduling of the pod, so the pod goes to the node of its volume.
+	bindingMode := storagev1.VolumeBindingWaitForFirstConsumer
+	return &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   ctx.Flags.StorageClassName(),
+			Labels: localPVLabels(),
+		},
+		Provisioner:       noProvisioner,
+		ReclaimPolicy:     &reclaimPolicy,
+		VolumeBindingMode: &bindingMode,
+	}
+}
+
+func localPV(ctx *installbase.StageContext, index int, node *v1.Node) *v1.PersistentVolume {
+	name := fmt.Sprintf("%s-%d", ctx.Flags.MeshControlPlanePersistVolumeName, index)
+	hostname := node.Labels[hostnameLabelKey]
+	if hostname == "" {
+		hostname = node.Name
+	}
+	hostPathType := v1.HostPathDirectoryOrCreate
+
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: localPVLabels(),
+		},
+		Spec: v1.PersistentVolumeSpec{
+			StorageClassName:              ctx.Flags.StorageClassName(),
+			AccessModes:                   []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimRetain,
+			Capacity: v1.ResourceList{
+				v1.ResourceStorage: resource.MustParse(ctx.Flags.MeshControlPlanePersistVolumeCapacity),
+			},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				HostPath: &v1.HostPathVolumeSource{
+					Path: path.Join(ctx.Flags.MeshControlPlanePersistVolumeHostPath, name),
+					Type: &hostPathType,
+				},
+			},
+			NodeAffinity: &v1.VolumeNodeAffinity{
+				Required: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{{
+						MatchExpressions: []v1.NodeSelectorRequirement{{
+							Key:      hostnameLabelKey,
+							Operator: v1.NodeSelectorOpIn,
+							Values:   []string{hostname},
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// clearLocalPV deletes the PersistentVolumes provisioned by emctl with their claims, and the
+// storage class. The data in the host paths is kept, since the nodes are out of reach of emctl.
+func clearLocalPV(ctx *installbase.StageContext) {
+	selector := metav1.ListOptions{LabelSelector: installbase.ControlPlaneLocalPVLabelKey + "=true"}
+
+	pvList, err := ctx.Client.CoreV1().PersistentVolumes().List(context.TODO(), selector)
+	if err != nil {
+		common.OutputErrorf("clear: list local persistent volumes failed: %s", err)
+		return
+	}
+
+	for _, pv := range pvList.Items {
+		if claim := pv.Spec.ClaimRef; claim != nil && claim.Namespace == ctx.Flags.MeshNamespace {
+			err = ctx.Client.CoreV1().PersistentVolumeClaims(claim.Namespace).Delete(context.TODO(),
+				claim.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				common.OutputErrorf("clear: delete persistent volume claim %s failed: %s", claim.Name, err)
+			}
+		}
+
+		err = ctx.Client.CoreV1().PersistentVolumes().Delete(context.TODO(), pv.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			common.OutputErrorf("clear: delete persistent volume %s failed: %s", pv.Name, err)
+		}
+	}
+
+	err = ctx.Client.StorageV1().StorageClasses().DeleteCollection(context.TODO(), metav1.DeleteOptions{}, selector)
+	if err != nil && !apierrors.IsNotFound(err) {
+		common.OutputErrorf("clear: delete local storage class failed: %s", err)
+	}
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controlpanel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func readyNode(name string, labels map[string]string, taints ...v1.Taint) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       v1.NodeSpec{Taints: taints},
+		Status: v1.NodeStatus{Conditions: []v1.NodeCondition{
+			{Type: v1.NodeReady, Status: v1.ConditionTrue},
+		}},
+	}
+}
+
+func TestCheckStorage(t *testing.T) {
+	ctx, client, _ := prepareContext()
+	ctx.Flags.MeshControlPlaneStorageClassName = "local-path"
+
+	err := checkStorage(ctx)
+	if err == nil {
+		t.Fatalf("expect error without PersistentVolume")
+	}
+
+	client.StorageV1().StorageClasses().Create(context.TODO(), &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: ctx.Flags.MeshControlPlaneStorageClassName},
+		Provisioner: "rancher.io/local-path",
+	}, metav1.CreateOptions{})
+	err = checkStorage(ctx)
+	if err != nil {
+		t.Fatalf("expect dynamic provisioning, but got %v", err)
+	}
+
+	ctx.Flags.MeshControlPlaneStorageClassName = ""
+	err = checkStorage(ctx)
+	if err == nil {
+		t.Fatalf("expect error without default StorageClass")
+	}
+
+	client.StorageV1().StorageClasses().Create(context.TODO(), &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "standard",
+			Annotations: map[string]string{defaultStorageClassAnnotation: "true"},
+		},
+		Provisioner: "kubernetes.io/gce-pd",
+	}, metav1.CreateOptions{})
+	err = checkStorage(ctx)
+	if err != nil {
+		t.Fatalf("expect default dynamic provisioning, but got %v", err)
+	}
+	statefulSet := statefulsetPVCSpec(initialStatefulSetSpec(nil))(ctx)
+	if statefulSet.Spec.VolumeClaimTemplates[0].Spec.StorageClassName != nil {
+		t.Fatalf("expect the claim of the default StorageClass without storage class name")
+	}
+}
+
+func TestProvisionLocalPV(t *testing.T) {
+	ctx, client, _ := prepareContext()
+	ctx.Flags.ProvisionLocalPV = true
+	ctx.Flags.EasegressControlPlaneReplicas = 3
+	ctx.Flags.MeshControlPlaneNodeSelectors = map[string]string{"mesh": "true"}
+
+	if err := checkStorage(ctx); err == nil {
+		t.Fatalf("expect error without nodes")
+	}
+
+	for _, node := range []*v1.Node{
+		readyNode("node-b", map[string]string{"mesh": "true", hostnameLabelKey: "host-b"}),
+		readyNode("node-a", map[string]string{"mesh": "true"}),
+		readyNode("node-c", map[string]string{"mesh": "false"}),
+		readyNode("master", map[string]string{"mesh": "true"},
+			v1.Taint{Key: "node-role.kubernetes.io/master", Effect: v1.TaintEffectNoSchedule}),
+	} {
+		client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+	}
+
+	if err := checkStorage(ctx); err != nil {
+		t.Fatalf("check storage failed: %v", err)
+	}
+	if err := localPVSpec(ctx)(ctx); err != nil {
+		t.Fatalf("provision local pv failed: %v", err)
+	}
+	// NOTE: Provisioning again is a no-op.
+	if err := localPVSpec(ctx)(ctx); err != nil {
+		t.Fatalf("provision local pv again failed: %v", err)
+	}
+
+	pvs, _ := client.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	if len(pvs.Items) != 3 {
+		t.Fatalf("expect 3 persistent volumes, but got %d", len(pvs.Items))
+	}
+	expected := map[string]string{
+		ctx.Flags.MeshControlPlanePersistVolumeName + "-0": "node-a",
+		ctx.Flags.MeshControlPlanePersistVolumeName + "-1": "host-b",
+		ctx.Flags.MeshControlPlanePersistVolumeName + "-2": "node-a",
+	}
+	for _, pv := range pvs.Items {
+		host := pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions[0].Values[0]
+		if expected[pv.Name] != host {
+			t.Fatalf("expect %s on %s, but got %s", pv.Name, expected[pv.Name], host)
+		}
+		if pv.Spec.HostPath.Path != ctx.Flags.MeshControlPlanePersistVolumeHostPath+"/"+pv.Name {
+			t.Fatalf("unexpected host path %s", pv.Spec.HostPath.Path)
+		}
+		if pv.Spec.StorageClassName != flags.DefaultLocalPVStorageClassName {
+			t.Fatalf("expect storage class %s, but got %s", flags.DefaultLocalPVStorageClassName, pv.Spec.StorageClassName)
+		}
+	}
+	statefulSet := statefulsetPVCSpec(initialStatefulSetSpec(nil))(ctx)
+	if name := statefulSet.Spec.VolumeClaimTemplates[0].Spec.StorageClassName; name == nil || *name != flags.DefaultLocalPVStorageClassName {
+		t.Fatalf("expect the claim of the local storage class, but got %v", name)
+	}
+
+	pv := &pvs.Items[0]
+	pv.Spec.ClaimRef = &v1.ObjectReference{Namespace: ctx.Flags.MeshNamespace, Name: installbase.ControlPlanePVCName + "-0"}
+	client.CoreV1().PersistentVolumes().Update(context.TODO(), pv, metav1.UpdateOptions{})
+	client.CoreV1().PersistentVolumeClaims(ctx.Flags.MeshNamespace).Create(context.TODO(), &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: installbase.ControlPlanePVCName + "-0"},
+	}, metav1.CreateOptions{})
+
+	clearLocalPV(ctx)
+	pvs, _ = client.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	pvcs, _ := client.CoreV1().PersistentVolumeClaims(ctx.Flags.MeshNamespace).List(context.TODO(), metav1.ListOptions{})
+	if len(pvs.Items) != 0 || len(pvcs.Items) != 0 {
+		t.Fatalf("expect local pv cleared, but got %d pv and %d pvc", len(pvs.Items), len(pvcs.Items))
+	}
+}
+
+func TestNodeSchedulable(t *testing.T) {
+	master := v1.Taint{Key: "node-role.kubernetes.io/master", Effect: v1.TaintEffectNoSchedule}
+	dedicated := v1.Taint{Key: "dedicated", Value: "mesh", Effect: v1.TaintEffectNoExecute}
+	preferred := v1.Taint{Key: "busy", Effect: v1.TaintEffectPreferNoSchedule}
+
+	cordoned := readyNode("cordoned", nil)
+	cordoned.Spec.Unschedulable = true
+	notReady := readyNode("not-ready", nil)
+	notReady.Status.Conditions[0].Status = v1.ConditionFalse
+
+	for i, c := range []struct {
+		node        *v1.Node
+		selectors   map[string]string
+		tolerations []v1.Toleration
+		expected    bool
+	}{
+		{readyNode("node", map[string]string{"mesh": "true"}), map[string]string{"mesh": "true"}, nil, true},
+		{readyNode("node", map[string]string{"mesh": "false"}), map[string]string{"mesh": "true"}, nil, false},
+		{cordoned, nil, nil, false},
+		{notReady, nil, nil, false},
+		{readyNode("node", nil, preferred), nil, nil, true},
+		{readyNode("node", nil, master), nil, nil, false},
+		{readyNode("node", nil, master), nil, []v1.Toleration{{Key: master.Key, Operator: v1.TolerationOpExists}}, true},
+		{readyNode("node", nil, master, dedicated), nil, []v1.Toleration{{Key: master.Key, Operator: v1.TolerationOpExists}}, false},
+		{readyNode("node", nil, master, dedicated), nil, []v1.Toleration{
+			{Key: master.Key, Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+			{Key: dedicated.Key, Operator: v1.TolerationOpEqual, Value: "mesh"},
+		}, true},
+		{readyNode("node", nil, dedicated), nil, []v1.Toleration{{Key: dedicated.Key, Operator: v1.TolerationOpEqual, Value: "other"}}, false},
+		{readyNode("node", nil, dedicated), nil, []v1.Toleration{{Operator: v1.TolerationOpExists}}, true},
+	} {
+		if got := nodeSchedulable(c.node, c.selectors, c.tolerations); got != c.expected {
+			t.Fatalf("case %d: expect schedulable %v, but got %v", i, c.expected, got)
+		}
+	}
+}
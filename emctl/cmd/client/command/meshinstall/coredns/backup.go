@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coredns
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/history"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	"github.com/pkg/errors"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// backupDeploymentAnnotation holds the original CoreDNS Deployment replaced by the EaseMesh.
+	backupDeploymentAnnotation = "mesh.megaease.com/coredns-backup-deployment"
+	// backupCorefileAnnotation holds the original Corefile replaced by the EaseMesh.
+	backupCorefileAnnotation = "mesh.megaease.com/coredns-backup-corefile"
+
+	corefileKey = "Corefile"
+)
+
+// backup is the original CoreDNS kept in the annotations of its ConfigMap, so anyone
+// accessing the cluster can restore it.
+type backup struct {
+	// Deployment is the YAML of the original Deployment, it's empty if there was none.
+	Deployment string
+	Corefile   string
+}
+
+// getCoreDNSConfigMap returns the ConfigMap of CoreDNS, it returns nil if it doesn't exist.
+func getCoreDNSConfigMap(ctx *installbase.StageContext) (*v1.ConfigMap, error) {
+	configMap, err := ctx.Client.CoreV1().ConfigMaps(coreDNSNamespace).Get(context.TODO(),
+		coreDNSConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get ConfigMap %s/%s failed", coreDNSNamespace, coreDNSConfigMap)
+	}
+	return configMap, nil
+}
+
+// loadBackup loads the backup from the ConfigMap, it returns nil if there's no backup.
+func loadBackup(configMap *v1.ConfigMap) *backup {
+	if configMap == nil {
+		return nil
+	}
+	corefile, exists := configMap.Annotations[backupCorefileAnnotation]
+	if !exists {
+		return nil
+	}
+	return &backup{
+		Deployment: configMap.Annotations[backupDeploymentAnnotation],
+		Corefile:   corefile,
+	}
+}
+
+// takeBackup returns the backup of the running CoreDNS, the existing backup is kept
+// so installing again never overwrites the original CoreDNS with the EaseMesh one.
+func takeBackup(ctx *installbase.StageContext, configMap *v1.ConfigMap) (*backup, error) {
+	if b := loadBackup(configMap); b != nil {
+		return b, nil
+	}
+
+	b := &backup{}
+	if configMap != nil {
+		b.Corefile = configMap.Data[corefileKey]
+	}
+
+	deployment, err := ctx.Client.AppsV1().Deployments(coreDNSNamespace).Get(context.TODO(),
+		coreDNSDeployment, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get Deployment %s/%s failed", coreDNSNamespace, coreDNSDeployment)
+	}
+
+	// NOTE: Only the desired state is kept, the restored Deployment is updated by it.
+	deployment.Kind = "Deployment"
+	deployment.APIVersion = "apps/v1"
+	deployment.Status = appsV1.DeploymentStatus{}
+	deployment.ObjectMeta = metav1.ObjectMeta{
+		Name:        deployment.Name,
+		Namespace:   deployment.Namespace,
+		Labels:      deployment.Labels,
+		Annotations: deployment.Annotations,
+	}
+	delete(deployment.Annotations, v1.LastAppliedConfigAnnotation)
+	delete(deployment.Annotations, "deployment.kubernetes.io/revision")
+
+	buff, err := yaml.Marshal(deployment)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal Deployment %s/%s failed", coreDNSNamespace, coreDNSDeployment)
+	}
+	b.Deployment = string(buff)
+
+	return b, nil
+}
+
+// annotate records the backup in the annotations.
+func (b *backup) annotate(annotations map[string]string) {
+	annotations[backupCorefileAnnotation] = b.Corefile
+	if b.Deployment != "" {
+		annotations[backupDeploymentAnnotation] = b.Deployment
+	}
+}
+
+// printCorefileDiff prints the changes of the Corefile.
+func printCorefileDiff(w io.Writer, previous, current string) {
+	if previous == current {
+		fmt.Fprintf(w, "Corefile of ConfigMap %s/%s is unchanged\n\n", coreDNSNamespace, coreDNSConfigMap)
+		return
+	}
+	fmt.Fprintf(w, "Corefile of ConfigMap %s/%s changes:\n%s\n", coreDNSNamespace, coreDNSConfigMap,
+		history.Diff(previous, current))
+}
+
+// Restore restores the original CoreDNS from the backup in its ConfigMap.
+func Restore(ctx *installbase.StageContext) error {
+	configMap, err := getCoreDNSConfigMap(ctx)
+	if err != nil {
+		return err
+	}
+	b := loadBackup(configMap)
+	if b == nil {
+		return errors.Errorf("no backup of CoreDNS found in ConfigMap %s/%s", coreDNSNamespace, coreDNSConfigMap)
+	}
+
+	if b.Deployment == "" {
+		err = ctx.Client.AppsV1().Deployments(coreDNSNamespace).Delete(context.TODO(),
+			coreDNSDeployment, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete Deployment %s/%s failed", coreDNSNamespace, coreDNSDeployment)
+		}
+	} else {
+		deployment := &appsV1.Deployment{}
+		err = yaml.Unmarshal([]byte(b.Deployment), deployment)
+		if err != nil {
+			return errors.Wrapf(err, "unmarshal backup Deployment %s/%s failed", coreDNSNamespace, coreDNSDeployment)
+		}
+		err = installbase.DeployDeployment(deployment, ctx.Client, coreDNSNamespace)
+		if err != nil {
+			return errors.Wrapf(err, "restore Deployment %s/%s failed", coreDNSNamespace, coreDNSDeployment)
+		}
+	}
+
+	err = installbase.DeletePolicyV1Resources(ctx.Client, "poddisruptionbudgets", coreDNSNamespace, coreDNSPodDisruptionBudget)
+	if err != nil {
+		return errors.Wrapf(err, "delete PodDisruptionBudget %s/%s failed", coreDNSNamespace, coreDNSPodDisruptionBudget)
+	}
+
+	// NOTE: The backup is removed after everything else is restored, so a failed restoring can be retried.
+	printCorefileDiff(ctx.Out(), configMap.Data[corefileKey], b.Corefile)
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[corefileKey] = b.Corefile
+	delete(configMap.Annotations, backupCorefileAnnotation)
+	delete(configMap.Annotations, backupDeploymentAnnotation)
+	_, err = ctx.Client.CoreV1().ConfigMaps(coreDNSNamespace).Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "restore ConfigMap %s/%s failed", coreDNSNamespace, coreDNSConfigMap)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coredns
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base/fake"
+
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	testclient "k8s.io/client-go/kubernetes/fake"
+)
+
+const originalCorefile = `.:53 {
+	kubernetes cluster.local in-addr.arpa ip6.arpa
+	forward . /etc/resolv.conf
+}
+`
+
+func TestBackupAndRestore(t *testing.T) {
+	client := testclient.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: coreDNSConfigMap, Namespace: coreDNSNamespace},
+			Data:       map[string]string{corefileKey: originalCorefile},
+		},
+		&appsV1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: coreDNSDeployment, Namespace: coreDNSNamespace, ResourceVersion: "7"},
+			Spec: appsV1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{Containers: []v1.Container{{Name: "coredns", Image: "k8s.gcr.io/coredns:1.7.0"}}},
+				},
+			},
+		},
+	)
+	ctx := fake.NewStageContextForApply(client, nil)
+
+	getConfigMap := func() *v1.ConfigMap {
+		configMap, err := getCoreDNSConfigMap(ctx)
+		if err != nil || configMap == nil {
+			t.Fatalf("get configmap failed: %v", err)
+		}
+		return configMap
+	}
+
+	// NOTE: Installing twice must keep the backup of the original CoreDNS.
+	for i := 0; i < 2; i++ {
+		err := configMapSpec(ctx).Deploy(ctx)
+		if err != nil {
+			t.Fatalf("deploy configmap failed: %v", err)
+		}
+	}
+
+	configMap := getConfigMap()
+	if !strings.Contains(configMap.Data[corefileKey], "easemesh") {
+		t.Fatalf("expect the EaseMesh Corefile, but got %s", configMap.Data[corefileKey])
+	}
+	b := loadBackup(configMap)
+	if b == nil || b.Corefile != originalCorefile || !strings.Contains(b.Deployment, "k8s.gcr.io/coredns:1.7.0") {
+		t.Fatalf("unexpected backup: %+v", b)
+	}
+	if strings.Contains(b.Deployment, "resourceVersion") {
+		t.Fatalf("expect no resource version in the backup: %s", b.Deployment)
+	}
+
+	maxUnavailable := intstr.FromInt(1)
+	ctx.CoreDNSFlags = &flags.CoreDNS{Component: &flags.Component{
+		PodDisruptionBudget: &flags.PodDisruptionBudget{MaxUnavailable: &maxUnavailable},
+	}}
+	err := podDisruptionBudgetSpec(ctx).Deploy(ctx)
+	if err != nil {
+		t.Fatalf("deploy pod disruption budget failed: %v", err)
+	}
+	client.PolicyV1().PodDisruptionBudgets(coreDNSNamespace).Create(context.TODO(), &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: coreDNSNamespace},
+	}, metav1.CreateOptions{})
+
+	client.AppsV1().Deployments(coreDNSNamespace).Delete(context.TODO(), coreDNSDeployment, metav1.DeleteOptions{})
+	err = Clear(ctx)
+	if err != nil {
+		t.Fatalf("clear failed: %v", err)
+	}
+
+	configMap = getConfigMap()
+	if configMap.Data[corefileKey] != originalCorefile || loadBackup(configMap) != nil {
+		t.Fatalf("unexpected restored configmap: %+v", configMap)
+	}
+	deployment, err := client.AppsV1().Deployments(coreDNSNamespace).Get(context.TODO(), coreDNSDeployment, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get restored deployment failed: %v", err)
+	}
+	if deployment.Spec.Template.Spec.Containers[0].Image != "k8s.gcr.io/coredns:1.7.0" {
+		t.Fatalf("unexpected restored deployment: %+v", deployment)
+	}
+	pdbs, _ := client.PolicyV1().PodDisruptionBudgets(coreDNSNamespace).List(context.TODO(), metav1.ListOptions{})
+	if len(pdbs.Items) != 1 || pdbs.Items[0].Name != "coredns" {
+		t.Fatalf("expect only the PodDisruptionBudget of the distribution kept, but got %+v", pdbs.Items)
+	}
+
+	err = Restore(ctx)
+	if err == nil || !strings.Contains(err.Error(), "no backup") {
+		t.Fatalf("expect no backup error, but got %v", err)
+	}
+	err = Clear(ctx)
+	if err != nil {
+		t.Fatalf("clear without backup failed: %v", err)
+	}
+}
@@ -53,9 +53,12 @@ type (
 func configMapSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 	return func(ctx *installbase.StageContext) error {
 		var dnsDomain string
-		if ctx.CoreDNSFlags.DNSDomain != "" {
+		switch {
+		case ctx.CoreDNSFlags.DNSDomain != "":
 			dnsDomain = ctx.CoreDNSFlags.DNSDomain
-		} else {
+		case ctx.Rendering():
+			return errors.New("--dns-domain is required in rendering, since kubeadm-config can't be read")
+		default:
 			// ClusterConfiguration.networking.dnsDomain in ConfigMap kube-system/kubeadm-config
 			kubeConfigMap, err := ctx.Client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "kubeadm-config", metav1.GetOptions{})
 			if err != nil {
@@ -109,6 +112,23 @@ func configMapSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 			},
 		}
 
+		// NOTE: Rendering can't read the running CoreDNS, so the rendered
+		// manifests carry no backup of it.
+		if !ctx.Rendering() {
+			oldConfigMap, err := getCoreDNSConfigMap(ctx)
+			if err != nil {
+				return err
+			}
+			b, err := takeBackup(ctx, oldConfigMap)
+			if err != nil {
+				return errors.Wrap(err, "backup CoreDNS failed")
+			}
+			b.annotate(configMap.Annotations)
+			if oldConfigMap != nil {
+				printCorefileDiff(ctx.Out(), oldConfigMap.Data[corefileKey], cfg)
+			}
+		}
+
 		data := map[string]string{}
 		data["Corefile"] = cfg
 		configMap.Data = data
@@ -18,27 +18,23 @@
 package coredns
 
 import (
-	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
 
+	"github.com/megaease/easemeshctl/cmd/client/command/bundle"
 	"github.com/megaease/easemeshctl/cmd/client/command/flags"
 	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
 	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/installation"
 	"github.com/megaease/easemeshctl/cmd/common"
-	"sigs.k8s.io/yaml"
+	yamlv2 "gopkg.in/yaml.v2"
 
 	"github.com/spf13/cobra"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-var (
-	coreDNSSpecFile = "coredns-old-spec.yaml"
-
-	warnMessage = fmt.Sprintf(`WARN: The process of installation for coredns can't be reverted in $ emctl reset
-you could use generated file to revert coredns spec by: $ kubectl apply -f %s
-`, coreDNSSpecFile)
-)
+const warnMessage = `NOTE: The original CoreDNS is backed up in the annotations of ConfigMap kube-system/coredns,
+you could restore it by: $ emctl reset coredns
+`
 
 // CoreDNSCmd returns coredns command.
 func CoreDNSCmd() *cobra.Command {
@@ -51,9 +47,45 @@ func CoreDNSCmd() *cobra.Command {
 	flags.AttachCmd(cmd)
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
-		fmt.Println(warnMessage)
-
 		var err error
+		if flags.SpecFile != "" {
+			flags.Component, err = loadComponent(flags.SpecFile)
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+		}
+
+		if flags.Bundle != "" {
+			m, err := bundle.LoadManifest(flags.Bundle)
+			if err == nil {
+				err = m.ApplyCoreDNS(flags)
+			}
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+		}
+
+		if !flags.Restore {
+			err = flags.CheckImage()
+			if err != nil {
+				common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+			}
+		}
+
+		cleanWhenFailed, err := flags.CleanWhenFailedMode()
+		if err != nil {
+			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+		}
+
+		stages := []installation.InstallStage{
+			installation.Wrap("coredns", PreCheck, Deploy, Clear, DescribePhase),
+		}
+
+		if flags.Render && !flags.Restore {
+			render(cmd, flags, stages)
+			return
+		}
+
 		kubeClient, clientConfig, err := installbase.NewKubernetesClient()
 		if err != nil {
 			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
@@ -72,47 +104,115 @@ func CoreDNSCmd() *cobra.Command {
 			APIExtensionsClient: apiExtensionClient,
 		}
 
-		err = storeOldCoreDNS(ctx)
-		if err != nil {
-			fmt.Printf("WARN: store old coredns spec failed: %v\n\n", err)
+		if flags.Restore {
+			restore(ctx)
+			return
 		}
 
-		stages := []installation.InstallStage{
-			installation.Wrap(PreCheck, Deploy, Clear, DescribePhase),
-		}
+		fmt.Print(warnMessage + "\n")
 
 		install := installation.New(stages...)
 
 		err = install.DoInstallStage(ctx)
 		if err != nil {
-			if flags.CleanWhenFailed {
-				install.ClearResource(ctx)
-			}
-			common.ExitWithErrorf("install coredns failed: %s", err)
+			installFailed(ctx, install, cleanWhenFailed, err)
 		}
 	}
 
 	return cmd
 }
 
-func storeOldCoreDNS(ctx *installbase.StageContext) error {
-	deploy, err := ctx.Client.AppsV1().Deployments(coreDNSNamespace).Get(context.Background(), coreDNSDeployment, metav1.GetOptions{})
+func installFailed(ctx *installbase.StageContext, install installation.Installation, cleanWhenFailed string, err error) {
+	switch cleanWhenFailed {
+	case flags.CleanWhenFailedAll:
+		install.ClearResource(ctx)
+	case flags.CleanWhenFailedStage:
+		install.ClearFailedStage(ctx)
+	}
+
+	common.ExitWithErrorf("install coredns failed: %s", err)
+}
+
+// render runs the stages against the recording clients and writes the manifests.
+func render(cmd *cobra.Command, flags *flags.CoreDNS, stages []installation.InstallStage) {
+	recorder := installbase.NewRecorder()
+	kubeClient, apiExtensionClient, err := installbase.NewRecordingClients(recorder)
 	if err != nil {
-		return err
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
 	}
 
-	deploy.Kind = "Deployment"
-	deploy.APIVersion = "apps/v1"
+	// NOTE: The progress of stages goes to stderr, in case of the manifests are written to stdout.
+	ctx := &installbase.StageContext{
+		Cmd:                 cmd,
+		CoreDNSFlags:        flags,
+		Client:              kubeClient,
+		APIExtensionsClient: apiExtensionClient,
+		Recorder:            recorder,
+		Progress:            os.Stderr,
+	}
+
+	err = installation.New(stages...).DoInstallStage(ctx)
+	if err != nil {
+		common.ExitWithErrorf("render coredns failed: %s", err)
+	}
 
-	buff, err := yaml.Marshal(deploy)
+	err = recorder.WriteManifests(flags.RenderOutput)
 	if err != nil {
-		return err
+		common.ExitWithErrorf("write rendered manifests failed: %s", err)
+	}
+
+	if flags.RenderOutput != installbase.RenderToStdout {
+		fmt.Printf("Manifests are rendered to %s\n", flags.RenderOutput)
+	}
+}
+
+// loadComponent loads the CoreDNS component from the spec file of the installation.
+func loadComponent(specFile string) (*flags.Component, error) {
+	buff, err := ioutil.ReadFile(specFile)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := struct {
+		Components flags.Components
+	}{}
+	err = yamlv2.Unmarshal(buff, &spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return spec.Components.CoreDNS, nil
+}
+
+// ResetCmd returns the command restoring the original CoreDNS.
+func ResetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "coredns",
+		Short:   "Restore the original CoreDNS replaced by the EaseMesh dedicated one",
+		Example: "emctl reset coredns",
 	}
 
-	err = ioutil.WriteFile(coreDNSSpecFile, buff, 0o644)
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		kubeClient, clientConfig, err := installbase.NewKubernetesClient()
+		if err != nil {
+			common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+		}
+
+		restore(&installbase.StageContext{
+			Cmd:          cmd,
+			ClientConfig: clientConfig,
+			Client:       kubeClient,
+		})
+	}
+
+	return cmd
+}
+
+func restore(ctx *installbase.StageContext) {
+	err := Restore(ctx)
 	if err != nil {
-		return err
+		common.ExitWithErrorf("restore coredns failed: %s", err)
 	}
 
-	return nil
+	fmt.Fprintf(ctx.Out(), "Original CoreDNS restored, deployment: %s/%s\n", coreDNSNamespace, coreDNSDeployment)
 }
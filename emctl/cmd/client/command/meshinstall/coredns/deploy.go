@@ -33,6 +33,8 @@ const (
 	coreDNSDeployment  = "coredns"
 	coreDNSConfigMap   = "coredns"
 	coreDNSClusterRole = "system:coredns"
+	// NOTE: The PodDisruptionBudget of the distribution may be named coredns, it's not overwritten.
+	coreDNSPodDisruptionBudget = "easemesh-coredns"
 )
 
 // Deploy deploy resources of coreDNS.
@@ -43,11 +45,16 @@ func Deploy(ctx *installbase.StageContext) error {
 			clusterRoleSpec(ctx),
 
 			coreDNSDeploymentSpec(ctx),
+			podDisruptionBudgetSpec(ctx),
 		})
 	if err != nil {
 		return err
 	}
 
+	if ctx.Rendering() {
+		return nil
+	}
+
 	return checkCoreDNSStatus(ctx.Client, ctx.Flags)
 }
 
@@ -57,9 +64,16 @@ func PreCheck(context *installbase.StageContext) error {
 	return nil
 }
 
-// Clear does nothing.
+// Clear restores the original CoreDNS if it has been backed up.
 func Clear(context *installbase.StageContext) error {
-	return nil
+	configMap, err := getCoreDNSConfigMap(context)
+	if err != nil {
+		return err
+	}
+	if loadBackup(configMap) == nil {
+		return nil
+	}
+	return Restore(context)
 }
 
 // DescribePhase leverage human-readable text to describe different phase
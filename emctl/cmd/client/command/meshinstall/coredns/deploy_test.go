@@ -18,6 +18,8 @@
 package coredns
 
 import (
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/megaease/easemeshctl/cmd/client/command/flags"
@@ -90,3 +92,42 @@ func TestDescribePhase(t *testing.T) {
 	DescribePhase(ctx, installbase.ErrorPhase)
 	PreCheck(ctx)
 }
+
+func TestRender(t *testing.T) {
+	recorder := installbase.NewRecorder()
+	client, extensionClient, err := installbase.NewRecordingClients(recorder)
+	if err != nil {
+		t.Fatalf("new recording clients failed: %v", err)
+	}
+
+	cmd := &cobra.Command{}
+	coreDNSFlags := &flags.CoreDNS{}
+	coreDNSFlags.AttachCmd(cmd)
+	ctx := &installbase.StageContext{
+		Cmd:                 cmd,
+		CoreDNSFlags:        coreDNSFlags,
+		Client:              client,
+		APIExtensionsClient: extensionClient,
+		Recorder:            recorder,
+		Progress:            ioutil.Discard,
+	}
+
+	err = Deploy(ctx)
+	if err == nil || !strings.Contains(err.Error(), "--dns-domain") {
+		t.Fatalf("expect dns domain required error, but got %v", err)
+	}
+
+	coreDNSFlags.DNSDomain = "cluster.local"
+	err = Deploy(ctx)
+	if err != nil {
+		t.Fatalf("render coredns failed: %v", err)
+	}
+
+	var kinds []string
+	for _, manifest := range recorder.Manifests() {
+		kinds = append(kinds, manifest["kind"].(string))
+	}
+	if strings.Join(kinds, ",") != "ConfigMap,ClusterRole,Deployment" {
+		t.Fatalf("unexpected rendered kinds %v", kinds)
+	}
+}
@@ -28,11 +28,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const coreDNSContainerName = "coredns"
+
 type deploymentSpecFunc func(ctx *installbase.StageContext) *appsV1.Deployment
 
 func coreDNSDeploymentSpec(ctx *installbase.StageContext) installbase.InstallFunc {
-	deployment := deploymentConfigVolumeSpec(
-		deploymentBaseSpec(deploymentInitialize(nil)))(ctx)
+	deployment := deploymentComponentSpec(
+		deploymentConfigVolumeSpec(
+			deploymentBaseSpec(deploymentInitialize(nil))))(ctx)
 
 	return func(ctx *installbase.StageContext) error {
 		err := installbase.DeployDeployment(deployment, ctx.Client, coreDNSNamespace)
@@ -96,8 +99,12 @@ func deploymentBaseSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 
 		spec.Spec.Template.Spec.SecurityContext = &v1.PodSecurityContext{}
 
-		container, _ := installbase.AcceptContainerVisitor("coredns",
-			ctx.CoreDNSFlags.Image,
+		image := ctx.CoreDNSFlags.Image
+		if ctx.CoreDNSFlags.ImageRegistryURL != "" {
+			image = ctx.CoreDNSFlags.ImageRegistryURL + "/" + image
+		}
+		container, _ := installbase.AcceptContainerVisitor(coreDNSContainerName,
+			image,
 			v1.PullPolicy(ctx.CoreDNSFlags.ImagePullPolicy),
 			newVisitor(ctx))
 
@@ -106,6 +113,19 @@ func deploymentBaseSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 	}
 }
 
+func deploymentComponentSpec(fn deploymentSpecFunc) deploymentSpecFunc {
+	return func(ctx *installbase.StageContext) *appsV1.Deployment {
+		spec := fn(ctx)
+		installbase.ApplyComponent(&spec.Spec.Template, coreDNSContainerName, ctx.CoreDNSFlags.Component)
+		return spec
+	}
+}
+
+func podDisruptionBudgetSpec(ctx *installbase.StageContext) installbase.InstallFunc {
+	return installbase.PodDisruptionBudgetInstallFunc(coreDNSPodDisruptionBudget,
+		coreDNSNamespace, coreDNSLabels(), ctx.CoreDNSFlags.Component)
+}
+
 func deploymentConfigVolumeSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 	var defaultMode int32 = 420
 	return func(ctx *installbase.StageContext) *appsV1.Deployment {
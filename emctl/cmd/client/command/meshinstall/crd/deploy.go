@@ -29,21 +29,46 @@ import (
 	clientGoScheme "k8s.io/client-go/kubernetes/scheme"
 )
 
-//go:embed  crd.yaml
-var easemeshDeploymentCRD []byte
+var (
+	//go:embed  crd.yaml
+	easemeshDeploymentCRD []byte
+
+	//go:embed  federation_crd.yaml
+	easemeshFederationCRD []byte
+
+	//go:embed  statefulset_crd.yaml
+	easemeshStatefulSetCRD []byte
+
+	//go:embed  daemonset_crd.yaml
+	easemeshDaemonSetCRD []byte
+)
+
+func crdSpecs() ([]*apiExtensionsV1.CustomResourceDefinition, error) {
+	var crds []*apiExtensionsV1.CustomResourceDefinition
+	for _, yaml := range [][]byte{easemeshDeploymentCRD, easemeshFederationCRD, easemeshStatefulSetCRD, easemeshDaemonSetCRD} {
+		crd, err := getCRDSpec(yaml)
+		if err != nil {
+			return nil, err
+		}
+		crds = append(crds, crd)
+	}
+	return crds, nil
+}
 
 // Deploy deploy resources of crd
 func Deploy(context *installbase.StageContext) error {
-	crd, err := getCRDSpec(easemeshDeploymentCRD)
+	crds, err := crdSpecs()
 	if err != nil {
 		return err
 	}
 
-	err = installbase.DeployCustomResourceDefinition(crd, context.APIExtensionsClient)
-	if err != nil {
-		return errors.Wrapf(err, "can't deploy CRD %s", crd.Name)
+	for _, crd := range crds {
+		err = installbase.DeployCustomResourceDefinition(crd, context.APIExtensionsClient)
+		if err != nil {
+			return errors.Wrapf(err, "can't deploy CRD %s", crd.Name)
+		}
 	}
-	return err
+	return nil
 }
 
 // PreCheck check prerequisite for installing CRD
@@ -53,11 +78,18 @@ func PreCheck(context *installbase.StageContext) error {
 
 // Clear will clear all installed resource about control panel
 func Clear(context *installbase.StageContext) error {
-	crd, err := getCRDSpec(easemeshDeploymentCRD)
+	crds, err := crdSpecs()
 	if err != nil {
 		return err
 	}
-	return installbase.DeleteCRDResource(context.APIExtensionsClient, crd.Name)
+
+	for _, crd := range crds {
+		err = installbase.DeleteCRDResource(context.APIExtensionsClient, crd.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // DescribePhase leverage human-readable text to describe different phase
@@ -65,9 +97,9 @@ func Clear(context *installbase.StageContext) error {
 func DescribePhase(context *installbase.StageContext, phase installbase.InstallPhase) string {
 	switch phase {
 	case installbase.BeginPhase:
-		return "Begin to deploy CRD meshdeployment\n"
+		return "Begin to deploy CRD meshdeployment, meshfederation, meshstatefulset and meshdaemonset\n"
 	case installbase.EndPhase:
-		return "CustomeResourceDefine meshdeployment deployed successfully\n"
+		return "CustomeResourceDefine meshdeployment, meshfederation, meshstatefulset and meshdaemonset deployed successfully\n"
 	}
 	return ""
 }
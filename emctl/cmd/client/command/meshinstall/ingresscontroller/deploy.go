@@ -34,11 +34,16 @@ func Deploy(ctx *installbase.StageContext) error {
 		configMapSpec(ctx),
 		serviceSpec(ctx),
 		deploymentSpec(ctx),
+		podDisruptionBudgetSpec(ctx),
 	})
 	if err != nil {
 		return err
 	}
 
+	if ctx.Rendering() {
+		return nil
+	}
+
 	return checkMeshIngressStatus(ctx.Client, ctx.Flags)
 }
 
@@ -57,6 +62,11 @@ func Clear(context *installbase.StageContext) error {
 		{"configmap", installbase.IngressControllerConfigMapName},
 	}
 
+	policyV1Resources := [][]string{
+		{"poddisruptionbudgets", installbase.IngressControllerDeploymentName},
+	}
+
+	installbase.DeleteResources(context.Client, policyV1Resources, context.Flags.MeshNamespace, installbase.DeletePolicyV1Resources)
 	installbase.DeleteResources(context.Client, appsV1Resources, context.Flags.MeshNamespace, installbase.DeleteAppsV1Resource)
 	installbase.DeleteResources(context.Client, coreV1Resources, context.Flags.MeshNamespace, installbase.DeleteCoreV1Resource)
 	return nil
@@ -36,10 +36,11 @@ func meshIngressLabel() map[string]string {
 }
 
 func deploymentSpec(ctx *installbase.StageContext) installbase.InstallFunc {
-	deployment := deploymentConfigVolumeSpec(
-		deploymentContainerSpec(
-			deploymentBaseSpec(
-				deploymentInitialize(nil))))(ctx)
+	deployment := deploymentComponentSpec(
+		deploymentConfigVolumeSpec(
+			deploymentContainerSpec(
+				deploymentBaseSpec(
+					deploymentInitialize(nil)))))(ctx)
 
 	return func(ctx *installbase.StageContext) error {
 		err := installbase.DeployDeployment(deployment, ctx.Client, ctx.Flags.MeshNamespace)
@@ -104,6 +105,20 @@ func deploymentConfigVolumeSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 	}
 }
 
+func deploymentComponentSpec(fn deploymentSpecFunc) deploymentSpecFunc {
+	return func(ctx *installbase.StageContext) *appsV1.Deployment {
+		spec := fn(ctx)
+		installbase.ApplyComponent(&spec.Spec.Template, installbase.IngressControllerDeploymentName,
+			ctx.Flags.Components.Ingress)
+		return spec
+	}
+}
+
+func podDisruptionBudgetSpec(ctx *installbase.StageContext) installbase.InstallFunc {
+	return installbase.PodDisruptionBudgetInstallFunc(installbase.IngressControllerDeploymentName,
+		ctx.Flags.MeshNamespace, meshIngressLabel(), ctx.Flags.Components.Ingress)
+}
+
 type containerVisitor struct {
 	ctx *installbase.StageContext
 }
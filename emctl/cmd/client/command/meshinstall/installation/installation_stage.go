@@ -19,6 +19,7 @@ package installation
 
 import (
 	"fmt"
+	"io/ioutil"
 
 	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
 	"github.com/megaease/easemeshctl/cmd/common"
@@ -28,7 +29,8 @@ import (
 
 // InstallStage holds operations in installation of a stage
 type InstallStage interface {
-	Do(*installbase.StageContext, Installation) error
+	Name() string
+	Do(*installbase.StageContext) error
 	Clear(*installbase.StageContext) error
 }
 
@@ -37,25 +39,48 @@ type InstallStage interface {
 type Installation interface {
 	DoInstallStage(*installbase.StageContext) error
 	ClearResource(*installbase.StageContext)
+	ClearFailedStage(*installbase.StageContext)
 }
 
 type installation struct {
 	stages []InstallStage
-	step   int
+	state  *State
+	failed InstallStage
 }
 
 // New creates a new Installation
 func New(stages ...InstallStage) Installation {
-	return &installation{stages: stages, step: 0}
+	return &installation{stages: stages}
+}
+
+// NewResumable creates a new Installation which persists its progress in the state,
+// the stages completed in the state are skipped.
+func NewResumable(state *State, stages ...InstallStage) Installation {
+	return &installation{stages: stages, state: state}
 }
 
 func (i *installation) DoInstallStage(context *installbase.StageContext) error {
-	if i.step >= len(i.stages) {
-		return nil
+	for _, stage := range i.stages {
+		if i.state != nil && i.state.Completed(stage.Name()) {
+			fmt.Fprintf(context.Out(), "Stage %s has been completed, skip it\n", stage.Name())
+			context.ClearFuncs = append(context.ClearFuncs, stage.Clear)
+			continue
+		}
+
+		err := stage.Do(context)
+		if err != nil {
+			i.failed = stage
+			return errors.Wrapf(err, "install stage %s failed", stage.Name())
+		}
+
+		if i.state != nil {
+			err = i.state.complete(context, stage.Name())
+			if err != nil {
+				return errors.Wrapf(err, "save state of stage %s failed", stage.Name())
+			}
+		}
 	}
-	current := i.step
-	i.step++
-	return i.stages[current].Do(context, i)
+	return nil
 }
 
 func (i *installation) ClearResource(context *installbase.StageContext) {
@@ -67,6 +92,17 @@ func (i *installation) ClearResource(context *installbase.StageContext) {
 	}
 }
 
+// ClearFailedStage clears the resources of the failed stage only, the completed stages are kept.
+func (i *installation) ClearFailedStage(context *installbase.StageContext) {
+	if i.failed == nil {
+		return
+	}
+	err := i.failed.Clear(context)
+	if err != nil {
+		common.OutputErrorf("clear resource of stage %s error:%s", i.failed.Name(), err)
+	}
+}
+
 // InstallFunc is the type of install function
 type InstallFunc func(*installbase.StageContext) error
 
@@ -82,12 +118,13 @@ type PreCheckFunc HookFunc
 // DescribeFunc is the type of function describing what's the situation of the installation
 type DescribeFunc func(*installbase.StageContext, installbase.InstallPhase) string
 
-// Wrap creates new InstallStage via wraping functions
-func Wrap(preCheckFunc HookFunc, installFunc InstallFunc, clearFunc HookFunc, description DescribeFunc) InstallStage {
-	return &baseInstallStage{preCheck: PreCheckFunc(preCheckFunc), installFunc: installFunc, clearFunc: ClearFunc(clearFunc), description: description}
+// Wrap creates new InstallStage via wraping functions, the name identifies the stage in the persisted state
+func Wrap(name string, preCheckFunc HookFunc, installFunc InstallFunc, clearFunc HookFunc, description DescribeFunc) InstallStage {
+	return &baseInstallStage{name: name, preCheck: PreCheckFunc(preCheckFunc), installFunc: installFunc, clearFunc: ClearFunc(clearFunc), description: description}
 }
 
 type baseInstallStage struct {
+	name        string
 	preCheck    PreCheckFunc
 	installFunc InstallFunc
 	clearFunc   ClearFunc
@@ -96,9 +133,14 @@ type baseInstallStage struct {
 
 var _ InstallStage = &baseInstallStage{}
 
-func (b *baseInstallStage) Do(context *installbase.StageContext, install Installation) error {
-	fmt.Printf("%s\n", b.description(context, installbase.BeginPhase))
-	if b.preCheck != nil {
+func (b *baseInstallStage) Name() string {
+	return b.name
+}
+
+func (b *baseInstallStage) Do(context *installbase.StageContext) error {
+	fmt.Fprintf(context.Out(), "%s\n", b.description(context, installbase.BeginPhase))
+	// NOTE: The pre-checks examine the cluster, which is not accessed in rendering.
+	if b.preCheck != nil && !context.Rendering() {
 		if err := b.preCheck(context); err != nil {
 			return errors.Wrap(err, "pre check installation condition failed")
 		}
@@ -109,8 +151,8 @@ func (b *baseInstallStage) Do(context *installbase.StageContext, install Install
 		return errors.Wrap(err, "invoke install func")
 	}
 
-	fmt.Printf("Install successfully end, following resource are deployed successfully: %s\n", b.description(context, installbase.EndPhase))
-	return install.DoInstallStage(context)
+	fmt.Fprintf(context.Out(), "Install successfully end, following resource are deployed successfully: %s\n", b.description(context, installbase.EndPhase))
+	return nil
 }
 
 func (b *baseInstallStage) Clear(context *installbase.StageContext) error {
@@ -119,3 +161,23 @@ func (b *baseInstallStage) Clear(context *installbase.StageContext) error {
 	}
 	return nil
 }
+
+// Render runs every stage against the recording clients of the context, and returns
+// the manifests rendered by each stage. The progress of stages is discarded.
+func Render(context *installbase.StageContext, stages ...InstallStage) ([][]map[string]interface{}, error) {
+	progress := context.Progress
+	context.Progress = ioutil.Discard
+	defer func() { context.Progress = progress }()
+
+	var result [][]map[string]interface{}
+	for _, stage := range stages {
+		before := len(context.Recorder.Manifests())
+		err := New(stage).DoInstallStage(context)
+		if err != nil {
+			return nil, errors.Wrap(err, "render stage failed")
+		}
+		result = append(result, context.Recorder.Manifests()[before:])
+	}
+
+	return result, nil
+}
@@ -17,8 +17,10 @@
 package installation
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
 	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
 )
 
@@ -56,8 +58,8 @@ func stepTwoPreCheck(s *installbase.StageContext) error {
 
 func TestInstallation(t *testing.T) {
 	installStages := []InstallStage{
-		Wrap(stepOnePreCheck, stepOneDeploy, stepOneClear, stepOneDescribe),
-		Wrap(stepTwoPreCheck, stepTwoDeploy, stepTwoClear, stepTwoDescribe),
+		Wrap("step-one", stepOnePreCheck, stepOneDeploy, stepOneClear, stepOneDescribe),
+		Wrap("step-two", stepTwoPreCheck, stepTwoDeploy, stepTwoClear, stepTwoDescribe),
 	}
 
 	installations := New(installStages...)
@@ -78,3 +80,80 @@ func TestInstallation(t *testing.T) {
 
 	installStages[0].Clear(&installContext)
 }
+
+func TestResumableInstallation(t *testing.T) {
+	client, _, err := installbase.NewRecordingClients(installbase.NewRecorder())
+	if err != nil {
+		t.Fatalf("new recording clients failed: %v", err)
+	}
+	context := &installbase.StageContext{
+		Client: client,
+		Flags:  &flags.Install{OperationGlobal: &flags.OperationGlobal{MeshNamespace: "easemesh"}},
+	}
+
+	deployed := map[string]int{}
+	cleared := map[string]int{}
+	failTwo := true
+	stage := func(name string) InstallStage {
+		return Wrap(name, nil, func(*installbase.StageContext) error {
+			if name == "step-two" && failTwo {
+				return errors.New("step two failed")
+			}
+			deployed[name]++
+			return nil
+		}, func(*installbase.StageContext) error {
+			cleared[name]++
+			return nil
+		}, stepOneDescribe)
+	}
+	stages := []InstallStage{stage("step-one"), stage("step-two")}
+
+	install := NewResumable(NewState(), stages...)
+	err = install.DoInstallStage(context)
+	if err == nil {
+		t.Fatalf("expect error of step two")
+	}
+	install.ClearFailedStage(context)
+	if cleared["step-one"] != 0 || cleared["step-two"] != 1 {
+		t.Fatalf("expect only step two cleared, but got %v", cleared)
+	}
+
+	state, err := LoadState(context)
+	if err != nil {
+		t.Fatalf("load state failed: %v", err)
+	}
+	if !state.Completed("step-one") || state.Completed("step-two") {
+		t.Fatalf("unexpected state: %v", state.completed)
+	}
+
+	failTwo = false
+	context.ClearFuncs = nil
+	err = NewResumable(state, stages...).DoInstallStage(context)
+	if err != nil {
+		t.Fatalf("resume installation failed: %v", err)
+	}
+	if deployed["step-one"] != 1 || deployed["step-two"] != 1 {
+		t.Fatalf("expect each stage deployed once, but got %v", deployed)
+	}
+	if len(context.ClearFuncs) != 2 {
+		t.Fatalf("expect clear funcs of skipped and installed stages, but got %d", len(context.ClearFuncs))
+	}
+
+	err = ForgetStages(context, "step-two")
+	if err != nil {
+		t.Fatalf("forget stages failed: %v", err)
+	}
+	state, _ = LoadState(context)
+	if !state.Completed("step-one") || state.Completed("step-two") {
+		t.Fatalf("unexpected state after forgetting step two: %v", state.completed)
+	}
+
+	err = ClearState(context)
+	if err != nil {
+		t.Fatalf("clear state failed: %v", err)
+	}
+	state, _ = LoadState(context)
+	if state.Completed("step-one") {
+		t.Fatalf("expect empty state, but got %v", state.completed)
+	}
+}
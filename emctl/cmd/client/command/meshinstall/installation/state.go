@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package installation
+
+import (
+	"context"
+	"time"
+
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// State is the progress of an installation persisted in a ConfigMap of the mesh namespace,
+// which maps the names of completed stages to their completion time.
+type State struct {
+	completed map[string]string
+}
+
+// NewState creates an empty State, which overwrites the persisted one when a stage is completed.
+func NewState() *State {
+	return &State{completed: map[string]string{}}
+}
+
+// LoadState loads the persisted State, it's empty if nothing was persisted.
+func LoadState(ctx *installbase.StageContext) (*State, error) {
+	configMap, err := ctx.Client.CoreV1().ConfigMaps(ctx.Flags.MeshNamespace).Get(context.TODO(),
+		installbase.InstallationStateConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get configmap %s failed", installbase.InstallationStateConfigMapName)
+	}
+
+	state := NewState()
+	for name, completedAt := range configMap.Data {
+		state.completed[name] = completedAt
+	}
+	return state, nil
+}
+
+// Completed returns whether the stage is completed.
+func (s *State) Completed(name string) bool {
+	_, exists := s.completed[name]
+	return exists
+}
+
+// CompletedAt returns the completion time of the stage in RFC3339, it's empty if the stage is not completed.
+func (s *State) CompletedAt(name string) string {
+	return s.completed[name]
+}
+
+// Reset marks the stages uncompleted, so that they are installed again.
+func (s *State) Reset(names ...string) {
+	for _, name := range names {
+		delete(s.completed, name)
+	}
+}
+
+func (s *State) complete(ctx *installbase.StageContext, name string) error {
+	s.completed[name] = time.Now().Format(time.RFC3339)
+	return s.save(ctx)
+}
+
+func (s *State) save(ctx *installbase.StageContext) error {
+	// NOTE: The namespace may not exist yet if the first stage doesn't create it,
+	// and it's not updated here, in case of dropping the labels of the installed one.
+	_, err := ctx.Client.CoreV1().Namespaces().Get(context.TODO(), ctx.Flags.MeshNamespace, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		err = installbase.DeployNamespace(&v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: ctx.Flags.MeshNamespace},
+		}, ctx.Client)
+	}
+	if err != nil {
+		return err
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      installbase.InstallationStateConfigMapName,
+			Namespace: ctx.Flags.MeshNamespace,
+		},
+		Data: s.completed,
+	}
+	return installbase.DeployConfigMap(configMap, ctx.Client, ctx.Flags.MeshNamespace)
+}
+
+// ForgetStages removes the stages from the persisted State, so that they are installed
+// again in a resumed installation.
+func ForgetStages(ctx *installbase.StageContext, names ...string) error {
+	state, err := LoadState(ctx)
+	if err != nil {
+		return err
+	}
+	if len(state.completed) == 0 {
+		return nil
+	}
+
+	state.Reset(names...)
+	return state.save(ctx)
+}
+
+// ClearState deletes the persisted State.
+func ClearState(ctx *installbase.StageContext) error {
+	return installbase.DeleteCoreV1Resource(ctx.Client, "configmaps", ctx.Flags.MeshNamespace,
+		installbase.InstallationStateConfigMapName)
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	certManagerAPIVersion     = "cert-manager.io/v1"
+	certManagerCertificateCRD = "certificates.cert-manager.io"
+	// certManagerInjectCAAnnotation makes the CA injector of cert-manager fill the caBundle of the webhook.
+	certManagerInjectCAAnnotation = "cert-manager.io/inject-ca-from"
+)
+
+// checkCertManager checks if cert-manager is installed in the cluster.
+func checkCertManager(ctx *installbase.StageContext) error {
+	_, err := ctx.APIExtensionsClient.ApiextensionsV1().CustomResourceDefinitions().
+		Get(context.TODO(), certManagerCertificateCRD, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return errors.Errorf("cert-manager is not installed (CustomResourceDefinition %s not found), "+
+			"install it or use another --webhook-cert-mode", certManagerCertificateCRD)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "get CustomResourceDefinition %s failed", certManagerCertificateCRD)
+	}
+	return nil
+}
+
+// issuerSpec creates a self-signed Issuer of cert-manager for the webhook certificate.
+func issuerSpec(ctx *installbase.StageContext) installbase.InstallFunc {
+	issuer := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selfSigned": map[string]interface{}{},
+		},
+	}}
+	issuer.SetAPIVersion(certManagerAPIVersion)
+	issuer.SetKind("Issuer")
+	issuer.SetName(installbase.OperatorIssuerName)
+	issuer.SetNamespace(ctx.Flags.MeshNamespace)
+
+	return func(ctx *installbase.StageContext) error {
+		if ctx.Flags.WebhookCertMode != flags.WebhookCertModeCertManager {
+			return nil
+		}
+
+		err := installbase.DeployCustomResource(issuer, "issuers", ctx.Client)
+		if err != nil {
+			return errors.Wrapf(err, "deploy issuer %s failed", issuer.GetName())
+		}
+		return nil
+	}
+}
+
+// certificateSpec creates the Certificate of cert-manager, which issues the secret of the webhook
+// and renews it before expiry.
+func certificateSpec(ctx *installbase.StageContext) installbase.InstallFunc {
+	dnsNames := []interface{}{}
+	for _, name := range operatorDNSNames(ctx.Flags.MeshNamespace) {
+		dnsNames = append(dnsNames, name)
+	}
+
+	certificate := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"secretName":  installbase.OperatorSecretName,
+			"commonName":  fmt.Sprintf("%s.%s.svc", installbase.OperatorServiceName, ctx.Flags.MeshNamespace),
+			"dnsNames":    dnsNames,
+			"duration":    servingCertValidity.String(),
+			"renewBefore": (servingCertValidity / 3).String(),
+			"issuerRef": map[string]interface{}{
+				"name": installbase.OperatorIssuerName,
+				"kind": "Issuer",
+			},
+		},
+	}}
+	certificate.SetAPIVersion(certManagerAPIVersion)
+	certificate.SetKind("Certificate")
+	certificate.SetName(installbase.OperatorCertificateName)
+	certificate.SetNamespace(ctx.Flags.MeshNamespace)
+
+	return func(ctx *installbase.StageContext) error {
+		if ctx.Flags.WebhookCertMode != flags.WebhookCertModeCertManager {
+			return nil
+		}
+
+		err := installbase.DeployCustomResource(certificate, "certificates", ctx.Client)
+		if err != nil {
+			return errors.Wrapf(err, "deploy certificate %s failed", certificate.GetName())
+		}
+		return nil
+	}
+}
+
+func clearCertManager(ctx *installbase.StageContext) {
+	if ctx.Flags.WebhookCertMode != flags.WebhookCertModeCertManager {
+		return
+	}
+
+	for _, resource := range [][]string{
+		{"certificates", installbase.OperatorCertificateName},
+		{"issuers", installbase.OperatorIssuerName},
+	} {
+		err := installbase.DeleteCustomResource(ctx.Client, certManagerAPIVersion, resource[0],
+			ctx.Flags.MeshNamespace, resource[1])
+		if err != nil {
+			common.OutputErrorf("clear resource %s of %s in %s error: %s\n", resource[1], resource[0], ctx.Flags.MeshNamespace, err)
+		}
+	}
+}
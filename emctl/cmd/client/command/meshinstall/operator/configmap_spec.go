@@ -42,11 +42,21 @@ func configMapSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 		CertDir:                   installbase.OperatorSecretVolumeMountPath,
 		CertName:                  installbase.OperatorSecretCertFileName,
 		KeyName:                   installbase.OperatorSecretKeyFileName,
-		SidecarImageName:          installbase.SidecarImageName,
-		AgentInitializerImageName: installbase.AgentInitializerImageName,
+		WebhookCertMode:           ctx.Flags.WebhookCertMode,
+		CertSecretName:            installbase.OperatorSecretName,
+		CertSecretNamespace:       ctx.Flags.MeshNamespace,
+		MutatingWebhookName:       installbase.OperatorMutatingWebhookName,
+		ValidatingWebhookName:     installbase.OperatorValidatingWebhookName,
+		SidecarImageName:          ctx.Flags.SidecarImage,
+		AgentInitializerImageName: ctx.Flags.AgentInitializerImage,
 		Log4jConfigName:           installbase.AgentLog4jConfigName,
 	}
 
+	if ctx.Flags.WebhookCertMode == flags.WebhookCertModeCertManager {
+		cfg.CertName = installbase.OperatorCertManagerCertFileName
+		cfg.KeyName = installbase.OperatorCertManagerKeyFileName
+	}
+
 	configMap := &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      installbase.OperatorConfigMapName,
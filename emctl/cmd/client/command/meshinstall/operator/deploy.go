@@ -46,6 +46,8 @@ const (
 func Deploy(ctx *installbase.StageContext) error {
 	err := installbase.BatchDeployResources(ctx,
 		[]installbase.InstallFunc{
+			issuerSpec(ctx),
+			certificateSpec(ctx),
 			secretSpec(ctx),
 			configMapSpec(ctx),
 			roleSpec(ctx),
@@ -54,21 +56,37 @@ func Deploy(ctx *installbase.StageContext) error {
 			clusterRoleBindingSpec(ctx),
 
 			operatorDeploymentSpec(ctx),
+			podDisruptionBudgetSpec(ctx),
 
 			serviceSpec(ctx),
 			mutatingWebhookSpec(ctx),
+			validatingWebhookSpec(ctx),
 		})
 	if err != nil {
 		return err
 	}
 
+	if ctx.Rendering() {
+		return nil
+	}
+
 	return checkOperatorStatus(ctx.Client, ctx.Flags)
 }
 
 // PreCheck check prerequisite for installing mesh operator
 func PreCheck(context *installbase.StageContext) error {
-	// Do nothing
-	return nil
+	switch context.Flags.WebhookCertMode {
+	case flags.WebhookCertModeCA, flags.WebhookCertModeCSR:
+		return nil
+	case flags.WebhookCertModeCertManager:
+		if context.Rendering() {
+			return nil
+		}
+		return checkCertManager(context)
+	default:
+		return errors.Errorf("unknown webhook cert mode %s (support %s, %s, %s)", context.Flags.WebhookCertMode,
+			flags.WebhookCertModeCA, flags.WebhookCertModeCSR, flags.WebhookCertModeCertManager)
+	}
 }
 
 // Clear clears all k8s resources about operator
@@ -100,10 +118,18 @@ func Clear(context *installbase.StageContext) error {
 
 	admissionregV1Resources := [][]string{
 		{"mutatingwebhookconfigurations", installbase.OperatorMutatingWebhookName},
+		{"validatingwebhookconfigurations", installbase.OperatorValidatingWebhookName},
+	}
+
+	policyV1Resources := [][]string{
+		{"poddisruptionbudgets", installbase.OperatorDeploymentName},
 	}
 
+	clearCertManager(context)
 	installbase.DeleteResources(context.Client, certificateV1BetaResources,
 		context.Flags.MeshNamespace, installbase.DeleteCertificateV1Beta1Resources)
+	installbase.DeleteResources(context.Client, policyV1Resources,
+		context.Flags.MeshNamespace, installbase.DeletePolicyV1Resources)
 	installbase.DeleteResources(context.Client, appsV1Resources,
 		context.Flags.MeshNamespace, installbase.DeleteAppsV1Resource)
 	installbase.DeleteResources(context.Client, coreV1Resources,
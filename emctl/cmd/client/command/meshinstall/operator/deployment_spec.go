@@ -28,13 +28,16 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const operatorContainerName = "operator-manager"
+
 type deploymentSpecFunc func(ctx *installbase.StageContext) *appsV1.Deployment
 
 func operatorDeploymentSpec(ctx *installbase.StageContext) installbase.InstallFunc {
-	deployment := deploymentConfigVolumeSpec(
-		deploymentManagerContainerSpec(
-			deploymentRBACContainerSpec(
-				deploymentBaseSpec(deploymentInitialize(nil)))))(ctx)
+	deployment := deploymentComponentSpec(
+		deploymentConfigVolumeSpec(
+			deploymentManagerContainerSpec(
+				deploymentRBACContainerSpec(
+					deploymentBaseSpec(deploymentInitialize(nil))))))(ctx)
 
 	return func(ctx *installbase.StageContext) error {
 		err := installbase.DeployDeployment(deployment, ctx.Client, ctx.Flags.MeshNamespace)
@@ -85,7 +88,7 @@ func deploymentRBACContainerSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 		spec := fn(ctx)
 		rbacContainer := v1.Container{}
 		rbacContainer.Name = "kube-rbac-proxy"
-		rbacContainer.Image = "gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0"
+		rbacContainer.Image = ctx.Flags.RBACProxyImage
 		rbacContainer.Ports = []v1.ContainerPort{
 			{
 				Name:          "https",
@@ -133,7 +136,7 @@ func deploymentConfigVolumeSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 func deploymentManagerContainerSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 	return func(ctx *installbase.StageContext) *appsV1.Deployment {
 		spec := fn(ctx)
-		container, _ := installbase.AcceptContainerVisitor("operator-manager",
+		container, _ := installbase.AcceptContainerVisitor(operatorContainerName,
 			ctx.Flags.ImageRegistryURL+"/"+ctx.Flags.EaseMeshOperatorImage,
 			v1.PullPolicy(ctx.Flags.ImagePullPolicy),
 			newVisitor(ctx))
@@ -143,6 +146,19 @@ func deploymentManagerContainerSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 	}
 }
 
+func deploymentComponentSpec(fn deploymentSpecFunc) deploymentSpecFunc {
+	return func(ctx *installbase.StageContext) *appsV1.Deployment {
+		spec := fn(ctx)
+		installbase.ApplyComponent(&spec.Spec.Template, operatorContainerName, ctx.Flags.Components.Operator)
+		return spec
+	}
+}
+
+func podDisruptionBudgetSpec(ctx *installbase.StageContext) installbase.InstallFunc {
+	return installbase.PodDisruptionBudgetInstallFunc(installbase.OperatorDeploymentName,
+		ctx.Flags.MeshNamespace, meshOperatorLabels(), ctx.Flags.Components.Operator)
+}
+
 func newVisitor(ctx *installbase.StageContext) installbase.ContainerVisitor {
 	return &containerVisitor{ctx: ctx}
 }
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
 	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
 
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
@@ -32,6 +33,8 @@ func mutatingWebhookSpec(ctx *installbase.StageContext) installbase.InstallFunc
 	mutatingPort := int32(installbase.OperatorMutatingWebhookPort)
 	mutatingScope := admissionregv1.NamespacedScope
 	mutatingSideEffects := admissionregv1.SideEffectClassNoneOnDryRun
+	localityPath := installbase.OperatorLocalityWebhookPath
+	localityFailurePolicy := admissionregv1.Ignore
 
 	mutatingWebhookConfig := func(caBundle []byte) *admissionregv1.MutatingWebhookConfiguration {
 		return &admissionregv1.MutatingWebhookConfiguration{
@@ -88,24 +91,61 @@ func mutatingWebhookSpec(ctx *installbase.StageContext) installbase.InstallFunc
 					SideEffects:             &mutatingSideEffects,
 					AdmissionReviewVersions: []string{"v1"},
 				},
+				{
+					// NOTE: It copies the topology labels of the node to the injected pod
+					// when it's bound, the binding is never blocked by it.
+					Name: "mesh-locality.megaease.com",
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{
+								Key:      "kubernetes.io/metadata.name",
+								Operator: metav1.LabelSelectorOpNotIn,
+								Values: []string{
+									ctx.Flags.MeshNamespace,
+									"kube-system",
+									"kube-public",
+								},
+							},
+						},
+					},
+					ClientConfig: admissionregv1.WebhookClientConfig{
+						Service: &admissionregv1.ServiceReference{
+							Name:      installbase.OperatorServiceName,
+							Namespace: ctx.Flags.MeshNamespace,
+							Path:      &localityPath,
+							Port:      &mutatingPort,
+						},
+						CABundle: caBundle,
+					},
+					Rules: []admissionregv1.RuleWithOperations{
+						{
+							Operations: []admissionregv1.OperationType{
+								admissionregv1.Create,
+							},
+							Rule: admissionregv1.Rule{
+								APIGroups:   []string{""},
+								APIVersions: []string{"v1"},
+								Resources:   []string{"pods/binding"},
+								Scope:       &mutatingScope,
+							},
+						},
+					},
+					FailurePolicy:           &localityFailurePolicy,
+					SideEffects:             &mutatingSideEffects,
+					AdmissionReviewVersions: []string{"v1"},
+				},
 			},
 		}
 	}
 
 	return func(ctx *installbase.StageContext) error {
-		secret, err := ctx.Client.CoreV1().Secrets(ctx.Flags.MeshNamespace).Get(context.TODO(), installbase.OperatorSecretName, metav1.GetOptions{})
+		caBundle, err := webhookCABundle(ctx)
 		if err != nil {
 			return err
 		}
 
-		certBase64, exists := secret.Data[installbase.OperatorSecretCertFileName]
-		if !exists {
-			return fmt.Errorf("key %v in secret %s not found",
-				installbase.OperatorSecretCertFileName,
-				installbase.OperatorSecretName)
-		}
-
-		config := mutatingWebhookConfig(certBase64)
+		config := mutatingWebhookConfig(caBundle)
+		config.Annotations = webhookAnnotations(ctx)
 
 		err = installbase.DeployMutatingWebhookConfig(config, ctx.Client, ctx.Flags.MeshNamespace)
 		if err != nil {
@@ -114,3 +154,41 @@ func mutatingWebhookSpec(ctx *installbase.StageContext) installbase.InstallFunc
 		return err
 	}
 }
+
+// webhookCABundle returns the CA bundle of the webhooks of the operator,
+// it's nil in mode cert-manager.
+func webhookCABundle(ctx *installbase.StageContext) ([]byte, error) {
+	if ctx.Flags.WebhookCertMode == flags.WebhookCertModeCertManager {
+		// NOTE: The CA injector of cert-manager fills the caBundle and keeps it up to date.
+		return nil, nil
+	}
+
+	secret, err := ctx.Client.CoreV1().Secrets(ctx.Flags.MeshNamespace).Get(context.TODO(), installbase.OperatorSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: The certificate signed by the cluster or itself works as the CA bundle if there's no CA.
+	caBundle, exists := secret.Data[installbase.OperatorSecretCABundleFileName]
+	if !exists {
+		caBundle, exists = secret.Data[installbase.OperatorSecretCertFileName]
+	}
+	if !exists {
+		return nil, fmt.Errorf("key %v in secret %s not found",
+			installbase.OperatorSecretCertFileName,
+			installbase.OperatorSecretName)
+	}
+
+	return caBundle, nil
+}
+
+// webhookAnnotations returns the annotations of the webhook configurations of the operator.
+func webhookAnnotations(ctx *installbase.StageContext) map[string]string {
+	if ctx.Flags.WebhookCertMode != flags.WebhookCertModeCertManager {
+		return nil
+	}
+
+	return map[string]string{
+		certManagerInjectCAAnnotation: ctx.Flags.MeshNamespace + "/" + installbase.OperatorCertificateName,
+	}
+}
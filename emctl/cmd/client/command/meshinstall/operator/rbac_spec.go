@@ -68,7 +68,7 @@ func clusterRoleSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 		Rules: []rbacv1.PolicyRule{
 			{
 				APIGroups: []string{"apps"},
-				Resources: []string{"deployments", "statefulsets"},
+				Resources: []string{"deployments", "statefulsets", "daemonsets"},
 				Verbs:     []string{roleVerbGet, roleVerbList, roleVerbWatch, roleVerbCreate, roleVerbUpdate, roleVerbPatch, roleVerbDelete},
 			},
 			{
@@ -76,6 +76,16 @@ func clusterRoleSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 				Resources: []string{"pods", "configmaps", "secrets"},
 				Verbs:     []string{roleVerbGet, roleVerbList, roleVerbWatch, roleVerbCreate, roleVerbUpdate, roleVerbPatch, roleVerbDelete},
 			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"nodes"},
+				Verbs:     []string{roleVerbGet},
+			},
+			{
+				APIGroups: []string{"admissionregistration.k8s.io"},
+				Resources: []string{"mutatingwebhookconfigurations", "validatingwebhookconfigurations"},
+				Verbs:     []string{roleVerbGet, roleVerbList, roleVerbWatch, roleVerbUpdate, roleVerbPatch},
+			},
 			{
 				APIGroups: []string{"mesh.megaease.com"},
 				Resources: []string{"meshdeployments"},
@@ -91,6 +101,51 @@ func clusterRoleSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 				Resources: []string{"meshdeployments/status"},
 				Verbs:     []string{roleVerbGet, roleVerbPatch, roleVerbUpdate},
 			},
+			{
+				APIGroups: []string{"mesh.megaease.com"},
+				Resources: []string{"meshfederations"},
+				Verbs:     []string{roleVerbGet, roleVerbList, roleVerbWatch, roleVerbCreate, roleVerbUpdate, roleVerbPatch, roleVerbDelete},
+			},
+			{
+				APIGroups: []string{"mesh.megaease.com"},
+				Resources: []string{"meshfederations/finalizers"},
+				Verbs:     []string{roleVerbUpdate},
+			},
+			{
+				APIGroups: []string{"mesh.megaease.com"},
+				Resources: []string{"meshfederations/status"},
+				Verbs:     []string{roleVerbGet, roleVerbPatch, roleVerbUpdate},
+			},
+			{
+				APIGroups: []string{"mesh.megaease.com"},
+				Resources: []string{"meshstatefulsets"},
+				Verbs:     []string{roleVerbGet, roleVerbList, roleVerbWatch, roleVerbCreate, roleVerbUpdate, roleVerbPatch, roleVerbDelete},
+			},
+			{
+				APIGroups: []string{"mesh.megaease.com"},
+				Resources: []string{"meshstatefulsets/finalizers"},
+				Verbs:     []string{roleVerbUpdate},
+			},
+			{
+				APIGroups: []string{"mesh.megaease.com"},
+				Resources: []string{"meshstatefulsets/status"},
+				Verbs:     []string{roleVerbGet, roleVerbPatch, roleVerbUpdate},
+			},
+			{
+				APIGroups: []string{"mesh.megaease.com"},
+				Resources: []string{"meshdaemonsets"},
+				Verbs:     []string{roleVerbGet, roleVerbList, roleVerbWatch, roleVerbCreate, roleVerbUpdate, roleVerbPatch, roleVerbDelete},
+			},
+			{
+				APIGroups: []string{"mesh.megaease.com"},
+				Resources: []string{"meshdaemonsets/finalizers"},
+				Verbs:     []string{roleVerbUpdate},
+			},
+			{
+				APIGroups: []string{"mesh.megaease.com"},
+				Resources: []string{"meshdaemonsets/status"},
+				Verbs:     []string{roleVerbGet, roleVerbPatch, roleVerbUpdate},
+			},
 		},
 	}
 
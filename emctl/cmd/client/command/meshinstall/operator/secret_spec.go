@@ -26,7 +26,10 @@ import (
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
+	"math/big"
+	"time"
 
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
 	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
 
 	v1 "k8s.io/api/core/v1"
@@ -34,6 +37,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// selfSignedCertValidity is the validity of the self-signed certificate of the webhook.
+	selfSignedCertValidity = 10 * 365 * 24 * time.Hour
+	// caValidity is the validity of the CA signing the certificate of the webhook.
+	caValidity = 5 * 365 * 24 * time.Hour
+	// servingCertValidity is the validity of the certificate of the webhook signed by the CA,
+	// the operator rotates it before expiry.
+	servingCertValidity = 365 * 24 * time.Hour
+)
+
 func secretSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 	secret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -44,24 +57,55 @@ func secretSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 	}
 
 	return func(ctx *installbase.StageContext) error {
+		// NOTE: cert-manager issues the secret by the Certificate.
+		if ctx.Flags.WebhookCertMode == flags.WebhookCertModeCertManager {
+			return nil
+		}
+
 		_, err := ctx.Client.CoreV1().Secrets(ctx.Flags.MeshNamespace).Get(context.TODO(),
 			secret.Name, metav1.GetOptions{})
 		if err == nil {
-			fmt.Printf("\nsecret %s existed, won't create it again\n\n", secret.Name)
+			fmt.Fprintf(ctx.Out(), "\nsecret %s existed, won't create it again\n\n", secret.Name)
 			return nil
 		} else if !errors.IsNotFound(err) {
 			return fmt.Errorf("deploy secret %s/%s failed: %v",
 				ctx.Flags.MeshNamespace, secret.Name, err)
 		}
 
-		csrPem, keyPem, err := generateCsrAndKeyPem(ctx.Flags.MeshNamespace)
-		if err != nil {
-			return fmt.Errorf("generate csr and key failed: %v", err)
-		}
+		var certPem, keyPem []byte
+		switch {
+		case ctx.Flags.WebhookCertMode == flags.WebhookCertModeCA:
+			caPem, caKeyPem, err := generateCAPem()
+			if err != nil {
+				return fmt.Errorf("generate CA failed: %v", err)
+			}
 
-		certPem, err := deployCSR(ctx, csrPem, keyPem)
-		if err != nil {
-			return fmt.Errorf("deploy CertificateSigningRequest failed: %v", err)
+			certPem, keyPem, err = generateServingCertPem(ctx.Flags.MeshNamespace, caPem, caKeyPem)
+			if err != nil {
+				return fmt.Errorf("generate cert and key failed: %v", err)
+			}
+
+			secret.Data[installbase.OperatorSecretCAFileName] = caPem
+			secret.Data[installbase.OperatorSecretCAKeyFileName] = caKeyPem
+			secret.Data[installbase.OperatorSecretCABundleFileName] = caPem
+		case ctx.Rendering():
+			// NOTE: No signer is available in rendering, so the certificate signs itself,
+			// and it works as the CA bundle of the webhook as well.
+			certPem, keyPem, err = generateSelfSignedCertAndKeyPem(ctx.Flags.MeshNamespace)
+			if err != nil {
+				return fmt.Errorf("generate self-signed cert and key failed: %v", err)
+			}
+		default:
+			var csrPem []byte
+			csrPem, keyPem, err = generateCsrAndKeyPem(ctx.Flags.MeshNamespace)
+			if err != nil {
+				return fmt.Errorf("generate csr and key failed: %v", err)
+			}
+
+			certPem, err = deployCSR(ctx, csrPem, keyPem)
+			if err != nil {
+				return fmt.Errorf("deploy CertificateSigningRequest failed: %v", err)
+			}
 		}
 
 		// NOTE: []byte will be automatically encoded as a base64-encoded string.
@@ -78,6 +122,157 @@ func secretSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 	}
 }
 
+func operatorDNSNames(namespace string) []string {
+	return []string{
+		installbase.OperatorServiceName,
+		fmt.Sprintf("%s.%s", installbase.OperatorServiceName, namespace),
+		fmt.Sprintf("%s.%s.svc", installbase.OperatorServiceName, namespace),
+	}
+}
+
+func generateSelfSignedCertAndKeyPem(namespace string) ([]byte, []byte, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"MegaEase"},
+			CommonName:   fmt.Sprintf("%s.%s.svc", installbase.OperatorServiceName, namespace),
+		},
+		DNSNames:              operatorDNSNames(namespace),
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedCertValidity),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encodeCertAndKeyPem(certBytes, privateKey)
+}
+
+// generateCAPem generates the CA signing the certificate of the webhook.
+func generateCAPem() ([]byte, []byte, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"MegaEase"},
+			CommonName:   "easemesh-operator-ca",
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encodeCertAndKeyPem(certBytes, privateKey)
+}
+
+// generateServingCertPem generates the certificate of the webhook signed by the CA.
+func generateServingCertPem(namespace string, caPem, caKeyPem []byte) ([]byte, []byte, error) {
+	caBlock, _ := pem.Decode(caPem)
+	if caBlock == nil {
+		return nil, nil, fmt.Errorf("no PEM certificate in the CA")
+	}
+	ca, err := x509.ParseCertificate(caBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	caKeyBlock, _ := pem.Decode(caKeyPem)
+	if caKeyBlock == nil {
+		return nil, nil, fmt.Errorf("no PEM key in the CA key")
+	}
+	caKey, err := x509.ParsePKCS1PrivateKey(caKeyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"MegaEase"},
+			CommonName:   fmt.Sprintf("%s.%s.svc", installbase.OperatorServiceName, namespace),
+		},
+		DNSNames:    operatorDNSNames(namespace),
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(servingCertValidity),
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, ca, &privateKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encodeCertAndKeyPem(certBytes, privateKey)
+}
+
+func encodeCertAndKeyPem(certBytes []byte, privateKey *rsa.PrivateKey) ([]byte, []byte, error) {
+	certBuffer := &bytes.Buffer{}
+	err := pem.Encode(certBuffer, &pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: certBytes,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBuffer := &bytes.Buffer{}
+	err = pem.Encode(keyBuffer, &pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return certBuffer.Bytes(), keyBuffer.Bytes(), nil
+}
+
 func generateCsrAndKeyPem(namespace string) ([]byte, []byte, error) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
@@ -90,11 +285,7 @@ func generateCsrAndKeyPem(namespace string) ([]byte, []byte, error) {
 			CommonName:   "system:node:MegaEase",
 		},
 
-		DNSNames: []string{
-			installbase.OperatorServiceName,
-			fmt.Sprintf("%s.%s", installbase.OperatorServiceName, namespace),
-			fmt.Sprintf("%s.%s.svc", installbase.OperatorServiceName, namespace),
-		},
+		DNSNames: operatorDNSNames(namespace),
 
 		SignatureAlgorithm: x509.SHA256WithRSA,
 	}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package operator
+
+import (
+	"fmt"
+
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func validatingWebhookSpec(ctx *installbase.StageContext) installbase.InstallFunc {
+	validatingPath := installbase.OperatorValidatingWebhookPath
+	validatingPort := int32(installbase.OperatorMutatingWebhookPort)
+	validatingScope := admissionregv1.NamespacedScope
+	validatingSideEffects := admissionregv1.SideEffectClassNone
+	// NOTE: The invalid objects mustn't slip through while the operator is unavailable,
+	// which blocks the same workloads as the mutating webhook does.
+	validatingFailurePolicy := admissionregv1.Fail
+
+	validatingWebhookConfig := func(caBundle []byte) *admissionregv1.ValidatingWebhookConfiguration {
+		return &admissionregv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      installbase.OperatorValidatingWebhookName,
+				Namespace: ctx.Flags.MeshNamespace,
+			},
+			Webhooks: []admissionregv1.ValidatingWebhook{
+				{
+					Name: "mesh-validator.megaease.com",
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{
+								Key:      "kubernetes.io/metadata.name",
+								Operator: metav1.LabelSelectorOpNotIn,
+								Values: []string{
+									ctx.Flags.MeshNamespace,
+									"kube-system",
+									"kube-public",
+								},
+							},
+						},
+					},
+					ClientConfig: admissionregv1.WebhookClientConfig{
+						Service: &admissionregv1.ServiceReference{
+							Name:      installbase.OperatorServiceName,
+							Namespace: ctx.Flags.MeshNamespace,
+							Path:      &validatingPath,
+							Port:      &validatingPort,
+						},
+						CABundle: caBundle,
+					},
+					Rules: []admissionregv1.RuleWithOperations{
+						{
+							Operations: []admissionregv1.OperationType{
+								admissionregv1.Create,
+								admissionregv1.Update,
+							},
+							Rule: admissionregv1.Rule{
+								APIGroups:   []string{"apps"},
+								APIVersions: []string{"v1"},
+								Resources: []string{
+									"replicasets",
+									"deployments",
+									"statefulsets",
+									"daemonsets",
+								},
+								Scope: &validatingScope,
+							},
+						},
+						{
+							Operations: []admissionregv1.OperationType{
+								admissionregv1.Create,
+								admissionregv1.Update,
+							},
+							Rule: admissionregv1.Rule{
+								APIGroups:   []string{"mesh.megaease.com"},
+								APIVersions: []string{"v1beta1"},
+								Resources: []string{
+									"meshdeployments",
+									"meshstatefulsets",
+									"meshdaemonsets",
+								},
+								Scope: &validatingScope,
+							},
+						},
+					},
+					FailurePolicy:           &validatingFailurePolicy,
+					SideEffects:             &validatingSideEffects,
+					AdmissionReviewVersions: []string{"v1"},
+				},
+			},
+		}
+	}
+
+	return func(ctx *installbase.StageContext) error {
+		caBundle, err := webhookCABundle(ctx)
+		if err != nil {
+			return err
+		}
+
+		config := validatingWebhookConfig(caBundle)
+		config.Annotations = webhookAnnotations(ctx)
+
+		err = installbase.DeployValidatingWebhookConfig(config, ctx.Client, ctx.Flags.MeshNamespace)
+		if err != nil {
+			return fmt.Errorf("create validating webhook configuration failed: %v ", err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shadowservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/addon"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/pkg/version"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AddOnName is the name of the shadow service add-on.
+const AddOnName = "shadowservice"
+
+func init() {
+	addon.Register(&addon.AddOn{
+		Name: AddOnName,
+		// NOTE: The built-in add-ons are released with emctl, whose components are
+		// pinned by the release in the components manifest.
+		Version:       addOnVersion(),
+		Description:   "Shadow service controller, which replicates services and their traffic for testing",
+		PreCheck:      PreCheck,
+		Deploy:        Deploy,
+		Clear:         Clear,
+		DescribePhase: DescribePhase,
+		Status:        Status,
+		ChartNotes: "The custom resource kind ShadowService lives in the mesh control plane, " +
+			"create it by emctl after the control plane is running:\n" +
+			"  emctl addon install shadowservice --mesh-namespace {{ .Release.Namespace }}",
+	})
+}
+
+// addOnVersion returns the release of the components which the add-on deploys,
+// the builds without a known release use the latest one in the manifest.
+func addOnVersion() string {
+	r, err := version.CurrentComponents()
+	if err != nil {
+		return version.RELEASE
+	}
+	return r.Release
+}
+
+// Status reports the readiness of the shadow service controller.
+func Status(ctx *installbase.StageContext) (string, error) {
+	deployment, err := ctx.Client.AppsV1().Deployments(ctx.Flags.MeshNamespace).Get(context.TODO(),
+		installbase.IngressControllerShadowServiceName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := fmt.Sprintf("%d/%d ready", deployment.Status.ReadyReplicas, replicas)
+	if !installbase.DeploymentReadyPredict(deployment) {
+		return status, errors.Errorf("deployment %s is not ready: %s", deployment.Name, status)
+	}
+	return status, nil
+}
@@ -18,6 +18,8 @@
 package shadowservice
 
 import (
+	"fmt"
+
 	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
 	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
 	"github.com/megaease/easemeshctl/cmd/client/resource"
@@ -104,6 +106,13 @@ spec:
 
 func shadowServiceKindSpec(ctx *installbase.StageContext) installbase.InstallFunc {
 	return func(ctx *installbase.StageContext) error {
+		// NOTE: The kind lives in the mesh control plane rather than Kubernetes.
+		if ctx.Rendering() {
+			fmt.Fprintf(ctx.Out(), "ignored: custom resource kind ShadowService is not rendered, "+
+				"apply it with emctl after the control plane is running:\n%s\n", shadowServiceKind)
+			return nil
+		}
+
 		entrypoints, err := installbase.GetMeshControlPlaneEndpoints(ctx.Client, ctx.Flags.MeshNamespace,
 			installbase.ControlPlanePlubicServiceName,
 			installbase.ControlPlaneStatefulSetAdminPortName)
@@ -34,12 +34,17 @@ func Deploy(ctx *installbase.StageContext) error {
 		clusterRoleSpec(ctx),
 		clusterRoleBindingSpec(ctx),
 		deploymentSpec(ctx),
+		podDisruptionBudgetSpec(ctx),
 		shadowServiceKindSpec(ctx),
 	})
 	if err != nil {
 		return err
 	}
 
+	if ctx.Rendering() {
+		return nil
+	}
+
 	return checkShadowServiceStatus(ctx.Client, ctx.Flags)
 }
 
@@ -54,6 +59,10 @@ func Clear(context *installbase.StageContext) error {
 	appsV1Resources := [][]string{
 		{"deployments", installbase.IngressControllerShadowServiceName},
 	}
+	policyV1Resources := [][]string{
+		{"poddisruptionbudgets", installbase.IngressControllerShadowServiceName},
+	}
+	installbase.DeleteResources(context.Client, policyV1Resources, context.Flags.MeshNamespace, installbase.DeletePolicyV1Resources)
 	installbase.DeleteResources(context.Client, appsV1Resources, context.Flags.MeshNamespace, installbase.DeleteAppsV1Resource)
 	return nil
 }
@@ -21,8 +21,10 @@ import (
 	"testing"
 
 	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/addon"
 	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
 	meshtesting "github.com/megaease/easemeshctl/cmd/client/testing"
+	"github.com/megaease/easemeshctl/pkg/version"
 
 	"github.com/spf13/cobra"
 	extensionfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
@@ -58,3 +60,18 @@ func TestDescribePhase(t *testing.T) {
 	DescribePhase(ctx, installbase.ErrorPhase)
 	PreCheck(ctx)
 }
+
+func TestAddOnVersion(t *testing.T) {
+	a, exists := addon.Get(AddOnName)
+	if !exists {
+		t.Fatalf("add-on %s is not registered", AddOnName)
+	}
+
+	r, err := version.CurrentComponents()
+	if err != nil {
+		t.Fatalf("get current components failed: %v", err)
+	}
+	if a.Version == "" || a.Version != r.Release {
+		t.Fatalf("expect version %s of the components, but got %s", r.Release, a.Version)
+	}
+}
@@ -29,6 +29,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const shadowServiceContainerName = "shadowservice-controller"
+
 type deploymentSpecFunc func(*flags.Install) *appsV1.Deployment
 
 func shadowServiceLabel() map[string]string {
@@ -38,9 +40,10 @@ func shadowServiceLabel() map[string]string {
 }
 
 func deploymentSpec(ctx *installbase.StageContext) installbase.InstallFunc {
-	deployment := deploymentContainerSpec(
-		deploymentBaseSpec(
-			deploymentInitialize(nil)))(ctx.Flags)
+	deployment := deploymentComponentSpec(
+		deploymentContainerSpec(
+			deploymentBaseSpec(
+				deploymentInitialize(nil))))(ctx.Flags)
 
 	return func(ctx *installbase.StageContext) error {
 		err := installbase.DeployDeployment(deployment, ctx.Client, ctx.Flags.MeshNamespace)
@@ -74,7 +77,7 @@ func deploymentBaseSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 func deploymentContainerSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 	return func(installFlags *flags.Install) *appsV1.Deployment {
 		spec := fn(installFlags)
-		container, _ := installbase.AcceptContainerVisitor("shadowservice-controller",
+		container, _ := installbase.AcceptContainerVisitor(shadowServiceContainerName,
 			installFlags.ImageRegistryURL+"/"+installFlags.ShadowServiceControllerImage,
 			v1.PullPolicy(installFlags.ImagePullPolicy),
 			newVisitor(installFlags))
@@ -84,6 +87,19 @@ func deploymentContainerSpec(fn deploymentSpecFunc) deploymentSpecFunc {
 	}
 }
 
+func deploymentComponentSpec(fn deploymentSpecFunc) deploymentSpecFunc {
+	return func(installFlags *flags.Install) *appsV1.Deployment {
+		spec := fn(installFlags)
+		installbase.ApplyComponent(&spec.Spec.Template, shadowServiceContainerName, installFlags.Components.ShadowService)
+		return spec
+	}
+}
+
+func podDisruptionBudgetSpec(ctx *installbase.StageContext) installbase.InstallFunc {
+	return installbase.PodDisruptionBudgetInstallFunc(installbase.IngressControllerShadowServiceName,
+		ctx.Flags.MeshNamespace, shadowServiceLabel(), ctx.Flags.Components.ShadowService)
+}
+
 type containerVisitor struct {
 	installFlags *flags.Install
 }
@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrade
+
+import (
+	"context"
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	"github.com/pkg/errors"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	controlPlaneContainerName  = "easegress"
+	operatorContainerName      = "operator-manager"
+	shadowServiceContainerName = "shadowservice-controller"
+	shadowServiceAddOnName     = "shadowservice"
+)
+
+// Detect returns the install flags of the deployed EaseMesh, the flags which can't be
+// detected from the running objects keep the values of the given flags.
+func Detect(client kubernetes.Interface, base *flags.Install) (*flags.Install, error) {
+	detected := *base
+	operationGlobal := *base.OperationGlobal
+	detected.OperationGlobal = &operationGlobal
+	detected.AddOns = nil
+	namespace := detected.MeshNamespace
+
+	statefulSet, err := client.AppsV1().StatefulSets(namespace).Get(context.TODO(),
+		installbase.ControlPlaneStatefulSetName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, errors.Errorf("EaseMesh is not installed in the namespace %s", namespace)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get statefulset %s failed", installbase.ControlPlaneStatefulSetName)
+	}
+
+	container := findContainer(statefulSet.Spec.Template.Spec.Containers, controlPlaneContainerName)
+	if container != nil {
+		detected.ImageRegistryURL, detected.EasegressImage = splitImage(container.Image)
+		detected.ImagePullPolicy = string(container.ImagePullPolicy)
+	}
+	if statefulSet.Spec.Replicas != nil {
+		detected.EasegressControlPlaneReplicas = int(*statefulSet.Spec.Replicas)
+	}
+	detected.MeshControlPlaneNodeSelectors = statefulSet.Spec.Template.Spec.NodeSelector
+	for _, pvc := range statefulSet.Spec.VolumeClaimTemplates {
+		if pvc.Name != installbase.ControlPlanePVCName {
+			continue
+		}
+		if pvc.Spec.StorageClassName != nil {
+			detected.MeshControlPlaneStorageClassName = *pvc.Spec.StorageClassName
+		}
+		if capacity, exists := pvc.Spec.Resources.Requests[v1.ResourceStorage]; exists {
+			detected.MeshControlPlanePersistVolumeCapacity = capacity.String()
+		}
+	}
+
+	service, err := client.CoreV1().Services(namespace).Get(context.TODO(),
+		installbase.ControlPlanePlubicServiceName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "get service %s failed", installbase.ControlPlanePlubicServiceName)
+	}
+	if err == nil {
+		for _, port := range service.Spec.Ports {
+			switch port.Name {
+			case installbase.ControlPlaneStatefulSetAdminPortName:
+				detected.EgAdminPort = int(port.Port)
+			case installbase.ControlPlaneStatefulSetPeerPortName:
+				detected.EgPeerPort = int(port.Port)
+			case installbase.ControlPlaneStatefulSetClientPortName:
+				detected.EgClientPort = int(port.Port)
+			}
+		}
+	}
+
+	operator, err := getDeployment(client, namespace, installbase.OperatorDeploymentName)
+	if err != nil {
+		return nil, err
+	}
+	if operator != nil {
+		if container := findContainer(operator.Spec.Template.Spec.Containers, operatorContainerName); container != nil {
+			_, detected.EaseMeshOperatorImage = splitImage(container.Image)
+		}
+		if operator.Spec.Replicas != nil {
+			detected.EaseMeshOperatorReplicas = int(*operator.Spec.Replicas)
+		}
+	}
+
+	secret, err := client.CoreV1().Secrets(namespace).Get(context.TODO(),
+		installbase.OperatorSecretName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "get secret %s failed", installbase.OperatorSecretName)
+	}
+	if err == nil {
+		detected.WebhookCertMode = detectWebhookCertMode(secret)
+	}
+
+	ingress, err := getDeployment(client, namespace, installbase.IngressControllerDeploymentName)
+	if err != nil {
+		return nil, err
+	}
+	if ingress != nil && ingress.Spec.Replicas != nil {
+		detected.MeshIngressReplicas = int(*ingress.Spec.Replicas)
+	}
+
+	service, err = client.CoreV1().Services(namespace).Get(context.TODO(),
+		installbase.IngressControllerServiceName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "get service %s failed", installbase.IngressControllerServiceName)
+	}
+	if err == nil && len(service.Spec.Ports) != 0 {
+		detected.MeshIngressServicePort = service.Spec.Ports[0].Port
+	}
+
+	shadowService, err := getDeployment(client, namespace, installbase.IngressControllerShadowServiceName)
+	if err != nil {
+		return nil, err
+	}
+	if shadowService != nil {
+		detected.AddOns = append(detected.AddOns, shadowServiceAddOnName)
+		if container := findContainer(shadowService.Spec.Template.Spec.Containers, shadowServiceContainerName); container != nil {
+			_, detected.ShadowServiceControllerImage = splitImage(container.Image)
+		}
+	}
+
+	return &detected, nil
+}
+
+// detectWebhookCertMode detects the mode by the keys of the webhook secret, the secrets
+// issued before the modes are added only have the certificate signed by the cluster.
+func detectWebhookCertMode(secret *v1.Secret) string {
+	if _, exists := secret.Data[installbase.OperatorCertManagerCertFileName]; exists {
+		return flags.WebhookCertModeCertManager
+	}
+	if _, exists := secret.Data[installbase.OperatorSecretCAFileName]; exists {
+		return flags.WebhookCertModeCA
+	}
+	return flags.WebhookCertModeCSR
+}
+
+func getDeployment(client kubernetes.Interface, namespace, name string) (*appsV1.Deployment, error) {
+	deployment, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get deployment %s failed", name)
+	}
+	return deployment, nil
+}
+
+func findContainer(containers []v1.Container, name string) *v1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+// splitImage splits the image into the registry and the name, the installation
+// always prefixes the image name with the registry URL.
+func splitImage(image string) (string, string) {
+	index := strings.Index(image, "/")
+	if index == -1 {
+		return flags.DefaultImageRegistryURL, image
+	}
+	return image[:index], image[index+1:]
+}
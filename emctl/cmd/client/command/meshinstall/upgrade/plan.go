@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/installation"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const noneValue = "<none>"
+
+type (
+	// Component is a component of the EaseMesh, the components are upgraded in order.
+	Component struct {
+		Name  string
+		Stage installation.InstallStage
+	}
+
+	// change is a difference between the deployed and the target object.
+	change struct {
+		kind    string
+		name    string
+		field   string
+		current string
+		target  string
+	}
+
+	// componentPlan holds the target manifests of a component and their differences to the deployed ones.
+	componentPlan struct {
+		component *Component
+		manifests []map[string]interface{}
+		changes   []*change
+	}
+)
+
+// newPlan renders the target manifests of components and compares them with the deployed objects.
+func newPlan(stageContext *installbase.StageContext, client kubernetes.Interface, components []*Component) ([]*componentPlan, error) {
+	var stages []installation.InstallStage
+	for _, c := range components {
+		stages = append(stages, c.Stage)
+	}
+
+	rendered, err := installation.Render(stageContext, stages...)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []*componentPlan
+	for i, c := range components {
+		p := &componentPlan{component: c, manifests: rendered[i]}
+		for _, manifest := range p.manifests {
+			changes, err := diff(client, manifest)
+			if err != nil {
+				return nil, err
+			}
+			p.changes = append(p.changes, changes...)
+		}
+		plans = append(plans, p)
+	}
+
+	return plans, nil
+}
+
+// diff compares the workloads and the configurations, which are changed by upgrades.
+func diff(client kubernetes.Interface, manifest map[string]interface{}) ([]*change, error) {
+	kind, _ := manifest["kind"].(string)
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+
+	switch kind {
+	case "StatefulSet":
+		target := &appsV1.StatefulSet{}
+		err := convert(manifest, target)
+		if err != nil {
+			return nil, err
+		}
+		current, err := client.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return []*change{newObjectChange(kind, name)}, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "get %s %s failed", kind, name)
+		}
+		return diffWorkload(kind, name, current.Spec.Replicas, target.Spec.Replicas,
+			&current.Spec.Template.Spec, &target.Spec.Template.Spec), nil
+	case "Deployment":
+		target := &appsV1.Deployment{}
+		err := convert(manifest, target)
+		if err != nil {
+			return nil, err
+		}
+		current, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return []*change{newObjectChange(kind, name)}, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "get %s %s failed", kind, name)
+		}
+		return diffWorkload(kind, name, current.Spec.Replicas, target.Spec.Replicas,
+			&current.Spec.Template.Spec, &target.Spec.Template.Spec), nil
+	case "ConfigMap":
+		target := &v1.ConfigMap{}
+		err := convert(manifest, target)
+		if err != nil {
+			return nil, err
+		}
+		current, err := client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return []*change{newObjectChange(kind, name)}, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "get %s %s failed", kind, name)
+		}
+		var changes []*change
+		for key, value := range target.Data {
+			if current.Data[key] != value {
+				changes = append(changes, &change{kind: kind, name: name, field: "data." + key,
+					current: "<modified>", target: "<modified>"})
+			}
+		}
+		return changes, nil
+	case "ValidatingWebhookConfiguration":
+		_, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return []*change{newObjectChange(kind, name)}, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "get %s %s failed", kind, name)
+		}
+		return nil, nil
+	}
+
+	return nil, nil
+}
+
+func diffWorkload(kind, name string, currentReplicas, targetReplicas *int32, current, target *v1.PodSpec) []*change {
+	var changes []*change
+	if !reflect.DeepEqual(currentReplicas, targetReplicas) {
+		changes = append(changes, &change{kind: kind, name: name, field: "replicas",
+			current: replicasString(currentReplicas), target: replicasString(targetReplicas)})
+	}
+
+	for _, t := range target.Containers {
+		currentImage := noneValue
+		if c := findContainer(current.Containers, t.Name); c != nil {
+			currentImage = c.Image
+		}
+		if currentImage != t.Image {
+			changes = append(changes, &change{kind: kind, name: name,
+				field: fmt.Sprintf("containers.%s.image", t.Name), current: currentImage, target: t.Image})
+		}
+	}
+
+	return changes
+}
+
+func newObjectChange(kind, name string) *change {
+	return &change{kind: kind, name: name, current: noneValue, target: "<new>"}
+}
+
+func replicasString(replicas *int32) string {
+	if replicas == nil {
+		return noneValue
+	}
+	return strconv.Itoa(int(*replicas))
+}
+
+// convert converts the manifest into the typed object.
+func convert(manifest map[string]interface{}, object interface{}) error {
+	buff, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buff, object)
+}
+
+func printPlans(w io.Writer, plans []*componentPlan) bool {
+	changed := false
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Component", "Kind", "Name", "Field", "Current", "Target"})
+	table.SetBorder(false)
+	table.SetRowLine(false)
+	table.SetColumnSeparator("")
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeaderLine(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+
+	for _, p := range plans {
+		for _, c := range p.changes {
+			changed = true
+			table.Append([]string{p.component.Name, c.kind, c.name, c.field, c.current, c.target})
+		}
+	}
+
+	if changed {
+		table.Render()
+	}
+	return changed
+}
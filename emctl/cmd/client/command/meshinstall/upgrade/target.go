@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrade
+
+import (
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/pkg/version"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// detectedFlags are the flags detected from the running objects, with the functions
+// copying the detected value into the target.
+var detectedFlags = []struct {
+	name string
+	copy func(target, detected *flags.Install)
+}{
+	{"image-registry-url", func(t, d *flags.Install) { t.ImageRegistryURL = d.ImageRegistryURL }},
+	{"image-pull-policy", func(t, d *flags.Install) { t.ImagePullPolicy = d.ImagePullPolicy }},
+	{"easegress-image", func(t, d *flags.Install) { t.EasegressImage = d.EasegressImage }},
+	{"easemesh-control-plane-replicas", func(t, d *flags.Install) { t.EasegressControlPlaneReplicas = d.EasegressControlPlaneReplicas }},
+	{"mesh-control-plane-client-port", func(t, d *flags.Install) { t.EgClientPort = d.EgClientPort }},
+	{"mesh-control-plane-peer-port", func(t, d *flags.Install) { t.EgPeerPort = d.EgPeerPort }},
+	{"mesh-control-plane-admin-port", func(t, d *flags.Install) { t.EgAdminPort = d.EgAdminPort }},
+	{"mesh-storage-class-name", func(t, d *flags.Install) {
+		t.MeshControlPlaneStorageClassName = d.MeshControlPlaneStorageClassName
+	}},
+	{"mesh-control-plane-pv-capacity", func(t, d *flags.Install) {
+		t.MeshControlPlanePersistVolumeCapacity = d.MeshControlPlanePersistVolumeCapacity
+	}},
+	{"mesh-control-plane-node-selectors", func(t, d *flags.Install) {
+		t.MeshControlPlaneNodeSelectors = d.MeshControlPlaneNodeSelectors
+	}},
+	{"easemesh-operator-image", func(t, d *flags.Install) { t.EaseMeshOperatorImage = d.EaseMeshOperatorImage }},
+	{"easemesh-operator-replicas", func(t, d *flags.Install) { t.EaseMeshOperatorReplicas = d.EaseMeshOperatorReplicas }},
+	{"easemesh-ingress-replicas", func(t, d *flags.Install) { t.MeshIngressReplicas = d.MeshIngressReplicas }},
+	{"mesh-ingress-service-port", func(t, d *flags.Install) { t.MeshIngressServicePort = d.MeshIngressServicePort }},
+	{"webhook-cert-mode", func(t, d *flags.Install) { t.WebhookCertMode = d.WebhookCertMode }},
+	{"shadowservice-controller-image", func(t, d *flags.Install) {
+		t.ShadowServiceControllerImage = d.ShadowServiceControllerImage
+	}},
+}
+
+// componentImages are the image flags of the components, the detected images released
+// by EaseMesh move to the ones of the release of emctl, while the custom ones are kept.
+var componentImages = map[string]struct {
+	component string
+	image     func(i *flags.Install) string
+}{
+	"easegress-image": {version.ComponentEasegress, func(i *flags.Install) string { return i.EasegressImage }},
+	"easemesh-operator-image": {version.ComponentEaseMeshOperator, func(i *flags.Install) string {
+		return i.EaseMeshOperatorImage
+	}},
+	"shadowservice-controller-image": {version.ComponentShadowServiceController, func(i *flags.Install) string {
+		return i.ShadowServiceControllerImage
+	}},
+}
+
+// Target returns the target spec of the upgrade, the flags given in the command line
+// override the detected ones, and the deployed add-ons are always kept.
+func Target(flagSet *pflag.FlagSet, requested, detected *flags.Install) (*flags.Install, error) {
+	components, err := version.Components()
+	if err != nil {
+		return nil, err
+	}
+	target := *requested
+	for _, f := range detectedFlags {
+		if flagSet.Changed(f.name) {
+			continue
+		}
+		if c, exists := componentImages[f.name]; exists && components.Released(c.component, c.image(detected)) {
+			continue
+		}
+		f.copy(&target, detected)
+	}
+
+	target.AddOns = append([]string{}, detected.AddOns...)
+	for _, addOn := range requested.AddOns {
+		addOn = strings.ToLower(addOn)
+		exists := false
+		for _, a := range target.AddOns {
+			exists = exists || a == addOn
+		}
+		if !exists {
+			target.AddOns = append(target.AddOns, addOn)
+		}
+	}
+
+	// NOTE: The members of control plane and its volumes are fixed at installing.
+	if target.EasegressControlPlaneReplicas != detected.EasegressControlPlaneReplicas {
+		return nil, errors.Errorf("changing replicas of the control plane from %d to %d is not supported by upgrade",
+			detected.EasegressControlPlaneReplicas, target.EasegressControlPlaneReplicas)
+	}
+	if target.MeshControlPlaneStorageClassName != detected.MeshControlPlaneStorageClassName ||
+		target.MeshControlPlanePersistVolumeCapacity != detected.MeshControlPlanePersistVolumeCapacity {
+		return nil, errors.Errorf("changing the persistent volume of the control plane is not supported by upgrade")
+	}
+	err = target.CheckImages()
+	if err != nil {
+		return nil, err
+	}
+
+	return &target, nil
+}
@@ -0,0 +1,331 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const checkInterval = 2 * time.Second
+
+// Run upgrades the deployed EaseMesh to the spec of the flags in place. The components are
+// rolled in order, and the upgrade pauses at the first failed one, the later ones are untouched.
+func Run(cmd *cobra.Command, upgradeFlags *flags.Upgrade, components func(*flags.Install) []*Component) error {
+	client, _, err := installbase.NewKubernetesClient()
+	if err != nil {
+		return err
+	}
+	apiExtensionsClient, err := installbase.NewKubernetesAPIExtensionsClient()
+	if err != nil {
+		return err
+	}
+
+	detected, err := Detect(client, upgradeFlags.Install)
+	if err != nil {
+		return err
+	}
+	printDetected(detected)
+
+	target, err := Target(cmd.Flags(), upgradeFlags.Install, detected)
+	if err != nil {
+		return err
+	}
+
+	recorder := installbase.NewRecorder()
+	recordingClient, recordingAPIExtensionsClient, err := installbase.NewRecordingClients(recorder)
+	if err != nil {
+		return err
+	}
+	renderContext := &installbase.StageContext{
+		Cmd:                 cmd,
+		Flags:               target,
+		Client:              recordingClient,
+		APIExtensionsClient: recordingAPIExtensionsClient,
+		Recorder:            recorder,
+	}
+
+	plans, err := newPlan(renderContext, client, components(target))
+	if err != nil {
+		return err
+	}
+
+	if !printPlans(os.Stdout, plans) {
+		fmt.Println("\nEaseMesh is up to date.")
+		return nil
+	}
+	if upgradeFlags.DryRun {
+		return nil
+	}
+
+	for _, p := range plans {
+		fmt.Printf("\nUpgrading %s\n", p.component.Name)
+		err := roll(client, apiExtensionsClient, p, upgradeFlags.WaitTimeout)
+		if err != nil {
+			return errors.Wrapf(err, "upgrade paused at %s, the components after it are untouched, "+
+				"run emctl upgrade again to continue after fixing it", p.component.Name)
+		}
+	}
+
+	fmt.Println("\nDone.")
+	return nil
+}
+
+func printDetected(detected *flags.Install) {
+	fmt.Printf("Deployed EaseMesh in the namespace %s:\n", detected.MeshNamespace)
+	fmt.Printf("  control plane:      %s/%s (%d replicas)\n", detected.ImageRegistryURL,
+		detected.EasegressImage, detected.EasegressControlPlaneReplicas)
+	fmt.Printf("  operator:           %s/%s (%d replicas)\n", detected.ImageRegistryURL,
+		detected.EaseMeshOperatorImage, detected.EaseMeshOperatorReplicas)
+	fmt.Printf("  ingress controller: %s/%s (%d replicas)\n", detected.ImageRegistryURL,
+		detected.EasegressImage, detected.MeshIngressReplicas)
+	for _, addOn := range detected.AddOns {
+		fmt.Printf("  add-on:             %s\n", addOn)
+	}
+	fmt.Println()
+}
+
+// roll applies the manifests of the component and waits for its workloads.
+func roll(client kubernetes.Interface, apiExtensionsClient apiextensions.Interface, p *componentPlan, timeout time.Duration) error {
+	for _, manifest := range p.manifests {
+		kind, _ := manifest["kind"].(string)
+		metadata, _ := manifest["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		namespace, _ := metadata["namespace"].(string)
+
+		var err error
+		switch kind {
+		case "Namespace", "Secret", "MutatingWebhookConfiguration", "CertificateSigningRequest":
+			// NOTE: The namespace exists, and the certificates of the webhook are kept.
+			continue
+		case "ValidatingWebhookConfiguration":
+			object := &admissionregv1.ValidatingWebhookConfiguration{}
+			if err = convert(manifest, object); err == nil {
+				err = createValidatingWebhook(client, object)
+			}
+		case "ConfigMap":
+			object := &v1.ConfigMap{}
+			if err = convert(manifest, object); err == nil {
+				err = installbase.DeployConfigMap(object, client, namespace)
+			}
+		case "Service":
+			object := &v1.Service{}
+			if err = convert(manifest, object); err == nil {
+				err = installbase.DeployService(object, client, namespace)
+			}
+		case "Role":
+			object := &rbacv1.Role{}
+			if err = convert(manifest, object); err == nil {
+				err = installbase.DeployRole(object, client, namespace)
+			}
+		case "RoleBinding":
+			object := &rbacv1.RoleBinding{}
+			if err = convert(manifest, object); err == nil {
+				err = installbase.DeployRoleBinding(object, client, namespace)
+			}
+		case "ClusterRole":
+			object := &rbacv1.ClusterRole{}
+			if err = convert(manifest, object); err == nil {
+				err = installbase.DeployClusterRole(object, client)
+			}
+		case "ClusterRoleBinding":
+			object := &rbacv1.ClusterRoleBinding{}
+			if err = convert(manifest, object); err == nil {
+				err = installbase.DeployClusterRoleBinding(object, client)
+			}
+		case "CustomResourceDefinition":
+			object := &apiextensionsv1.CustomResourceDefinition{}
+			if err = convert(manifest, object); err == nil {
+				err = installbase.DeployCustomResourceDefinition(object, apiExtensionsClient)
+			}
+		case "PodDisruptionBudget":
+			object := &policyv1.PodDisruptionBudget{}
+			if err = convert(manifest, object); err == nil {
+				err = installbase.DeployPodDisruptionBudget(object, client, namespace)
+			}
+		case "Deployment":
+			object := &appsV1.Deployment{}
+			if err = convert(manifest, object); err == nil {
+				err = rollDeployment(client, object, timeout)
+			}
+		case "StatefulSet":
+			object := &appsV1.StatefulSet{}
+			if err = convert(manifest, object); err == nil {
+				err = rollStatefulSet(client, object, timeout)
+			}
+		default:
+			fmt.Printf("ignored: %s %s is not supported by upgrade\n", kind, name)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "upgrade %s %s failed", kind, name)
+		}
+	}
+
+	return nil
+}
+
+// createValidatingWebhook creates the validating webhook configuration missed by the installation
+// of old versions, the existing one is kept as the certificates of the webhook.
+func createValidatingWebhook(client kubernetes.Interface, config *admissionregv1.ValidatingWebhookConfiguration) error {
+	_, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.TODO(), config.Name, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	// NOTE: The rendered CA bundle belongs to the rendered certificates which aren't applied,
+	// so it's replaced by the one of the mutating webhook working with the kept certificates.
+	mutatingName := installbase.OperatorMutatingWebhookName
+	mutating, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.TODO(), mutatingName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "get mutatingwebhookconfiguration %s for the CA bundle", mutatingName)
+	}
+	var caBundle []byte
+	if len(mutating.Webhooks) != 0 {
+		caBundle = mutating.Webhooks[0].ClientConfig.CABundle
+	}
+	for i := range config.Webhooks {
+		config.Webhooks[i].ClientConfig.CABundle = caBundle
+	}
+
+	_, err = client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Create(context.TODO(), config, metav1.CreateOptions{})
+	return err
+}
+
+func rollDeployment(client kubernetes.Interface, deployment *appsV1.Deployment, timeout time.Duration) error {
+	err := installbase.DeployDeployment(deployment, client, deployment.Namespace)
+	if err != nil {
+		return err
+	}
+
+	return waitFor(timeout, func() (bool, error) {
+		return installbase.CheckDeploymentResourceStatus(client, deployment.Namespace, deployment.Name,
+			deploymentRolledPredict)
+	})
+}
+
+// rollStatefulSet updates the pods of StatefulSet one at a time from the highest ordinal,
+// by lowering the partition of the rolling update after the previous pod is ready.
+func rollStatefulSet(client kubernetes.Interface, statefulSet *appsV1.StatefulSet, timeout time.Duration) error {
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	partition := replicas
+	statefulSet.Spec.UpdateStrategy = appsV1.StatefulSetUpdateStrategy{
+		Type:          appsV1.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &appsV1.RollingUpdateStatefulSetStrategy{Partition: &partition},
+	}
+	err := installbase.DeployStatefulset(statefulSet, client, statefulSet.Namespace)
+	if err != nil {
+		return err
+	}
+
+	for ordinal := replicas - 1; ordinal >= 0; ordinal-- {
+		err := setPartition(client, statefulSet.Namespace, statefulSet.Name, ordinal)
+		if err != nil {
+			return err
+		}
+
+		err = waitFor(timeout, func() (bool, error) {
+			return installbase.CheckStatefulsetResourceStatus(client, statefulSet.Namespace, statefulSet.Name,
+				statefulSetRolledPredict(replicas-ordinal))
+		})
+		if err != nil {
+			return errors.Wrapf(err, "pod %s", installbase.ControlPlanePodName(int(ordinal)))
+		}
+		fmt.Printf("pod %s is upgraded\n", installbase.ControlPlanePodName(int(ordinal)))
+	}
+
+	return nil
+}
+
+func setPartition(client kubernetes.Interface, namespace, name string, partition int32) error {
+	statefulSet, err := client.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	statefulSet.Spec.UpdateStrategy.Type = appsV1.RollingUpdateStatefulSetStrategyType
+	statefulSet.Spec.UpdateStrategy.RollingUpdate = &appsV1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+	_, err = client.AppsV1().StatefulSets(namespace).Update(context.TODO(), statefulSet, metav1.UpdateOptions{})
+	return err
+}
+
+// statefulSetRolledPredict returns if the given number of pods are updated and all pods are ready.
+func statefulSetRolledPredict(updated int32) installbase.PredictFunc {
+	return func(object interface{}) bool {
+		statefulSet, ok := object.(*appsV1.StatefulSet)
+		if !ok {
+			return false
+		}
+		return statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+			statefulSet.Status.UpdatedReplicas >= updated &&
+			installbase.StatefulsetReadyPredict(statefulSet)
+	}
+}
+
+// deploymentRolledPredict returns if all pods of the Deployment are updated and ready.
+func deploymentRolledPredict(object interface{}) bool {
+	deployment, ok := object.(*appsV1.Deployment)
+	if !ok {
+		return false
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == *deployment.Spec.Replicas &&
+		installbase.DeploymentReadyPredict(deployment)
+}
+
+func waitFor(timeout time.Duration, check func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ready, err := check()
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("not ready in %s", timeout)
+		}
+		time.Sleep(checkInterval)
+	}
+}
@@ -0,0 +1,313 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrade
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/controlpanel"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/crd"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/ingresscontroller"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/installation"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshinstall/operator"
+	"github.com/megaease/easemeshctl/pkg/version"
+
+	"github.com/spf13/cobra"
+	appsV1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func testComponents() []*Component {
+	return []*Component{
+		{Name: "custom resource definitions", Stage: installation.Wrap("crd", crd.PreCheck, crd.Deploy, crd.Clear, crd.DescribePhase)},
+		{Name: "control plane", Stage: installation.Wrap("control-plane", controlpanel.PreCheck, controlpanel.Deploy, controlpanel.Clear, controlpanel.DescribePhase)},
+		{Name: "operator", Stage: installation.Wrap("operator", operator.PreCheck, operator.Deploy, operator.Clear, operator.DescribePhase)},
+		{Name: "ingress controller", Stage: installation.Wrap("ingress-controller", ingresscontroller.PreCheck, ingresscontroller.Deploy, ingresscontroller.Clear, ingresscontroller.DescribePhase)},
+	}
+}
+
+// renderingContext returns the context rendering the installation of the arguments into a recorder.
+func renderingContext(t *testing.T, args ...string) (*installbase.StageContext, *cobra.Command, *flags.Upgrade) {
+	cmd := &cobra.Command{}
+	upgradeFlags := &flags.Upgrade{}
+	upgradeFlags.AttachCmd(cmd)
+	err := cmd.Flags().Parse(args)
+	if err != nil {
+		t.Fatalf("parse flags failed: %v", err)
+	}
+
+	recorder := installbase.NewRecorder()
+	client, apiExtensionsClient, err := installbase.NewRecordingClients(recorder)
+	if err != nil {
+		t.Fatalf("new recording clients failed: %v", err)
+	}
+
+	return &installbase.StageContext{
+		Cmd:                 cmd,
+		Flags:               upgradeFlags.Install,
+		Client:              client,
+		APIExtensionsClient: apiExtensionsClient,
+		Recorder:            recorder,
+	}, cmd, upgradeFlags
+}
+
+// installedCluster returns the client of a cluster installed with the default flags.
+func installedCluster(t *testing.T) kubernetes.Interface {
+	context, _, _ := renderingContext(t)
+	var stages []installation.InstallStage
+	for _, c := range testComponents() {
+		stages = append(stages, c.Stage)
+	}
+	_, err := installation.Render(context, stages...)
+	if err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	return context.Client
+}
+
+func TestDetect(t *testing.T) {
+	_, _, upgradeFlags := renderingContext(t)
+	detected, err := Detect(installedCluster(t), upgradeFlags.Install)
+	if err != nil {
+		t.Fatalf("detect failed: %v", err)
+	}
+
+	if detected.ImageRegistryURL != flags.DefaultImageRegistryURL ||
+		detected.EasegressImage != flags.DefaultEasegressImage ||
+		detected.EaseMeshOperatorImage != flags.DefaultEaseMeshOperatorImage ||
+		detected.EasegressControlPlaneReplicas != flags.DefaultMeshControlPlaneReplicas ||
+		detected.MeshIngressReplicas != flags.DefaultMeshIngressReplicas ||
+		detected.WebhookCertMode != flags.DefaultWebhookCertMode {
+		t.Fatalf("unexpected detected flags: %+v", detected)
+	}
+	if len(detected.AddOns) != 0 {
+		t.Fatalf("expect no add-ons, but got %v", detected.AddOns)
+	}
+
+	context, _, _ := renderingContext(t)
+	_, err = Detect(context.Client, upgradeFlags.Install)
+	if err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Fatalf("expect not installed error, but got %v", err)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	_, cmd, upgradeFlags := renderingContext(t, "--easegress-image", "megaease/easegress:v2",
+		"--add-ons", "shadowservice")
+	detected := *upgradeFlags.Install
+	detected.EasegressImage = "megaease/easegress:v1"
+	detected.EaseMeshOperatorImage = "megaease/easemesh-operator:v1"
+
+	target, err := Target(cmd.Flags(), upgradeFlags.Install, &detected)
+	if err != nil {
+		t.Fatalf("target failed: %v", err)
+	}
+	if target.EasegressImage != "megaease/easegress:v2" ||
+		target.EaseMeshOperatorImage != "megaease/easemesh-operator:v1" ||
+		strings.Join(target.AddOns, ",") != "shadowservice" {
+		t.Fatalf("unexpected target: %+v", target)
+	}
+
+	// The released images move to the ones of the release of emctl.
+	components, err := version.Components()
+	if err != nil {
+		t.Fatalf("parse components manifest failed: %v", err)
+	}
+	detected.EaseMeshOperatorImage, err = components.Releases[0].Image(version.ComponentEaseMeshOperator)
+	if err != nil {
+		t.Fatalf("get released image failed: %v", err)
+	}
+	_, cmd, upgradeFlags = renderingContext(t)
+	target, err = Target(cmd.Flags(), upgradeFlags.Install, &detected)
+	if err != nil {
+		t.Fatalf("target failed: %v", err)
+	}
+	if target.EasegressImage != "megaease/easegress:v1" ||
+		target.EaseMeshOperatorImage != flags.DefaultEaseMeshOperatorImage {
+		t.Fatalf("unexpected target: %+v", target)
+	}
+
+	_, cmd, upgradeFlags = renderingContext(t, "--easemesh-control-plane-replicas", "5")
+	_, err = Target(cmd.Flags(), upgradeFlags.Install, &detected)
+	if err == nil {
+		t.Fatalf("expect error of changing replicas of the control plane")
+	}
+
+	_, cmd, upgradeFlags = renderingContext(t, "--sidecar-image", "")
+	_, err = Target(cmd.Flags(), upgradeFlags.Install, &detected)
+	if err == nil || !strings.Contains(err.Error(), "no image of sidecar") {
+		t.Fatalf("expect error of empty sidecar image, but got: %v", err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	cluster := installedCluster(t)
+	context, cmd, upgradeFlags := renderingContext(t, "--easegress-image", "megaease/easegress:v2")
+	detected, err := Detect(cluster, upgradeFlags.Install)
+	if err != nil {
+		t.Fatalf("detect failed: %v", err)
+	}
+	context.Flags, err = Target(cmd.Flags(), upgradeFlags.Install, detected)
+	if err != nil {
+		t.Fatalf("target failed: %v", err)
+	}
+
+	plans, err := newPlan(context, cluster, testComponents())
+	if err != nil {
+		t.Fatalf("plan failed: %v", err)
+	}
+
+	changed := map[string]bool{}
+	for _, p := range plans {
+		for _, c := range p.changes {
+			changed[p.component.Name+"/"+c.kind+"/"+c.field] = true
+			if !strings.HasSuffix(c.target, "megaease/easegress:v2") {
+				t.Fatalf("unexpected change: %+v", c)
+			}
+		}
+	}
+	for _, expected := range []string{
+		"control plane/StatefulSet/containers.easegress.image",
+		"ingress controller/Deployment/containers." + installbase.IngressControllerDeploymentName + ".image",
+	} {
+		if !changed[expected] {
+			t.Fatalf("expect change %s, but got %v", expected, changed)
+		}
+	}
+
+	buff := &bytes.Buffer{}
+	if !printPlans(buff, plans) || !strings.Contains(buff.String(), "megaease/easegress:v2") {
+		t.Fatalf("unexpected printed plans:\n%s", buff)
+	}
+}
+
+func TestRollValidatingWebhook(t *testing.T) {
+	cluster := installedCluster(t)
+	webhooks := cluster.AdmissionregistrationV1()
+	name := installbase.OperatorValidatingWebhookName
+	err := webhooks.ValidatingWebhookConfigurations().Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatalf("delete validatingwebhookconfiguration failed: %v", err)
+	}
+	mutating, err := webhooks.MutatingWebhookConfigurations().Get(context.TODO(), installbase.OperatorMutatingWebhookName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get mutatingwebhookconfiguration failed: %v", err)
+	}
+	for i := range mutating.Webhooks {
+		mutating.Webhooks[i].ClientConfig.CABundle = []byte("kept-ca")
+	}
+	_, err = webhooks.MutatingWebhookConfigurations().Update(context.TODO(), mutating, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("update mutatingwebhookconfiguration failed: %v", err)
+	}
+
+	stageContext, _, _ := renderingContext(t)
+	plans, err := newPlan(stageContext, cluster, testComponents())
+	if err != nil {
+		t.Fatalf("plan failed: %v", err)
+	}
+	var webhookPlan *componentPlan
+	for _, p := range plans {
+		for _, manifest := range p.manifests {
+			if manifest["kind"] == "ValidatingWebhookConfiguration" {
+				webhookPlan = &componentPlan{component: p.component, manifests: []map[string]interface{}{manifest}}
+			}
+		}
+		for _, c := range p.changes {
+			if c.kind == "ValidatingWebhookConfiguration" && c.target != "<new>" {
+				t.Fatalf("unexpected change: %+v", c)
+			}
+		}
+	}
+	if webhookPlan == nil {
+		t.Fatalf("expect the manifest of validatingwebhookconfiguration")
+	}
+
+	for i := 0; i < 2; i++ {
+		err = roll(cluster, nil, webhookPlan, time.Second)
+		if err != nil {
+			t.Fatalf("roll failed: %v", err)
+		}
+	}
+
+	validating, err := webhooks.ValidatingWebhookConfigurations().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expect validatingwebhookconfiguration created, but got %v", err)
+	}
+	for _, webhook := range validating.Webhooks {
+		if string(webhook.ClientConfig.CABundle) != "kept-ca" {
+			t.Fatalf("expect the CA bundle of the mutating webhook, but got %s", webhook.ClientConfig.CABundle)
+		}
+	}
+}
+
+func TestSplitImage(t *testing.T) {
+	for image, expected := range map[string][2]string{
+		"docker.io/megaease/easegress:latest": {"docker.io", "megaease/easegress:latest"},
+		"easegress":                           {flags.DefaultImageRegistryURL, "easegress"},
+	} {
+		registry, name := splitImage(image)
+		if registry != expected[0] || name != expected[1] {
+			t.Fatalf("split %s: expect %v, but got %s %s", image, expected, registry, name)
+		}
+	}
+}
+
+func TestRolledPredict(t *testing.T) {
+	replicas := int32(3)
+	statefulSet := &appsV1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec:       appsV1.StatefulSetSpec{Replicas: &replicas},
+		Status: appsV1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			ReadyReplicas:      3,
+			UpdatedReplicas:    1,
+		},
+	}
+	if !statefulSetRolledPredict(1)(statefulSet) || statefulSetRolledPredict(2)(statefulSet) {
+		t.Fatalf("unexpected result of predicting %+v", statefulSet.Status)
+	}
+	statefulSet.Status.ReadyReplicas = 2
+	if statefulSetRolledPredict(1)(statefulSet) {
+		t.Fatalf("expect not rolled when a pod is not ready")
+	}
+
+	deployment := &appsV1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec:       appsV1.DeploymentSpec{Replicas: &replicas},
+		Status: appsV1.DeploymentStatus{
+			ObservedGeneration: 1,
+			ReadyReplicas:      3,
+			UpdatedReplicas:    3,
+		},
+	}
+	if deploymentRolledPredict(deployment) {
+		t.Fatalf("expect not rolled before the generation is observed")
+	}
+	deployment.Status.ObservedGeneration = 2
+	if !deploymentRolledPredict(deployment) {
+		t.Fatalf("expect rolled")
+	}
+}
@@ -30,7 +30,10 @@ import (
 type (
 	// RCFile contains information of rc file of emctl.
 	RCFile struct {
-		Server string `yaml:"server"`
+		Server       string `yaml:"server"`
+		AuditWebhook string `yaml:"auditWebhook,omitempty"`
+		PolicyDir    string `yaml:"policyDir,omitempty"`
+		AddOnDir     string `yaml:"addOnDir,omitempty"`
 
 		path string
 	}
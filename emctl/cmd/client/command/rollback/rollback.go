@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rollback
+
+import (
+	"fmt"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/apply"
+	"github.com/megaease/easemeshctl/cmd/client/command/delete"
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/history"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/command/writer"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	"github.com/megaease/easemeshctl/cmd/client/util"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Run is the entrypoint of the emctl rollback sub command
+func Run(cmd *cobra.Command, flag *flags.Rollback) {
+	if flag.Server == "" {
+		flag.Server = flags.GetServerAddress()
+	}
+
+	cmdArgs := cmd.Flags().Args()
+	if len(cmdArgs) != 2 {
+		common.ExitWithErrorf("invalid command args: support <resource kind> <resource name>")
+	}
+	kind, name := cmdArgs[0], cmdArgs[1]
+
+	if flag.ToRevision <= 0 {
+		common.ExitWithErrorf("no revision specified")
+	}
+
+	w, err := writer.New(flag.AdminGlobal, flag.AdminHistory, flag.AdminAudit, flag.AdminPolicy)
+	if err != nil {
+		common.ExitWithError(err)
+	}
+	store := w.Store()
+
+	revision, err := store.Get(kind, name, flag.ToRevision)
+	if err != nil {
+		common.ExitWithError(err)
+	}
+
+	// The revision holds the version before its mutation, the resource
+	// did not exist at that time if there is no object in the revision.
+	visitorBuilder := util.NewVisitorBuilder()
+	if revision.Existed {
+		visitorBuilder.FilenameParam(&util.FilenameOptions{
+			Filenames: []string{store.ObjectPath(kind, name, flag.ToRevision)},
+		})
+	} else {
+		visitorBuilder.CommandParam(&util.CommandOptions{
+			Kind: kind,
+			Name: name,
+		})
+	}
+
+	vss, err := visitorBuilder.Do()
+	if err != nil {
+		common.ExitWithErrorf("build visitor failed: %v", err)
+	}
+
+	var errs []error
+	for _, vs := range vss {
+		err := vs.Visit(func(mo meta.MeshObject, e error) error {
+			if e != nil {
+				return errors.Wrap(e, "visit failed")
+			}
+
+			var current meta.MeshObject
+			if revision.Existed {
+				current = mo
+			}
+			err := w.Write(history.ActionRollback, mo, current, func(client meshclient.MeshClient, previous meta.MeshObject) error {
+				if revision.Existed {
+					return apply.WrapApplierByMeshObject(mo, client, flag.Timeout).Apply()
+				}
+				if previous != nil {
+					return delete.WrapDeleterByMeshObject(mo, client, flag.Timeout).Delete()
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s/%s rolled back to revision %d successfully\n", mo.Kind(), mo.Name(), flag.ToRevision)
+			return nil
+		})
+
+		common.OutputError(err)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		common.ExitWithErrorf("rolling back resources has errors occurred")
+	}
+}
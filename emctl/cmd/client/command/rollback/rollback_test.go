@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rollback
+
+import (
+	"os"
+	"testing"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/history"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient/fake"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	meshtesting "github.com/megaease/easemeshctl/cmd/client/testing"
+
+	"bou.ke/monkey"
+	"github.com/megaease/easemesh-api/v2alpha1"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func TestRollbackRun(t *testing.T) {
+	reactorType := "__test_rollback_reactor"
+	fake.NewResourceReactorBuilder(reactorType).
+		AddReactor("get", resource.KindTenant, "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, []meta.MeshObject{resource.ToTenant(&v2alpha1.Tenant{Name: "tenant-001", Description: "current"})}, nil
+		}).
+		AddReactor("*", "*", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, nil, nil
+		}).Added()
+
+	rollbackFlag := meshtesting.PrepareRollbackFlags(reactorType, t)
+	store, err := history.NewLocalStore(rollbackFlag.HistoryDir, rollbackFlag.Server)
+	if err != nil {
+		t.Fatalf("create store error: %s", err)
+	}
+
+	err = store.Save(&history.Revision{
+		Kind:    resource.KindTenant,
+		Name:    "tenant-001",
+		Action:  history.ActionApply,
+		Existed: true,
+		Object:  []byte(tenantSpec),
+	})
+	if err != nil {
+		t.Fatalf("save revision error: %s", err)
+	}
+	err = store.Save(&history.Revision{
+		Kind:   resource.KindTenant,
+		Name:   "tenant-001",
+		Action: history.ActionApply,
+	})
+	if err != nil {
+		t.Fatalf("save revision error: %s", err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.ParseFlags([]string{"tenant", "tenant-001"})
+	for _, revision := range []int{1, 2} {
+		rollbackFlag.ToRevision = revision
+		Run(cmd, rollbackFlag)
+	}
+
+	revisions, err := store.List(resource.KindTenant, "tenant-001")
+	if err != nil {
+		t.Fatalf("list revisions error: %s", err)
+	}
+	if len(revisions) != 4 || revisions[3].Action != history.ActionRollback {
+		t.Fatalf("rollback should be recorded as new revisions, but got %+v", revisions)
+	}
+}
+
+type exitCode int
+
+func expectExit(t *testing.T, msg string, f func()) {
+	defer func() {
+		if _, ok := recover().(exitCode); !ok {
+			t.Fatalf(msg)
+		}
+	}()
+	f()
+}
+
+func TestRollbackRunFail(t *testing.T) {
+	patch := monkey.Patch(os.Exit, func(code int) {
+		panic(exitCode(code))
+	})
+	defer patch.Unpatch()
+
+	reactorType := "__test_rollback_reactor_fail"
+	fake.NewResourceReactorBuilder(reactorType).
+		AddReactor("*", "*", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, nil, errors.Errorf("mock rollback error")
+		}).Added()
+
+	rollbackFlag := meshtesting.PrepareRollbackFlags(reactorType, t)
+	cmd := &cobra.Command{}
+	cmd.ParseFlags([]string{"tenant", "tenant-001"})
+	expectExit(t, "rollback without revision should fail", func() {
+		Run(cmd, rollbackFlag)
+	})
+
+	rollbackFlag.ToRevision = 1
+	expectExit(t, "rollback to a nonexistent revision should fail", func() {
+		Run(cmd, rollbackFlag)
+	})
+
+	cmd = &cobra.Command{}
+	cmd.ParseFlags([]string{"tenant"})
+	expectExit(t, "rollback with invalid args should fail", func() {
+		Run(cmd, rollbackFlag)
+	})
+}
+
+var tenantSpec = `
+kind: Tenant
+apiVersion: mesh.megaease.com/v2alpha1
+metadata:
+  name: tenant-001
+spec:
+  description: 'previous'
+`
@@ -0,0 +1,362 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/common/client"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	componentCRD               = "custom resource definitions"
+	componentControlPlane      = "control plane"
+	componentMembers           = "control plane members"
+	componentAPIAddress        = "API address"
+	componentOperator          = "operator"
+	componentWebhook           = "mutating webhook"
+	componentWebhookCert       = "webhook certificate"
+	componentIngressController = "ingress controller"
+	componentShadowService     = "shadow service controller"
+)
+
+// checks are all checks run by status in order.
+var checks = []func(ctx *Context) []*Result{
+	checkCRDs,
+	checkControlPlane,
+	checkControlPlaneMembers,
+	checkAPIAddress,
+	checkOperator,
+	checkWebhook,
+	checkWebhookCert,
+	checkIngressController,
+	checkShadowService,
+}
+
+// crdNames are the names of the CustomResourceDefinitions installed by EaseMesh.
+var crdNames = []string{
+	installbase.MeshDeploymentCRDName,
+	installbase.MeshFederationCRDName,
+	installbase.MeshStatefulSetCRDName,
+	installbase.MeshDaemonSetCRDName,
+}
+
+func checkCRDs(ctx *Context) []*Result {
+	var results []*Result
+	for _, name := range crdNames {
+		crd, err := ctx.APIExtensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			results = append(results, fail(componentCRD, installHint(ctx), "%s not found", name))
+			continue
+		}
+		if err != nil {
+			results = append(results, fail(componentCRD, clusterHint, "get %s failed: %v", name, err))
+			continue
+		}
+
+		established := false
+		for _, condition := range crd.Status.Conditions {
+			if condition.Type == apiextensionsv1.Established && condition.Status == apiextensionsv1.ConditionTrue {
+				established = true
+			}
+		}
+		if !established {
+			results = append(results, warn(componentCRD, fmt.Sprintf("check its conditions by: kubectl describe crd %s", name),
+				"%s is not established", name))
+			continue
+		}
+		results = append(results, pass(componentCRD, "%s is established", name))
+	}
+	return results
+}
+
+func checkControlPlane(ctx *Context) []*Result {
+	name := installbase.ControlPlaneStatefulSetName
+	statefulSet, err := ctx.Client.AppsV1().StatefulSets(ctx.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []*Result{fail(componentControlPlane, installHint(ctx), "statefulset %s not found", name)}
+	}
+	if err != nil {
+		return []*Result{fail(componentControlPlane, clusterHint, "get statefulset %s failed: %v", name, err)}
+	}
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	ready := statefulSet.Status.ReadyReplicas
+	selector := matchLabels(statefulSet.Spec.Selector)
+
+	var result *Result
+	switch {
+	case installbase.StatefulsetReadyPredict(statefulSet):
+		return []*Result{pass(componentControlPlane, "statefulset %s is ready (%d/%d)", name, ready, replicas)}
+	case ready >= replicas/2+1:
+		result = warn(componentControlPlane, podsHint(ctx, selector),
+			"statefulset %s is partially ready (%d/%d), the cluster keeps its quorum", name, ready, replicas)
+	default:
+		result = fail(componentControlPlane, podsHint(ctx, selector)+
+			", the pods are pending if there are no available PersistentVolumes for their claims",
+			"statefulset %s is not ready (%d/%d), the cluster loses its quorum", name, ready, replicas)
+	}
+	result.Pods = installbase.FormatPodStatus(ctx.Client, ctx.Namespace, installbase.AdaptListPodFunc(selector))
+	return []*Result{result}
+}
+
+func checkControlPlaneMembers(ctx *Context) []*Result {
+	statefulSet, err := ctx.Client.AppsV1().StatefulSets(ctx.Namespace).Get(context.TODO(),
+		installbase.ControlPlaneStatefulSetName, metav1.GetOptions{})
+	if err != nil {
+		// NOTE: It has been reported by the check of the control plane.
+		return nil
+	}
+	replicas := 1
+	if statefulSet.Spec.Replicas != nil {
+		replicas = int(*statefulSet.Spec.Replicas)
+	}
+
+	endpoints, err := installbase.GetMeshControlPlaneEndpoints(ctx.Client, ctx.Namespace,
+		installbase.ControlPlanePlubicServiceName, installbase.ControlPlaneStatefulSetAdminPortName)
+	if err != nil {
+		return []*Result{fail(componentMembers, installHint(ctx), "get endpoints of service %s failed: %v",
+			installbase.ControlPlanePlubicServiceName, err)}
+	}
+	if len(endpoints) == 0 {
+		return []*Result{fail(componentMembers, clusterHint, "no node address found for service %s",
+			installbase.ControlPlanePlubicServiceName)}
+	}
+
+	var lastErr error
+	for _, endpoint := range endpoints {
+		members, err := getMembers(endpoint, ctx.Timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		hint := fmt.Sprintf("check the logs of the control plane by: kubectl logs -n %s %s",
+			ctx.Namespace, installbase.ControlPlanePodName(0))
+		switch {
+		case members == replicas:
+			return []*Result{pass(componentMembers, "%d/%d members joined the cluster (via %s)", members, replicas, endpoint)}
+		case members >= replicas/2+1:
+			return []*Result{warn(componentMembers, hint, "%d/%d members joined the cluster (via %s)", members, replicas, endpoint)}
+		default:
+			return []*Result{fail(componentMembers, hint, "%d/%d members joined the cluster, the cluster loses its quorum (via %s)",
+				members, replicas, endpoint)}
+		}
+	}
+
+	return []*Result{fail(componentMembers,
+		"set the environment variable EMCTL_NODE_ADDRESS to a reachable address of the nodes if their internal IPs are unreachable from here",
+		"none of the endpoints %s is reachable: %v", strings.Join(endpoints, ", "), lastErr)}
+}
+
+func checkAPIAddress(ctx *Context) []*Result {
+	if ctx.Server == "" {
+		return []*Result{warn(componentAPIAddress, "emctl install records it in the rcfile, or give it by --server",
+			"no API address configured")}
+	}
+
+	_, err := getMembers("http://"+strings.TrimPrefix(ctx.Server, "http://"), ctx.Timeout)
+	if err != nil {
+		return []*Result{fail(componentAPIAddress,
+			fmt.Sprintf("the API address should be a node address with the node port of %s of service %s",
+				installbase.ControlPlaneStatefulSetAdminPortName, installbase.ControlPlanePlubicServiceName),
+			"%s is unreachable: %v", ctx.Server, err)}
+	}
+	return []*Result{pass(componentAPIAddress, "%s is reachable", ctx.Server)}
+}
+
+func checkOperator(ctx *Context) []*Result {
+	return checkDeployment(ctx, componentOperator, installbase.OperatorDeploymentName, false)
+}
+
+func checkIngressController(ctx *Context) []*Result {
+	return checkDeployment(ctx, componentIngressController, installbase.IngressControllerDeploymentName, false)
+}
+
+func checkShadowService(ctx *Context) []*Result {
+	return checkDeployment(ctx, componentShadowService, installbase.IngressControllerShadowServiceName, true)
+}
+
+func checkDeployment(ctx *Context, component, name string, addOn bool) []*Result {
+	deployment, err := ctx.Client.AppsV1().Deployments(ctx.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if addOn {
+			return []*Result{pass(component, "add-on is not installed")}
+		}
+		return []*Result{fail(component, installHint(ctx), "deployment %s not found", name)}
+	}
+	if err != nil {
+		return []*Result{fail(component, clusterHint, "get deployment %s failed: %v", name, err)}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	ready := deployment.Status.ReadyReplicas
+	selector := matchLabels(deployment.Spec.Selector)
+
+	var result *Result
+	switch {
+	case installbase.DeploymentReadyPredict(deployment):
+		return []*Result{pass(component, "deployment %s is ready (%d/%d)", name, ready, replicas)}
+	case ready > 0:
+		result = warn(component, podsHint(ctx, selector), "deployment %s is partially ready (%d/%d)", name, ready, replicas)
+	default:
+		result = fail(component, podsHint(ctx, selector), "deployment %s is not ready (%d/%d)", name, ready, replicas)
+	}
+	result.Pods = installbase.FormatPodStatus(ctx.Client, ctx.Namespace, installbase.AdaptListPodFunc(selector))
+	return []*Result{result}
+}
+
+func checkWebhook(ctx *Context) []*Result {
+	name := installbase.OperatorMutatingWebhookName
+	config, err := ctx.Client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []*Result{fail(componentWebhook, installHint(ctx)+", sidecars are not injected without it",
+			"mutatingwebhookconfiguration %s not found", name)}
+	}
+	if err != nil {
+		return []*Result{fail(componentWebhook, clusterHint, "get mutatingwebhookconfiguration %s failed: %v", name, err)}
+	}
+
+	for _, webhook := range config.Webhooks {
+		service := webhook.ClientConfig.Service
+		if service == nil || service.Namespace != ctx.Namespace || service.Name != installbase.OperatorServiceName {
+			return []*Result{fail(componentWebhook, installHint(ctx),
+				"webhook %s doesn't call service %s/%s", webhook.Name, ctx.Namespace, installbase.OperatorServiceName)}
+		}
+		if len(webhook.ClientConfig.CABundle) == 0 {
+			return []*Result{fail(componentWebhook, installHint(ctx), "webhook %s has no CA bundle", webhook.Name)}
+		}
+	}
+	if len(config.Webhooks) == 0 {
+		return []*Result{fail(componentWebhook, installHint(ctx), "mutatingwebhookconfiguration %s has no webhooks", name)}
+	}
+
+	return []*Result{pass(componentWebhook, "%d webhooks call service %s/%s",
+		len(config.Webhooks), ctx.Namespace, installbase.OperatorServiceName)}
+}
+
+func checkWebhookCert(ctx *Context) []*Result {
+	name := installbase.OperatorSecretName
+	renewHint := fmt.Sprintf("issue a new certificate by: kubectl delete secret -n %s %s && emctl install --mesh-namespace %s",
+		ctx.Namespace, name, ctx.Namespace)
+
+	secret, err := ctx.Client.CoreV1().Secrets(ctx.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []*Result{fail(componentWebhookCert, installHint(ctx), "secret %s not found", name)}
+	}
+	if err != nil {
+		return []*Result{fail(componentWebhookCert, clusterHint, "get secret %s failed: %v", name, err)}
+	}
+
+	key := installbase.OperatorSecretCertFileName
+	if _, exists := secret.Data[installbase.OperatorCertManagerCertFileName]; exists {
+		// NOTE: The secret is issued and renewed by cert-manager.
+		key = installbase.OperatorCertManagerCertFileName
+		renewHint = fmt.Sprintf("check the certificate by: kubectl describe certificate -n %s %s",
+			ctx.Namespace, installbase.OperatorCertificateName)
+	} else if _, exists := secret.Data[installbase.OperatorSecretCAFileName]; exists {
+		// NOTE: The operator rotates the certificate signed by its own CA.
+		renewHint = fmt.Sprintf("check the certificate rotation in the logs by: kubectl logs -n %s deployment/%s",
+			ctx.Namespace, installbase.OperatorDeploymentName)
+	}
+
+	block, _ := pem.Decode(secret.Data[key])
+	if block == nil {
+		return []*Result{fail(componentWebhookCert, renewHint, "no PEM certificate in %s of secret %s", key, name)}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return []*Result{fail(componentWebhookCert, renewHint, "parse certificate of secret %s failed: %v", name, err)}
+	}
+
+	expiry := cert.NotAfter.Format(time.RFC3339)
+	switch {
+	case ctx.Now.Before(cert.NotBefore):
+		return []*Result{fail(componentWebhookCert, "check the clock of this machine and the cluster",
+			"certificate is not valid before %s", cert.NotBefore.Format(time.RFC3339))}
+	case ctx.Now.After(cert.NotAfter):
+		return []*Result{fail(componentWebhookCert, renewHint, "certificate expired at %s", expiry)}
+	case ctx.Now.Add(ctx.CertExpiryWarning).After(cert.NotAfter):
+		return []*Result{warn(componentWebhookCert, renewHint, "certificate expires at %s, in %s",
+			expiry, cert.NotAfter.Sub(ctx.Now).Round(time.Hour))}
+	}
+	return []*Result{pass(componentWebhookCert, "certificate is valid until %s", expiry)}
+}
+
+// getMembers returns the number of members of the control plane cluster.
+func getMembers(endpoint string, timeout time.Duration) (int, error) {
+	members, err := client.NewHTTPJSON().
+		Get(endpoint+installbase.MemberList, nil, timeout, nil).
+		HandleResponse(func(body []byte, statusCode int) (interface{}, error) {
+			if statusCode != 200 {
+				return nil, errors.Errorf("get member list returns status code %d", statusCode)
+			}
+
+			var members []map[string]interface{}
+			err := yaml.Unmarshal(body, &members)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse member list failed")
+			}
+			return len(members), nil
+		})
+	if err != nil {
+		return 0, err
+	}
+	return members.(int), nil
+}
+
+func matchLabels(selector *metav1.LabelSelector) map[string]string {
+	if selector == nil {
+		return nil
+	}
+	return selector.MatchLabels
+}
+
+const clusterHint = "check the access to the Kubernetes cluster by: kubectl cluster-info"
+
+func installHint(ctx *Context) string {
+	return fmt.Sprintf("install EaseMesh by: emctl install --mesh-namespace %s", ctx.Namespace)
+}
+
+func podsHint(ctx *Context, selector map[string]string) string {
+	var labels []string
+	for k, v := range selector {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	return fmt.Sprintf("check the pods by: kubectl describe pods -n %s -l %s", ctx.Namespace, strings.Join(labels, ","))
+}
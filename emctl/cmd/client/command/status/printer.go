@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/common"
+)
+
+type printer struct {
+	outputFormat string
+}
+
+const textIndent = "       "
+
+func newPrinter(outputFormat string) *printer {
+	return &printer{outputFormat: outputFormat}
+}
+
+func (p *printer) print(w io.Writer, results []*Result) error {
+	switch p.outputFormat {
+	case "text":
+		return p.printText(w, results)
+	case "json":
+		return p.printJSON(w, results)
+	default:
+		common.ExitWithErrorf("unsupported output format: %s", p.outputFormat)
+	}
+	return nil
+}
+
+func (p *printer) printText(w io.Writer, results []*Result) error {
+	counts := map[Level]int{}
+	for _, r := range results {
+		counts[r.Level]++
+
+		_, err := fmt.Fprintf(w, "[%s] %-28s %s\n", strings.ToUpper(string(r.Level)), r.Component, r.Message)
+		if err != nil {
+			return err
+		}
+		if r.Hint != "" {
+			_, err = fmt.Fprintf(w, "%shint: %s\n", textIndent, r.Hint)
+			if err != nil {
+				return err
+			}
+		}
+		if r.Pods != "" {
+			for _, line := range strings.Split(strings.TrimRight(r.Pods, "\n"), "\n") {
+				_, err = fmt.Fprintf(w, "%s%s\n", textIndent, line)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed\n", counts[LevelPass], counts[LevelWarn], counts[LevelFail])
+	return err
+}
+
+func (p *printer) printJSON(w io.Writer, results []*Result) error {
+	if results == nil {
+		results = []*Result{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/spf13/cobra"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/kubernetes"
+)
+
+type (
+	// Level is the result of a check.
+	Level string
+
+	// Result is the result of checking a part of the EaseMesh infrastructure.
+	Result struct {
+		Component string `json:"component"`
+		Level     Level  `json:"level"`
+		Message   string `json:"message"`
+		Hint      string `json:"hint,omitempty"`
+		// Pods is the formatted status of the pods of the component, it's only
+		// filled when the component is not healthy.
+		Pods string `json:"pods,omitempty"`
+	}
+
+	// Context holds the clients and options of checks.
+	Context struct {
+		Client              kubernetes.Interface
+		APIExtensionsClient apiextensions.Interface
+		Namespace           string
+		Server              string
+		Timeout             time.Duration
+		CertExpiryWarning   time.Duration
+		Now                 time.Time
+	}
+)
+
+const (
+	// LevelPass means the component is healthy.
+	LevelPass Level = "pass"
+	// LevelWarn means the component works but needs attention.
+	LevelWarn Level = "warn"
+	// LevelFail means the component is broken.
+	LevelFail Level = "fail"
+)
+
+// Run is the entrypoint of the emctl status subcommand
+func Run(cmd *cobra.Command, flag *flags.Status) {
+	client, _, err := installbase.NewKubernetesClient()
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+
+	apiExtensionsClient, err := installbase.NewKubernetesAPIExtensionsClient()
+	if err != nil {
+		common.ExitWithErrorf("%s failed: %v", cmd.Short, err)
+	}
+
+	if flag.Server == "" {
+		flag.Server = flags.GetServerAddress()
+	}
+
+	results := Check(&Context{
+		Client:              client,
+		APIExtensionsClient: apiExtensionsClient,
+		Namespace:           flag.MeshNamespace,
+		Server:              flag.Server,
+		Timeout:             flag.Timeout,
+		CertExpiryWarning:   flag.CertExpiryWarning,
+		Now:                 time.Now(),
+	})
+
+	err = newPrinter(flag.OutputFormat).print(os.Stdout, results)
+	if err != nil {
+		common.ExitWithErrorf("print results failed: %v", err)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Level == LevelFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		common.ExitWithErrorf("%d checks failed", failed)
+	}
+}
+
+// Check checks all parts of the EaseMesh infrastructure.
+func Check(ctx *Context) []*Result {
+	var results []*Result
+	for _, c := range checks {
+		results = append(results, c(ctx)...)
+	}
+	return results
+}
+
+func pass(component, format string, args ...interface{}) *Result {
+	return &Result{Component: component, Level: LevelPass, Message: fmt.Sprintf(format, args...)}
+}
+
+func warn(component, hint, format string, args ...interface{}) *Result {
+	return &Result{Component: component, Level: LevelWarn, Message: fmt.Sprintf(format, args...), Hint: hint}
+}
+
+func fail(component, hint, format string, args ...interface{}) *Result {
+	return &Result{Component: component, Level: LevelFail, Message: fmt.Sprintf(format, args...), Hint: hint}
+}
@@ -0,0 +1,242 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	installbase "github.com/megaease/easemeshctl/cmd/client/command/meshinstall/base"
+
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testNamespace = "easemesh"
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func certPem(t *testing.T, notBefore, notAfter time.Time) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: installbase.OperatorServiceName},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate failed: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// newContext returns the context of a cluster, in which the control plane and the operator are ready,
+// while the ingress controller isn't.
+func newContext(t *testing.T, now time.Time) *Context {
+	recorder := installbase.NewRecorder()
+	client, apiExtensionsClient, err := installbase.NewRecordingClients(recorder)
+	if err != nil {
+		t.Fatalf("new recording clients failed: %v", err)
+	}
+
+	labels := map[string]string{"app": "easemesh"}
+	for _, name := range crdNames {
+		err = installbase.DeployCustomResourceDefinition(&apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: apiextensionsv1.CustomResourceDefinitionStatus{
+				Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+					{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+				},
+			},
+		}, apiExtensionsClient)
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = installbase.DeployStatefulset(&appsV1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: installbase.ControlPlaneStatefulSetName},
+			Spec: appsV1.StatefulSetSpec{
+				Replicas: int32Ptr(3),
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+			},
+			Status: appsV1.StatefulSetStatus{ReadyReplicas: 3},
+		}, client, testNamespace)
+	}
+	if err == nil {
+		err = installbase.DeployDeployment(&appsV1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: installbase.OperatorDeploymentName},
+			Spec: appsV1.DeploymentSpec{
+				Replicas: int32Ptr(1),
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+			},
+			Status: appsV1.DeploymentStatus{ReadyReplicas: 1},
+		}, client, testNamespace)
+	}
+	if err == nil {
+		err = installbase.DeployDeployment(&appsV1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: installbase.IngressControllerDeploymentName},
+			Spec: appsV1.DeploymentSpec{
+				Replicas: int32Ptr(2),
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+			},
+			Status: appsV1.DeploymentStatus{ReadyReplicas: 1},
+		}, client, testNamespace)
+	}
+	if err == nil {
+		err = installbase.DeploySecret(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: installbase.OperatorSecretName},
+			Data: map[string][]byte{
+				installbase.OperatorSecretCertFileName: certPem(t, now.Add(-time.Hour), now.Add(10*24*time.Hour)),
+			},
+		}, client, testNamespace)
+	}
+	if err == nil {
+		err = installbase.DeployMutatingWebhookConfig(&admissionregv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: installbase.OperatorMutatingWebhookName},
+			Webhooks: []admissionregv1.MutatingWebhook{{
+				Name: "mesh-injector.megaease.com",
+				ClientConfig: admissionregv1.WebhookClientConfig{
+					Service:  &admissionregv1.ServiceReference{Namespace: testNamespace, Name: installbase.OperatorServiceName},
+					CABundle: []byte("ca"),
+				},
+			}},
+		}, client, testNamespace)
+	}
+	if err != nil {
+		t.Fatalf("deploy objects failed: %v", err)
+	}
+
+	return &Context{
+		Client:              client,
+		APIExtensionsClient: apiExtensionsClient,
+		Namespace:           testNamespace,
+		Timeout:             time.Second,
+		CertExpiryWarning:   30 * 24 * time.Hour,
+		Now:                 now,
+	}
+}
+
+func levels(results []*Result) map[string]Level {
+	m := map[string]Level{}
+	for _, r := range results {
+		m[r.Component] = r.Level
+	}
+	return m
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	ctx := newContext(t, now)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != installbase.MemberList {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("- name: member-1\n- name: member-2\n- name: member-3\n"))
+	}))
+	defer server.Close()
+	ctx.Server = server.URL
+
+	results := Check(ctx)
+	expected := map[string]Level{
+		componentCRD:               LevelPass,
+		componentControlPlane:      LevelPass,
+		componentMembers:           LevelFail,
+		componentAPIAddress:        LevelPass,
+		componentOperator:          LevelPass,
+		componentWebhook:           LevelPass,
+		componentWebhookCert:       LevelWarn,
+		componentIngressController: LevelWarn,
+		componentShadowService:     LevelPass,
+	}
+	got := levels(results)
+	for component, level := range expected {
+		if got[component] != level {
+			t.Errorf("expect %s of %s, but got %s", level, component, got[component])
+		}
+	}
+
+	for _, r := range results {
+		if r.Level != LevelPass && r.Hint == "" {
+			t.Errorf("expect hint of %s", r.Component)
+		}
+		if r.Component == componentIngressController && !strings.Contains(r.Pods, "Restarts") {
+			t.Errorf("expect pod status of %s, but got %q", r.Component, r.Pods)
+		}
+	}
+
+	buff := &bytes.Buffer{}
+	err := newPrinter("text").print(buff, results)
+	if err != nil {
+		t.Fatalf("print results failed: %v", err)
+	}
+	if !strings.Contains(buff.String(), "9 passed, 2 warnings, 1 failed") {
+		t.Fatalf("unexpected report:\n%s", buff)
+	}
+}
+
+func TestCheckFailures(t *testing.T) {
+	now := time.Now()
+	ctx := newContext(t, now)
+	ctx.Now = now.Add(30 * 24 * time.Hour)
+
+	err := ctx.Client.AppsV1().StatefulSets(testNamespace).Delete(context.TODO(),
+		installbase.ControlPlaneStatefulSetName, metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatalf("delete statefulset failed: %v", err)
+	}
+	err = ctx.Client.AdmissionregistrationV1().MutatingWebhookConfigurations().Delete(context.TODO(),
+		installbase.OperatorMutatingWebhookName, metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatalf("delete webhook failed: %v", err)
+	}
+
+	got := levels(Check(ctx))
+	expected := map[string]Level{
+		componentControlPlane: LevelFail,
+		componentMembers:      "",
+		componentAPIAddress:   LevelWarn,
+		componentWebhook:      LevelFail,
+		componentWebhookCert:  LevelFail,
+	}
+	for component, level := range expected {
+		if got[component] != level {
+			t.Errorf("expect %q of %s, but got %q", level, component, got[component])
+		}
+	}
+}
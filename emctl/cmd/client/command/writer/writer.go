@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package writer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/audit"
+	"github.com/megaease/easemeshctl/cmd/client/command/flags"
+	"github.com/megaease/easemeshctl/cmd/client/command/history"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/policy"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	"github.com/megaease/easemeshctl/cmd/common"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// MutateFunc mutates the object in the control plane, previous is the version
+	// before the mutation, which is nil if the object doesn't exist.
+	MutateFunc func(client meshclient.MeshClient, previous meta.MeshObject) error
+
+	// Writer is the only way the emctl commands mutate the mesh resources, so that
+	// every mutation is checked by the policies, audited and recorded into the
+	// revision history.
+	Writer struct {
+		client   meshclient.MeshClient
+		timeout  time.Duration
+		store    history.Store
+		recorder *history.Recorder
+		auditor  audit.Auditor
+		policies policy.Set
+	}
+)
+
+// New creates a Writer of the control plane with the options of the command.
+func New(global *flags.AdminGlobal, historyFlag *flags.AdminHistory,
+	auditFlag *flags.AdminAudit, policyFlag *flags.AdminPolicy) (*Writer, error) {
+	store, err := history.NewLocalStore(historyFlag.HistoryDir, global.Server)
+	if err != nil {
+		return nil, errors.Wrap(err, "create history store failed")
+	}
+
+	policyDir := policyFlag.PolicyDir
+	if policyDir == "" {
+		policyDir = flags.GetPolicyDir()
+	}
+	policies, err := policy.Load(policyDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "load policies failed")
+	}
+
+	client := meshclient.New(global.Server)
+	return &Writer{
+		client:   client,
+		timeout:  global.Timeout,
+		store:    store,
+		recorder: history.NewRecorder(store, client, global.Timeout),
+		auditor:  audit.New(auditFlag, global.Server),
+		policies: policies,
+	}, nil
+}
+
+// Client returns the client of the control plane.
+func (w *Writer) Client() meshclient.MeshClient {
+	return w.client
+}
+
+// Timeout returns the timeout of requesting the control plane.
+func (w *Writer) Timeout() time.Duration {
+	return w.timeout
+}
+
+// Store returns the store of the revision history.
+func (w *Writer) Store() history.Store {
+	return w.store
+}
+
+// Write mutates the object by the action, current is the object after the mutation,
+// which is nil for the deletion. The current object must pass the policies, and the
+// mutation is refused if the previous version can't be read from the control plane,
+// since the revision without it would delete the object when it's rolled back to.
+func (w *Writer) Write(action string, object, current meta.MeshObject, mutate MutateFunc) error {
+	if current != nil {
+		vr := w.policies.Evaluate(current)
+		if !vr.Valid() {
+			err := fmt.Errorf("%s/%s rejected by policies: %s", object.Kind(), object.Name(), vr)
+			w.auditor.Record(action, object, nil, current, err)
+			return err
+		}
+	}
+
+	previous, err := w.recorder.Previous(object)
+	if err != nil {
+		w.auditor.Record(action, object, nil, current, err)
+		return err
+	}
+
+	err = mutate(w.client, previous)
+	w.auditor.Record(action, object, previous, current, err)
+	if err != nil {
+		return errors.Wrapf(err, "%s/%s %s failed", object.Kind(), object.Name(), action)
+	}
+
+	if previous == nil && current == nil {
+		return nil
+	}
+	err = w.recorder.Record(action, object, previous, current)
+	if err != nil {
+		common.OutputErrorf("ignored: record history of %s/%s failed: %v", object.Kind(), object.Name(), err)
+	}
+
+	return nil
+}
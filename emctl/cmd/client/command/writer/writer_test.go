@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package writer
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/megaease/easemeshctl/cmd/client/command/history"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient"
+	"github.com/megaease/easemeshctl/cmd/client/command/meshclient/fake"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	meshtesting "github.com/megaease/easemeshctl/cmd/client/testing"
+)
+
+const testPolicy = `
+name: canary-priority
+kinds: [ServiceCanary]
+rules:
+- expr: spec.priority < 100
+  message: priority of canary must be less than 100
+`
+
+func TestWrite(t *testing.T) {
+	reactorType := "__test_writer_reactor"
+	fake.NewResourceReactorBuilder(reactorType).
+		AddReactor("get", "*", "*", func(action fake.Action) (handled bool, rets []meta.MeshObject, err error) {
+			return true, nil, nil
+		}).Added()
+
+	rollbackFlags := meshtesting.PrepareRollbackFlags(reactorType, t)
+	err := ioutil.WriteFile(filepath.Join(rollbackFlags.PolicyDir, "policies.yaml"), []byte(testPolicy), 0644)
+	if err != nil {
+		t.Fatalf("write policies failed: %v", err)
+	}
+	w, err := New(rollbackFlags.AdminGlobal, rollbackFlags.AdminHistory, rollbackFlags.AdminAudit, rollbackFlags.AdminPolicy)
+	if err != nil {
+		t.Fatalf("create writer failed: %v", err)
+	}
+
+	mutated := 0
+	mutate := func(client meshclient.MeshClient, previous meta.MeshObject) error {
+		mutated++
+		return nil
+	}
+
+	canary := &resource.ServiceCanary{
+		MeshResource: resource.NewServiceCanaryResource(resource.DefaultAPIVersion, "canary"),
+		Spec:         &resource.ServiceCanarySpec{Priority: 200},
+	}
+	err = w.Write(history.ActionRestore, canary, canary, mutate)
+	if err == nil || !strings.Contains(err.Error(), "rejected by policies") {
+		t.Fatalf("expect rejected by policies, but got %v", err)
+	}
+	if mutated != 0 {
+		t.Fatalf("expect no mutation, but mutated %d times", mutated)
+	}
+
+	canary.Spec.Priority = 10
+	err = w.Write(history.ActionRestore, canary, canary, mutate)
+	if err != nil {
+		t.Fatalf("write canary failed: %v", err)
+	}
+	if mutated != 1 {
+		t.Fatalf("expect one mutation, but mutated %d times", mutated)
+	}
+	revisions, err := w.Store().List(canary.Kind(), canary.Name())
+	if err != nil || len(revisions) != 1 || revisions[0].Action != history.ActionRestore {
+		t.Fatalf("expect one restore revision, but got %v, %v", revisions, err)
+	}
+}
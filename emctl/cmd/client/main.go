@@ -34,6 +34,12 @@ var exampleUsage = ` # EaseMesh command line tool for management and operation
 # Install EaseMesh Components
 emctl install --clean-when-failed
 
+# Upgrade EaseMesh Components in place, review the changes first
+emctl upgrade --easegress-image megaease/easegress:v1.4.0 --dry-run
+
+# Check the health of EaseMesh Components
+emctl status
+
 # Apply Tenant (kind is case-insensitive in command line)
 emctl apply -f tenant-001.yaml
 
@@ -63,6 +69,30 @@ emctl delete service -f service-001.yaml
 # Delete LoadBalance
 emctl delete loadbalance service-001
 
+# Show revision history of Resilience and roll back to the version before revision 2
+emctl history resilience service-001
+emctl rollback resilience service-001 --to-revision 2
+
+# Install add-ons and show their status
+emctl addon list
+emctl addon install shadowservice
+emctl addon status
+
+# Back up all resources of EaseMesh and restore them
+emctl backup create
+emctl backup list
+emctl backup restore easemesh-20211001-120000.tar.gz
+
+# Take service instance out of load balancing
+emctl instance drain service-001/instance-001
+emctl instance cordon service-001/instance-001
+
+# Show the images of the components pinned by the release of emctl
+emctl version --components
+
+# Check configuration files against best practices and output SARIF for CI annotations
+emctl lint -f configs/ -o sarif
+
 # NOTE: The manipulation of the kinds attached to Service below is the same with LoadBalance:
 # - Sidecar
 # - Resilience
@@ -96,9 +126,19 @@ func main() {
 	rootCmd.AddCommand(
 		command.InstallCmd(),
 		command.ResetCmd(),
+		command.UpgradeCmd(),
+		command.AddOnCmd(),
+		command.StatusCmd(),
 		command.ApplyCmd(),
 		command.DeleteCmd(),
 		command.GetCmd(),
+		command.InstanceCmd(),
+		command.HistoryCmd(),
+		command.RollbackCmd(),
+		command.BackupCmd(),
+		command.BundleCmd(),
+		command.LintCmd(),
+		command.VersionCmd(),
 		completionCmd,
 	)
 
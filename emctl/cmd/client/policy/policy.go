@@ -0,0 +1,228 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package policy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	"github.com/megaease/easemeshctl/cmd/client/valid"
+
+	yamljsontool "github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// Policy is a set of rules which must be satisfied by the selected mesh objects
+	// before they are written to the control plane.
+	Policy struct {
+		Name        string   `yaml:"name"`
+		Description string   `yaml:"description,omitempty"`
+		Kinds       []string `yaml:"kinds"`
+		Selector    Selector `yaml:"selector,omitempty"`
+		Rules       []*Rule  `yaml:"rules"`
+	}
+
+	// Selector selects mesh objects by name and labels, an empty selector selects all objects.
+	Selector struct {
+		// Name is a regular expression matching the name of the object.
+		Name   string            `yaml:"name,omitempty"`
+		Labels map[string]string `yaml:"labels,omitempty"`
+	}
+
+	// Rule is an assertion against a field of the mesh object. It could be
+	// written in the structured form with path, operator and value, or as
+	// an expression such as "spec.priority < 100".
+	Rule struct {
+		Expr     string      `yaml:"expr,omitempty"`
+		Path     string      `yaml:"path,omitempty"`
+		Operator Operator    `yaml:"operator,omitempty"`
+		Value    interface{} `yaml:"value,omitempty"`
+		Message  string      `yaml:"message,omitempty"`
+	}
+
+	// Set is a set of policies loaded from a directory.
+	Set []*Policy
+)
+
+var policyFileExtensions = []string{".yaml", ".yml"}
+
+// Load loads all policies from the files in the directory, an empty dir returns an empty Set.
+func Load(dir string) (Set, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	var set Set
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !hasPolicyExtension(path) {
+			return nil
+		}
+
+		buff, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "read file %s failed", path)
+		}
+
+		for _, document := range strings.Split(string(buff), "\n---") {
+			if strings.TrimSpace(document) == "" {
+				continue
+			}
+			policy := &Policy{}
+			err = yaml.Unmarshal([]byte(document), policy)
+			if err != nil {
+				return errors.Wrapf(err, "unmarshal policy in %s failed", path)
+			}
+			err = policy.compile()
+			if err != nil {
+				return errors.Wrapf(err, "invalid policy %s in %s", policy.Name, path)
+			}
+			set = append(set, policy)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+func hasPolicyExtension(path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range policyFileExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) compile() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(p.Rules) == 0 {
+		return errors.New("rules are required")
+	}
+	if p.Selector.Name != "" {
+		_, err := regexp.Compile(p.Selector.Name)
+		if err != nil {
+			return errors.Wrapf(err, "invalid selector name %s", p.Selector.Name)
+		}
+	}
+
+	for _, rule := range p.Rules {
+		err := rule.compile()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Selects returns whether the policy applies to the object.
+func (p *Policy) Selects(object meta.MeshObject) bool {
+	if len(p.Kinds) != 0 {
+		matched := false
+		for _, kind := range p.Kinds {
+			if kind == "*" || strings.EqualFold(kind, object.Kind()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if p.Selector.Name != "" {
+		matched, _ := regexp.MatchString(p.Selector.Name, object.Name())
+		if !matched {
+			return false
+		}
+	}
+
+	labels := object.Labels()
+	for k, v := range p.Selector.Labels {
+		if labels[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Evaluate evaluates all the policies selecting the object, and records
+// every violation into the ValidateRecorder.
+func (s Set) Evaluate(object meta.MeshObject) *valid.ValidateRecorder {
+	vr := &valid.ValidateRecorder{}
+
+	var doc interface{}
+	for _, policy := range s {
+		if !policy.Selects(object) {
+			continue
+		}
+
+		if doc == nil {
+			var err error
+			doc, err = toDocument(object)
+			if err != nil {
+				vr.RecordPolicy(policy.Name, err)
+				return vr
+			}
+		}
+
+		for _, rule := range policy.Rules {
+			vr.RecordPolicy(policy.Name, rule.evaluate(doc))
+		}
+	}
+
+	return vr
+}
+
+// toDocument converts the object to a generic document with the same layout of its YAML.
+func toDocument(object meta.MeshObject) (interface{}, error) {
+	yamlBuff, err := yaml.Marshal(object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal %s/%s to yaml failed", object.Kind(), object.Name())
+	}
+
+	jsonBuff, err := yamljsontool.YAMLToJSON(yamlBuff)
+	if err != nil {
+		return nil, errors.Wrapf(err, "transform %s to json failed", yamlBuff)
+	}
+
+	var doc interface{}
+	err = json.Unmarshal(jsonBuff, &doc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal %s failed", jsonBuff)
+	}
+
+	return doc, nil
+}
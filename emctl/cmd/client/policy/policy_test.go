@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/megaease/easemesh-api/v2alpha1"
+	"github.com/megaease/easemeshctl/cmd/client/resource"
+)
+
+const testPolicies = `
+name: canary-priority
+kinds: [ServiceCanary]
+rules:
+- expr: spec.priority < 100
+  message: priority of canary must be less than 100
+---
+name: bounded-retry
+kinds: [Resilience]
+selector:
+  labels:
+    team: payment
+rules:
+- path: spec.retry.maxAttempts
+  operator: le
+  value: 5
+- expr: spec.timeLimiter
+`
+
+func loadTestPolicies(t *testing.T) Set {
+	dir, err := ioutil.TempDir("", "emctl-policy")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	err = ioutil.WriteFile(filepath.Join(dir, "policies.yaml"), []byte(testPolicies), 0644)
+	if err != nil {
+		t.Fatalf("write policies failed: %v", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a policy"), 0644)
+	if err != nil {
+		t.Fatalf("write readme failed: %v", err)
+	}
+
+	set, err := Load(dir)
+	if err != nil {
+		t.Fatalf("load policies failed: %v", err)
+	}
+	if len(set) != 2 {
+		t.Fatalf("expect 2 policies, but got %d", len(set))
+	}
+	return set
+}
+
+func TestLoadEmptyDir(t *testing.T) {
+	set, err := Load("")
+	if err != nil || len(set) != 0 {
+		t.Fatalf("expect empty set, but got %v, %v", set, err)
+	}
+}
+
+func TestLoadInvalidPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emctl-policy")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "invalid.yaml"),
+		[]byte("name: invalid\nrules:\n- path: spec.priority\n  operator: between\n"), 0644)
+	if err != nil {
+		t.Fatalf("write policy failed: %v", err)
+	}
+
+	_, err = Load(dir)
+	if err == nil {
+		t.Fatalf("expect error of unsupported operator")
+	}
+}
+
+func TestEvaluateServiceCanary(t *testing.T) {
+	set := loadTestPolicies(t)
+
+	canary := &resource.ServiceCanary{
+		MeshResource: resource.NewServiceCanaryResource(resource.DefaultAPIVersion, "canary"),
+		Spec:         &resource.ServiceCanarySpec{Priority: 5},
+	}
+	vr := set.Evaluate(canary)
+	if !vr.Valid() {
+		t.Fatalf("expect valid canary, but got %s", vr)
+	}
+
+	canary.Spec.Priority = 100
+	vr = set.Evaluate(canary)
+	if vr.Valid() || len(vr.PolicyErrs) != 1 {
+		t.Fatalf("expect one violation, but got %s", vr)
+	}
+}
+
+func TestEvaluateResilience(t *testing.T) {
+	set := loadTestPolicies(t)
+
+	resilience := &resource.Resilience{
+		MeshResource: resource.NewResilienceResource(resource.DefaultAPIVersion, "order"),
+		Spec: &v2alpha1.Resilience{
+			Retry: &v2alpha1.Retry{MaxAttempts: 10},
+		},
+	}
+
+	vr := set.Evaluate(resilience)
+	if !vr.Valid() {
+		t.Fatalf("expect unselected resilience to be valid, but got %s", vr)
+	}
+
+	resilience.MeshResource.MetaData.Labels = map[string]string{"team": "payment"}
+	vr = set.Evaluate(resilience)
+	if len(vr.PolicyErrs) != 2 {
+		t.Fatalf("expect two violations, but got %s", vr)
+	}
+
+	resilience.Spec.Retry.MaxAttempts = 3
+	resilience.Spec.TimeLimiter = &v2alpha1.TimeLimiter{Timeout: "1s"}
+	vr = set.Evaluate(resilience)
+	if !vr.Valid() {
+		t.Fatalf("expect valid resilience, but got %s", vr)
+	}
+}
+
+func TestRuleExpr(t *testing.T) {
+	cases := []struct {
+		expr  string
+		doc   interface{}
+		valid bool
+	}{
+		{"spec.name == foo", map[string]interface{}{"spec": map[string]interface{}{"name": "foo"}}, true},
+		{"spec.name != foo", map[string]interface{}{"spec": map[string]interface{}{"name": "foo"}}, false},
+		{"spec.name =~ ^f", map[string]interface{}{"spec": map[string]interface{}{"name": "foo"}}, true},
+		{"spec.name in [bar, baz]", map[string]interface{}{"spec": map[string]interface{}{"name": "foo"}}, false},
+		{"spec.timeout >= 1s", map[string]interface{}{"spec": map[string]interface{}{"timeout": "500ms"}}, false},
+		{"!spec.mock", map[string]interface{}{"spec": map[string]interface{}{}}, true},
+		{"spec.rules.weight <= 100", map[string]interface{}{"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{"weight": 50.0},
+				map[string]interface{}{"weight": 150.0},
+			},
+		}}, false},
+	}
+
+	for _, c := range cases {
+		rule := &Rule{Expr: c.expr}
+		err := rule.compile()
+		if err != nil {
+			t.Fatalf("compile %s failed: %v", c.expr, err)
+		}
+		err = rule.evaluate(c.doc)
+		if (err == nil) != c.valid {
+			t.Errorf("expr %s: expect valid %v, but got %v", c.expr, c.valid, err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Operator is the comparison operator of a rule
+type Operator string
+
+const (
+	// OperatorExists asserts the field exists
+	OperatorExists Operator = "exists"
+	// OperatorNotExists asserts the field doesn't exist
+	OperatorNotExists Operator = "notExists"
+	// OperatorEqual asserts the field equals to the value
+	OperatorEqual Operator = "eq"
+	// OperatorNotEqual asserts the field doesn't equal to the value
+	OperatorNotEqual Operator = "ne"
+	// OperatorLessThan asserts the field is less than the value
+	OperatorLessThan Operator = "lt"
+	// OperatorLessEqual asserts the field is less than or equal to the value
+	OperatorLessEqual Operator = "le"
+	// OperatorGreaterThan asserts the field is greater than the value
+	OperatorGreaterThan Operator = "gt"
+	// OperatorGreaterEqual asserts the field is greater than or equal to the value
+	OperatorGreaterEqual Operator = "ge"
+	// OperatorIn asserts the field is one of the values
+	OperatorIn Operator = "in"
+	// OperatorMatches asserts the field matches the regular expression
+	OperatorMatches Operator = "matches"
+)
+
+// exprOperators maps the operators in expressions to the structured ones,
+// the longer ones must be tried first.
+var exprOperators = []struct {
+	token    string
+	operator Operator
+}{
+	{"<=", OperatorLessEqual},
+	{">=", OperatorGreaterEqual},
+	{"==", OperatorEqual},
+	{"!=", OperatorNotEqual},
+	{"=~", OperatorMatches},
+	{"<", OperatorLessThan},
+	{">", OperatorGreaterThan},
+	{" in ", OperatorIn},
+}
+
+func (r *Rule) compile() error {
+	if r.Expr != "" {
+		err := r.parseExpr()
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.Path == "" {
+		return errors.Errorf("path of rule is required")
+	}
+
+	switch r.Operator {
+	case OperatorExists, OperatorNotExists:
+	case OperatorEqual, OperatorNotEqual, OperatorIn:
+	case OperatorLessThan, OperatorLessEqual, OperatorGreaterThan, OperatorGreaterEqual:
+	case OperatorMatches:
+		_, err := regexp.Compile(fmt.Sprint(r.Value))
+		if err != nil {
+			return errors.Wrapf(err, "invalid regular expression of rule %s", r)
+		}
+	default:
+		return errors.Errorf("unsupported operator %s of rule %s", r.Operator, r)
+	}
+
+	return nil
+}
+
+// parseExpr parses expressions like "spec.priority < 100", a bare path
+// such as "spec.circuitBreaker" asserts the field exists, and a path
+// with a leading "!" asserts the field doesn't exist.
+func (r *Rule) parseExpr() error {
+	expr := strings.TrimSpace(r.Expr)
+	for _, op := range exprOperators {
+		index := strings.Index(expr, op.token)
+		if index < 0 {
+			continue
+		}
+
+		r.Path = strings.TrimSpace(expr[:index])
+		r.Operator = op.operator
+
+		var value interface{}
+		err := yaml.Unmarshal([]byte(strings.TrimSpace(expr[index+len(op.token):])), &value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value of expression %s", r.Expr)
+		}
+		r.Value = value
+		return nil
+	}
+
+	if strings.HasPrefix(expr, "!") {
+		r.Path = strings.TrimSpace(expr[1:])
+		r.Operator = OperatorNotExists
+		return nil
+	}
+
+	r.Path = expr
+	r.Operator = OperatorExists
+	return nil
+}
+
+func (r *Rule) String() string {
+	if r.Expr != "" {
+		return r.Expr
+	}
+	if r.Operator == OperatorExists || r.Operator == OperatorNotExists {
+		return fmt.Sprintf("%s %s", r.Path, r.Operator)
+	}
+	return fmt.Sprintf("%s %s %v", r.Path, r.Operator, r.Value)
+}
+
+// evaluate evaluates the rule against the document. When the path goes
+// through a list, every element of the list must satisfy the rule. A missing
+// field satisfies all operators except exists.
+func (r *Rule) evaluate(doc interface{}) error {
+	values := lookup(doc, strings.Split(r.Path, "."))
+
+	switch r.Operator {
+	case OperatorExists:
+		if len(values) == 0 {
+			return r.violation(nil)
+		}
+		return nil
+	case OperatorNotExists:
+		if len(values) != 0 {
+			return r.violation(values[0])
+		}
+		return nil
+	}
+
+	for _, value := range values {
+		ok, err := r.compare(value)
+		if err != nil {
+			return errors.Wrapf(err, "rule %s", r)
+		}
+		if !ok {
+			return r.violation(value)
+		}
+	}
+
+	return nil
+}
+
+func (r *Rule) violation(actual interface{}) error {
+	msg := r.Message
+	if msg == "" {
+		msg = fmt.Sprintf("rule %s is violated", r)
+	}
+	if actual != nil {
+		return errors.Errorf("%s (actual: %v)", msg, actual)
+	}
+	return errors.New(msg)
+}
+
+func (r *Rule) compare(actual interface{}) (bool, error) {
+	switch r.Operator {
+	case OperatorEqual:
+		return equal(actual, r.Value), nil
+	case OperatorNotEqual:
+		return !equal(actual, r.Value), nil
+	case OperatorIn:
+		values, ok := r.Value.([]interface{})
+		if !ok {
+			return false, errors.Errorf("value of operator in must be a list")
+		}
+		for _, v := range values {
+			if equal(actual, v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case OperatorMatches:
+		return regexp.MatchString(fmt.Sprint(r.Value), fmt.Sprint(actual))
+	}
+
+	cmp, err := order(actual, r.Value)
+	if err != nil {
+		return false, err
+	}
+
+	switch r.Operator {
+	case OperatorLessThan:
+		return cmp < 0, nil
+	case OperatorLessEqual:
+		return cmp <= 0, nil
+	case OperatorGreaterThan:
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// lookup returns all values at the path of the document, field names
+// are matched case-insensitively.
+func lookup(doc interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		if doc == nil {
+			return nil
+		}
+		return []interface{}{doc}
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if strings.EqualFold(key, path[0]) {
+				return lookup(child, path[1:])
+			}
+		}
+	case []interface{}:
+		var results []interface{}
+		for _, child := range v {
+			results = append(results, lookup(child, path)...)
+		}
+		return results
+	}
+
+	return nil
+}
+
+func equal(actual, expected interface{}) bool {
+	a, aok := toFloat(actual)
+	e, eok := toFloat(expected)
+	if aok && eok {
+		return a == e
+	}
+	if reflect.DeepEqual(actual, expected) {
+		return true
+	}
+	return fmt.Sprint(actual) == fmt.Sprint(expected)
+}
+
+// order compares numbers or durations, it returns -1, 0, 1 like strings.Compare.
+func order(actual, expected interface{}) (int, error) {
+	a, aok := toFloat(actual)
+	e, eok := toFloat(expected)
+	if !aok || !eok {
+		ad, aerr := time.ParseDuration(fmt.Sprint(actual))
+		ed, eerr := time.ParseDuration(fmt.Sprint(expected))
+		if aerr != nil || eerr != nil {
+			return 0, errors.Errorf("%v and %v are not comparable", actual, expected)
+		}
+		a, e = float64(ad), float64(ed)
+	}
+
+	switch {
+	case a < e:
+		return -1, nil
+	case a > e:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -18,6 +18,9 @@
 package resource
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/megaease/easemesh-api/v2alpha1"
 	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
 )
@@ -26,7 +29,39 @@ type (
 	// LoadBalance describes loadbalance resource of the EaseMesh
 	LoadBalance struct {
 		meta.MeshResource `yaml:",inline"`
-		Spec              *v2alpha1.LoadBalance `yaml:"spec" jsonschema:"required"`
+		Spec              *LoadBalanceSpec `yaml:"spec" jsonschema:"required"`
+	}
+
+	// LoadBalanceSpec extends v2alpha1.LoadBalance with the locality of the instances.
+	LoadBalanceSpec struct {
+		Policy        string `yaml:"policy" json:"policy,omitempty" jsonschema:"required"`
+		HeaderHashKey string `yaml:"headerHashKey,omitempty" json:"headerHashKey,omitempty" jsonschema:"omitempty"`
+
+		// Locality is only used by the locality policies, which prefer the instances
+		// in the zone of the caller, then the ones in its region, then all instances.
+		// NOTE: The sidecar doesn't carry out the locality policies yet.
+		Locality *LoadBalanceLocality `yaml:"locality,omitempty" json:"locality,omitempty" jsonschema:"omitempty"`
+	}
+
+	// LoadBalanceLocality describes how the traffic fails over across zones, the zone and
+	// the region of an instance are its labels topology.kubernetes.io/zone and
+	// topology.kubernetes.io/region, which are injected by the operator.
+	LoadBalanceLocality struct {
+		// Failover distributes the traffic of a zone to other zones by weight,
+		// when the zone has no available instance.
+		Failover []*LocalityFailover `yaml:"failover,omitempty" json:"failover,omitempty" jsonschema:"omitempty"`
+	}
+
+	// LocalityFailover distributes the traffic from a zone to other zones by weight.
+	LocalityFailover struct {
+		From string            `yaml:"from" json:"from" jsonschema:"required"`
+		To   []*LocalityWeight `yaml:"to" json:"to" jsonschema:"required"`
+	}
+
+	// LocalityWeight is the weight of a zone receiving the failover traffic.
+	LocalityWeight struct {
+		Zone   string `yaml:"zone" json:"zone" jsonschema:"required"`
+		Weight int32  `yaml:"weight" json:"weight" jsonschema:"required"`
 	}
 )
 
@@ -47,19 +82,88 @@ func (l *LoadBalance) Columns() []*meta.TableColumn {
 			Name:  "HeaderHashKey",
 			Value: l.Spec.HeaderHashKey,
 		},
+		{
+			Name:  "Failover",
+			Value: l.Spec.failoverColumn(),
+		},
 	}
 }
 
-// ToV2Alpha1 converts a loadbalance resource to v2alpha1.LoadBalance
-func (l *LoadBalance) ToV2Alpha1() *v2alpha1.LoadBalance {
-	return l.Spec
+func (s *LoadBalanceSpec) failoverColumn() string {
+	if s.Locality == nil {
+		return ""
+	}
+
+	var failovers []string
+	for _, f := range s.Locality.Failover {
+		var weights []string
+		for _, w := range f.To {
+			weights = append(weights, fmt.Sprintf("%s:%d", w.Zone, w.Weight))
+		}
+		failovers = append(failovers, f.From+"->"+strings.Join(weights, ","))
+	}
+	return strings.Join(failovers, " ")
+}
+
+// IsLocality returns whether the policy prefers the instances in the same zone.
+func (s *LoadBalanceSpec) IsLocality() bool {
+	return s.Policy == LoadBalanceLocalityRoundRobinPolicy || s.Policy == LoadBalanceLocalityRandomPolicy
 }
 
-// ToLoadBalance converts a v2alpha1.LoadBalance resource to a LoadBalance resource
-func ToLoadBalance(name string, loadBalance *v2alpha1.LoadBalance) *LoadBalance {
-	result := &LoadBalance{
-		Spec: &v2alpha1.LoadBalance{},
+// Validate validates the locality of LoadBalanceSpec.
+func (s *LoadBalanceSpec) Validate() error {
+	if s.Locality == nil {
+		return nil
 	}
+
+	if !s.IsLocality() {
+		return fmt.Errorf("locality is only supported by policies %s and %s, but got %s",
+			LoadBalanceLocalityRoundRobinPolicy, LoadBalanceLocalityRandomPolicy, s.Policy)
+	}
+
+	froms := map[string]bool{}
+	for _, f := range s.Locality.Failover {
+		if f.From == "" {
+			return fmt.Errorf("empty zone of failover")
+		}
+		if froms[f.From] {
+			return fmt.Errorf("failover of zone %s is defined more than once", f.From)
+		}
+		froms[f.From] = true
+
+		if len(f.To) == 0 {
+			return fmt.Errorf("failover of zone %s has no target zone", f.From)
+		}
+		for _, w := range f.To {
+			switch {
+			case w.Zone == "":
+				return fmt.Errorf("failover of zone %s has an empty target zone", f.From)
+			case w.Zone == f.From:
+				return fmt.Errorf("failover of zone %s targets itself", f.From)
+			case w.Weight <= 0:
+				return fmt.Errorf("failover of zone %s to zone %s has non-positive weight %d", f.From, w.Zone, w.Weight)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ToV2Alpha1 converts a loadbalance resource to v2alpha1.LoadBalance,
+// NOTE: the locality isn't part of v2alpha1.LoadBalance, so it's dropped.
+func (l *LoadBalance) ToV2Alpha1() *v2alpha1.LoadBalance {
+	if l.Spec == nil {
+		return nil
+	}
+	return &v2alpha1.LoadBalance{
+		Policy:        l.Spec.Policy,
+		HeaderHashKey: l.Spec.HeaderHashKey,
+	}
+}
+
+// ToLoadBalance converts a LoadBalanceSpec to a LoadBalance resource
+func ToLoadBalance(name string, loadBalance *LoadBalanceSpec) *LoadBalance {
+	result := &LoadBalance{}
 	result.MeshResource = NewLoadBalanceResource(DefaultAPIVersion, name)
 	result.Spec = loadBalance
 	return result
@@ -33,6 +33,18 @@ const (
 	// LoadBalanceRoundRobinPolicy is round robin policy
 	LoadBalanceRoundRobinPolicy = "roundRobin"
 
+	// LoadBalanceLocalityRoundRobinPolicy is round robin policy preferring the instances in the same zone
+	LoadBalanceLocalityRoundRobinPolicy = "localityRoundRobin"
+
+	// LoadBalanceLocalityRandomPolicy is random policy preferring the instances in the same zone
+	LoadBalanceLocalityRandomPolicy = "localityRandom"
+
+	// LocalityZoneLabel is the label of the service instance holding the zone of its node
+	LocalityZoneLabel = "topology.kubernetes.io/zone"
+
+	// LocalityRegionLabel is the label of the service instance holding the region of its node
+	LocalityRegionLabel = "topology.kubernetes.io/region"
+
 	// DefaultSideIngressProtocol is default communication protocol for inbound traffic of the sidecar
 	DefaultSideIngressProtocol = "http"
 
@@ -17,10 +17,13 @@
 package resource
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/megaease/easemesh-api/v2alpha1"
 	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
+	"github.com/megaease/easemeshctl/cmd/client/valid"
 	"gopkg.in/yaml.v2"
 )
 
@@ -45,11 +48,11 @@ func TestObjectCreator(t *testing.T) {
 		}
 		switch r := resource.(type) {
 		case *LoadBalance:
-			l := r.ToV2Alpha1()
-			ToLoadBalance("new", l).Columns()
-			r.Spec = &v2alpha1.LoadBalance{}
-			l = r.ToV2Alpha1()
-			ToLoadBalance("new", l).Columns()
+			r.ToV2Alpha1()
+			ToLoadBalance("new", r.Spec).Columns()
+			r.Spec = &LoadBalanceSpec{}
+			r.ToV2Alpha1()
+			ToLoadBalance("new", r.Spec).Columns()
 		case *MeshController:
 			ToMeshController(r.ToV2Alpha1()).Columns()
 		case *Ingress:
@@ -133,3 +136,65 @@ func TestDynamicObject(t *testing.T) {
 		t.Errorf("the type of 'field1' should be 'map[string]interface{}'")
 	}
 }
+
+func TestLoadBalanceLocality(t *testing.T) {
+	spec := &LoadBalanceSpec{
+		Policy: LoadBalanceLocalityRoundRobinPolicy,
+		Locality: &LoadBalanceLocality{
+			Failover: []*LocalityFailover{
+				{
+					From: "us-east-1a",
+					To: []*LocalityWeight{
+						{Zone: "us-east-1b", Weight: 80},
+						{Zone: "us-east-1c", Weight: 20},
+					},
+				},
+			},
+		},
+	}
+	lb := ToLoadBalance("order", spec)
+
+	if vr := valid.Validate(lb); !vr.Valid() {
+		t.Fatalf("expect valid load balance, got %s", vr)
+	}
+	if got := spec.failoverColumn(); got != "us-east-1a->us-east-1b:80,us-east-1c:20" {
+		t.Fatalf("unexpected failover column %s", got)
+	}
+
+	buff, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("marshal load balance failed: %v", err)
+	}
+	decoded := &LoadBalanceSpec{}
+	err = json.Unmarshal(buff, decoded)
+	if err != nil {
+		t.Fatalf("unmarshal load balance failed: %v", err)
+	}
+	if decoded.Locality == nil || decoded.Locality.Failover[0].To[1].Weight != 20 {
+		t.Fatalf("expect locality in %s", buff)
+	}
+
+	for _, c := range []struct {
+		modify func(s *LoadBalanceSpec)
+		err    string
+	}{
+		{func(s *LoadBalanceSpec) { s.Policy = LoadBalanceRoundRobinPolicy }, "only supported by policies"},
+		{func(s *LoadBalanceSpec) { s.Locality.Failover[0].To[0].Weight = 0 }, "non-positive weight"},
+		{func(s *LoadBalanceSpec) { s.Locality.Failover[0].To[0].Zone = "us-east-1a" }, "targets itself"},
+		{func(s *LoadBalanceSpec) {
+			s.Locality.Failover = append(s.Locality.Failover, &LocalityFailover{
+				From: "us-east-1a", To: []*LocalityWeight{{Zone: "us-east-1b", Weight: 1}},
+			})
+		}, "defined more than once"},
+	} {
+		buff, _ := json.Marshal(spec)
+		s := &LoadBalanceSpec{}
+		json.Unmarshal(buff, s)
+		c.modify(s)
+
+		err := s.Validate()
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("expect error %q, got %v", c.err, err)
+		}
+	}
+}
@@ -25,6 +25,15 @@ import (
 	"github.com/megaease/easemeshctl/cmd/client/resource/meta"
 )
 
+const (
+	// ServiceInstanceStatusUp indicates the instance is in the load balancing of its service
+	ServiceInstanceStatusUp = "UP"
+
+	// ServiceInstanceStatusOutOfService indicates the instance is taken out of the load balancing,
+	// but it is still alive
+	ServiceInstanceStatusOutOfService = "OUT_OF_SERVICE"
+)
+
 type (
 	// ServiceInstance describes service instance resource of the EaseMesh
 	ServiceInstance struct {
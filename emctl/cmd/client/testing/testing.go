@@ -99,6 +99,36 @@ func PrepareYamlFile(spec string, t *testing.T) (specFile string) {
 	return specFile
 }
 
+func prepareHistory(t *testing.T) *flags.AdminHistory {
+	historyDir, err := utiltesting.MkTmpdir("historydir")
+	if err != nil {
+		t.Fatalf("mkdir tmpdir %s error:%s", historyDir, err)
+	}
+	return &flags.AdminHistory{
+		HistoryDir: historyDir,
+	}
+}
+
+func prepareAudit(t *testing.T) *flags.AdminAudit {
+	auditDir, err := utiltesting.MkTmpdir("auditdir")
+	if err != nil {
+		t.Fatalf("mkdir tmpdir %s error:%s", auditDir, err)
+	}
+	return &flags.AdminAudit{
+		AuditLogFile: filepath.Join(auditDir, "audit.jsonl"),
+	}
+}
+
+func preparePolicy(t *testing.T) *flags.AdminPolicy {
+	policyDir, err := utiltesting.MkTmpdir("policydir")
+	if err != nil {
+		t.Fatalf("mkdir tmpdir %s error:%s", policyDir, err)
+	}
+	return &flags.AdminPolicy{
+		PolicyDir: policyDir,
+	}
+}
+
 func prepareFileInput(spec string, t *testing.T) *flags.AdminFileInput {
 	specFile := PrepareYamlFile(spec, t)
 	return &flags.AdminFileInput{
@@ -108,12 +138,22 @@ func prepareFileInput(spec string, t *testing.T) *flags.AdminFileInput {
 
 // PrepareApplyFlags return a mock Apply flag
 func PrepareApplyFlags(server, spec string, t *testing.T) *flags.Apply {
-	return &flags.Apply{AdminGlobal: prepareAdminGlobal(server), AdminFileInput: prepareFileInput(spec, t)}
+	return &flags.Apply{AdminGlobal: prepareAdminGlobal(server), AdminFileInput: prepareFileInput(spec, t), AdminHistory: prepareHistory(t), AdminAudit: prepareAudit(t), AdminPolicy: preparePolicy(t)}
 }
 
 // PrepareDeleteFlags return a mock Apply flag
 func PrepareDeleteFlags(server, spec string, t *testing.T) *flags.Delete {
-	return &flags.Delete{AdminGlobal: prepareAdminGlobal(server), AdminFileInput: prepareFileInput(spec, t)}
+	return &flags.Delete{AdminGlobal: prepareAdminGlobal(server), AdminFileInput: prepareFileInput(spec, t), AdminHistory: prepareHistory(t), AdminAudit: prepareAudit(t)}
+}
+
+// PrepareRollbackFlags return a mock Rollback flag
+func PrepareRollbackFlags(server string, t *testing.T) *flags.Rollback {
+	return &flags.Rollback{AdminGlobal: prepareAdminGlobal(server), AdminHistory: prepareHistory(t), AdminAudit: prepareAudit(t), AdminPolicy: preparePolicy(t)}
+}
+
+// PrepareBackupFlags return a mock Backup flag
+func PrepareBackupFlags(server string, t *testing.T) *flags.Backup {
+	return &flags.Backup{AdminGlobal: prepareAdminGlobal(server), AdminHistory: prepareHistory(t), AdminAudit: prepareAudit(t), AdminPolicy: preparePolicy(t)}
 }
 
 // PrepareGetFlags return a mock Get flag
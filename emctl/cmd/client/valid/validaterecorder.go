@@ -49,6 +49,8 @@ type (
 		FormatErrs []string `yaml:"formatErrs,omitempty"`
 		// GeneralErrs generated by Validate() of the Validator itself.
 		GeneralErrs []string `yaml:"generalErrs,omitempty"`
+		// PolicyErrs generated by the policies evaluated against the object.
+		PolicyErrs []string `yaml:"policyErrs,omitempty"`
 
 		// SystemErr stands internal error, which often means bugs.
 		SystemErr string `yaml:"systemErr,omitempty"`
@@ -164,6 +166,13 @@ func (vr *ValidateRecorder) recordGeneral(val *reflect.Value, field *reflect.Str
 	}
 }
 
+// RecordPolicy records a violation of the policy.
+func (vr *ValidateRecorder) RecordPolicy(policyName string, err error) {
+	if err != nil {
+		vr.PolicyErrs = append(vr.PolicyErrs, fmt.Sprintf("%s: %s", policyName, err.Error()))
+	}
+}
+
 func (vr *ValidateRecorder) recordSystem(err error) {
 	if err != nil {
 		vr.SystemErr = err.Error()
@@ -185,5 +194,5 @@ func (vr *ValidateRecorder) String() string {
 // Valid represents if the result is valid.
 func (vr *ValidateRecorder) Valid() bool {
 	return len(vr.JSONSchemaErrs) == 0 && len(vr.FormatErrs) == 0 &&
-		len(vr.GeneralErrs) == 0 && len(vr.SystemErr) == 0
+		len(vr.GeneralErrs) == 0 && len(vr.PolicyErrs) == 0 && len(vr.SystemErr) == 0
 }
@@ -47,6 +47,9 @@ type (
 		SourceFile       string
 		ResourceMapping  map[string]string
 		SubResource      string
+		// ResourceSpec indicates the objects of the control plane are <Resource>Spec of the resource
+		// package, which carry the fields not in v2alpha1 yet.
+		ResourceSpec bool
 	}
 	generator struct {
 		spec   *InterfaceFileSpec
@@ -22,6 +22,7 @@ import (
 	"go/ast"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/dave/jennifer/jen"
@@ -33,9 +34,11 @@ func TestGenerator(t *testing.T) {
 		goPackage    string
 		sourceFile   string
 		resourceType ResourceType
+		resourceSpec bool
 	}{
 		{goPackage: "github.com/megaease/easemeshctl/cmd/client/command/meshclient", sourceFile: "../../client/command/meshclient/serviceinstance.go", resourceType: "Global"},
 		{goPackage: "github.com/megaease/easemeshctl/cmd/client/command/meshclient", sourceFile: "../../client/command/meshclient/resilience.go", resourceType: "Service"},
+		{goPackage: "github.com/megaease/easemeshctl/cmd/client/command/meshclient", sourceFile: "../../client/command/meshclient/loadbalance.go", resourceType: "Service", resourceSpec: true},
 	}
 
 	var specs []*InterfaceFileSpec
@@ -44,13 +47,14 @@ func TestGenerator(t *testing.T) {
 		spec := &InterfaceFileSpec{}
 		// Get the package of the file with go:generate comment
 		goPackage := data.goPackage
-		spec.Buf = jen.NewFile(goPackage)
+		spec.Buf = jen.NewFile(filepath.Base(goPackage))
 		spec.SourceFile = data.sourceFile
 		spec.PkgName = goPackage
 		ext := filepath.Ext(spec.SourceFile)
 		baseFilename := spec.SourceFile[0 : len(spec.SourceFile)-len(ext)]
 		spec.GenerateFileName = baseFilename + "_gen.go"
 		spec.ResourceType = data.resourceType
+		spec.ResourceSpec = data.resourceSpec
 		specs = append(specs, spec)
 	}
 	for _, spec := range specs {
@@ -59,6 +63,21 @@ func TestGenerator(t *testing.T) {
 			t.Fatalf("generate code for %s error, %s", spec.SourceFile, err)
 		}
 	}
+
+	buf := &bytes.Buffer{}
+	err := specs[2].Buf.Render(buf)
+	if err != nil {
+		t.Fatalf("render code for %s error, %s", specs[2].SourceFile, err)
+	}
+	code := buf.String()
+	for _, want := range []string{"&resource.LoadBalanceSpec{}", "object := args1.Spec", "`json:\"loadBalance\"`"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code of resource spec should contain %s, but got:\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "v2alpha1") {
+		t.Errorf("generated code of resource spec should not use v2alpha1, but got:\n%s", code)
+	}
 }
 
 func TestQualRender(t *testing.T) {
@@ -48,6 +48,7 @@ type (
 		resourceType        ResourceType
 		subResource         string
 		resource2UrlMapping map[string]string
+		resourceSpec        bool
 	}
 )
 
@@ -239,6 +240,9 @@ func buildServiceTypePostURLStatement(info *buildInfo) func(string) (jen.Code, e
 
 func buildResourceToObjectStatement(info *buildInfo) func(string) (jen.Code, error) {
 	return func(resourceName string) (jen.Code, error) {
+		if info.resourceSpec {
+			return jen.Id("object").Op(":=").Id("args1").Dot("Spec"), nil
+		}
 		return jen.Id("object").Op(":=").Id("args1").Dot("ToV2Alpha1").Call(), nil
 	}
 }
@@ -271,14 +275,18 @@ func buildGetByContextHTTPCallStatement(info *buildInfo) func(string) (jen.Code,
 						jen.Id("url"), jen.Id("statusCode"), jen.String().Call(jen.Id("buff")),
 					)),
 				)
-				stmt3 := jen.Id(resourceName).Op(":=").Op("&").Qual(v2alpha1Pkg, capResourceName).Block()
+				objectPkg, objectType := v2alpha1Pkg, capResourceName
+				if info.resourceSpec {
+					objectPkg, objectType = resourcePkg, capResourceName+"Spec"
+				}
+				stmt3 := jen.Id(resourceName).Op(":=").Op("&").Qual(objectPkg, objectType).Block()
 				stmt4 := jen.Id("err").Op(":=").Qual("encoding/json", "Unmarshal").Call(
 					jen.Id("buff"), jen.Id(resourceName),
 				)
 				stmt5 := jen.If(jen.Id("err").Op("!=").Nil()).Block(
 					jen.Return(jen.Nil(), jen.Qual(errorsPkg, "Wrapf").Call(
 						jen.Id("err"),
-						jen.Lit("unmarshal data to v2alpha1."+resourceName)),
+						jen.Lit("unmarshal data to "+objectPkg[strings.LastIndex(objectPkg, "/")+1:]+"."+objectType)),
 					))
 
 				var returnStmt jen.Code
@@ -463,7 +471,7 @@ func buildListByContextStatement(info *buildInfo) func(string) (jen.Code, error)
 				jen.Id("b").Op("[]").Byte(),
 				jen.Id("statusCode").Int(),
 			).Params(jen.Interface(), jen.Error()).BlockFunc(func(g1 *jen.Group) {
-				err = listMethodAcceptor(newListMethodVisitor(info.resourceType, resourceName, info.subResource, g1))
+				err = listMethodAcceptor(newListMethodVisitor(info.resourceType, resourceName, info.subResource, info.resourceSpec, g1))
 			}),
 		)
 		if err != nil {
@@ -51,14 +51,19 @@ type (
 )
 
 func newListMethodVisitor(resourceType ResourceType, resourceName string,
-	subResource string, group *jen.Group) listMethodVisitor {
+	subResource string, resourceSpec bool, group *jen.Group) listMethodVisitor {
+	unmarshalService := serviceUnmarshalObject
+	if resourceSpec {
+		unmarshalService = serviceResourceSpecUnmarshalObject
+	}
+
 	return &baseListMethodVisitor{
 		resourceType: resourceType,
 		resourceName: resourceName,
 		subResource:  subResource,
 		group:        group,
 		unmarshalStatementMappings: map[ResourceType]statementBuilder{
-			Service: statementBuilderFunc(serviceUnmarshalObject),
+			Service: statementBuilderFunc(unmarshalService),
 			Global:  statementBuilderFunc(globalUnmarshalObject),
 		},
 
@@ -143,6 +148,34 @@ func serviceUnmarshalObject(resourceName, subResource string) (result []jen.Code
 	return
 }
 
+// serviceResourceSpecUnmarshalObject only unmarshals the resource of the services,
+// which is <Resource>Spec of the resource package to keep the fields not in v2alpha1.
+func serviceResourceSpecUnmarshalObject(resourceName, subResource string) (result []jen.Code, err error) {
+	if subResource != "" {
+		err = errors.Errorf("resource spec of sub resource %s is unsupported", subResource)
+		return
+	}
+
+	capResourceName := strings.ToUpper(resourceName[0:1]) + resourceName[1:]
+	jsonName := strings.ToLower(resourceName[0:1]) + resourceName[1:]
+	stmt3 := jen.Id("services").Op(":=").Op("[]").Struct(
+		jen.Id("Name").String().Tag(map[string]string{"json": "name"}),
+		jen.Id(capResourceName).Op("*").Qual(resourcePkg, capResourceName+"Spec").Tag(map[string]string{"json": jsonName}),
+	).Block()
+	result = append(result, stmt3)
+	stmt4 := jen.Id("err").Op(":=").Qual("encoding/json", "Unmarshal").Call(
+		jen.Id("b"), jen.Op("&").Id("services"),
+	)
+	result = append(result, stmt4)
+	stmt5 := jen.If(jen.Id("err").Op("!=").Nil()).Block(
+		jen.Return(jen.Nil(), jen.Qual(errorsPkg, "Wrapf").Call(
+			jen.Id("err"),
+			jen.Lit("unmarshal data to services")),
+		))
+	result = append(result, stmt5)
+	return
+}
+
 func globalUnmarshalObject(resourceName, subResource string) (codes []jen.Code, err error) {
 	resourceVarName := strings.ToLower(resourceName[0:1]) + resourceName[1:]
 	def := jen.Id(resourceVarName).Op(":=").Op("[]").Qual(v2alpha1Pkg, resourceName).Op("{}")
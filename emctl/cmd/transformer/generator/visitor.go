@@ -112,6 +112,7 @@ func (m *meshClientVisitor) visitorIntrefaceMethod(concreateStruct string, verb
 		resourceType:        m.resourceType,
 		subResource:         spec.SubResource,
 		resource2UrlMapping: spec.ResourceMapping,
+		resourceSpec:        spec.ResourceSpec,
 	}
 
 	switch verb {
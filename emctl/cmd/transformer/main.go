@@ -29,6 +29,8 @@ import (
 )
 
 func main() {
+	resourceSpec := flag.Bool("resource-spec", false,
+		"the objects sent to and received from the control plane are <Resource>Spec of the resource package instead of the ones of v2alpha1")
 	flag.Parse()
 
 	resourceType := flag.Arg(0)
@@ -49,6 +51,7 @@ func main() {
 	}
 
 	spec := initialSpec(generator.ResourceType(resourceType), subResource, resourceMappings)
+	spec.ResourceSpec = *resourceSpec
 	err := generator.New(spec).Accept(generator.NewVisitor(generator.ResourceType(spec.ResourceType)))
 	if err != nil {
 		common.ExitWithError(err)
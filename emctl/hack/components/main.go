@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command components maintains the manifest of the components compiled into emctl,
+// which is the only source of the copy compiled into the operator.
+//
+// Usage:
+//
+//	go run ./hack/components -release 2.2.1  # resolve the digests of the release and generate the copy
+//	go run ./hack/components                 # generate the copy of the operator only
+//	go run ./hack/components -check          # fail if the copy is stale
+//	go run ./hack/components -check -pinned  # fail also if any release is not pinned, before publishing it
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/megaease/easemeshctl/pkg/version"
+
+	"gopkg.in/yaml.v2"
+)
+
+func main() {
+	release := flag.String("release", "", "The release to resolve, it copies the components of the latest release if it's new")
+	manifestFile := flag.String("manifest", "pkg/version/components.yaml", "The manifest of emctl")
+	operatorManifestFile := flag.String("operator-manifest", "../operator/pkg/version/components.yaml", "The copy of the manifest of the operator, generated from the manifest of emctl")
+	check := flag.Bool("check", false, "Check the copy of the operator is up to date")
+	pinned := flag.Bool("pinned", false, "Check also all releases are pinned by digests and immutable tags, with -check")
+	flag.Parse()
+
+	var err error
+	switch {
+	case *check:
+		err = checkManifest(*manifestFile, *operatorManifestFile, *pinned)
+	case *release != "":
+		err = resolve(*release, *manifestFile)
+		if err == nil {
+			err = generateCopy(*manifestFile, *operatorManifestFile)
+		}
+	default:
+		err = generateCopy(*manifestFile, *operatorManifestFile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "components: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// checkManifest fails if the copy of the operator is stale, and if any component of
+// the releases is not pinned when pinned is set, so a release is never published with
+// the images which could change under the same reference.
+func checkManifest(manifestFile, operatorManifestFile string, pinned bool) error {
+	buff, err := ioutil.ReadFile(manifestFile)
+	if err != nil {
+		return err
+	}
+	manifest, err := version.ParseComponentsManifest(buff)
+	if err != nil {
+		return err
+	}
+
+	problems := []string{}
+	if pinned {
+		problems = manifest.Unpinned()
+	}
+	operatorBuff, err := ioutil.ReadFile(operatorManifestFile)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(operatorBuff, version.GenerateCopy(buff)) {
+		problems = append(problems, fmt.Sprintf("%s is out of sync, run: make components-sync", operatorManifestFile))
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("%s is not ready:\n  %s\npin the tags and run: make components RELEASE=<release>",
+			manifestFile, strings.Join(problems, "\n  "))
+	}
+
+	return nil
+}
+
+// generateCopy writes the copy of the manifest for the operator.
+func generateCopy(manifestFile, operatorManifestFile string) error {
+	buff, err := ioutil.ReadFile(manifestFile)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(operatorManifestFile, version.GenerateCopy(buff), 0o644)
+}
+
+// resolve resolves the digests of the components of the release from the registry.
+func resolve(release, manifestFile string) error {
+	buff, err := ioutil.ReadFile(manifestFile)
+	if err != nil {
+		return err
+	}
+	manifest, err := version.ParseComponentsManifest(buff)
+	if err != nil {
+		return err
+	}
+
+	r, err := manifest.Release(release)
+	if err != nil {
+		latest, _ := manifest.Release("")
+		r = &version.ComponentsRelease{Release: release}
+		for _, c := range latest.Components {
+			r.Components = append(r.Components, &version.Component{Name: c.Name, Image: c.Image})
+		}
+		manifest.Releases = append(manifest.Releases, r)
+	}
+
+	for _, c := range r.Components {
+		c.Digest, err = resolveDigest(c.Image)
+		if err != nil {
+			return fmt.Errorf("resolve %s: %v", c.Image, err)
+		}
+		fmt.Printf("%s: %s\n", c.Image, c.Digest)
+	}
+
+	out, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: Keep the header comments of the manifest.
+	header := &bytes.Buffer{}
+	for _, line := range strings.SplitAfter(string(buff), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		header.WriteString(line)
+	}
+	out = append(header.Bytes(), out...)
+
+	return ioutil.WriteFile(manifestFile, out, 0o644)
+}
+
+// resolveDigest resolves the digest of the image in the registry, which is the digest
+// of the manifest list for the multi-platform images.
+func resolveDigest(image string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.Command("docker", "buildx", "imagetools", "inspect", image, "--format", "{{json .Manifest}}")
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	descriptor := struct {
+		Digest string `json:"digest"`
+	}{}
+	err = json.Unmarshal(stdout.Bytes(), &descriptor)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(descriptor.Digest, "sha256:") {
+		return "", fmt.Errorf("invalid digest %q", descriptor.Digest)
+	}
+
+	return descriptor.Digest, nil
+}
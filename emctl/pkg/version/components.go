@@ -0,0 +1,224 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package version
+
+import (
+	_ "embed" // for the manifest of the components
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// The names of the components.
+const (
+	ComponentEasegress               = "easegress"
+	ComponentEaseMeshOperator        = "easemesh-operator"
+	ComponentShadowServiceController = "shadowservice-controller"
+	ComponentRBACProxy               = "rbac-proxy"
+	ComponentSidecar                 = "sidecar"
+	ComponentAgentInitializer        = "agent-initializer"
+	ComponentCoreDNS                 = "coredns"
+)
+
+// generatedHeader marks the copies of the manifest generated from the one of emctl.
+const generatedHeader = "# Code generated by emctl/hack/components from emctl/pkg/version/components.yaml. DO NOT EDIT.\n\n"
+
+//go:embed components.yaml
+var componentsYAML []byte
+
+type (
+	// ComponentsManifest maps every release to the images of its components.
+	ComponentsManifest struct {
+		Releases []*ComponentsRelease `yaml:"releases" json:"releases"`
+	}
+
+	// ComponentsRelease is the components of a release.
+	ComponentsRelease struct {
+		Release    string       `yaml:"release" json:"release"`
+		Components []*Component `yaml:"components" json:"components"`
+	}
+
+	// Component is the image of a component pinned by its digest.
+	Component struct {
+		Name   string `yaml:"name" json:"name"`
+		Image  string `yaml:"image" json:"image"`
+		Digest string `yaml:"digest" json:"digest"`
+	}
+)
+
+// ParseComponentsManifest parses the manifest of the components.
+func ParseComponentsManifest(buff []byte) (*ComponentsManifest, error) {
+	manifest := &ComponentsManifest{}
+	err := yaml.Unmarshal(buff, manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal components manifest")
+	}
+	if len(manifest.Releases) == 0 {
+		return nil, errors.Errorf("no release in components manifest")
+	}
+
+	for _, r := range manifest.Releases {
+		for _, c := range r.Components {
+			if c.Name == "" || c.Image == "" {
+				return nil, errors.Errorf("release %s: component without name or image", r.Release)
+			}
+			if c.Digest != "" && !strings.HasPrefix(c.Digest, "sha256:") {
+				return nil, errors.Errorf("release %s: invalid digest %s of %s", r.Release, c.Digest, c.Name)
+			}
+		}
+	}
+
+	return manifest, nil
+}
+
+// GenerateCopy returns the copy of the manifest for the other modules such as the
+// operator, which can't embed the manifest of emctl.
+func GenerateCopy(manifest []byte) []byte {
+	return append([]byte(generatedHeader), manifest...)
+}
+
+// Components returns the manifest of the components compiled into emctl.
+func Components() (*ComponentsManifest, error) {
+	return ParseComponentsManifest(componentsYAML)
+}
+
+// Release returns the components of the release, the empty release means the latest one.
+func (m *ComponentsManifest) Release(release string) (*ComponentsRelease, error) {
+	if release == "" {
+		return m.Releases[len(m.Releases)-1], nil
+	}
+
+	release = strings.TrimPrefix(release, "v")
+	for _, r := range m.Releases {
+		if strings.TrimPrefix(r.Release, "v") == release {
+			return r, nil
+		}
+	}
+
+	return nil, errors.Errorf("release %s not found in components manifest", release)
+}
+
+// Released reports whether the image is one of the released images of the component,
+// which is referred by either its tag or its digest.
+func (m *ComponentsManifest) Released(name, image string) bool {
+	for _, r := range m.Releases {
+		c := r.Component(name)
+		if c != nil && (image == c.Image || image == c.Reference()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unpinned returns the problems of the components which are not pinned, whose
+// digests are not resolved or whose tags float, a release must not be built with them.
+func (m *ComponentsManifest) Unpinned() []string {
+	var problems []string
+	for _, r := range m.Releases {
+		for _, c := range r.Components {
+			if c.Digest == "" {
+				problems = append(problems, fmt.Sprintf("release %s: digest of %s is not resolved", r.Release, c.Name))
+			}
+			if tag := Tag(c.Image); tag == "" || tag == "latest" {
+				problems = append(problems, fmt.Sprintf("release %s: tag of %s is floating: %s", r.Release, c.Name, c.Image))
+			}
+		}
+	}
+	return problems
+}
+
+// CurrentComponents returns the components of the release of emctl, the builds
+// without a known release use the latest one.
+func CurrentComponents() (*ComponentsRelease, error) {
+	manifest, err := Components()
+	if err != nil {
+		return nil, err
+	}
+	r, err := manifest.Release(RELEASE)
+	if err != nil {
+		r, _ = manifest.Release("")
+	}
+	return r, nil
+}
+
+// CurrentImage returns the pinned image of the component of the release of emctl.
+func CurrentImage(name string) (string, error) {
+	r, err := CurrentComponents()
+	if err != nil {
+		return "", err
+	}
+	return r.Image(name)
+}
+
+// Component returns the component by its name, or nil if not found.
+func (r *ComponentsRelease) Component(name string) *Component {
+	for _, c := range r.Components {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Image returns the pinned image of the component, it fails if the component
+// is not in the release.
+func (r *ComponentsRelease) Image(name string) (string, error) {
+	c := r.Component(name)
+	if c == nil {
+		return "", errors.Errorf("component %s not found in release %s", name, r.Release)
+	}
+	return c.Reference(), nil
+}
+
+// Reference returns the image pinned by the digest, the image with the tag
+// only if the digest has not been resolved.
+func (c *Component) Reference() string {
+	if c.Digest == "" {
+		return c.Image
+	}
+	return c.Image + "@" + c.Digest
+}
+
+// Repository returns the image without the tag and the digest.
+func (c *Component) Repository() string {
+	return Repository(c.Image)
+}
+
+// Tag returns the tag of the image, it's empty if the image has no tag.
+func Tag(image string) string {
+	if i := strings.Index(image, "@"); i != -1 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
+
+// Repository returns the image without the tag and the digest.
+func Repository(image string) string {
+	if i := strings.Index(image, "@"); i != -1 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package version
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestComponents(t *testing.T) {
+	manifest, err := Components()
+	if err != nil {
+		t.Fatalf("parse components manifest failed: %v", err)
+	}
+	release, err := CurrentComponents()
+	if err != nil {
+		t.Fatalf("get current components failed: %v", err)
+	}
+	for _, name := range []string{
+		ComponentEasegress, ComponentEaseMeshOperator, ComponentShadowServiceController,
+		ComponentRBACProxy, ComponentSidecar, ComponentAgentInitializer, ComponentCoreDNS,
+	} {
+		c := release.Component(name)
+		if c == nil {
+			t.Fatalf("component %s not found in release %s", name, release.Release)
+		}
+		if !manifest.Released(name, c.Reference()) || !manifest.Released(name, c.Image) {
+			t.Fatalf("expect %s released", c.Reference())
+		}
+	}
+	if manifest.Released(ComponentEasegress, "megaease/easegress:custom") {
+		t.Fatalf("expect custom image not released")
+	}
+	if _, err := release.Image("unknown"); err == nil {
+		t.Fatalf("expect component not found error")
+	}
+
+	// NOTE: The operator compiles its copy of the manifest.
+	operatorManifest, err := ioutil.ReadFile("../../../operator/pkg/version/components.yaml")
+	if err != nil {
+		t.Fatalf("read manifest of the operator failed: %v", err)
+	}
+	if !bytes.Equal(operatorManifest, GenerateCopy(componentsYAML)) {
+		t.Fatalf("manifest of the operator is out of sync, run: make components-sync")
+	}
+}
+
+func TestComponentReference(t *testing.T) {
+	c := &Component{Name: ComponentEasegress, Image: "megaease/easegress:easemesh"}
+	if c.Reference() != "megaease/easegress:easemesh" {
+		t.Fatalf("unexpected reference of unpinned image: %s", c.Reference())
+	}
+
+	c.Digest = "sha256:0123"
+	if c.Reference() != "megaease/easegress:easemesh@sha256:0123" ||
+		Repository(c.Reference()) != "megaease/easegress" ||
+		Repository("localhost:5000/easegress") != "localhost:5000/easegress" {
+		t.Fatalf("unexpected reference of pinned image: %s", c.Reference())
+	}
+
+	_, err := ParseComponentsManifest([]byte(`releases:
+- release: 1.0.0
+  components:
+  - name: easegress
+    image: megaease/easegress:easemesh
+    digest: md5:0123
+`))
+	if err == nil {
+		t.Fatalf("expect invalid digest error")
+	}
+
+	manifest, err := Components()
+	if err != nil {
+		t.Fatalf("parse components manifest failed: %v", err)
+	}
+	_, err = manifest.Release("0.0.0")
+	if err == nil {
+		t.Fatalf("expect release not found error")
+	}
+}
+
+func TestUnpinned(t *testing.T) {
+	manifest, err := ParseComponentsManifest([]byte(`releases:
+- release: 1.0.0
+  components:
+  - name: easegress
+    image: megaease/easegress:v1.0.0
+    digest: sha256:0123
+  - name: easemesh-operator
+    image: megaease/easemesh-operator:latest
+    digest: sha256:4567
+  - name: coredns
+    image: localhost:5000/coredns
+`))
+	if err != nil {
+		t.Fatalf("parse manifest failed: %v", err)
+	}
+
+	expected := []string{
+		"release 1.0.0: tag of easemesh-operator is floating: megaease/easemesh-operator:latest",
+		"release 1.0.0: digest of coredns is not resolved",
+		"release 1.0.0: tag of coredns is floating: localhost:5000/coredns",
+	}
+	if got := manifest.Unpinned(); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expect unpinned:\n%s\nbut got:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -18,6 +18,7 @@
 package main
 
 import (
+	"crypto/tls"
 	"io/ioutil"
 	"os"
 	"time"
@@ -29,8 +30,13 @@ import (
 
 	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
 	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/certificate"
 	"github.com/megaease/easemesh/mesh-operator/pkg/controllers"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
+	"github.com/megaease/easemesh/mesh-operator/pkg/federation"
 	"github.com/megaease/easemesh/mesh-operator/pkg/hook"
+	"github.com/megaease/easemesh/mesh-operator/pkg/installation"
+	"github.com/megaease/easemesh/mesh-operator/pkg/version"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -50,17 +56,17 @@ const (
 	// DefaultImageRegistryURL is the default image registry URL.
 	DefaultImageRegistryURL = "docker.io"
 
-	// DefaultSidecarImageName is the default sidecar image name.
-	DefaultSidecarImageName = "megaease/easegress:easemesh"
-
 	// DefaultImagePullPolicy is the default image pull policy.
 	DefaultImagePullPolicy = "IfNotPresent"
 
-	// DefaultAgentInitializerImageName is the default easeagent initializer image name.
-	DefaultAgentInitializerImageName = "megaease/easeagent-initializer"
-
 	// DefaultLog4jConfigName is the default log4j config file name.
 	DefaultLog4jConfigName = "easeagent-log4j2.xml"
+
+	// WebhookCertModeCA represents the webhook certificate is signed by the CA managed by the operator.
+	WebhookCertModeCA = "ca"
+	// DefaultWebhookCertMode is the default mode of the webhook certificate, which keeps the
+	// certificate as it is, for the config files written before the modes were added.
+	DefaultWebhookCertMode = "csr"
 )
 
 var scheme = runtime.NewScheme()
@@ -87,6 +93,12 @@ type ConfigSpec struct {
 	KeyName              string   `yaml:"key-name" jsonschema:"required"`
 	Log4jConfigName      string   `yaml:"log4j-config-name" jsonschema:"required"`
 
+	WebhookCertMode       string `yaml:"webhook-cert-mode" jsonschema:"omitempty"`
+	CertSecretName        string `yaml:"cert-secret-name" jsonschema:"omitempty"`
+	CertSecretNamespace   string `yaml:"cert-secret-namespace" jsonschema:"omitempty"`
+	MutatingWebhookName   string `yaml:"mutating-webhook-name" jsonschema:"omitempty"`
+	ValidatingWebhookName string `yaml:"validating-webhook-name" jsonschema:"omitempty"`
+
 	AgentInitializerImageName string `yaml:"agent-initializer-image-name" jsonschema:"required"`
 	SidecarImageName          string `yaml:"sidecar-image-name" jsonschema:"required"`
 }
@@ -95,28 +107,36 @@ func main() {
 	// TODO: Make flags/specfile parsing more maintainable.
 
 	var (
-		imageRegistryURL     string
-		sidecarImageName     string
-		imagePullPolicy      string
-		apiAddr              string
-		clusterName          string
-		clusterJoinURLs      []string
-		metricsAddr          string
-		enableLeaderElection bool
-		configFile           string
-		probeAddr            string
-		webhookPort          uint16
-		certDir              string
-		certName             string
-		keyName              string
-		log4jConfigName      string
+		imageRegistryURL      string
+		sidecarImageName      string
+		imagePullPolicy       string
+		apiAddr               string
+		clusterName           string
+		clusterJoinURLs       []string
+		metricsAddr           string
+		enableLeaderElection  bool
+		configFile            string
+		probeAddr             string
+		webhookPort           uint16
+		certDir               string
+		certName              string
+		keyName               string
+		log4jConfigName       string
+		webhookCertMode       string
+		certSecretName        string
+		certSecretNamespace   string
+		mutatingWebhookName   string
+		validatingWebhookName string
+		installerMode         bool
+		emctlPath             string
 		//
 		agentInitializerImageName string
 	)
 
 	pflag.StringVar(&imageRegistryURL, "image-registry-url", DefaultImageRegistryURL, "The image registry URL")
-	pflag.StringVar(&sidecarImageName, "sidecar-image-name", DefaultSidecarImageName, "The sidecar image name.")
-	pflag.StringVar(&agentInitializerImageName, "agent-initializer-image-name", DefaultAgentInitializerImageName, "The agent initializer image name.")
+	pflag.StringVar(&sidecarImageName, "sidecar-image-name", "", "The sidecar image name, default to the one pinned by the release.")
+	pflag.StringVar(&agentInitializerImageName, "agent-initializer-image-name", "",
+		"The agent initializer image name, default to the one pinned by the release.")
 	pflag.StringVar(&log4jConfigName, "log4j-config-name", DefaultLog4jConfigName, "The log4j config file name")
 	pflag.StringVar(&imagePullPolicy, "image-pull-policy", DefaultImagePullPolicy, "The image pull policy. (support Always, IfNotPresent, Never)")
 	pflag.StringVar(&clusterName, "cluster-name", "", "The name of the Easegress cluster.")
@@ -130,7 +150,18 @@ func main() {
 	pflag.StringVar(&certDir, "cert-dir", "/cert-volume", "The TLS cert directory.")
 	pflag.StringVar(&certName, "cert-file", "cert.pem", "The TLS cert file name.")
 	pflag.StringVar(&keyName, "key-file", "key.pem", "The TLS key file name.")
+	pflag.StringVar(&webhookCertMode, "webhook-cert-mode", DefaultWebhookCertMode, "The mode of the webhook certificate, "+
+		"the operator rotates the certificate and its CA in mode ca. (support ca, csr, cert-manager)")
+	pflag.StringVar(&certSecretName, "cert-secret-name", "easemesh-operator-secret", "The name of the secret of the TLS cert.")
+	pflag.StringVar(&certSecretNamespace, "cert-secret-namespace", "easemesh", "The namespace of the secret of the TLS cert.")
+	pflag.StringVar(&mutatingWebhookName, "mutating-webhook-name", "easemesh-operator-mutating-webhook",
+		"The name of the mutating webhook configuration whose caBundle is kept in sync in mode ca.")
+	pflag.StringVar(&validatingWebhookName, "validating-webhook-name", "easemesh-operator-validating-webhook",
+		"The name of the validating webhook configuration whose caBundle is kept in sync in mode ca.")
 	pflag.Uint16Var(&webhookPort, "webhook-port", 9090, "Webhook port listening on.")
+	pflag.BoolVar(&installerMode, "installer-mode", false, "Run as the installer of the EaseMesh, "+
+		"which reconciles EaseMeshInstallation only, instead of MeshDeployment and the sidecar injection.")
+	pflag.StringVar(&emctlPath, "emctl-path", installation.DefaultEmctlPath, "The path of emctl rendering the installation.")
 
 	pflag.Parse()
 
@@ -158,9 +189,21 @@ func main() {
 			agentInitializerImageName = spec.AgentInitializerImageName
 			sidecarImageName = spec.SidecarImageName
 			log4jConfigName = spec.Log4jConfigName
+			if spec.WebhookCertMode != "" {
+				webhookCertMode = spec.WebhookCertMode
+				certSecretName = spec.CertSecretName
+				certSecretNamespace = spec.CertSecretNamespace
+				mutatingWebhookName = spec.MutatingWebhookName
+				if spec.ValidatingWebhookName != "" {
+					validatingWebhookName = spec.ValidatingWebhookName
+				}
+			}
 		})
 	}
 
+	sidecarImageName = releasedImage(setupLog, sidecarImageName, version.ComponentSidecar)
+	agentInitializerImageName = releasedImage(setupLog, agentInitializerImageName, version.ComponentAgentInitializer)
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -190,22 +233,102 @@ func main() {
 		ClusterName:     clusterName,
 	}
 
+	if installerMode {
+		// Create EaseMeshInstallationReconciler.
+		installationRuntime := baseRuntime
+		installationRuntime.Name = "EaseMeshInstallation"
+		installationRuntime.Recorder = mgr.GetEventRecorderFor("controller.EaseMeshInstallation")
+		installationRuntime.Log = ctrl.Log.WithName("controllers").WithName("EaseMeshInstallation")
+		installationReconciler := &controllers.EaseMeshInstallationReconciler{
+			Runtime:   &installationRuntime,
+			Renderer:  &installation.EmctlRenderer{Path: emctlPath},
+			Provision: installation.ProvisionMeshController,
+		}
+		err = installationReconciler.SetupWithManager(mgr)
+		if err != nil {
+			setupLog.Error(err, "create controller of EaseMeshInstallation failed")
+			os.Exit(1)
+		}
+
+		startManager(mgr, setupLog)
+		return
+	}
+
 	// Create MeshDeploymentReconciler.
 	meshDeploymentRuntime := baseRuntime
 	meshDeploymentRuntime.Name = "MeshDeployment"
 	meshDeploymentRuntime.Log = ctrl.Log.WithName("controllers").WithName("MeshDeployment")
-	meshDeploymentReconciler := &controllers.MeshDeploymentReconciler{Runtime: &meshDeploymentRuntime}
+	meshDeploymentReconciler := &controllers.MeshDeploymentReconciler{
+		Runtime:      &meshDeploymentRuntime,
+		ControlPlane: controlplane.NewClient(apiAddr, nil),
+	}
 	err = meshDeploymentReconciler.SetupWithManager(mgr)
 	if err != nil {
 		setupLog.Error(err, "create controller of MeshDeployment failed")
 		os.Exit(1)
 	}
 
+	// Create MeshStatefulSetReconciler.
+	meshStatefulSetRuntime := baseRuntime
+	meshStatefulSetRuntime.Name = "MeshStatefulSet"
+	meshStatefulSetRuntime.Recorder = mgr.GetEventRecorderFor("controller.MeshStatefulSet")
+	meshStatefulSetRuntime.Log = ctrl.Log.WithName("controllers").WithName("MeshStatefulSet")
+	meshStatefulSetReconciler := &controllers.MeshStatefulSetReconciler{
+		Runtime:      &meshStatefulSetRuntime,
+		ControlPlane: controlplane.NewClient(apiAddr, nil),
+	}
+	err = meshStatefulSetReconciler.SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "create controller of MeshStatefulSet failed")
+		os.Exit(1)
+	}
+
+	// Create MeshDaemonSetReconciler.
+	meshDaemonSetRuntime := baseRuntime
+	meshDaemonSetRuntime.Name = "MeshDaemonSet"
+	meshDaemonSetRuntime.Recorder = mgr.GetEventRecorderFor("controller.MeshDaemonSet")
+	meshDaemonSetRuntime.Log = ctrl.Log.WithName("controllers").WithName("MeshDaemonSet")
+	meshDaemonSetReconciler := &controllers.MeshDaemonSetReconciler{
+		Runtime:      &meshDaemonSetRuntime,
+		ControlPlane: controlplane.NewClient(apiAddr, nil),
+	}
+	err = meshDaemonSetReconciler.SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "create controller of MeshDaemonSet failed")
+		os.Exit(1)
+	}
+
+	// Create MeshFederationReconciler.
+	meshFederationRuntime := baseRuntime
+	meshFederationRuntime.Name = "MeshFederation"
+	meshFederationRuntime.Recorder = mgr.GetEventRecorderFor("controller.MeshFederation")
+	meshFederationRuntime.Log = ctrl.Log.WithName("controllers").WithName("MeshFederation")
+	meshFederationReconciler := &controllers.MeshFederationReconciler{
+		Runtime: &meshFederationRuntime,
+		Local:   controlplane.NewClient(apiAddr, nil),
+		NewRemote: func(addr string, tlsConfig *tls.Config) federation.ControlPlane {
+			return controlplane.NewClient(addr, tlsConfig)
+		},
+	}
+	err = meshFederationReconciler.SetupWithManager(mgr)
+	if err != nil {
+		setupLog.Error(err, "create controller of MeshFederation failed")
+		os.Exit(1)
+	}
+
 	// Create a webhook server.
 	webhookRuntime := baseRuntime
 	webhookRuntime.Name = "Webhook"
 	webhookRuntime.Log = ctrl.Log.WithName("webhook").WithName("mutate")
 	webhookMutate := hook.NewMutateHook(&webhookRuntime)
+	validateRuntime := baseRuntime
+	validateRuntime.Name = "Webhook"
+	validateRuntime.Log = ctrl.Log.WithName("webhook").WithName("validate")
+	webhookValidate := hook.NewValidateHook(&validateRuntime, controlplane.NewClient(apiAddr, nil))
+	localityRuntime := baseRuntime
+	localityRuntime.Name = "Webhook"
+	localityRuntime.Log = ctrl.Log.WithName("webhook").WithName("locality")
+	webhookLocality := hook.NewLocalityHook(&localityRuntime, mgr.GetAPIReader())
 	webhookServer := &webhook.Server{
 		Port:     int(webhookPort),
 		CertDir:  certDir,
@@ -214,14 +337,41 @@ func main() {
 	}
 
 	webhookServer.Register("/mutate", webhookMutate.Admission)
+	webhookServer.Register("/validate", webhookValidate.Admission)
+	webhookServer.Register("/locality", webhookLocality.Admission)
 
 	if err := mgr.Add(webhookServer); err != nil {
 		setupLog.Error(err, "unable to set up webhook server")
 		os.Exit(1)
 	}
 
+	if webhookCertMode == WebhookCertModeCA {
+		rotator := &certificate.Rotator{
+			Client:                mgr.GetClient(),
+			Reader:                mgr.GetAPIReader(),
+			Log:                   ctrl.Log.WithName("webhook").WithName("certificate"),
+			SecretName:            certSecretName,
+			SecretNamespace:       certSecretNamespace,
+			WebhookName:           mutatingWebhookName,
+			ValidatingWebhookName: validatingWebhookName,
+			DNSNames: []string{
+				"easemesh-operator-service." + certSecretNamespace + ".svc",
+				"easemesh-operator-service." + certSecretNamespace,
+				"easemesh-operator-service",
+			},
+		}
+		if err := mgr.Add(rotator); err != nil {
+			setupLog.Error(err, "unable to set up certificate rotator")
+			os.Exit(1)
+		}
+	}
+
 	// +kubebuilder:scaffold:builder
 
+	startManager(mgr, setupLog)
+}
+
+func startManager(mgr ctrl.Manager, setupLog logr.Logger) {
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -284,3 +434,18 @@ func unmarshalConfigFile(file string, setupLog logr.Logger, onSuccess func(*Conf
 	}
 	onSuccess(spec)
 }
+
+// releasedImage returns the image pinned by the release of the operator if the given
+// one is empty, it exits if the component is missing in the manifest of the components.
+func releasedImage(setupLog logr.Logger, image, name string) string {
+	if image != "" {
+		return image
+	}
+
+	image, err := version.CurrentImage(name)
+	if err != nil {
+		setupLog.Error(err, "unable to get the image pinned by the release", "component", name)
+		os.Exit(1)
+	}
+	return image
+}
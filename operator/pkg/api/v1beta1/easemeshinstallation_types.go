@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// InstallationConditionRendered reports whether the manifests of the spec are rendered.
+	InstallationConditionRendered = "Rendered"
+	// InstallationConditionApplied reports whether the rendered manifests are applied.
+	InstallationConditionApplied = "Applied"
+	// InstallationConditionProvisioned reports whether the EaseMesh controller is provisioned
+	// in the control plane.
+	InstallationConditionProvisioned = "Provisioned"
+	// InstallationConditionReady reports whether all the workloads of the installation are ready.
+	InstallationConditionReady = "Ready"
+)
+
+// EaseMeshInstallationSpec defines the desired state of EaseMeshInstallation.
+// The fields mirror the flags of `emctl install`, the omitted ones take the defaults of emctl.
+type EaseMeshInstallationSpec struct {
+	// MeshNamespace is the namespace the mesh infrastructure is deployed in.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=easemesh
+	MeshNamespace string `json:"meshNamespace,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	ImageRegistryURL string `json:"imageRegistryURL,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	EasegressImage string `json:"easegressImage,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	EasegressControlPlaneReplicas int `json:"easegressControlPlaneReplicas,omitempty"`
+	// +kubebuilder:validation:Optional
+	EgClientPort int `json:"egClientPort,omitempty"`
+	// +kubebuilder:validation:Optional
+	EgAdminPort int `json:"egAdminPort,omitempty"`
+	// +kubebuilder:validation:Optional
+	EgPeerPort int `json:"egPeerPort,omitempty"`
+	// +kubebuilder:validation:Optional
+	EgServicePeerPort int `json:"egServicePeerPort,omitempty"`
+	// +kubebuilder:validation:Optional
+	EgServiceAdminPort int `json:"egServiceAdminPort,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	MeshControlPlaneStorageClassName string `json:"meshControlPlaneStorageClassName,omitempty"`
+	// +kubebuilder:validation:Optional
+	MeshControlPlanePersistVolumeName string `json:"meshControlPlanePersistVolumeName,omitempty"`
+	// +kubebuilder:validation:Optional
+	MeshControlPlanePersistVolumeHostPath string `json:"meshControlPlanePersistVolumeHostPath,omitempty"`
+	// +kubebuilder:validation:Optional
+	MeshControlPlanePersistVolumeCapacity string `json:"meshControlPlanePersistVolumeCapacity,omitempty"`
+	// +kubebuilder:validation:Optional
+	MeshControlPlaneNodeSelectors map[string]string `json:"meshControlPlaneNodeSelectors,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	MeshIngressReplicas int `json:"meshIngressReplicas,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=19527
+	MeshIngressServicePort int32 `json:"meshIngressServicePort,omitempty"`
+
+	// AddOns are the names of the add-ons installed with the infrastructure.
+	// +kubebuilder:validation:Optional
+	AddOns []string `json:"addOns,omitempty"`
+	// +kubebuilder:validation:Optional
+	ShadowServiceControllerImage string `json:"shadowServiceControllerImage,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=eureka;consul;nacos
+	// +kubebuilder:default=eureka
+	EaseMeshRegistryType string `json:"easeMeshRegistryType,omitempty"`
+	// HeartbeatInterval is the heartbeat interval of the sidecars, in seconds.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=5
+	HeartbeatInterval int `json:"heartbeatInterval,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	EaseMeshOperatorImage string `json:"easeMeshOperatorImage,omitempty"`
+	// +kubebuilder:validation:Optional
+	EaseMeshOperatorReplicas int `json:"easeMeshOperatorReplicas,omitempty"`
+	// RBACProxyImage is the image of the RBAC proxy of the operator, including its registry.
+	// +kubebuilder:validation:Optional
+	RBACProxyImage string `json:"rbacProxyImage,omitempty"`
+	// +kubebuilder:validation:Optional
+	SidecarImage string `json:"sidecarImage,omitempty"`
+	// +kubebuilder:validation:Optional
+	AgentInitializerImage string `json:"agentInitializerImage,omitempty"`
+	// WebhookCertMode is the mode managing the certificate of the webhook of the operator.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=ca;csr;cert-manager
+	WebhookCertMode string `json:"webhookCertMode,omitempty"`
+
+	// Components are the resources and the scheduling of the installed components,
+	// in the same layout as the components of the spec file of `emctl install`.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Components *runtime.RawExtension `json:"components,omitempty"`
+}
+
+// ComponentStatus is the observed state of a workload of the installation.
+type ComponentStatus struct {
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	Replicas      int32  `json:"replicas"`
+	ReadyReplicas int32  `json:"readyReplicas"`
+}
+
+// EaseMeshInstallationStatus defines the observed state of EaseMeshInstallation
+type EaseMeshInstallationStatus struct {
+	// ObservedGeneration is the generation of the spec which the status reports.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are Rendered, Applied, Provisioned and Ready.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Components are the workloads of the installation.
+	// +optional
+	Components []ComponentStatus `json:"components,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=easemeshinstallations,scope=Cluster,shortName=emi
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.meshNamespace`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// EaseMeshInstallation is the Schema for the easemeshinstallations API,
+// the operator installs the EaseMesh as it specifies and keeps it installed.
+type EaseMeshInstallation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EaseMeshInstallationSpec   `json:"spec,omitempty"`
+	Status EaseMeshInstallationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// EaseMeshInstallationList contains a list of EaseMeshInstallation
+type EaseMeshInstallationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EaseMeshInstallation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EaseMeshInstallation{}, &EaseMeshInstallationList{})
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v1beta1
+
+import (
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DaemonSetDeploySpec is the specification of the desired behavior of the DaemonSet.
+type DaemonSetDeploySpec struct {
+	v1.DaemonSetSpec `json:",inline"`
+}
+
+// MeshDaemonSetSpec defines the desired state of MeshDaemonSet
+type MeshDaemonSetSpec struct {
+	Service ServiceSpec `json:"service"`
+	// Deploy describes a service desired state of the K8s DaemonSet.
+	Deploy DaemonSetDeploySpec `json:"deploy,omitempty"`
+}
+
+// MeshDaemonSetStatus defines the observed state of MeshDaemonSet
+type MeshDaemonSetStatus struct {
+	// ObservedGeneration is the generation of the spec which the status reports.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// DesiredNumberScheduled is the number of nodes which should run the pod of the DaemonSet.
+	// +optional
+	DesiredNumberScheduled int32 `json:"desiredNumberScheduled,omitempty"`
+	// NumberReady is the number of ready pods of the DaemonSet.
+	// +optional
+	NumberReady int32 `json:"numberReady,omitempty"`
+	// NumberAvailable is the number of available pods of the DaemonSet.
+	// +optional
+	NumberAvailable int32 `json:"numberAvailable,omitempty"`
+	// SidecarImage is the image of the injected sidecar.
+	// +optional
+	SidecarImage string `json:"sidecarImage,omitempty"`
+	// InitializerImage is the image of the injected init container.
+	// +optional
+	InitializerImage string `json:"initializerImage,omitempty"`
+	// Conditions are SidecarInjected, Synced and MeshServiceRegistered.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=meshdaemonsets,scope=Namespaced
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.service.name`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.status.desiredNumberScheduled`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.numberReady`
+// +kubebuilder:printcolumn:name="Available",type=integer,JSONPath=`.status.numberAvailable`
+// +kubebuilder:printcolumn:name="Registered",type=string,JSONPath=`.status.conditions[?(@.type=="MeshServiceRegistered")].status`
+// +kubebuilder:printcolumn:name="Sidecar",type=string,JSONPath=`.status.sidecarImage`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// MeshDaemonSet is the Schema for the meshdaemonsets API
+type MeshDaemonSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MeshDaemonSetSpec   `json:"spec,omitempty"`
+	Status MeshDaemonSetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MeshDaemonSetList contains a list of MeshDaemonSet
+type MeshDaemonSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MeshDaemonSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MeshDaemonSet{}, &MeshDaemonSetList{})
+}
@@ -54,6 +54,49 @@ type DeploySpec struct {
 	v1.DeploymentSpec `json:",inline"`
 }
 
+// CanarySpec describes the canary version of the mesh service,
+// which runs in a second Deployment besides the stable one.
+type CanarySpec struct {
+	// Replicas is the number of the pods of the canary version. Defaults to 1.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Image is the image of the application container of the canary version,
+	// it's the same as the stable version if empty.
+	// +kubebuilder:validation:Optional
+	Image string `json:"image,omitempty"`
+
+	// Labels label the instances of the canary version, the traffic chosen by
+	// the traffic rules is routed to the instances with them.
+	// +kubebuilder:validation:MinProperties=1
+	Labels map[string]string `json:"labels"`
+
+	// Priority is the priority of the canary among the canaries of the mesh service.
+	// +kubebuilder:validation:Optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// TrafficRules choose the traffic routed to the canary version.
+	TrafficRules CanaryTrafficRules `json:"trafficRules"`
+}
+
+// CanaryTrafficRules choose the traffic by the HTTP headers.
+type CanaryTrafficRules struct {
+	// Headers are the matches of the HTTP headers, all of them must be matched.
+	// +kubebuilder:validation:MinProperties=1
+	Headers map[string]StringMatch `json:"headers"`
+}
+
+// StringMatch matches a string exactly, by the prefix or by the regular expression.
+type StringMatch struct {
+	// +optional
+	Exact string `json:"exact,omitempty"`
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// +optional
+	Regex string `json:"regex,omitempty"`
+}
+
 // MeshDeploymentSpec defines the desired state of MeshDeployment
 type MeshDeploymentSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -61,17 +104,73 @@ type MeshDeploymentSpec struct {
 	Service ServiceSpec `json:"service"`
 	// Deploy describes a service desired state of the K8s deployment.
 	Deploy DeploySpec `json:"deploy,omitempty"`
+	// Canary describes the canary version of the service. Removing it promotes
+	// the canary, which deletes the canary Deployment and its ServiceCanary.
+	// +optional
+	Canary *CanarySpec `json:"canary,omitempty"`
 }
 
+// The conditions shared by the mesh workloads, which are MeshDeployment,
+// MeshStatefulSet and MeshDaemonSet.
+const (
+	// MeshWorkloadConditionSidecarInjected reports whether the sidecar is injected
+	// into the pod template of the workload.
+	MeshWorkloadConditionSidecarInjected = "SidecarInjected"
+	// MeshWorkloadConditionSynced reports whether the workload is synced.
+	MeshWorkloadConditionSynced = "Synced"
+	// MeshWorkloadConditionMeshServiceRegistered reports whether the mesh service
+	// is registered in the control plane.
+	MeshWorkloadConditionMeshServiceRegistered = "MeshServiceRegistered"
+)
+
+const (
+	// MeshDeploymentConditionCanarySynced reports whether the canary Deployment and
+	// its ServiceCanary are synced, it's removed after the canary is promoted.
+	MeshDeploymentConditionCanarySynced = "CanarySynced"
+)
+
 // MeshDeploymentStatus defines the observed state of MeshDeployment
 type MeshDeploymentStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// ObservedGeneration is the generation of the spec which the status reports.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Replicas is the number of desired pods of the Deployment.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of ready pods of the Deployment.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// AvailableReplicas is the number of available pods of the Deployment.
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+	// CanaryReplicas is the number of desired pods of the canary Deployment.
+	// +optional
+	CanaryReplicas int32 `json:"canaryReplicas,omitempty"`
+	// CanaryReadyReplicas is the number of ready pods of the canary Deployment.
+	// +optional
+	CanaryReadyReplicas int32 `json:"canaryReadyReplicas,omitempty"`
+	// SidecarImage is the image of the injected sidecar.
+	// +optional
+	SidecarImage string `json:"sidecarImage,omitempty"`
+	// InitializerImage is the image of the injected init container.
+	// +optional
+	InitializerImage string `json:"initializerImage,omitempty"`
+	// Conditions are SidecarInjected, Synced, MeshServiceRegistered and CanarySynced.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=meshdeployments,scope=Namespaced
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.service.name`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.status.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Available",type=integer,JSONPath=`.status.availableReplicas`
+// +kubebuilder:printcolumn:name="Canary",type=integer,JSONPath=`.status.canaryReadyReplicas`,priority=1
+// +kubebuilder:printcolumn:name="Registered",type=string,JSONPath=`.status.conditions[?(@.type=="MeshServiceRegistered")].status`
+// +kubebuilder:printcolumn:name="Sidecar",type=string,JSONPath=`.status.sidecarImage`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // MeshDeployment is the Schema for the meshdeployments API
 type MeshDeployment struct {
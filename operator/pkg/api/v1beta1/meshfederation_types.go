@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// FederationConditionExported reports whether the catalog of the exported services
+	// is published to the local control plane.
+	FederationConditionExported = "Exported"
+	// FederationConditionReady reports whether the catalogs of all remote clusters are imported.
+	FederationConditionReady = "Ready"
+)
+
+// FederationExport is a rule of the services exported to the remote clusters,
+// a service is exported if it matches any of the services or the tenants.
+type FederationExport struct {
+	// Services are the names of the exported services, "*" exports all services.
+	// +kubebuilder:validation:Optional
+	Services []string `json:"services,omitempty"`
+	// Tenants are the tenants whose services are exported.
+	// +kubebuilder:validation:Optional
+	Tenants []string `json:"tenants,omitempty"`
+}
+
+// FederationRemote is a remote cluster linked into the federation.
+type FederationRemote struct {
+	// Name is the name of the remote cluster, which is the cluster name in its MeshFederation.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// APIAddr is the address of the admin API of the control plane of the remote cluster.
+	APIAddr string `json:"apiAddr"`
+	// Gateway is the address of the east-west gateway of the remote cluster, which
+	// overrides the one published in its catalog.
+	// +kubebuilder:validation:Optional
+	Gateway string `json:"gateway,omitempty"`
+	// TrustSecretName is the secret in the namespace of the MeshFederation, holding the ca.crt
+	// verifying the remote control plane and the optional tls.crt and tls.key as the client
+	// certificate. The remote control plane is accessed by plain HTTP without it.
+	// +kubebuilder:validation:Optional
+	TrustSecretName string `json:"trustSecretName,omitempty"`
+}
+
+// MeshFederationSpec defines the desired state of MeshFederation
+type MeshFederationSpec struct {
+	// ClusterName is the name of the local cluster in the federation.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	ClusterName string `json:"clusterName"`
+	// Gateway is the address of the east-west gateway of the local cluster reachable from
+	// the remote clusters, which is the mesh ingress controller exposed out of the cluster.
+	Gateway string `json:"gateway"`
+	// Exports are the rules of the services exported to the remote clusters.
+	// +kubebuilder:validation:Optional
+	Exports []FederationExport `json:"exports,omitempty"`
+	// Remotes are the remote clusters whose exported services are imported.
+	// +kubebuilder:validation:Optional
+	Remotes []FederationRemote `json:"remotes,omitempty"`
+}
+
+// FederationRemoteStatus is the observed state of a remote cluster.
+type FederationRemoteStatus struct {
+	Name string `json:"name"`
+	// Connected reports whether the catalog of the remote cluster is fetched in the last sync.
+	Connected bool `json:"connected"`
+	// ImportedServices are the services imported from the remote cluster.
+	// +optional
+	ImportedServices []string `json:"importedServices,omitempty"`
+	// LastSyncTime is the time of the last successful sync.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Message is the error of the last sync.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// MeshFederationStatus defines the observed state of MeshFederation
+type MeshFederationStatus struct {
+	// ObservedGeneration is the generation of the spec which the status reports.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are Exported and Ready.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ExportedServices are the services exported to the remote clusters.
+	// +optional
+	ExportedServices []string `json:"exportedServices,omitempty"`
+	// Remotes are the states of the remote clusters.
+	// +optional
+	Remotes []FederationRemoteStatus `json:"remotes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=meshfederations,scope=Namespaced,shortName=mfed
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Gateway",type=string,JSONPath=`.spec.gateway`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// MeshFederation is the Schema for the meshfederations API, it links the control plane
+// of the local cluster with the ones of the remote clusters, which exchange the catalogs
+// of their exported services.
+type MeshFederation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MeshFederationSpec   `json:"spec,omitempty"`
+	Status MeshFederationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MeshFederationList contains a list of MeshFederation
+type MeshFederationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MeshFederation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MeshFederation{}, &MeshFederationList{})
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v1beta1
+
+import (
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StatefulSetDeploySpec is the specification of the desired behavior of the StatefulSet.
+type StatefulSetDeploySpec struct {
+	v1.StatefulSetSpec `json:",inline"`
+}
+
+// MeshStatefulSetSpec defines the desired state of MeshStatefulSet
+type MeshStatefulSetSpec struct {
+	Service ServiceSpec `json:"service"`
+	// Deploy describes a service desired state of the K8s StatefulSet.
+	Deploy StatefulSetDeploySpec `json:"deploy,omitempty"`
+}
+
+// MeshStatefulSetStatus defines the observed state of MeshStatefulSet
+type MeshStatefulSetStatus struct {
+	// ObservedGeneration is the generation of the spec which the status reports.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Replicas is the number of desired pods of the StatefulSet.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of ready pods of the StatefulSet.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// RegisteredInstances are the instance IDs registered in the control plane
+	// in the order of the ordinals, which are the pod names such as <name>-<ordinal>.
+	// +optional
+	RegisteredInstances []string `json:"registeredInstances,omitempty"`
+	// SidecarImage is the image of the injected sidecar.
+	// +optional
+	SidecarImage string `json:"sidecarImage,omitempty"`
+	// InitializerImage is the image of the injected init container.
+	// +optional
+	InitializerImage string `json:"initializerImage,omitempty"`
+	// Conditions are SidecarInjected, Synced and MeshServiceRegistered.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=meshstatefulsets,scope=Namespaced
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.service.name`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.status.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Registered",type=string,JSONPath=`.status.conditions[?(@.type=="MeshServiceRegistered")].status`
+// +kubebuilder:printcolumn:name="Sidecar",type=string,JSONPath=`.status.sidecarImage`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// MeshStatefulSet is the Schema for the meshstatefulsets API
+type MeshStatefulSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MeshStatefulSetSpec   `json:"spec,omitempty"`
+	Status MeshStatefulSetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MeshStatefulSetList contains a list of MeshStatefulSet
+type MeshStatefulSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MeshStatefulSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MeshStatefulSet{}, &MeshStatefulSetList{})
+}
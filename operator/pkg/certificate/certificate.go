@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certificate
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CAValidity is the validity of the CA signing the certificate of the webhook.
+	CAValidity = 5 * 365 * 24 * time.Hour
+	// ServingCertValidity is the validity of the certificate of the webhook.
+	ServingCertValidity = 365 * 24 * time.Hour
+)
+
+// KeyPair is a PEM encoded certificate with its private key.
+type KeyPair struct {
+	CertPem []byte
+	KeyPem  []byte
+
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+// ParseKeyPair parses the PEM encoded certificate and private key.
+func ParseKeyPair(certPem, keyPem []byte) (*KeyPair, error) {
+	cert, err := parseCert(certPem)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBlock, _ := pem.Decode(keyPem)
+	if keyBlock == nil {
+		return nil, errors.New("no PEM private key")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse private key")
+	}
+
+	return &KeyPair{CertPem: certPem, KeyPem: keyPem, cert: cert, key: key}, nil
+}
+
+// Cert returns the parsed certificate.
+func (kp *KeyPair) Cert() *x509.Certificate {
+	return kp.cert
+}
+
+// NeedRenew returns true if less than one third of the validity of the certificate remains.
+func (kp *KeyPair) NeedRenew(now time.Time) bool {
+	validity := kp.cert.NotAfter.Sub(kp.cert.NotBefore)
+	return now.Add(validity / 3).After(kp.cert.NotAfter)
+}
+
+// SignedBy returns true if the certificate is signed by the CA.
+func (kp *KeyPair) SignedBy(ca *KeyPair) bool {
+	return kp.cert.CheckSignatureFrom(ca.cert) == nil
+}
+
+// GenerateCA generates a self-signed CA.
+func GenerateCA(now time.Time) (*KeyPair, error) {
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			Organization: []string{"MegaEase"},
+			CommonName:   "easemesh-operator-ca",
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(CAValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	return generate(template, nil)
+}
+
+// GenerateServingCert generates the certificate of the webhook signed by the CA,
+// the first DNS name is the common name.
+func GenerateServingCert(ca *KeyPair, dnsNames []string, now time.Time) (*KeyPair, error) {
+	if len(dnsNames) == 0 {
+		return nil, errors.New("no DNS names of the serving certificate")
+	}
+
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			Organization: []string{"MegaEase"},
+			CommonName:   dnsNames[0],
+		},
+		DNSNames:    dnsNames,
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(ServingCertValidity),
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	// NOTE: The certificate never outlives the CA, so the CA is rotated first.
+	if template.NotAfter.After(ca.cert.NotAfter) {
+		template.NotAfter = ca.cert.NotAfter
+	}
+
+	return generate(template, ca)
+}
+
+// Bundle returns the PEM encoded CA bundle of the CAs, the expired ones are dropped.
+func Bundle(now time.Time, cas ...*x509.Certificate) []byte {
+	buff := &bytes.Buffer{}
+	for _, ca := range cas {
+		if ca == nil || now.After(ca.NotAfter) {
+			continue
+		}
+		pem.Encode(buff, &pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+	}
+	return buff.Bytes()
+}
+
+// ParseBundle parses the certificates in the PEM encoded CA bundle, the invalid blocks are skipped.
+func ParseBundle(bundle []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, bundle = pem.Decode(bundle)
+		if block == nil {
+			return certs
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+func parseCert(certPem []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPem)
+	if block == nil {
+		return nil, errors.New("no PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse certificate")
+	}
+	return cert, nil
+}
+
+// generate generates the key pair of the template signed by the CA, it signs itself if the CA is nil.
+func generate(template *x509.Certificate, ca *KeyPair) (*KeyPair, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate private key")
+	}
+
+	template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.Wrap(err, "generate serial number")
+	}
+
+	parent, signer := template, key
+	if ca != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		return nil, errors.Wrap(err, "create certificate")
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return ParseKeyPair(certPem, keyPem)
+}
@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// The keys of the secret of the webhook, the same as the ones created by `emctl install`.
+	secretCAKey       = "ca.pem"
+	secretCAKeyKey    = "ca-key.pem"
+	secretCABundleKey = "ca-bundle.pem"
+	secretCertKey     = "cert.pem"
+	secretKeyKey      = "key.pem"
+
+	// DefaultCheckInterval is the default interval checking the certificates.
+	DefaultCheckInterval = time.Hour
+)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;update
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;update
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;update
+
+// Rotator rotates the CA and the serving certificate of the webhook in the secret before
+// they expire, and keeps the caBundle of the mutating and validating webhooks in sync with the CA.
+// The webhook server reloads the certificate once the mounted secret is updated.
+//
+// The rotated CA stays in the CA bundle until it expires, so the serving certificate
+// signed by it is trusted during the rotation.
+type Rotator struct {
+	// Client writes the secret and the webhook configuration.
+	Client client.Client
+	// Reader reads them without the cache.
+	Reader client.Reader
+	Log    logr.Logger
+
+	SecretName      string
+	SecretNamespace string
+	WebhookName     string
+	// ValidatingWebhookName is the name of the validating webhook configuration,
+	// it's skipped if empty or not found, such as in the clusters upgraded from
+	// the releases without it.
+	ValidatingWebhookName string
+	DNSNames              []string
+
+	CheckInterval time.Duration
+	// Now returns the current time, it's time.Now if nil.
+	Now func() time.Time
+}
+
+// NeedLeaderElection returns false, since every replica serves the webhook, the replicas
+// racing to update the secret fail with conflicts and take the winner's at the next check.
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+// Start checks the certificates periodically until the context is done.
+func (r *Rotator) Start(ctx context.Context) error {
+	interval := r.CheckInterval
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := r.Rotate(ctx)
+		if err != nil {
+			r.Log.Error(err, "rotate certificates failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rotate rotates the certificates if needed and syncs the caBundle of the webhook.
+func (r *Rotator) Rotate(ctx context.Context) error {
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+
+	secret := &v1.Secret{}
+	err := r.Reader.Get(ctx, types.NamespacedName{Namespace: r.SecretNamespace, Name: r.SecretName}, secret)
+	if err != nil {
+		return errors.Wrapf(err, "get secret %s/%s", r.SecretNamespace, r.SecretName)
+	}
+
+	changed, err := rotateSecret(secret, r.DNSNames, now)
+	if err != nil {
+		return errors.Wrapf(err, "rotate secret %s/%s", r.SecretNamespace, r.SecretName)
+	}
+	if changed {
+		err = r.Client.Update(ctx, secret)
+		if err != nil {
+			return errors.Wrapf(err, "update secret %s/%s", r.SecretNamespace, r.SecretName)
+		}
+		r.Log.Info("rotated certificates", "secret", r.SecretNamespace+"/"+r.SecretName)
+	}
+
+	err = r.syncWebhook(ctx, secret.Data[secretCABundleKey])
+	if err != nil {
+		return err
+	}
+	return r.syncValidatingWebhook(ctx, secret.Data[secretCABundleKey])
+}
+
+func (r *Rotator) syncWebhook(ctx context.Context, caBundle []byte) error {
+	config := &admissionregv1.MutatingWebhookConfiguration{}
+	err := r.Reader.Get(ctx, types.NamespacedName{Name: r.WebhookName}, config)
+	if err != nil {
+		return errors.Wrapf(err, "get mutatingwebhookconfiguration %s", r.WebhookName)
+	}
+
+	changed := false
+	for i := range config.Webhooks {
+		if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			config.Webhooks[i].ClientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	err = r.Client.Update(ctx, config)
+	if err != nil {
+		return errors.Wrapf(err, "update mutatingwebhookconfiguration %s", r.WebhookName)
+	}
+	r.Log.Info("updated caBundle of webhook", "mutatingwebhookconfiguration", r.WebhookName)
+	return nil
+}
+
+func (r *Rotator) syncValidatingWebhook(ctx context.Context, caBundle []byte) error {
+	if r.ValidatingWebhookName == "" {
+		return nil
+	}
+
+	config := &admissionregv1.ValidatingWebhookConfiguration{}
+	err := r.Reader.Get(ctx, types.NamespacedName{Name: r.ValidatingWebhookName}, config)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "get validatingwebhookconfiguration %s", r.ValidatingWebhookName)
+	}
+
+	changed := false
+	for i := range config.Webhooks {
+		if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			config.Webhooks[i].ClientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	err = r.Client.Update(ctx, config)
+	if err != nil {
+		return errors.Wrapf(err, "update validatingwebhookconfiguration %s", r.ValidatingWebhookName)
+	}
+	r.Log.Info("updated caBundle of webhook", "validatingwebhookconfiguration", r.ValidatingWebhookName)
+	return nil
+}
+
+// rotateSecret rotates the CA and the serving certificate in the secret if they are missing,
+// invalid or in the last third of their validity, it returns true if the secret changed.
+func rotateSecret(secret *v1.Secret, dnsNames []string, now time.Time) (bool, error) {
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	changed := false
+
+	ca, err := ParseKeyPair(secret.Data[secretCAKey], secret.Data[secretCAKeyKey])
+	if err != nil || ca.NeedRenew(now) {
+		newCA, err := GenerateCA(now)
+		if err != nil {
+			return false, errors.Wrap(err, "generate CA")
+		}
+
+		// NOTE: Trust both of them until the old one expires.
+		bundle := []*x509.Certificate{newCA.Cert()}
+		if ca != nil {
+			bundle = append(bundle, ca.Cert())
+		}
+		secret.Data[secretCAKey] = newCA.CertPem
+		secret.Data[secretCAKeyKey] = newCA.KeyPem
+		secret.Data[secretCABundleKey] = Bundle(now, bundle...)
+		ca, changed = newCA, true
+	} else if !hasCert(secret.Data[secretCABundleKey], ca.Cert().Raw) {
+		secret.Data[secretCABundleKey] = Bundle(now, append([]*x509.Certificate{ca.Cert()},
+			ParseBundle(secret.Data[secretCABundleKey])...)...)
+		changed = true
+	}
+
+	cert, err := ParseKeyPair(secret.Data[secretCertKey], secret.Data[secretKeyKey])
+	if err != nil || cert.NeedRenew(now) || !cert.SignedBy(ca) {
+		cert, err = GenerateServingCert(ca, dnsNames, now)
+		if err != nil {
+			return false, errors.Wrap(err, "generate serving certificate")
+		}
+		secret.Data[secretCertKey] = cert.CertPem
+		secret.Data[secretKeyKey] = cert.KeyPem
+		changed = true
+	}
+
+	return changed, nil
+}
+
+func hasCert(bundle []byte, raw []byte) bool {
+	for _, cert := range ParseBundle(bundle) {
+		if bytes.Equal(cert.Raw, raw) {
+			return true
+		}
+	}
+	return false
+}
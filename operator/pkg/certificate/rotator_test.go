@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certificate
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var dnsNames = []string{"easemesh-operator-service.easemesh.svc", "easemesh-operator-service"}
+
+var _ = Describe("Rotator", func() {
+	It("issues the CA and the serving certificate into an empty secret", func() {
+		now := time.Now()
+		secret := &v1.Secret{}
+
+		changed, err := rotateSecret(secret, dnsNames, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		ca, err := ParseKeyPair(secret.Data[secretCAKey], secret.Data[secretCAKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+		cert, err := ParseKeyPair(secret.Data[secretCertKey], secret.Data[secretKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.SignedBy(ca)).To(BeTrue())
+		Expect(cert.Cert().DNSNames).To(Equal(dnsNames))
+		Expect(ParseBundle(secret.Data[secretCABundleKey])).To(HaveLen(1))
+
+		changed, err = rotateSecret(secret, dnsNames, now.Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeFalse())
+	})
+
+	It("renews the serving certificate in the last third of its validity", func() {
+		now := time.Now()
+		secret := &v1.Secret{}
+		_, err := rotateSecret(secret, dnsNames, now)
+		Expect(err).NotTo(HaveOccurred())
+		caPem, certPem := secret.Data[secretCAKey], secret.Data[secretCertKey]
+
+		changed, err := rotateSecret(secret, dnsNames, now.Add(ServingCertValidity*3/4))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(secret.Data[secretCAKey]).To(Equal(caPem))
+		Expect(secret.Data[secretCertKey]).NotTo(Equal(certPem))
+	})
+
+	It("rotates the CA and trusts both CAs until the old one expires", func() {
+		now := time.Now()
+		secret := &v1.Secret{}
+		_, err := rotateSecret(secret, dnsNames, now)
+		Expect(err).NotTo(HaveOccurred())
+		oldCA, err := ParseKeyPair(secret.Data[secretCAKey], secret.Data[secretCAKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+
+		later := now.Add(CAValidity * 3 / 4)
+		changed, err := rotateSecret(secret, dnsNames, later)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		newCA, err := ParseKeyPair(secret.Data[secretCAKey], secret.Data[secretCAKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newCA.Cert().Equal(oldCA.Cert())).To(BeFalse())
+		Expect(ParseBundle(secret.Data[secretCABundleKey])).To(HaveLen(2))
+
+		cert, err := ParseKeyPair(secret.Data[secretCertKey], secret.Data[secretKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.SignedBy(newCA)).To(BeTrue())
+
+		Expect(Bundle(now.Add(CAValidity*2), oldCA.Cert(), newCA.Cert())).To(HaveLen(0))
+	})
+
+	It("replaces the certificate signed by the cluster", func() {
+		now := time.Now()
+		other, err := GenerateCA(now)
+		Expect(err).NotTo(HaveOccurred())
+		secret := &v1.Secret{Data: map[string][]byte{
+			secretCertKey: other.CertPem,
+			secretKeyKey:  other.KeyPem,
+		}}
+
+		changed, err := rotateSecret(secret, dnsNames, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		ca, err := ParseKeyPair(secret.Data[secretCAKey], secret.Data[secretCAKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+		cert, err := ParseKeyPair(secret.Data[secretCertKey], secret.Data[secretKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.SignedBy(ca)).To(BeTrue())
+	})
+})
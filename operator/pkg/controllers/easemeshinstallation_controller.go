@@ -0,0 +1,340 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/installation"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// installationFieldOwner is the field manager of the server-side applying.
+	installationFieldOwner = "easemesh-installation"
+
+	// installationProgressPeriod is the requeue period while the installation is in progress.
+	installationProgressPeriod = 10 * time.Second
+
+	// DefaultInstallationResyncPeriod is the default period of correcting the drift of the installation.
+	DefaultInstallationResyncPeriod = 5 * time.Minute
+)
+
+// EaseMeshInstallationReconciler reconciles a EaseMeshInstallation object
+type EaseMeshInstallationReconciler struct {
+	*base.Runtime
+
+	Renderer installation.Renderer
+	// Provision provisions the EaseMesh controller in the control plane.
+	Provision func(ctx context.Context, adminURL string, spec *meshv1beta1.EaseMeshInstallationSpec) error
+	// ResyncPeriod is the period of reconciling the ready installation.
+	ResyncPeriod time.Duration
+}
+
+// NOTE: The installation creates all kinds of the mesh infrastructure, including the cluster roles
+// of the components, so the operator needs to hold the permissions it grants.
+
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=easemeshinstallations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=easemeshinstallations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=easemeshinstallations/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=namespaces;services;configmaps;secrets;serviceaccounts;persistentvolumes;persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=statefulsets;deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings;clusterroles;clusterrolebindings,verbs=get;list;watch;create;update;patch;delete;escalate;bind
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=issuers;certificates,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile reconciles EaseMeshInstallation.
+func (r *EaseMeshInstallationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("EaseMeshInstallationID", req.NamespacedName)
+
+	meshInstallation := &meshv1beta1.EaseMeshInstallation{}
+	err := r.Client.Get(ctx, req.NamespacedName, meshInstallation)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("EaseMeshInstallation not found")
+			return reconcile.Result{}, nil
+		}
+		log.Error(err, "get EaseMeshInstallation")
+		return reconcile.Result{}, err
+	}
+
+	log.Info("syncing EaseMeshInstallation")
+
+	ready, err := r.sync(ctx, meshInstallation)
+	if err != nil {
+		log.V(1).Error(err, "sync EaseMeshInstallation")
+		r.Recorder.Event(meshInstallation, corev1.EventTypeWarning, "SyncFailed", err.Error())
+	}
+
+	meshInstallation.Status.ObservedGeneration = meshInstallation.Generation
+	statusErr := r.Client.Status().Update(ctx, meshInstallation)
+	if statusErr != nil {
+		log.Error(statusErr, "update status of EaseMeshInstallation")
+		if err == nil {
+			err = statusErr
+		}
+	}
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !ready {
+		return ctrl.Result{RequeueAfter: installationProgressPeriod}, nil
+	}
+
+	resyncPeriod := r.ResyncPeriod
+	if resyncPeriod == 0 {
+		resyncPeriod = DefaultInstallationResyncPeriod
+	}
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
+
+// sync renders the spec, applies the objects, provisions the control plane
+// and reports the conditions into the status, it returns whether the installation is ready.
+func (r *EaseMeshInstallationReconciler) sync(ctx context.Context, meshInstallation *meshv1beta1.EaseMeshInstallation) (bool, error) {
+	spec := &meshInstallation.Spec
+	status := &meshInstallation.Status
+
+	objects, err := r.Renderer.Render(ctx, spec)
+	if err != nil {
+		setCondition(meshInstallation, meshv1beta1.InstallationConditionRendered, "RenderFailed", err)
+		return false, err
+	}
+	setCondition(meshInstallation, meshv1beta1.InstallationConditionRendered, "Rendered", nil)
+
+	err = r.apply(ctx, meshInstallation, objects)
+	if err != nil {
+		setCondition(meshInstallation, meshv1beta1.InstallationConditionApplied, "ApplyFailed", err)
+		return false, err
+	}
+	setCondition(meshInstallation, meshv1beta1.InstallationConditionApplied, "Applied", nil)
+
+	components, err := r.components(ctx, spec.MeshNamespace, objects)
+	if err != nil {
+		return false, err
+	}
+	status.Components = components
+
+	controlPlaneReady, componentsReady := true, true
+	for _, c := range components {
+		if c.ReadyReplicas >= c.Replicas {
+			continue
+		}
+		componentsReady = false
+		if c.Kind == "StatefulSet" && c.Name == installation.ControlPlaneStatefulSetName {
+			controlPlaneReady = false
+		}
+	}
+
+	if !controlPlaneReady {
+		setCondition(meshInstallation, meshv1beta1.InstallationConditionProvisioned, "ControlPlaneNotReady",
+			errors.New("waiting for the control plane to be ready"))
+		setCondition(meshInstallation, meshv1beta1.InstallationConditionReady, "ControlPlaneNotReady",
+			errors.New("waiting for the control plane to be ready"))
+		return false, nil
+	}
+
+	adminURL, err := installation.AdminURL(spec.MeshNamespace, objects)
+	if err == nil {
+		err = r.Provision(ctx, adminURL, spec)
+	}
+	if err != nil {
+		setCondition(meshInstallation, meshv1beta1.InstallationConditionProvisioned, "ProvisionFailed", err)
+		setCondition(meshInstallation, meshv1beta1.InstallationConditionReady, "ProvisionFailed", err)
+		return false, err
+	}
+	setCondition(meshInstallation, meshv1beta1.InstallationConditionProvisioned, "Provisioned", nil)
+
+	if !componentsReady {
+		setCondition(meshInstallation, meshv1beta1.InstallationConditionReady, "ComponentsNotReady",
+			errors.New("waiting for the components to be ready"))
+		return false, nil
+	}
+	setCondition(meshInstallation, meshv1beta1.InstallationConditionReady, "Ready", nil)
+
+	return true, nil
+}
+
+// apply applies the objects by server-side applying, which heals the drifted fields
+// of the objects as well.
+func (r *EaseMeshInstallationReconciler) apply(ctx context.Context, meshInstallation *meshv1beta1.EaseMeshInstallation,
+	objects []*unstructured.Unstructured) error {
+	// NOTE: Every rendering generates a new certificate for the webhook, so the secrets are only
+	// created, and the webhook configurations with the CA bundle follow them.
+	secretCreated := false
+	var webhooks []*unstructured.Unstructured
+
+	for _, obj := range objects {
+		err := r.setOwner(meshInstallation, obj)
+		if err != nil {
+			return err
+		}
+
+		switch obj.GetKind() {
+		case "Secret":
+			created, err := r.create(ctx, obj)
+			if err != nil {
+				return err
+			}
+			secretCreated = secretCreated || created
+		case "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration":
+			webhooks = append(webhooks, obj)
+		default:
+			err = r.serverSideApply(ctx, obj)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, obj := range webhooks {
+		var err error
+		if secretCreated {
+			err = r.serverSideApply(ctx, obj)
+		} else {
+			_, err = r.create(ctx, obj)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *EaseMeshInstallationReconciler) setOwner(meshInstallation *meshv1beta1.EaseMeshInstallation, obj *unstructured.Unstructured) error {
+	// NOTE: Deleting the installation must not delete the custom resources of the users
+	// and the persistent data of the control plane.
+	switch obj.GetKind() {
+	case "CustomResourceDefinition", "Namespace", "PersistentVolume", "StorageClass":
+		return nil
+	}
+
+	err := controllerutil.SetControllerReference(meshInstallation, obj, r.Scheme)
+	if err != nil {
+		return errors.Wrapf(err, "set owner of %s %s", obj.GetKind(), obj.GetName())
+	}
+	return nil
+}
+
+func (r *EaseMeshInstallationReconciler) serverSideApply(ctx context.Context, obj *unstructured.Unstructured) error {
+	err := r.Client.Patch(ctx, obj, client.Apply, client.FieldOwner(installationFieldOwner), client.ForceOwnership)
+	if err != nil {
+		return errors.Wrapf(err, "apply %s %s", obj.GetKind(), obj.GetName())
+	}
+	return nil
+}
+
+func (r *EaseMeshInstallationReconciler) create(ctx context.Context, obj *unstructured.Unstructured) (bool, error) {
+	err := r.Client.Create(ctx, obj, client.FieldOwner(installationFieldOwner))
+	if apierrors.IsAlreadyExists(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "create %s %s", obj.GetKind(), obj.GetName())
+	}
+	return true, nil
+}
+
+// components returns the status of the workloads among the objects.
+func (r *EaseMeshInstallationReconciler) components(ctx context.Context, namespace string,
+	objects []*unstructured.Unstructured) ([]meshv1beta1.ComponentStatus, error) {
+	var components []meshv1beta1.ComponentStatus
+	for _, obj := range objects {
+		key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+		if key.Namespace == "" {
+			key.Namespace = namespace
+		}
+
+		var replicas *int32
+		var readyReplicas int32
+		switch obj.GetKind() {
+		case "StatefulSet":
+			statefulSet := &v1.StatefulSet{}
+			err := r.Client.Get(ctx, key, statefulSet)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get statefulset %s", key)
+			}
+			replicas, readyReplicas = statefulSet.Spec.Replicas, statefulSet.Status.ReadyReplicas
+		case "Deployment":
+			deployment := &v1.Deployment{}
+			err := r.Client.Get(ctx, key, deployment)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get deployment %s", key)
+			}
+			replicas, readyReplicas = deployment.Spec.Replicas, deployment.Status.ReadyReplicas
+		default:
+			continue
+		}
+
+		component := meshv1beta1.ComponentStatus{
+			Kind:          obj.GetKind(),
+			Name:          obj.GetName(),
+			Replicas:      1,
+			ReadyReplicas: readyReplicas,
+		}
+		if replicas != nil {
+			component.Replicas = *replicas
+		}
+		components = append(components, component)
+	}
+
+	return components, nil
+}
+
+func setCondition(meshInstallation *meshv1beta1.EaseMeshInstallation, conditionType, reason string, err error) {
+	condition := metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		ObservedGeneration: meshInstallation.Generation,
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(&meshInstallation.Status.Conditions, condition)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EaseMeshInstallationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&meshv1beta1.EaseMeshInstallation{}).
+		Owns(&v1.StatefulSet{}).
+		Owns(&v1.Deployment{}).
+		Complete(r)
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package controllers
+
+import (
+	"context"
+	"time"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/sidecarinjector"
+	"github.com/megaease/easemesh/mesh-operator/pkg/syncer"
+
+	"github.com/imdario/mergo"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// MeshDaemonSetReconciler reconciles a MeshDaemonSet object
+type MeshDaemonSetReconciler struct {
+	*base.Runtime
+
+	// ControlPlane checks whether the mesh service is registered,
+	// the condition MeshServiceRegistered is Unknown if it's nil.
+	ControlPlane MeshControlPlane
+	// RegistrationCheckPeriod is the period of checking the mesh service
+	// which is not registered yet.
+	RegistrationCheckPeriod time.Duration
+}
+
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshdaemonsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshdaemonsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshdaemonsets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile reconciles MeshDaemonSet.
+func (r *MeshDaemonSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log.WithValues("MeshDaemonSetID", req.NamespacedName)
+
+	meshDaemonSet := &meshv1beta1.MeshDaemonSet{}
+	err := r.Client.Get(ctx, req.NamespacedName, meshDaemonSet)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.Log.Info("MeshDaemonSet not found", "id", req.NamespacedName)
+			return reconcile.Result{}, nil
+		}
+		r.Log.Error(err, "get MeshDaemonSet", "id", req.NamespacedName)
+		return reconcile.Result{}, err
+	}
+
+	if !meshDaemonSet.DeletionTimestamp.IsZero() {
+		// NOTE: The DaemonSet is deleted by the garbage collector as it's owned by the MeshDaemonSet.
+		return reconcile.Result{}, nil
+	}
+
+	daemonSet := &v1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      meshDaemonSet.Name,
+			Namespace: meshDaemonSet.Namespace,
+		},
+	}
+
+	r.Log.Info("syncing MeshDaemonSet", "id", req.NamespacedName)
+
+	injectErr, err := r.syncDaemonSet(meshDaemonSet, daemonSet)
+	if err != nil {
+		r.Log.V(1).Error(err, "sync MeshDaemonSet")
+	}
+
+	registered := r.reportStatus(ctx, meshDaemonSet, daemonSet, injectErr, err)
+
+	statusErr := r.Client.Status().Update(ctx, meshDaemonSet)
+	if statusErr != nil {
+		r.Log.Error(statusErr, "update status of MeshDaemonSet", "id", req.NamespacedName)
+		if err == nil {
+			err = statusErr
+		}
+	}
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return registrationResult(registered, r.RegistrationCheckPeriod), nil
+}
+
+// syncDaemonSet syncs the DaemonSet by the spec.
+// It returns the error of the injection besides the one of the sync.
+func (r *MeshDaemonSetReconciler) syncDaemonSet(meshDaemonSet *meshv1beta1.MeshDaemonSet,
+	daemonSet *v1.DaemonSet) (injectErr, err error) {
+	sourceSpec := meshDaemonSet.Spec.Deploy.DaemonSetSpec
+	mutateFn := func() error {
+		err := mergo.Merge(&daemonSet.Spec, &sourceSpec, mergo.WithOverride)
+		if err != nil {
+			return errors.Wrap(err, "merge MeshDaemonSet into DaemonSet failed")
+		}
+
+		// FIXME: The same issue of labels as MeshDeployment, see syncDeployment.
+		daemonSet.Spec.Template.ObjectMeta.Labels = sourceSpec.Selector.MatchLabels
+
+		service := &sidecarinjector.MeshService{
+			Name:             meshServiceName(&meshDaemonSet.Spec.Service, meshDaemonSet.Name),
+			Labels:           meshDaemonSet.Spec.Service.Labels,
+			AppContainerName: meshDaemonSet.Spec.Service.AppContainerName,
+			AliveProbeURL:    meshDaemonSet.Spec.Service.AliveProbeURL,
+			ApplicationPort:  meshDaemonSet.Spec.Service.ApplicationPort,
+		}
+		injector := sidecarinjector.New(r.Runtime, service, &daemonSet.Spec.Template.Spec)
+
+		injectErr = injector.Inject()
+		return injectErr
+	}
+
+	daemonSetSyncer := syncer.New(r.Runtime, meshDaemonSet, daemonSet, mutateFn)
+	err = syncer.Sync(context.TODO(), daemonSetSyncer, r.Recorder)
+	return injectErr, err
+}
+
+// reportStatus reports the DaemonSet and the mesh service into the status of the MeshDaemonSet,
+// it returns whether the mesh service is registered in the control plane.
+func (r *MeshDaemonSetReconciler) reportStatus(ctx context.Context, meshDaemonSet *meshv1beta1.MeshDaemonSet,
+	daemonSet *v1.DaemonSet, injectErr, syncErr error) bool {
+	status := &meshDaemonSet.Status
+
+	status.ObservedGeneration = meshDaemonSet.Generation
+	status.DesiredNumberScheduled = daemonSet.Status.DesiredNumberScheduled
+	status.NumberReady = daemonSet.Status.NumberReady
+	status.NumberAvailable = daemonSet.Status.NumberAvailable
+	status.SidecarImage = sidecarinjector.SidecarImage(&daemonSet.Spec.Template.Spec)
+	status.InitializerImage = sidecarinjector.InitializerImage(&daemonSet.Spec.Template.Spec)
+
+	conditions := &workloadConditions{object: meshDaemonSet, conditions: &status.Conditions}
+	conditions.reportSync(status.SidecarImage, injectErr, syncErr)
+	return conditions.reportRegistration(ctx, r.ControlPlane, r.Recorder, meshServiceName(&meshDaemonSet.Spec.Service, meshDaemonSet.Name))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MeshDaemonSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&meshv1beta1.MeshDaemonSet{}).
+		Owns(&v1.DaemonSet{}).
+		Complete(r)
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package controllers
+
+import (
+	"context"
+
+	"github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("meshdaemonset controller", func() {
+	var meshDaemonSet v1beta1.MeshDaemonSet
+	daemonSetKey := types.NamespacedName{Namespace: namespace, Name: "test-daemon"}
+
+	BeforeEach(func() {
+		reconciler := &MeshDaemonSetReconciler{
+			Runtime: &base.Runtime{
+				Name:     "mesh-controller-test",
+				Client:   k8sClient,
+				Scheme:   scheme.Scheme,
+				Recorder: &mockRecorder{},
+				Log:      ctrl.Log.WithName("controllers").WithName("MeshDaemonSet"),
+			},
+		}
+		meshDaemonSet = v1beta1.MeshDaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      daemonSetKey.Name,
+				Namespace: namespace,
+			},
+			Spec: v1beta1.MeshDaemonSetSpec{
+				Service: v1beta1.ServiceSpec{
+					Name: "test-daemon-server",
+				},
+				Deploy: v1beta1.DaemonSetDeploySpec{
+					DaemonSetSpec: v1.DaemonSetSpec{
+						Selector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"app": "test-daemon-server",
+							},
+						},
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{
+									{
+										Name:  "test-daemon-server",
+										Image: "megaease/non-existent:1.0-alpine",
+										Ports: []corev1.ContainerPort{
+											{
+												Name:          "test-port",
+												ContainerPort: 8080,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.TODO(), &meshDaemonSet)).To(Succeed())
+
+		_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: daemonSetKey})
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		k8sClient.Delete(context.TODO(), &meshDaemonSet)
+		k8sClient.Delete(context.TODO(), &v1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: daemonSetKey.Name, Namespace: namespace}})
+	})
+
+	It("should create the injected daemonset and report the status", func() {
+		daemonSet := v1.DaemonSet{}
+		Expect(k8sClient.Get(context.TODO(), daemonSetKey, &daemonSet)).To(Succeed())
+		Expect(len(daemonSet.Spec.Template.Spec.Containers)).To(Equal(2))
+
+		got := v1beta1.MeshDaemonSet{}
+		Expect(k8sClient.Get(context.TODO(), daemonSetKey, &got)).To(Succeed())
+		Expect(got.Status.SidecarImage).NotTo(BeEmpty())
+		Expect(meta.IsStatusConditionTrue(got.Status.Conditions, v1beta1.MeshWorkloadConditionSidecarInjected)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(got.Status.Conditions, v1beta1.MeshWorkloadConditionSynced)).To(BeTrue())
+	})
+})
@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package controllers
+
+import (
+	"context"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// syncCanary syncs the canary Deployment and its ServiceCanary in the control plane.
+// If the canary is promoted, which removes the canary section, it deletes both of them,
+// and returns nil Deployment.
+func (r *MeshDeploymentReconciler) syncCanary(ctx context.Context, meshDeploy *meshv1beta1.MeshDeployment) (*v1.Deployment, error) {
+	canaryDeploy := &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      canaryDeploymentName(meshDeploy),
+			Namespace: meshDeploy.Namespace,
+		},
+	}
+
+	if meshDeploy.Spec.Canary == nil {
+		// NOTE: The finalizer is added along with the canary, it's absent if there is no canary to clean up.
+		if !controllerutil.ContainsFinalizer(meshDeploy, canaryFinalizer) {
+			return nil, nil
+		}
+
+		err := r.Client.Delete(ctx, canaryDeploy)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "delete canary Deployment %s", canaryDeploy.Name)
+		}
+
+		err = r.deleteServiceCanary(ctx, meshDeploy)
+		if err != nil {
+			return nil, err
+		}
+
+		controllerutil.RemoveFinalizer(meshDeploy, canaryFinalizer)
+		err = r.Client.Update(ctx, meshDeploy)
+		if err != nil {
+			return nil, errors.Wrap(err, "remove finalizer of MeshDeployment")
+		}
+
+		return nil, nil
+	}
+
+	if len(meshDeploy.Spec.Canary.Labels) == 0 {
+		return canaryDeploy, errors.New("labels of canary are empty")
+	}
+
+	_, err := r.syncDeployment(meshDeploy, canaryDeploy, canaryDeploymentSpec(meshDeploy), canaryServiceLabels(meshDeploy))
+	if err != nil {
+		return canaryDeploy, errors.Wrapf(err, "sync canary Deployment %s", canaryDeploy.Name)
+	}
+
+	if r.ControlPlane == nil {
+		return canaryDeploy, errors.New("control plane is not configured")
+	}
+
+	serviceCanary := newServiceCanary(meshDeploy)
+	err = r.ControlPlane.ApplyServiceCanary(ctx, serviceCanary)
+	if err != nil {
+		return canaryDeploy, errors.Wrapf(err, "apply ServiceCanary %s", serviceCanary.Name)
+	}
+
+	return canaryDeploy, nil
+}
+
+// deleteServiceCanary deletes the ServiceCanary of the MeshDeployment, it's fine if not found.
+func (r *MeshDeploymentReconciler) deleteServiceCanary(ctx context.Context, meshDeploy *meshv1beta1.MeshDeployment) error {
+	// NOTE: The ServiceCanary can't be applied without the control plane.
+	if r.ControlPlane == nil {
+		return nil
+	}
+
+	name := serviceCanaryName(meshDeploy)
+	err := r.ControlPlane.DeleteServiceCanary(ctx, name)
+	if err != nil {
+		return errors.Wrapf(err, "delete ServiceCanary %s", name)
+	}
+
+	return nil
+}
+
+// canaryDeploymentSpec returns the spec of the canary Deployment, which is the stable one
+// with the canary replicas and image, and selects the pods by the canary labels as well.
+func canaryDeploymentSpec(meshDeploy *meshv1beta1.MeshDeployment) v1.DeploymentSpec {
+	canary := meshDeploy.Spec.Canary
+	spec := *meshDeploy.Spec.Deploy.DeploymentSpec.DeepCopy()
+
+	replicas := int32(1)
+	if canary.Replicas != nil {
+		replicas = *canary.Replicas
+	}
+	spec.Replicas = &replicas
+
+	if spec.Selector == nil {
+		spec.Selector = &metav1.LabelSelector{}
+	}
+	matchLabels := map[string]string{}
+	for k, v := range spec.Selector.MatchLabels {
+		matchLabels[k] = v
+	}
+	for k, v := range canary.Labels {
+		matchLabels[k] = v
+	}
+	spec.Selector.MatchLabels = matchLabels
+
+	if canary.Image != "" && len(spec.Template.Spec.Containers) != 0 {
+		appContainer := &spec.Template.Spec.Containers[0]
+		for i := range spec.Template.Spec.Containers {
+			if spec.Template.Spec.Containers[i].Name == meshDeploy.Spec.Service.AppContainerName {
+				appContainer = &spec.Template.Spec.Containers[i]
+			}
+		}
+		appContainer.Image = canary.Image
+	}
+
+	return spec
+}
+
+// canaryServiceLabels returns the labels of the canary instances,
+// which are the ones of the stable instances overridden by the canary labels.
+func canaryServiceLabels(meshDeploy *meshv1beta1.MeshDeployment) map[string]string {
+	labels := map[string]string{}
+	for k, v := range meshDeploy.Spec.Service.Labels {
+		labels[k] = v
+	}
+	for k, v := range meshDeploy.Spec.Canary.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// newServiceCanary returns the ServiceCanary which routes the traffic chosen by
+// the traffic rules of the canary to the canary instances of the mesh service.
+func newServiceCanary(meshDeploy *meshv1beta1.MeshDeployment) *controlplane.ServiceCanary {
+	canary := meshDeploy.Spec.Canary
+
+	headers := map[string]*controlplane.StringMatch{}
+	for name, match := range canary.TrafficRules.Headers {
+		headers[name] = &controlplane.StringMatch{
+			Exact:  match.Exact,
+			Prefix: match.Prefix,
+			Regex:  match.Regex,
+		}
+	}
+
+	return &controlplane.ServiceCanary{
+		Name:     serviceCanaryName(meshDeploy),
+		Priority: canary.Priority,
+		Selector: &controlplane.ServiceSelector{
+			MatchServices:       []string{meshServiceName(&meshDeploy.Spec.Service, meshDeploy.Name)},
+			MatchInstanceLabels: canary.Labels,
+		},
+		TrafficRules: &controlplane.TrafficRules{
+			Headers: headers,
+		},
+	}
+}
+
+// reportCanaryStatus reports the canary Deployment into the status of the MeshDeployment.
+func reportCanaryStatus(meshDeploy *meshv1beta1.MeshDeployment, canaryDeploy *v1.Deployment, canaryErr error) {
+	status := &meshDeploy.Status
+
+	if meshDeploy.Spec.Canary == nil && canaryErr == nil {
+		status.CanaryReplicas = 0
+		status.CanaryReadyReplicas = 0
+		meta.RemoveStatusCondition(&status.Conditions, meshv1beta1.MeshDeploymentConditionCanarySynced)
+		return
+	}
+
+	if canaryDeploy != nil {
+		status.CanaryReplicas = canaryDeploy.Status.Replicas
+		status.CanaryReadyReplicas = canaryDeploy.Status.ReadyReplicas
+	}
+
+	conditions := &workloadConditions{object: meshDeploy, conditions: &status.Conditions}
+	if canaryErr != nil {
+		conditions.set(meshv1beta1.MeshDeploymentConditionCanarySynced,
+			metav1.ConditionFalse, "CanarySyncFailed", canaryErr.Error())
+		return
+	}
+
+	conditions.set(meshv1beta1.MeshDeploymentConditionCanarySynced,
+		metav1.ConditionTrue, "CanarySynced", "")
+}
+
+// canaryDeploymentName returns the name of the canary Deployment of the MeshDeployment.
+func canaryDeploymentName(meshDeploy *meshv1beta1.MeshDeployment) string {
+	return meshDeploy.Name + "-canary"
+}
+
+// serviceCanaryName returns the name of the ServiceCanary of the MeshDeployment,
+// it's qualified by the namespace since the resources of the control plane are global.
+func serviceCanaryName(meshDeploy *meshv1beta1.MeshDeployment) string {
+	return meshDeploy.Namespace + "-" + meshDeploy.Name
+}
@@ -19,6 +19,7 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
 	"github.com/megaease/easemesh/mesh-operator/pkg/base"
@@ -28,15 +29,29 @@ import (
 	"github.com/imdario/mergo"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+// canaryFinalizer removes the ServiceCanary from the control plane
+// before the MeshDeployment with the canary is deleted.
+const canaryFinalizer = "mesh.megaease.com/canary"
+
 // MeshDeploymentReconciler reconciles a MeshDeployment object
 type MeshDeploymentReconciler struct {
 	*base.Runtime
+
+	// ControlPlane checks whether the mesh service is registered and routes
+	// the traffic to the canary, the condition MeshServiceRegistered is Unknown
+	// and the canary fails to sync if it's nil.
+	ControlPlane MeshControlPlane
+	// RegistrationCheckPeriod is the period of checking the mesh service
+	// which is not registered yet.
+	RegistrationCheckPeriod time.Duration
 }
 
 // +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshdeployments,verbs=get;list;watch;create;update;patch;delete
@@ -60,6 +75,30 @@ func (r *MeshDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return reconcile.Result{}, err
 	}
 
+	if !meshDeploy.DeletionTimestamp.IsZero() {
+		// NOTE: The Deployments are deleted by the garbage collector as they're owned by the MeshDeployment.
+		if !controllerutil.ContainsFinalizer(meshDeploy, canaryFinalizer) {
+			return reconcile.Result{}, nil
+		}
+
+		err = r.deleteServiceCanary(ctx, meshDeploy)
+		if err != nil {
+			r.Log.Error(err, "delete ServiceCanary", "id", req.NamespacedName)
+			return reconcile.Result{}, err
+		}
+		controllerutil.RemoveFinalizer(meshDeploy, canaryFinalizer)
+		return reconcile.Result{}, r.Client.Update(ctx, meshDeploy)
+	}
+
+	if meshDeploy.Spec.Canary != nil && !controllerutil.ContainsFinalizer(meshDeploy, canaryFinalizer) {
+		controllerutil.AddFinalizer(meshDeploy, canaryFinalizer)
+		err = r.Client.Update(ctx, meshDeploy)
+		if err != nil {
+			r.Log.Error(err, "add finalizer of MeshDeployment", "id", req.NamespacedName)
+			return reconcile.Result{}, err
+		}
+	}
+
 	deploy := &v1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      meshDeploy.Name,
@@ -69,9 +108,44 @@ func (r *MeshDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	r.Log.Info("syncing MeshDeployment", "id", req.NamespacedName)
 
-	mutateFn := func() error {
-		sourceDeploySpec := meshDeploy.Spec.Deploy.DeploymentSpec
+	injectErr, err := r.syncDeployment(meshDeploy, deploy, meshDeploy.Spec.Deploy.DeploymentSpec, meshDeploy.Spec.Service.Labels)
+	if err != nil {
+		r.Log.V(1).Error(err, "sync MeshDeployment")
+	}
 
+	canaryDeploy, canaryErr := r.syncCanary(ctx, meshDeploy)
+	if canaryErr != nil {
+		r.Log.V(1).Error(canaryErr, "sync canary of MeshDeployment")
+		r.Recorder.Event(meshDeploy, corev1.EventTypeWarning, "CanarySyncFailed", canaryErr.Error())
+		if err == nil {
+			err = canaryErr
+		}
+	}
+
+	registered := r.reportStatus(ctx, meshDeploy, deploy, injectErr, err)
+	reportCanaryStatus(meshDeploy, canaryDeploy, canaryErr)
+
+	statusErr := r.Client.Status().Update(ctx, meshDeploy)
+	if statusErr != nil {
+		r.Log.Error(statusErr, "update status of MeshDeployment", "id", req.NamespacedName)
+		if err == nil {
+			err = statusErr
+		}
+	}
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return registrationResult(registered, r.RegistrationCheckPeriod), nil
+}
+
+// syncDeployment syncs the Deployment of a version of the mesh service by the spec,
+// the sidecar injected into its pods labels the instances with the labels.
+// It returns the error of the injection besides the one of the sync.
+func (r *MeshDeploymentReconciler) syncDeployment(meshDeploy *meshv1beta1.MeshDeployment, deploy *v1.Deployment,
+	sourceDeploySpec v1.DeploymentSpec, labels map[string]string) (injectErr, err error) {
+	mutateFn := func() error {
 		err := mergo.Merge(&deploy.Spec, &sourceDeploySpec, mergo.WithOverride)
 		if err != nil {
 			return errors.Wrap(err, "merge MeshDeployment into Deployment failed")
@@ -86,24 +160,39 @@ func (r *MeshDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		deploy.Spec.Template.ObjectMeta.Labels = sourceDeploySpec.Selector.MatchLabels
 
 		service := &sidecarinjector.MeshService{
-			Name:             meshDeploy.Name,
-			Labels:           meshDeploy.Spec.Service.Labels,
+			Name:             meshServiceName(&meshDeploy.Spec.Service, meshDeploy.Name),
+			Labels:           labels,
 			AppContainerName: meshDeploy.Spec.Service.AppContainerName,
 			AliveProbeURL:    meshDeploy.Spec.Service.AliveProbeURL,
 			ApplicationPort:  meshDeploy.Spec.Service.ApplicationPort,
 		}
 		injector := sidecarinjector.New(r.Runtime, service, &deploy.Spec.Template.Spec)
 
-		return injector.Inject()
+		injectErr = injector.Inject()
+		return injectErr
 	}
 
-	meshDeploymentSyncer := syncer.New(r.Runtime, meshDeploy, deploy, mutateFn)
-	err = syncer.Sync(context.TODO(), meshDeploymentSyncer, r.Recorder)
-	if err != nil {
-		r.Log.V(1).Error(err, "sync MeshDeployment")
-	}
+	deploymentSyncer := syncer.New(r.Runtime, meshDeploy, deploy, mutateFn)
+	err = syncer.Sync(context.TODO(), deploymentSyncer, r.Recorder)
+	return injectErr, err
+}
 
-	return ctrl.Result{}, err
+// reportStatus reports the Deployment and the mesh service into the status of the MeshDeployment,
+// it returns whether the mesh service is registered in the control plane.
+func (r *MeshDeploymentReconciler) reportStatus(ctx context.Context, meshDeploy *meshv1beta1.MeshDeployment,
+	deploy *v1.Deployment, injectErr, syncErr error) bool {
+	status := &meshDeploy.Status
+
+	status.ObservedGeneration = meshDeploy.Generation
+	status.Replicas = deploy.Status.Replicas
+	status.ReadyReplicas = deploy.Status.ReadyReplicas
+	status.AvailableReplicas = deploy.Status.AvailableReplicas
+	status.SidecarImage = sidecarinjector.SidecarImage(&deploy.Spec.Template.Spec)
+	status.InitializerImage = sidecarinjector.InitializerImage(&deploy.Spec.Template.Spec)
+
+	conditions := &workloadConditions{object: meshDeploy, conditions: &status.Conditions}
+	conditions.reportSync(status.SidecarImage, injectErr, syncErr)
+	return conditions.reportRegistration(ctx, r.ControlPlane, r.Recorder, meshServiceName(&meshDeploy.Spec.Service, meshDeploy.Name))
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -23,9 +23,12 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
 	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
 
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -129,8 +132,168 @@ var _ = Describe("meshdeployment controller", func() {
 		})
 	})
 
+	Context("normal deploy meshdeployment check status", func() {
+		got := v1beta1.MeshDeployment{}
+		It("should report the injection and the sync", func() {
+			Expect(k8sClient.Get(context.TODO(), key, &got)).To(Succeed())
+			Expect(got.Status.SidecarImage).NotTo(BeEmpty())
+			Expect(got.Status.InitializerImage).NotTo(BeEmpty())
+			Expect(meta.IsStatusConditionTrue(got.Status.Conditions, v1beta1.MeshWorkloadConditionSidecarInjected)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(got.Status.Conditions, v1beta1.MeshWorkloadConditionSynced)).To(BeTrue())
+
+			registered := meta.FindStatusCondition(got.Status.Conditions, v1beta1.MeshWorkloadConditionMeshServiceRegistered)
+			Expect(registered).NotTo(BeNil())
+			Expect(registered.Status).To(Equal(metav1.ConditionUnknown))
+		})
+	})
+
+})
+
+var _ = Describe("meshdeployment canary", func() {
+	var meshDeployment v1beta1.MeshDeployment
+	var reconciler *MeshDeploymentReconciler
+	var controlPlane *fakeControlPlane
+	canaryKey := types.NamespacedName{Namespace: namespace, Name: "test-canary"}
+	canaryDeployKey := types.NamespacedName{Namespace: namespace, Name: "test-canary-canary"}
+
+	BeforeEach(func() {
+		controlPlane = &fakeControlPlane{serviceCanaries: map[string]*controlplane.ServiceCanary{}}
+		reconciler = &MeshDeploymentReconciler{
+			Runtime: &base.Runtime{
+				Name:     "mesh-controller-test",
+				Client:   k8sClient,
+				Scheme:   scheme.Scheme,
+				Recorder: &mockRecorder{},
+				Log:      ctrl.Log.WithName("controllers").WithName("MeshDeployment"),
+			},
+			ControlPlane: controlPlane,
+		}
+		meshDeployment = v1beta1.MeshDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      canaryKey.Name,
+				Namespace: namespace,
+			},
+			Spec: v1beta1.MeshDeploymentSpec{
+				Service: v1beta1.ServiceSpec{
+					Name: "test-canary-server",
+				},
+				Deploy: v1beta1.DeploySpec{
+					DeploymentSpec: v1.DeploymentSpec{
+						Selector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"app": "test-canary-server",
+							},
+						},
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{
+									{
+										Name:  "test-canary-server",
+										Image: "megaease/non-existent:1.0-alpine",
+										Ports: []corev1.ContainerPort{
+											{
+												Name:          "test-port",
+												ContainerPort: 8080,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				Canary: &v1beta1.CanarySpec{
+					Image: "megaease/non-existent:2.0-alpine",
+					Labels: map[string]string{
+						"version": "canary",
+					},
+					TrafficRules: v1beta1.CanaryTrafficRules{
+						Headers: map[string]v1beta1.StringMatch{
+							"X-Mesh-Canary": {Exact: "lv1"},
+						},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.TODO(), &meshDeployment)).To(Succeed())
+
+		_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: canaryKey})
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		got := v1beta1.MeshDeployment{}
+		if k8sClient.Get(context.TODO(), canaryKey, &got) == nil {
+			got.Finalizers = nil
+			k8sClient.Update(context.TODO(), &got)
+			k8sClient.Delete(context.TODO(), &got)
+		}
+		k8sClient.Delete(context.TODO(), &v1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: canaryKey.Name, Namespace: namespace}})
+		k8sClient.Delete(context.TODO(), &v1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: canaryDeployKey.Name, Namespace: namespace}})
+	})
+
+	It("should create the canary deployment and the service canary", func() {
+		deploy := v1.Deployment{}
+		Expect(k8sClient.Get(context.TODO(), canaryDeployKey, &deploy)).To(Succeed())
+		Expect(*deploy.Spec.Replicas).To(Equal(int32(1)))
+		Expect(deploy.Spec.Selector.MatchLabels).To(Equal(map[string]string{
+			"app":     "test-canary-server",
+			"version": "canary",
+		}))
+		Expect(deploy.Spec.Template.Spec.Containers[0].Image).To(Equal("megaease/non-existent:2.0-alpine"))
+
+		serviceCanary := controlPlane.serviceCanaries["default-test-canary"]
+		Expect(serviceCanary).NotTo(BeNil())
+		Expect(serviceCanary.Selector.MatchServices).To(Equal([]string{"test-canary-server"}))
+		Expect(serviceCanary.Selector.MatchInstanceLabels).To(Equal(map[string]string{"version": "canary"}))
+		Expect(serviceCanary.TrafficRules.Headers["X-Mesh-Canary"].Exact).To(Equal("lv1"))
+	})
+
+	It("should clean up the canary on promotion", func() {
+		got := v1beta1.MeshDeployment{}
+		Expect(k8sClient.Get(context.TODO(), canaryKey, &got)).To(Succeed())
+		Expect(got.Finalizers).To(ContainElement(canaryFinalizer))
+
+		got.Spec.Canary = nil
+		Expect(k8sClient.Update(context.TODO(), &got)).To(Succeed())
+		_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: canaryKey})
+		Expect(err).To(BeNil())
+
+		Expect(k8sClient.Get(context.TODO(), canaryKey, &got)).To(Succeed())
+		Expect(got.Finalizers).NotTo(ContainElement(canaryFinalizer))
+		Expect(meta.FindStatusCondition(got.Status.Conditions, v1beta1.MeshDeploymentConditionCanarySynced)).To(BeNil())
+		Expect(controlPlane.serviceCanaries).To(BeEmpty())
+
+		deploy := v1.Deployment{}
+		Expect(apierrors.IsNotFound(k8sClient.Get(context.TODO(), canaryDeployKey, &deploy))).To(BeTrue())
+	})
 })
 
+type fakeControlPlane struct {
+	serviceCanaries  map[string]*controlplane.ServiceCanary
+	serviceInstances []*controlplane.ServiceInstance
+}
+
+var _ MeshControlPlane = &fakeControlPlane{}
+
+func (f *fakeControlPlane) GetService(ctx context.Context, name string) (*controlplane.Service, error) {
+	return &controlplane.Service{Name: name}, nil
+}
+
+func (f *fakeControlPlane) ServiceInstances(ctx context.Context) ([]*controlplane.ServiceInstance, error) {
+	return f.serviceInstances, nil
+}
+
+func (f *fakeControlPlane) ApplyServiceCanary(ctx context.Context, serviceCanary *controlplane.ServiceCanary) error {
+	f.serviceCanaries[serviceCanary.Name] = serviceCanary
+	return nil
+}
+
+func (f *fakeControlPlane) DeleteServiceCanary(ctx context.Context, name string) error {
+	delete(f.serviceCanaries, name)
+	return nil
+}
+
 func fromInt32(i int32) *int32 {
 	return &i
 }
@@ -0,0 +1,237 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/federation"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// federationFinalizer removes the catalog and the federated services from the
+	// control plane before the MeshFederation is deleted.
+	federationFinalizer = "mesh.megaease.com/federation"
+
+	// DefaultFederationSyncPeriod is the default period of exchanging the catalogs.
+	DefaultFederationSyncPeriod = 30 * time.Second
+)
+
+// MeshFederationReconciler reconciles a MeshFederation object
+type MeshFederationReconciler struct {
+	*base.Runtime
+
+	// Local is the control plane of the local cluster.
+	Local federation.ControlPlane
+	// NewRemote creates the control plane of the remote cluster, the TLS config is nil
+	// if the remote cluster has no trust secret.
+	NewRemote func(addr string, tlsConfig *tls.Config) federation.ControlPlane
+	// SyncPeriod is the period of exchanging the catalogs.
+	SyncPeriod time.Duration
+}
+
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshfederations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshfederations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshfederations/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// Reconcile reconciles MeshFederation.
+func (r *MeshFederationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("MeshFederationID", req.NamespacedName)
+
+	meshFederation := &meshv1beta1.MeshFederation{}
+	err := r.Client.Get(ctx, req.NamespacedName, meshFederation)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("MeshFederation not found")
+			return reconcile.Result{}, nil
+		}
+		log.Error(err, "get MeshFederation")
+		return reconcile.Result{}, err
+	}
+
+	syncer := &federation.Syncer{Local: r.Local}
+
+	if !meshFederation.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(meshFederation, federationFinalizer) {
+			return reconcile.Result{}, nil
+		}
+
+		log.Info("clearing MeshFederation")
+		err = syncer.Clear(ctx, &meshFederation.Spec)
+		if err != nil {
+			log.Error(err, "clear MeshFederation")
+			return reconcile.Result{}, err
+		}
+		controllerutil.RemoveFinalizer(meshFederation, federationFinalizer)
+		return reconcile.Result{}, r.Client.Update(ctx, meshFederation)
+	}
+
+	if !controllerutil.ContainsFinalizer(meshFederation, federationFinalizer) {
+		controllerutil.AddFinalizer(meshFederation, federationFinalizer)
+		err = r.Client.Update(ctx, meshFederation)
+		if err != nil {
+			log.Error(err, "add finalizer of MeshFederation")
+			return reconcile.Result{}, err
+		}
+	}
+
+	log.Info("syncing MeshFederation")
+
+	err = r.sync(ctx, syncer, meshFederation)
+	if err != nil {
+		log.V(1).Error(err, "sync MeshFederation")
+		r.Recorder.Event(meshFederation, corev1.EventTypeWarning, "SyncFailed", err.Error())
+	}
+
+	meshFederation.Status.ObservedGeneration = meshFederation.Generation
+	statusErr := r.Client.Status().Update(ctx, meshFederation)
+	if statusErr != nil {
+		log.Error(statusErr, "update status of MeshFederation")
+		if err == nil {
+			err = statusErr
+		}
+	}
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	syncPeriod := r.SyncPeriod
+	if syncPeriod == 0 {
+		syncPeriod = DefaultFederationSyncPeriod
+	}
+	return ctrl.Result{RequeueAfter: syncPeriod}, nil
+}
+
+// sync exports the local catalog, imports the remote ones and reports them into the status.
+// The failures of the remote clusters are reported in their status only, so that a broken
+// remote cluster doesn't block the others.
+func (r *MeshFederationReconciler) sync(ctx context.Context, syncer *federation.Syncer, meshFederation *meshv1beta1.MeshFederation) error {
+	spec := &meshFederation.Spec
+	status := &meshFederation.Status
+
+	catalog, err := syncer.Export(ctx, spec)
+	if err != nil {
+		setFederationCondition(meshFederation, meshv1beta1.FederationConditionExported, "ExportFailed", err)
+		setFederationCondition(meshFederation, meshv1beta1.FederationConditionReady, "ExportFailed", err)
+		return err
+	}
+	setFederationCondition(meshFederation, meshv1beta1.FederationConditionExported, "Exported", nil)
+	status.ExportedServices = nil
+	for _, service := range catalog.Services {
+		status.ExportedServices = append(status.ExportedServices, service.Name)
+	}
+
+	err = syncer.Unlink(ctx, spec.Remotes)
+	if err != nil {
+		setFederationCondition(meshFederation, meshv1beta1.FederationConditionReady, "UnlinkFailed", err)
+		return err
+	}
+
+	previous := map[string]meshv1beta1.FederationRemoteStatus{}
+	for _, remoteStatus := range status.Remotes {
+		previous[remoteStatus.Name] = remoteStatus
+	}
+
+	var disconnected []string
+	status.Remotes = nil
+	for i := range spec.Remotes {
+		remote := &spec.Remotes[i]
+		remoteStatus := meshv1beta1.FederationRemoteStatus{
+			Name:         remote.Name,
+			LastSyncTime: previous[remote.Name].LastSyncTime,
+		}
+
+		importedServices, err := r.importRemote(ctx, syncer, meshFederation.Namespace, remote)
+		if err != nil {
+			remoteStatus.Message = err.Error()
+			disconnected = append(disconnected, remote.Name)
+			r.Recorder.Eventf(meshFederation, corev1.EventTypeWarning, "ImportFailed",
+				"import cluster %s failed: %v", remote.Name, err)
+		} else {
+			now := metav1.Now()
+			remoteStatus.Connected = true
+			remoteStatus.ImportedServices = importedServices
+			remoteStatus.LastSyncTime = &now
+		}
+		status.Remotes = append(status.Remotes, remoteStatus)
+	}
+
+	if len(disconnected) != 0 {
+		setFederationCondition(meshFederation, meshv1beta1.FederationConditionReady, "RemoteDisconnected",
+			errors.Errorf("remote clusters disconnected: %v", disconnected))
+	} else {
+		setFederationCondition(meshFederation, meshv1beta1.FederationConditionReady, "Synced", nil)
+	}
+
+	return nil
+}
+
+func (r *MeshFederationReconciler) importRemote(ctx context.Context, syncer *federation.Syncer,
+	namespace string, remote *meshv1beta1.FederationRemote) ([]string, error) {
+	var tlsConfig *tls.Config
+	if remote.TrustSecretName != "" {
+		secret := &corev1.Secret{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: remote.TrustSecretName}, secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get trust secret %s", remote.TrustSecretName)
+		}
+		tlsConfig, err = federation.TLSConfig(secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return syncer.Import(ctx, remote, r.NewRemote(remote.APIAddr, tlsConfig))
+}
+
+func setFederationCondition(meshFederation *meshv1beta1.MeshFederation, conditionType, reason string, err error) {
+	condition := metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		ObservedGeneration: meshFederation.Generation,
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(&meshFederation.Status.Conditions, condition)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MeshFederationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&meshv1beta1.MeshFederation{}).
+		Complete(r)
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package controllers
+
+import (
+	"context"
+	"time"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
+	"github.com/megaease/easemesh/mesh-operator/pkg/sidecarinjector"
+	"github.com/megaease/easemesh/mesh-operator/pkg/syncer"
+
+	"github.com/imdario/mergo"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// MeshStatefulSetReconciler reconciles a MeshStatefulSet object
+type MeshStatefulSetReconciler struct {
+	*base.Runtime
+
+	// ControlPlane checks whether the mesh service and its instances are registered,
+	// the condition MeshServiceRegistered is Unknown if it's nil.
+	ControlPlane MeshControlPlane
+	// RegistrationCheckPeriod is the period of checking the mesh service
+	// and its instances which are not registered yet.
+	RegistrationCheckPeriod time.Duration
+}
+
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshstatefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshstatefulsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mesh.megaease.com,resources=meshstatefulsets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile reconciles MeshStatefulSet.
+func (r *MeshStatefulSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log.WithValues("MeshStatefulSetID", req.NamespacedName)
+
+	meshStatefulSet := &meshv1beta1.MeshStatefulSet{}
+	err := r.Client.Get(ctx, req.NamespacedName, meshStatefulSet)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.Log.Info("MeshStatefulSet not found", "id", req.NamespacedName)
+			return reconcile.Result{}, nil
+		}
+		r.Log.Error(err, "get MeshStatefulSet", "id", req.NamespacedName)
+		return reconcile.Result{}, err
+	}
+
+	if !meshStatefulSet.DeletionTimestamp.IsZero() {
+		// NOTE: The StatefulSet is deleted by the garbage collector as it's owned by the MeshStatefulSet.
+		return reconcile.Result{}, nil
+	}
+
+	statefulSet := &v1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      meshStatefulSet.Name,
+			Namespace: meshStatefulSet.Namespace,
+		},
+	}
+
+	r.Log.Info("syncing MeshStatefulSet", "id", req.NamespacedName)
+
+	injectErr, err := r.syncStatefulSet(meshStatefulSet, statefulSet)
+	if err != nil {
+		r.Log.V(1).Error(err, "sync MeshStatefulSet")
+	}
+
+	registered := r.reportStatus(ctx, meshStatefulSet, statefulSet, injectErr, err)
+
+	statusErr := r.Client.Status().Update(ctx, meshStatefulSet)
+	if statusErr != nil {
+		r.Log.Error(statusErr, "update status of MeshStatefulSet", "id", req.NamespacedName)
+		if err == nil {
+			err = statusErr
+		}
+	}
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return registrationResult(registered, r.RegistrationCheckPeriod), nil
+}
+
+// syncStatefulSet syncs the StatefulSet by the spec, the sidecar injected into
+// its pods registers the pod names as the instance IDs.
+// It returns the error of the injection besides the one of the sync.
+func (r *MeshStatefulSetReconciler) syncStatefulSet(meshStatefulSet *meshv1beta1.MeshStatefulSet,
+	statefulSet *v1.StatefulSet) (injectErr, err error) {
+	sourceSpec := meshStatefulSet.Spec.Deploy.StatefulSetSpec
+	mutateFn := func() error {
+		err := mergo.Merge(&statefulSet.Spec, &sourceSpec, mergo.WithOverride)
+		if err != nil {
+			return errors.Wrap(err, "merge MeshStatefulSet into StatefulSet failed")
+		}
+
+		// FIXME: The same issue of labels as MeshDeployment, see syncDeployment.
+		statefulSet.Spec.Template.ObjectMeta.Labels = sourceSpec.Selector.MatchLabels
+
+		service := &sidecarinjector.MeshService{
+			Name:             meshServiceName(&meshStatefulSet.Spec.Service, meshStatefulSet.Name),
+			Labels:           meshStatefulSet.Spec.Service.Labels,
+			AppContainerName: meshStatefulSet.Spec.Service.AppContainerName,
+			AliveProbeURL:    meshStatefulSet.Spec.Service.AliveProbeURL,
+			ApplicationPort:  meshStatefulSet.Spec.Service.ApplicationPort,
+			StableInstanceID: true,
+		}
+		injector := sidecarinjector.New(r.Runtime, service, &statefulSet.Spec.Template.Spec)
+
+		injectErr = injector.Inject()
+		return injectErr
+	}
+
+	statefulSetSyncer := syncer.New(r.Runtime, meshStatefulSet, statefulSet, mutateFn)
+	err = syncer.Sync(context.TODO(), statefulSetSyncer, r.Recorder)
+	return injectErr, err
+}
+
+// reportStatus reports the StatefulSet, the mesh service and its instances into the status
+// of the MeshStatefulSet, it returns whether all of them are registered in the control plane.
+func (r *MeshStatefulSetReconciler) reportStatus(ctx context.Context, meshStatefulSet *meshv1beta1.MeshStatefulSet,
+	statefulSet *v1.StatefulSet, injectErr, syncErr error) bool {
+	status := &meshStatefulSet.Status
+
+	status.ObservedGeneration = meshStatefulSet.Generation
+	status.Replicas = statefulSet.Status.Replicas
+	status.ReadyReplicas = statefulSet.Status.ReadyReplicas
+	status.SidecarImage = sidecarinjector.SidecarImage(&statefulSet.Spec.Template.Spec)
+	status.InitializerImage = sidecarinjector.InitializerImage(&statefulSet.Spec.Template.Spec)
+
+	serviceName := meshServiceName(&meshStatefulSet.Spec.Service, meshStatefulSet.Name)
+	conditions := &workloadConditions{object: meshStatefulSet, conditions: &status.Conditions}
+	conditions.reportSync(status.SidecarImage, injectErr, syncErr)
+	registered := conditions.reportRegistration(ctx, r.ControlPlane, r.Recorder, serviceName)
+	if r.ControlPlane == nil || !registered {
+		status.RegisteredInstances = nil
+		return registered
+	}
+
+	instances, err := r.ControlPlane.ServiceInstances(ctx)
+	if err != nil {
+		r.Log.Error(err, "list service instances", "service", serviceName)
+		return false
+	}
+
+	status.RegisteredInstances = registeredInstances(instances, serviceName, statefulSet)
+	return len(status.RegisteredInstances) == int(statefulSetReplicas(statefulSet))
+}
+
+// registeredInstances returns the stable instance IDs of the StatefulSet registered
+// in the control plane in the order of the ordinals.
+func registeredInstances(instances []*controlplane.ServiceInstance, serviceName string,
+	statefulSet *v1.StatefulSet) []string {
+	instanceIDs := map[string]struct{}{}
+	for _, instance := range instances {
+		if instance.ServiceName == serviceName {
+			instanceIDs[instance.InstanceID] = struct{}{}
+		}
+	}
+
+	registered := []string{}
+	for ordinal := int32(0); ordinal < statefulSetReplicas(statefulSet); ordinal++ {
+		instanceID := sidecarinjector.StatefulSetInstanceID(statefulSet.Name, ordinal)
+		if _, exists := instanceIDs[instanceID]; exists {
+			registered = append(registered, instanceID)
+		}
+	}
+
+	return registered
+}
+
+func statefulSetReplicas(statefulSet *v1.StatefulSet) int32 {
+	if statefulSet.Spec.Replicas == nil {
+		return 1
+	}
+	return *statefulSet.Spec.Replicas
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MeshStatefulSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&meshv1beta1.MeshStatefulSet{}).
+		Owns(&v1.StatefulSet{}).
+		Complete(r)
+}
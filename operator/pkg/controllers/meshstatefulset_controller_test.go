@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package controllers
+
+import (
+	"context"
+
+	"github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("meshstatefulset controller", func() {
+	var meshStatefulSet v1beta1.MeshStatefulSet
+	var result ctrl.Result
+	statefulSetKey := types.NamespacedName{Namespace: namespace, Name: "test-stateful"}
+
+	BeforeEach(func() {
+		controlPlane := &fakeControlPlane{
+			serviceInstances: []*controlplane.ServiceInstance{
+				{ServiceName: "test-stateful-server", InstanceID: "test-stateful-1"},
+				{ServiceName: "another-server", InstanceID: "test-stateful-0"},
+			},
+		}
+		reconciler := &MeshStatefulSetReconciler{
+			Runtime: &base.Runtime{
+				Name:     "mesh-controller-test",
+				Client:   k8sClient,
+				Scheme:   scheme.Scheme,
+				Recorder: &mockRecorder{},
+				Log:      ctrl.Log.WithName("controllers").WithName("MeshStatefulSet"),
+			},
+			ControlPlane: controlPlane,
+		}
+		meshStatefulSet = v1beta1.MeshStatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      statefulSetKey.Name,
+				Namespace: namespace,
+			},
+			Spec: v1beta1.MeshStatefulSetSpec{
+				Service: v1beta1.ServiceSpec{
+					Name: "test-stateful-server",
+				},
+				Deploy: v1beta1.StatefulSetDeploySpec{
+					StatefulSetSpec: v1.StatefulSetSpec{
+						Replicas:    fromInt32(2),
+						ServiceName: "test-stateful-server",
+						Selector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"app": "test-stateful-server",
+							},
+						},
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{
+									{
+										Name:  "test-stateful-server",
+										Image: "megaease/non-existent:1.0-alpine",
+										Ports: []corev1.ContainerPort{
+											{
+												Name:          "test-port",
+												ContainerPort: 8080,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.TODO(), &meshStatefulSet)).To(Succeed())
+
+		var err error
+		result, err = reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: statefulSetKey})
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		k8sClient.Delete(context.TODO(), &meshStatefulSet)
+		k8sClient.Delete(context.TODO(), &v1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: statefulSetKey.Name, Namespace: namespace}})
+	})
+
+	It("should create the injected statefulset owned by the meshstatefulset", func() {
+		statefulSet := v1.StatefulSet{}
+		Expect(k8sClient.Get(context.TODO(), statefulSetKey, &statefulSet)).To(Succeed())
+		Expect(len(statefulSet.Spec.Template.Spec.Containers)).To(Equal(2))
+		Expect(metav1.IsControlledBy(&statefulSet, &meshStatefulSet)).To(BeTrue())
+
+		sidecar := statefulSet.Spec.Template.Spec.Containers[1]
+		Expect(sidecar.Env).To(ContainElement(corev1.EnvVar{
+			Name: "HOSTNAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.name"},
+			},
+		}))
+	})
+
+	It("should report the registered instances by the ordinals", func() {
+		got := v1beta1.MeshStatefulSet{}
+		Expect(k8sClient.Get(context.TODO(), statefulSetKey, &got)).To(Succeed())
+		Expect(got.Status.RegisteredInstances).To(Equal([]string{"test-stateful-1"}))
+		Expect(got.Status.SidecarImage).NotTo(BeEmpty())
+		Expect(meta.IsStatusConditionTrue(got.Status.Conditions, v1beta1.MeshWorkloadConditionSynced)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(got.Status.Conditions, v1beta1.MeshWorkloadConditionMeshServiceRegistered)).To(BeTrue())
+
+		// NOTE: The instance test-stateful-0 is not registered yet.
+		Expect(result.RequeueAfter).To(Equal(DefaultRegistrationCheckPeriod))
+	})
+})
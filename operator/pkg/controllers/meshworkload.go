@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package controllers
+
+import (
+	"context"
+	"time"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultRegistrationCheckPeriod is the default period of checking
+// the mesh service which is not registered yet.
+const DefaultRegistrationCheckPeriod = 30 * time.Second
+
+type (
+	// MeshControlPlane is the control plane of the mesh services of the mesh workloads,
+	// which are MeshDeployment, MeshStatefulSet and MeshDaemonSet.
+	MeshControlPlane interface {
+		GetService(ctx context.Context, name string) (*controlplane.Service, error)
+		ServiceInstances(ctx context.Context) ([]*controlplane.ServiceInstance, error)
+		ApplyServiceCanary(ctx context.Context, serviceCanary *controlplane.ServiceCanary) error
+		DeleteServiceCanary(ctx context.Context, name string) error
+	}
+
+	// workloadConditions reports the conditions shared by the mesh workloads.
+	workloadConditions struct {
+		object     client.Object
+		conditions *[]metav1.Condition
+	}
+)
+
+var _ MeshControlPlane = &controlplane.Client{}
+
+func (c *workloadConditions) set(conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(c.conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: c.object.GetGeneration(),
+	})
+}
+
+// reportSync reports the injection of the sidecar and the sync of the workload.
+func (c *workloadConditions) reportSync(sidecarImage string, injectErr, syncErr error) {
+	// NOTE: The sync failure of other reasons leaves SidecarInjected as it was.
+	switch {
+	case injectErr != nil:
+		c.set(meshv1beta1.MeshWorkloadConditionSidecarInjected,
+			metav1.ConditionFalse, "InjectFailed", injectErr.Error())
+	case sidecarImage != "":
+		c.set(meshv1beta1.MeshWorkloadConditionSidecarInjected,
+			metav1.ConditionTrue, "Injected", "")
+	}
+
+	if syncErr != nil {
+		c.set(meshv1beta1.MeshWorkloadConditionSynced,
+			metav1.ConditionFalse, "SyncFailed", syncErr.Error())
+	} else {
+		c.set(meshv1beta1.MeshWorkloadConditionSynced,
+			metav1.ConditionTrue, "Synced", "")
+	}
+}
+
+// reportRegistration checks whether the mesh service is registered in the control plane,
+// and reports it. It returns false if the registration is worth checking again.
+func (c *workloadConditions) reportRegistration(ctx context.Context, controlPlane MeshControlPlane,
+	recorder record.EventRecorder, serviceName string) bool {
+	if controlPlane == nil {
+		c.set(meshv1beta1.MeshWorkloadConditionMeshServiceRegistered,
+			metav1.ConditionUnknown, "NoControlPlane", "control plane is not configured")
+		return true
+	}
+
+	service, err := controlPlane.GetService(ctx, serviceName)
+	switch {
+	case err != nil:
+		c.set(meshv1beta1.MeshWorkloadConditionMeshServiceRegistered,
+			metav1.ConditionUnknown, "CheckFailed", err.Error())
+		return false
+	case service == nil:
+		message := "mesh service " + serviceName + " not found in the control plane"
+		c.set(meshv1beta1.MeshWorkloadConditionMeshServiceRegistered,
+			metav1.ConditionFalse, "NotRegistered", message)
+		recorder.Event(c.object, corev1.EventTypeWarning, "NotRegistered", message)
+		return false
+	default:
+		c.set(meshv1beta1.MeshWorkloadConditionMeshServiceRegistered,
+			metav1.ConditionTrue, "Registered", "")
+		return true
+	}
+}
+
+// registrationResult requeues the workload after the period if the registration
+// is worth checking again.
+func registrationResult(registered bool, checkPeriod time.Duration) ctrl.Result {
+	if registered {
+		return ctrl.Result{}
+	}
+
+	if checkPeriod == 0 {
+		checkPeriod = DefaultRegistrationCheckPeriod
+	}
+	return ctrl.Result{RequeueAfter: checkPeriod}
+}
+
+// meshServiceName returns the name of the mesh service of the workload,
+// multiple workloads such as the canary ones could share the same mesh service.
+func meshServiceName(service *meshv1beta1.ServiceSpec, workloadName string) string {
+	if service.Name != "" {
+		return service.Name
+	}
+	return workloadName
+}
@@ -0,0 +1,256 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package controlplane calls the admin API of the control plane of EaseMesh.
+package controlplane
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	meshAPIURL            = "/apis/v2/mesh"
+	servicesURL           = meshAPIURL + "/services"
+	serviceURL            = meshAPIURL + "/services/%s"
+	serviceInstancesURL   = meshAPIURL + "/serviceinstances"
+	serviceCanariesURL    = meshAPIURL + "/servicecanaries"
+	customResourceKindURL = meshAPIURL + "/customresourcekinds"
+	customResourcesURL    = meshAPIURL + "/customresources"
+
+	requestTimeout = 10 * time.Second
+)
+
+type (
+	// Service is a service of EaseMesh.
+	Service struct {
+		Name           string `json:"name"`
+		RegisterTenant string `json:"registerTenant"`
+	}
+
+	// ServiceInstance is an instance of a service of EaseMesh.
+	ServiceInstance struct {
+		ServiceName string `json:"serviceName"`
+		InstanceID  string `json:"instanceID"`
+		Status      string `json:"status"`
+	}
+
+	// ServiceCanary routes the traffic chosen by the rules to the instances
+	// of the services matched by the selector.
+	ServiceCanary struct {
+		Name         string           `json:"name"`
+		Priority     int32            `json:"priority,omitempty"`
+		Selector     *ServiceSelector `json:"selector"`
+		TrafficRules *TrafficRules    `json:"trafficRules"`
+	}
+
+	// ServiceSelector selects the instances of the services by their labels.
+	ServiceSelector struct {
+		MatchServices       []string          `json:"matchServices"`
+		MatchInstanceLabels map[string]string `json:"matchInstanceLabels"`
+	}
+
+	// TrafficRules choose the traffic by the HTTP headers.
+	TrafficRules struct {
+		Headers map[string]*StringMatch `json:"headers"`
+	}
+
+	// StringMatch matches a string exactly, by the prefix or by the regular expression.
+	StringMatch struct {
+		Exact  string `json:"exact,omitempty"`
+		Prefix string `json:"prefix,omitempty"`
+		Regex  string `json:"regex,omitempty"`
+	}
+
+	// Client calls the admin API of the control plane by HTTP.
+	Client struct {
+		baseURL string
+		client  *http.Client
+	}
+)
+
+// NewClient creates the client of the control plane of the address, it's accessed by
+// HTTPS with the TLS config, or by plain HTTP if the config is nil.
+func NewClient(addr string, tlsConfig *tls.Config) *Client {
+	if tlsConfig == nil {
+		return &Client{
+			baseURL: "http://" + addr,
+			client:  &http.Client{Timeout: requestTimeout},
+		}
+	}
+
+	return &Client{
+		baseURL: "https://" + addr,
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+}
+
+// Services lists the services.
+func (c *Client) Services(ctx context.Context) ([]*Service, error) {
+	services := []*Service{}
+	_, err := c.do(ctx, http.MethodGet, servicesURL, nil, &services)
+	return services, err
+}
+
+// GetService gets the service, it returns nil if not found.
+func (c *Client) GetService(ctx context.Context, name string) (*Service, error) {
+	service := &Service{}
+	statusCode, err := c.do(ctx, http.MethodGet, fmt.Sprintf(serviceURL, name), nil, service)
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// ServiceInstances lists the instances of all services.
+func (c *Client) ServiceInstances(ctx context.Context) ([]*ServiceInstance, error) {
+	instances := []*ServiceInstance{}
+	_, err := c.do(ctx, http.MethodGet, serviceInstancesURL, nil, &instances)
+	return instances, err
+}
+
+// ApplyServiceCanary creates or updates the service canary.
+func (c *Client) ApplyServiceCanary(ctx context.Context, serviceCanary *ServiceCanary) error {
+	statusCode, err := c.do(ctx, http.MethodPost, serviceCanariesURL, serviceCanary, nil)
+	if statusCode == http.StatusConflict {
+		_, err = c.do(ctx, http.MethodPut, serviceCanariesURL+"/"+serviceCanary.Name, serviceCanary, nil)
+	}
+	return err
+}
+
+// DeleteServiceCanary deletes the service canary, it's fine if not found.
+func (c *Client) DeleteServiceCanary(ctx context.Context, name string) error {
+	statusCode, err := c.do(ctx, http.MethodDelete, serviceCanariesURL+"/"+name, nil, nil)
+	if statusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// EnsureCustomResourceKind creates the kind of the custom resources if it doesn't exist.
+func (c *Client) EnsureCustomResourceKind(ctx context.Context, kind string) error {
+	statusCode, err := c.do(ctx, http.MethodGet, customResourceKindURL+"/"+kind, nil, nil)
+	if err == nil || statusCode != http.StatusNotFound {
+		return err
+	}
+
+	body := map[string]interface{}{"name": kind}
+	statusCode, err = c.do(ctx, http.MethodPost, customResourceKindURL, body, nil)
+	// NOTE: The kind may be created by another replica at the same time.
+	if statusCode == http.StatusConflict {
+		return nil
+	}
+	return err
+}
+
+// CustomResources lists the custom resources of the kind.
+func (c *Client) CustomResources(ctx context.Context, kind string) ([]map[string]interface{}, error) {
+	resources := []map[string]interface{}{}
+	statusCode, err := c.do(ctx, http.MethodGet, customResourcesURL+"/"+kind, nil, &resources)
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return resources, err
+}
+
+// GetCustomResource gets the custom resource, it returns nil if not found.
+func (c *Client) GetCustomResource(ctx context.Context, kind, name string) (map[string]interface{}, error) {
+	resource := map[string]interface{}{}
+	statusCode, err := c.do(ctx, http.MethodGet, customResourcesURL+"/"+kind+"/"+name, nil, &resource)
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// ApplyCustomResource creates or updates the custom resource.
+func (c *Client) ApplyCustomResource(ctx context.Context, resource map[string]interface{}) error {
+	statusCode, err := c.do(ctx, http.MethodPost, customResourcesURL, resource, nil)
+	if statusCode == http.StatusConflict {
+		_, err = c.do(ctx, http.MethodPut, customResourcesURL, resource, nil)
+	}
+	return err
+}
+
+// DeleteCustomResource deletes the custom resource, it's fine if not found.
+func (c *Client) DeleteCustomResource(ctx context.Context, kind, name string) error {
+	statusCode, err := c.do(ctx, http.MethodDelete, customResourcesURL+"/"+kind+"/"+name, nil, nil)
+	if statusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// do calls the API, it returns the status code with the error if the status code is not 2xx.
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) (int, error) {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return 0, errors.Wrap(err, "marshal request body")
+		}
+	}
+
+	url := c.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "call %s %s", method, url)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, errors.Wrapf(err, "read response of %s %s", method, url)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.Errorf("call %s %s failed, status code %d, body: %s",
+			method, url, resp.StatusCode, string(respBody))
+	}
+
+	if result != nil {
+		err = json.Unmarshal(respBody, result)
+		if err != nil {
+			return resp.StatusCode, errors.Wrapf(err, "unmarshal response of %s %s", method, url)
+		}
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,361 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package federation exchanges the catalogs of the exported services between the
+// control planes of the clusters linked into a federation.
+//
+// Every cluster publishes the catalog of its exported services to its own control plane
+// as a FederationCatalog custom resource, and fetches the ones of the remote clusters.
+// The services in the remote catalogs are recorded as the FederatedService custom
+// resources in the local control plane.
+//
+// NOTE: The federation doesn't route any traffic, nothing consumes the FederatedService
+// resources, and the east-west gateways are recorded only, until the sidecar of Easegress
+// routes the federated services.
+package federation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"sort"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// CatalogKind is the kind of the custom resource of the catalog exported by a cluster,
+	// it's named after the cluster.
+	CatalogKind = "FederationCatalog"
+	// FederatedServiceKind is the kind of the custom resource of a service imported from
+	// a remote cluster, it's named <service>.<cluster>.
+	FederatedServiceKind = "FederatedService"
+
+	// ExportAll exports all services.
+	ExportAll = "*"
+
+	instanceStatusUp = "UP"
+
+	trustCAKey   = "ca.crt"
+	trustCertKey = "tls.crt"
+	trustKeyKey  = "tls.key"
+)
+
+type (
+	// ControlPlane is the API of the control plane of EaseMesh used by the federation.
+	ControlPlane interface {
+		// Services lists the services.
+		Services(ctx context.Context) ([]*controlplane.Service, error)
+		// ServiceInstances lists the instances of all services.
+		ServiceInstances(ctx context.Context) ([]*controlplane.ServiceInstance, error)
+		// EnsureCustomResourceKind creates the kind of the custom resources if it doesn't exist.
+		EnsureCustomResourceKind(ctx context.Context, kind string) error
+		// CustomResources lists the custom resources of the kind.
+		CustomResources(ctx context.Context, kind string) ([]map[string]interface{}, error)
+		// GetCustomResource gets the custom resource, it returns nil if not found.
+		GetCustomResource(ctx context.Context, kind, name string) (map[string]interface{}, error)
+		// ApplyCustomResource creates or updates the custom resource.
+		ApplyCustomResource(ctx context.Context, resource map[string]interface{}) error
+		// DeleteCustomResource deletes the custom resource, it's fine if not found.
+		DeleteCustomResource(ctx context.Context, kind, name string) error
+	}
+
+	// Catalog is the catalog of the services exported by a cluster.
+	Catalog struct {
+		Kind     string            `json:"kind"`
+		Name     string            `json:"name"`
+		Gateway  string            `json:"gateway"`
+		Services []*CatalogService `json:"services"`
+	}
+
+	// CatalogService is an exported service.
+	CatalogService struct {
+		Name   string `json:"name"`
+		Tenant string `json:"tenant"`
+		// ReadyInstances is the number of the instances up, the remote clusters fail
+		// over to the service when none of their own instances is up.
+		ReadyInstances int `json:"readyInstances"`
+	}
+
+	// FederatedService is a service imported from a remote cluster.
+	FederatedService struct {
+		Kind           string `json:"kind"`
+		Name           string `json:"name"`
+		Service        string `json:"service"`
+		Tenant         string `json:"tenant"`
+		Cluster        string `json:"cluster"`
+		Gateway        string `json:"gateway"`
+		ReadyInstances int    `json:"readyInstances"`
+	}
+
+	// Syncer syncs the catalogs between the local control plane and the remote ones.
+	Syncer struct {
+		Local ControlPlane
+	}
+)
+
+var _ ControlPlane = &controlplane.Client{}
+
+// BuildCatalog builds the catalog of the services matching the export rules.
+func BuildCatalog(spec *meshv1beta1.MeshFederationSpec, services []*controlplane.Service, instances []*controlplane.ServiceInstance) *Catalog {
+	readyInstances := map[string]int{}
+	for _, instance := range instances {
+		if instance.Status == instanceStatusUp {
+			readyInstances[instance.ServiceName]++
+		}
+	}
+
+	catalog := &Catalog{
+		Kind:     CatalogKind,
+		Name:     spec.ClusterName,
+		Gateway:  spec.Gateway,
+		Services: []*CatalogService{},
+	}
+	for _, service := range services {
+		if !exported(spec.Exports, service) {
+			continue
+		}
+		catalog.Services = append(catalog.Services, &CatalogService{
+			Name:           service.Name,
+			Tenant:         service.RegisterTenant,
+			ReadyInstances: readyInstances[service.Name],
+		})
+	}
+	sort.Slice(catalog.Services, func(i, j int) bool {
+		return catalog.Services[i].Name < catalog.Services[j].Name
+	})
+
+	return catalog
+}
+
+func exported(exports []meshv1beta1.FederationExport, service *controlplane.Service) bool {
+	for _, export := range exports {
+		for _, name := range export.Services {
+			if name == ExportAll || name == service.Name {
+				return true
+			}
+		}
+		for _, tenant := range export.Tenants {
+			if tenant == service.RegisterTenant {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Export publishes the catalog of the exported services to the local control plane.
+func (s *Syncer) Export(ctx context.Context, spec *meshv1beta1.MeshFederationSpec) (*Catalog, error) {
+	for _, kind := range []string{CatalogKind, FederatedServiceKind} {
+		err := s.Local.EnsureCustomResourceKind(ctx, kind)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ensure custom resource kind %s", kind)
+		}
+	}
+
+	services, err := s.Local.Services(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list services")
+	}
+	instances, err := s.Local.ServiceInstances(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list service instances")
+	}
+
+	catalog := BuildCatalog(spec, services, instances)
+	resource, err := toResource(catalog)
+	if err != nil {
+		return nil, err
+	}
+	err = s.Local.ApplyCustomResource(ctx, resource)
+	if err != nil {
+		return nil, errors.Wrapf(err, "publish catalog %s", catalog.Name)
+	}
+
+	return catalog, nil
+}
+
+// Import fetches the catalog of the remote cluster and records its services as the
+// federated services in the local control plane, it returns the imported services.
+func (s *Syncer) Import(ctx context.Context, remote *meshv1beta1.FederationRemote, remoteControlPlane ControlPlane) ([]string, error) {
+	resource, err := remoteControlPlane.GetCustomResource(ctx, CatalogKind, remote.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get catalog of cluster %s", remote.Name)
+	}
+	if resource == nil {
+		return nil, errors.Errorf("catalog of cluster %s not found, check its MeshFederation", remote.Name)
+	}
+
+	catalog := &Catalog{}
+	err = fromResource(resource, catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	gateway := catalog.Gateway
+	if remote.Gateway != "" {
+		gateway = remote.Gateway
+	}
+	if gateway == "" {
+		return nil, errors.Errorf("no gateway of cluster %s", remote.Name)
+	}
+
+	imported := map[string]bool{}
+	importedServices := []string{}
+	for _, service := range catalog.Services {
+		federatedService := &FederatedService{
+			Kind:           FederatedServiceKind,
+			Name:           service.Name + "." + remote.Name,
+			Service:        service.Name,
+			Tenant:         service.Tenant,
+			Cluster:        remote.Name,
+			Gateway:        gateway,
+			ReadyInstances: service.ReadyInstances,
+		}
+		resource, err := toResource(federatedService)
+		if err != nil {
+			return nil, err
+		}
+		err = s.Local.ApplyCustomResource(ctx, resource)
+		if err != nil {
+			return nil, errors.Wrapf(err, "apply federated service %s", federatedService.Name)
+		}
+
+		imported[federatedService.Name] = true
+		importedServices = append(importedServices, service.Name)
+	}
+
+	// NOTE: Remove the services no longer exported by the remote cluster.
+	err = s.prune(ctx, func(fs *FederatedService) bool {
+		return fs.Cluster == remote.Name && !imported[fs.Name]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return importedServices, nil
+}
+
+// Unlink removes the federated services of the clusters not linked anymore.
+func (s *Syncer) Unlink(ctx context.Context, remotes []meshv1beta1.FederationRemote) error {
+	linked := map[string]bool{}
+	for _, remote := range remotes {
+		linked[remote.Name] = true
+	}
+
+	return s.prune(ctx, func(fs *FederatedService) bool {
+		return !linked[fs.Cluster]
+	})
+}
+
+// Clear removes the catalog and all federated services of the local cluster.
+func (s *Syncer) Clear(ctx context.Context, spec *meshv1beta1.MeshFederationSpec) error {
+	err := s.prune(ctx, func(fs *FederatedService) bool { return true })
+	if err != nil {
+		return err
+	}
+
+	err = s.Local.DeleteCustomResource(ctx, CatalogKind, spec.ClusterName)
+	if err != nil {
+		return errors.Wrapf(err, "delete catalog %s", spec.ClusterName)
+	}
+
+	return nil
+}
+
+func (s *Syncer) prune(ctx context.Context, stale func(fs *FederatedService) bool) error {
+	resources, err := s.Local.CustomResources(ctx, FederatedServiceKind)
+	if err != nil {
+		return errors.Wrap(err, "list federated services")
+	}
+
+	for _, resource := range resources {
+		federatedService := &FederatedService{}
+		err := fromResource(resource, federatedService)
+		if err != nil {
+			return err
+		}
+		if !stale(federatedService) {
+			continue
+		}
+
+		err = s.Local.DeleteCustomResource(ctx, FederatedServiceKind, federatedService.Name)
+		if err != nil {
+			return errors.Wrapf(err, "delete federated service %s", federatedService.Name)
+		}
+	}
+
+	return nil
+}
+
+// TLSConfig loads the TLS config accessing the remote control plane from the trust secret.
+func TLSConfig(secret *corev1.Secret) (*tls.Config, error) {
+	caPem := secret.Data[trustCAKey]
+	if len(caPem) == 0 {
+		return nil, errors.Errorf("no %s in secret %s", trustCAKey, secret.Name)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPem) {
+		return nil, errors.Errorf("invalid %s in secret %s", trustCAKey, secret.Name)
+	}
+
+	config := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	certPem, keyPem := secret.Data[trustCertKey], secret.Data[trustKeyKey]
+	if len(certPem) != 0 || len(keyPem) != 0 {
+		cert, err := tls.X509KeyPair(certPem, keyPem)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load client certificate in secret %s", secret.Name)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+func toResource(v interface{}) (map[string]interface{}, error) {
+	buff, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal custom resource")
+	}
+
+	resource := map[string]interface{}{}
+	err = json.Unmarshal(buff, &resource)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal custom resource")
+	}
+
+	return resource, nil
+}
+
+func fromResource(resource map[string]interface{}, v interface{}) error {
+	buff, err := json.Marshal(resource)
+	if err != nil {
+		return errors.Wrap(err, "marshal custom resource")
+	}
+
+	err = json.Unmarshal(buff, v)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal custom resource")
+	}
+
+	return nil
+}
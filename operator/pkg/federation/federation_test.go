@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeControlPlane keeps the custom resources in memory.
+type fakeControlPlane struct {
+	services  []*controlplane.Service
+	instances []*controlplane.ServiceInstance
+	kinds     map[string]bool
+	resources map[string]map[string]map[string]interface{}
+}
+
+func newFakeControlPlane(services []*controlplane.Service, instances []*controlplane.ServiceInstance) *fakeControlPlane {
+	return &fakeControlPlane{
+		services:  services,
+		instances: instances,
+		kinds:     map[string]bool{},
+		resources: map[string]map[string]map[string]interface{}{},
+	}
+}
+
+func (cp *fakeControlPlane) Services(ctx context.Context) ([]*controlplane.Service, error) {
+	return cp.services, nil
+}
+
+func (cp *fakeControlPlane) ServiceInstances(ctx context.Context) ([]*controlplane.ServiceInstance, error) {
+	return cp.instances, nil
+}
+
+func (cp *fakeControlPlane) EnsureCustomResourceKind(ctx context.Context, kind string) error {
+	cp.kinds[kind] = true
+	return nil
+}
+
+func (cp *fakeControlPlane) CustomResources(ctx context.Context, kind string) ([]map[string]interface{}, error) {
+	resources := []map[string]interface{}{}
+	for _, resource := range cp.resources[kind] {
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+func (cp *fakeControlPlane) GetCustomResource(ctx context.Context, kind, name string) (map[string]interface{}, error) {
+	return cp.resources[kind][name], nil
+}
+
+func (cp *fakeControlPlane) ApplyCustomResource(ctx context.Context, resource map[string]interface{}) error {
+	kind, name := resource["kind"].(string), resource["name"].(string)
+	if cp.resources[kind] == nil {
+		cp.resources[kind] = map[string]map[string]interface{}{}
+	}
+	cp.resources[kind][name] = resource
+	return nil
+}
+
+func (cp *fakeControlPlane) DeleteCustomResource(ctx context.Context, kind, name string) error {
+	delete(cp.resources[kind], name)
+	return nil
+}
+
+func federationSpec(cluster string, exports ...meshv1beta1.FederationExport) *meshv1beta1.MeshFederationSpec {
+	return &meshv1beta1.MeshFederationSpec{
+		ClusterName: cluster,
+		Gateway:     cluster + ".example.com:19527",
+		Exports:     exports,
+	}
+}
+
+var _ = Describe("Federation", func() {
+	ctx := context.Background()
+
+	It("builds the catalog by the export rules", func() {
+		spec := federationSpec("dc1",
+			meshv1beta1.FederationExport{Services: []string{"order"}},
+			meshv1beta1.FederationExport{Tenants: []string{"public"}})
+		catalog := BuildCatalog(spec, []*controlplane.Service{
+			{Name: "order", RegisterTenant: "shop"},
+			{Name: "payment", RegisterTenant: "private"},
+			{Name: "delivery", RegisterTenant: "public"},
+		}, []*controlplane.ServiceInstance{
+			{ServiceName: "order", InstanceID: "1", Status: "UP"},
+			{ServiceName: "order", InstanceID: "2", Status: "OUT_OF_SERVICE"},
+			{ServiceName: "order", InstanceID: "3", Status: "UP"},
+		})
+
+		Expect(catalog.Name).To(Equal("dc1"))
+		Expect(catalog.Services).To(HaveLen(2))
+		Expect(catalog.Services[0].Name).To(Equal("delivery"))
+		Expect(catalog.Services[0].ReadyInstances).To(Equal(0))
+		Expect(catalog.Services[1].Name).To(Equal("order"))
+		Expect(catalog.Services[1].ReadyInstances).To(Equal(2))
+
+		all := BuildCatalog(federationSpec("dc1", meshv1beta1.FederationExport{Services: []string{ExportAll}}),
+			[]*controlplane.Service{{Name: "order"}, {Name: "payment"}}, nil)
+		Expect(all.Services).To(HaveLen(2))
+		Expect(BuildCatalog(federationSpec("dc1"), []*controlplane.Service{{Name: "order"}}, nil).Services).To(BeEmpty())
+	})
+
+	It("exchanges the catalogs between the clusters", func() {
+		dc1 := newFakeControlPlane([]*controlplane.Service{{Name: "order"}}, []*controlplane.ServiceInstance{{ServiceName: "order", Status: "UP"}})
+		dc2 := newFakeControlPlane([]*controlplane.Service{{Name: "payment"}, {Name: "internal"}}, nil)
+		dc1Spec := federationSpec("dc1", meshv1beta1.FederationExport{Services: []string{ExportAll}})
+		dc2Spec := federationSpec("dc2", meshv1beta1.FederationExport{Services: []string{"payment"}})
+		dc1Syncer, dc2Syncer := &Syncer{Local: dc1}, &Syncer{Local: dc2}
+
+		_, err := dc1Syncer.Export(ctx, dc1Spec)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = dc2Syncer.Export(ctx, dc2Spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dc1.kinds).To(HaveKey(FederatedServiceKind))
+
+		imported, err := dc1Syncer.Import(ctx, &meshv1beta1.FederationRemote{Name: "dc2"}, dc2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imported).To(Equal([]string{"payment"}))
+		federatedService := dc1.resources[FederatedServiceKind]["payment.dc2"]
+		Expect(federatedService).NotTo(BeNil())
+		Expect(federatedService["gateway"]).To(Equal("dc2.example.com:19527"))
+		Expect(federatedService["cluster"]).To(Equal("dc2"))
+
+		imported, err = dc2Syncer.Import(ctx, &meshv1beta1.FederationRemote{Name: "dc1", Gateway: "10.0.0.1:19527"}, dc1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imported).To(Equal([]string{"order"}))
+		Expect(dc2.resources[FederatedServiceKind]["order.dc1"]["gateway"]).To(Equal("10.0.0.1:19527"))
+		Expect(dc2.resources[FederatedServiceKind]["order.dc1"]["readyInstances"]).To(BeEquivalentTo(1))
+
+		// The services no longer exported are removed from the remote cluster.
+		dc2Spec.Exports = nil
+		_, err = dc2Syncer.Export(ctx, dc2Spec)
+		Expect(err).NotTo(HaveOccurred())
+		imported, err = dc1Syncer.Import(ctx, &meshv1beta1.FederationRemote{Name: "dc2"}, dc2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imported).To(BeEmpty())
+		Expect(dc1.resources[FederatedServiceKind]).To(BeEmpty())
+
+		Expect(dc2Syncer.Unlink(ctx, nil)).To(Succeed())
+		Expect(dc2.resources[FederatedServiceKind]).To(BeEmpty())
+
+		Expect(dc1Syncer.Clear(ctx, dc1Spec)).To(Succeed())
+		Expect(dc1.resources[CatalogKind]).To(BeEmpty())
+	})
+
+	It("fails to import the cluster not federated", func() {
+		dc1 := newFakeControlPlane(nil, nil)
+		_, err := (&Syncer{Local: dc1}).Import(ctx, &meshv1beta1.FederationRemote{Name: "dc2"}, newFakeControlPlane(nil, nil))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("catalog of cluster dc2 not found"))
+	})
+
+	It("calls the custom resource API of the control plane", func() {
+		const customResourcesURL = "/apis/v2/mesh/customresources"
+
+		methods := []string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method+" "+r.URL.Path)
+			switch {
+			case r.Method == http.MethodPost:
+				w.WriteHeader(http.StatusConflict)
+			case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/dc2"):
+				w.WriteHeader(http.StatusNotFound)
+			case r.Method == http.MethodGet:
+				json.NewEncoder(w).Encode(map[string]interface{}{"kind": CatalogKind, "name": "dc1"})
+			}
+		}))
+		defer server.Close()
+
+		cp := controlplane.NewClient(strings.TrimPrefix(server.URL, "http://"), nil)
+		Expect(cp.ApplyCustomResource(ctx, map[string]interface{}{"kind": CatalogKind, "name": "dc1"})).To(Succeed())
+		resource, err := cp.GetCustomResource(ctx, CatalogKind, "dc1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resource["name"]).To(Equal("dc1"))
+		resource, err = cp.GetCustomResource(ctx, CatalogKind, "dc2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resource).To(BeNil())
+
+		Expect(methods).To(Equal([]string{
+			"POST " + customResourcesURL,
+			"PUT " + customResourcesURL,
+			"GET " + customResourcesURL + "/" + CatalogKind + "/dc1",
+			"GET " + customResourcesURL + "/" + CatalogKind + "/dc2",
+		}))
+	})
+})
@@ -54,7 +54,21 @@ func (h *MutateHook) mutateHandler(cxt context.Context, req admission.Request) a
 		return ignoreResp(&req)
 	}
 
-	h.Log.Info("mutate", "id", fmt.Sprintf("%s %s/%s", req.Kind.Kind, req.Namespace, req.Name))
+	id := fmt.Sprintf("%s %s/%s", req.Kind.Kind, req.Namespace, req.Name)
+
+	// NOTE: The mutating webhook is called before the validating one,
+	// so it rejects the invalid annotations with the same reasons instead of failing the injection.
+	_, errs, err := validateAnnotatedObject(&req)
+	if err != nil {
+		h.Log.Error(err, "")
+		return errorResp(err)
+	}
+	if len(errs) != 0 {
+		h.Log.Info("denied", "id", id, "reason", errs.ToAggregate().Error())
+		return deniedResp(errs)
+	}
+
+	h.Log.Info("mutate", "id", id)
 	currentRaw, err := h.injectSidecar(&req)
 	if err != nil {
 		h.Log.Error(err, "")
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/sidecarinjector"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// localityLabels are the topology labels of the node copied to the pod bound to it.
+var localityLabels = []string{corev1.LabelTopologyZone, corev1.LabelTopologyRegion}
+
+type (
+	// LocalityHook handle requests of the bindings of pods from the MutatingWebhookConfiguration,
+	// it copies the topology labels of the node to the injected pod bound to it,
+	// which are exposed to the init container by the downward API.
+	LocalityHook struct {
+		*base.Runtime
+		Admission *webhook.Admission
+
+		// Reader reads the pod and the node from the API server,
+		// the pod is just created and may not be in the cache of the client.
+		Reader client.Reader
+	}
+)
+
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;patch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get
+
+// NewLocalityHook creates a locality hook.
+func NewLocalityHook(baseRuntime *base.Runtime, reader client.Reader) *LocalityHook {
+	h := &LocalityHook{
+		Runtime: baseRuntime,
+		Reader:  reader,
+	}
+	h.Admission = &webhook.Admission{
+		Handler: admission.HandlerFunc(h.localityHandler),
+	}
+	h.Admission.InjectLogger(h.Log)
+
+	return h
+}
+
+func (h *LocalityHook) localityHandler(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create || req.Kind.Kind != "Binding" {
+		return ignoreResp(&req)
+	}
+
+	// NOTE: The binding is always allowed, the pod only misses the locality if it fails,
+	// and the patch is skipped in dry run since the webhook claims no side effects on it.
+	if req.DryRun != nil && *req.DryRun {
+		return ignoreResp(&req)
+	}
+
+	binding := &corev1.Binding{}
+	err := json.Unmarshal(req.Object.Raw, binding)
+	if err != nil {
+		h.Log.Error(err, "unmarshal json to binding", "raw", req.String())
+		return ignoreResp(&req)
+	}
+
+	err = h.copyLocality(ctx, req.Namespace, req.Name, binding.Target.Name)
+	if err != nil {
+		h.Log.Error(err, "", "id", fmt.Sprintf("Pod %s/%s", req.Namespace, req.Name))
+	}
+
+	return ignoreResp(&req)
+}
+
+func (h *LocalityHook) copyLocality(ctx context.Context, namespace, podName, nodeName string) error {
+	pod := &corev1.Pod{}
+	err := h.Reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: podName}, pod)
+	if err != nil {
+		return errors.Wrapf(err, "get pod %s/%s", namespace, podName)
+	}
+	if sidecarinjector.SidecarImage(&pod.Spec) == "" {
+		return nil
+	}
+
+	node := &corev1.Node{}
+	err = h.Reader.Get(ctx, types.NamespacedName{Name: nodeName}, node)
+	if err != nil {
+		return errors.Wrapf(err, "get node %s", nodeName)
+	}
+
+	original := pod.DeepCopy()
+	changed := false
+	for _, key := range localityLabels {
+		value, exists := node.Labels[key]
+		if !exists || pod.Labels[key] == value {
+			continue
+		}
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[key] = value
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	err = h.Client.Patch(ctx, pod, client.MergeFrom(original))
+	if err != nil {
+		return errors.Wrapf(err, "patch locality labels of pod %s/%s", namespace, podName)
+	}
+	h.Log.Info("locality", "id", fmt.Sprintf("Pod %s/%s", namespace, podName), "node", nodeName)
+
+	return nil
+}
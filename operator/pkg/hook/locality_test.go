@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hook
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LocalityHook", func() {
+	var fakeClient client.Client
+	var hook *LocalityHook
+
+	newPod := func(name string, containers ...string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{"app": name},
+			},
+		}
+		for _, container := range containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container, Image: container})
+		}
+		return pod
+	}
+
+	bindingRequest := func(pod, node string) admission.Request {
+		binding := &corev1.Binding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: pod},
+			Target:     corev1.ObjectReference{Kind: "Node", Name: node},
+		}
+		raw, err := json.Marshal(binding)
+		Expect(err).NotTo(HaveOccurred())
+
+		return admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:         "binding-uid",
+				Kind:        metav1.GroupVersionKind{Version: "v1", Kind: "Binding"},
+				Resource:    metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+				SubResource: "binding",
+				Namespace:   "default",
+				Name:        pod,
+				Operation:   admissionv1.Create,
+				Object:      runtime.RawExtension{Raw: raw},
+			},
+		}
+	}
+
+	podLabels := func(name string) map[string]string {
+		pod := &corev1.Pod{}
+		Expect(fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, pod)).To(Succeed())
+		return pod.Labels
+	}
+
+	BeforeEach(func() {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-1",
+				Labels: map[string]string{
+					corev1.LabelTopologyZone:   "zone-a",
+					corev1.LabelTopologyRegion: "region-1",
+					corev1.LabelHostname:       "node-1",
+				},
+			},
+		}
+
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(node, newPod("injected", "app", "easemesh-sidecar"), newPod("plain", "app")).
+			Build()
+		hook = NewLocalityHook(&base.Runtime{
+			Name:   "test-runtime-name",
+			Client: fakeClient,
+			Log:    logr.Discard(),
+		}, fakeClient)
+	})
+
+	It("copies the locality of the node to the injected pod", func() {
+		resp := hook.Admission.Handle(context.Background(), bindingRequest("injected", "node-1"))
+		Expect(resp.Allowed).To(BeTrue())
+
+		Expect(podLabels("injected")).To(Equal(map[string]string{
+			"app":                      "injected",
+			corev1.LabelTopologyZone:   "zone-a",
+			corev1.LabelTopologyRegion: "region-1",
+		}))
+	})
+
+	It("leaves the pod without sidecar alone", func() {
+		resp := hook.Admission.Handle(context.Background(), bindingRequest("plain", "node-1"))
+		Expect(resp.Allowed).To(BeTrue())
+
+		Expect(podLabels("plain")).To(Equal(map[string]string{"app": "plain"}))
+	})
+
+	It("allows the binding to the missing node", func() {
+		resp := hook.Admission.Handle(context.Background(), bindingRequest("injected", "node-2"))
+		Expect(resp.Allowed).To(BeTrue())
+
+		Expect(podLabels("injected")).To(Equal(map[string]string{"app": "injected"}))
+	})
+
+	It("skips the dry run", func() {
+		req := bindingRequest("injected", "node-1")
+		dryRun := true
+		req.DryRun = &dryRun
+		resp := hook.Admission.Handle(context.Background(), req)
+		Expect(resp.Allowed).To(BeTrue())
+
+		Expect(podLabels("injected")).To(Equal(map[string]string{"app": "injected"}))
+	})
+})
@@ -128,13 +128,13 @@ func (h *MutateHook) injectSidecar(req *admission.Request) ([]byte, error) {
 		return nil, err
 	}
 
-	object := h.newObject(req.Kind.Kind)
+	object := newObject(req.Kind.Kind)
 	err = json.Unmarshal(req.Object.Raw, object)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unmarshal json %s", req.String())
 	}
 
-	podSpec := h.getPodSpec(object)
+	podSpec := getPodSpec(object)
 
 	injector := sidecarinjector.New(h.Runtime, meshService, podSpec)
 	err = injector.Inject()
@@ -150,7 +150,7 @@ func (h *MutateHook) injectSidecar(req *admission.Request) ([]byte, error) {
 	return currentRaw, nil
 }
 
-func (h *MutateHook) newObject(kind string) interface{} {
+func newObject(kind string) interface{} {
 	switch kind {
 	case "Pod":
 		return &corev1.Pod{}
@@ -167,7 +167,7 @@ func (h *MutateHook) newObject(kind string) interface{} {
 	return nil
 }
 
-func (h *MutateHook) getPodSpec(object interface{}) *corev1.PodSpec {
+func getPodSpec(object interface{}) *corev1.PodSpec {
 	switch obj := object.(type) {
 	case *corev1.Pod:
 		return &obj.Spec
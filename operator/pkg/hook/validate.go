@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
+	"github.com/megaease/easemesh/mesh-operator/pkg/util/labelstool"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// serviceCheckTimeout bounds the check of the mesh service, which only gives a warning,
+// so that the webhook responds before the API server gives up.
+const serviceCheckTimeout = 3 * time.Second
+
+type (
+	// MeshServiceGetter gets the mesh service from the control plane.
+	MeshServiceGetter interface {
+		GetService(ctx context.Context, name string) (*controlplane.Service, error)
+	}
+
+	// ValidateHook handle requests from the ValidatingWebhookConfiguration,
+	// it rejects the objects with invalid mesh annotations and the invalid mesh workloads.
+	ValidateHook struct {
+		*base.Runtime
+		Admission *webhook.Admission
+
+		// ControlPlane checks whether the mesh service exists,
+		// the admission gives no warning if it's nil.
+		ControlPlane MeshServiceGetter
+	}
+
+	// meshWorkload is the part of MeshDeployment, MeshStatefulSet and MeshDaemonSet to validate.
+	meshWorkload struct {
+		service  *meshv1beta1.ServiceSpec
+		selector *metav1.LabelSelector
+		template *corev1.PodTemplateSpec
+	}
+)
+
+var _ MeshServiceGetter = &controlplane.Client{}
+
+// NewValidateHook creates a validate hook.
+func NewValidateHook(baseRuntime *base.Runtime, controlPlane MeshServiceGetter) *ValidateHook {
+	h := &ValidateHook{
+		Runtime:      baseRuntime,
+		ControlPlane: controlPlane,
+	}
+	h.Admission = &webhook.Admission{
+		Handler: admission.HandlerFunc(h.validateHandler),
+	}
+	h.Admission.InjectLogger(h.Log)
+
+	return h
+}
+
+func (h *ValidateHook) validateHandler(ctx context.Context, req admission.Request) admission.Response {
+	switch req.Operation {
+	case admissionv1.Connect, admissionv1.Delete:
+		return ignoreResp(&req)
+	}
+
+	var serviceName string
+	var errs field.ErrorList
+	var err error
+	switch req.Kind.Kind {
+	case "Pod", "ReplicaSet", "Deployment", "StatefulSet", "DaemonSet":
+		serviceName, errs, err = validateAnnotatedObject(&req)
+	case "MeshDeployment", "MeshStatefulSet", "MeshDaemonSet":
+		serviceName, errs, err = validateMeshWorkload(&req)
+	default:
+		return ignoreResp(&req)
+	}
+	if err != nil {
+		h.Log.Error(err, "")
+		return errorResp(err)
+	}
+	if serviceName == "" {
+		return ignoreResp(&req)
+	}
+
+	id := fmt.Sprintf("%s %s/%s", req.Kind.Kind, req.Namespace, req.Name)
+	if len(errs) != 0 {
+		h.Log.Info("denied", "id", id, "reason", errs.ToAggregate().Error())
+		return deniedResp(errs)
+	}
+
+	resp := admission.Allowed("")
+	resp.Warnings = h.checkMeshService(ctx, serviceName)
+	return resp
+}
+
+// checkMeshService returns the warning if the mesh service doesn't exist in the control plane.
+func (h *ValidateHook) checkMeshService(ctx context.Context, serviceName string) []string {
+	if h.ControlPlane == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, serviceCheckTimeout)
+	defer cancel()
+
+	service, err := h.ControlPlane.GetService(ctx, serviceName)
+	if err != nil {
+		// NOTE: The unavailable control plane shouldn't block the workloads.
+		h.Log.Error(err, "get mesh service", "service", serviceName)
+		return nil
+	}
+	if service == nil {
+		return []string{fmt.Sprintf("mesh service %s not found in the control plane, "+
+			"the sidecars can't serve until it's created by emctl apply", serviceName)}
+	}
+
+	return nil
+}
+
+// validateAnnotatedObject validates the mesh annotations of the native workload,
+// it returns empty service name if the object isn't annotated.
+func validateAnnotatedObject(req *admission.Request) (string, field.ErrorList, error) {
+	baseObject := &BaseObject{}
+	err := json.Unmarshal(req.Object.Raw, baseObject)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "unmarshal json %s to base object", req.String())
+	}
+
+	serviceName := baseObject.Annotations[annotationServiceNameKey]
+	if serviceName == "" {
+		return "", nil, nil
+	}
+
+	object := newObject(req.Kind.Kind)
+	err = json.Unmarshal(req.Object.Raw, object)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "unmarshal json %s", req.String())
+	}
+
+	return serviceName, validateAnnotations(baseObject.Annotations, getPodSpec(object)), nil
+}
+
+// validateAnnotations validates the mesh annotations with the pod spec they apply to.
+func validateAnnotations(annotations map[string]string, podSpec *corev1.PodSpec) field.ErrorList {
+	errs := field.ErrorList{}
+	annotationsPath := field.NewPath("metadata", "annotations")
+
+	if value, exists := annotations[annotationApplicationPortKey]; exists && value != "" {
+		port, err := strconv.ParseUint(value, 10, 16)
+		if err != nil || port == 0 {
+			errs = append(errs, field.Invalid(annotationsPath.Key(annotationApplicationPortKey),
+				value, "must be a port number between 1 and 65535"))
+		}
+	}
+
+	if value := annotations[annotationServiceLabels]; value != "" {
+		_, err := labelstool.Unmarshal(value)
+		if err != nil {
+			errs = append(errs, field.Invalid(annotationsPath.Key(annotationServiceLabels),
+				value, err.Error()))
+		}
+	}
+
+	if name := annotations[annotationAppContainerNameKey]; name != "" {
+		errs = append(errs, validateAppContainer(podSpec, name,
+			annotationsPath.Key(annotationAppContainerNameKey))...)
+	}
+
+	return errs
+}
+
+// validateMeshWorkload validates the mesh service and the deploy spec of the mesh workload.
+func validateMeshWorkload(req *admission.Request) (string, field.ErrorList, error) {
+	var workload *meshWorkload
+	switch req.Kind.Kind {
+	case "MeshDeployment":
+		meshDeploy := &meshv1beta1.MeshDeployment{}
+		err := json.Unmarshal(req.Object.Raw, meshDeploy)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "unmarshal json %s", req.String())
+		}
+		deploy := &meshDeploy.Spec.Deploy
+		workload = &meshWorkload{service: &meshDeploy.Spec.Service, selector: deploy.Selector, template: &deploy.Template}
+	case "MeshStatefulSet":
+		meshStatefulSet := &meshv1beta1.MeshStatefulSet{}
+		err := json.Unmarshal(req.Object.Raw, meshStatefulSet)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "unmarshal json %s", req.String())
+		}
+		deploy := &meshStatefulSet.Spec.Deploy
+		workload = &meshWorkload{service: &meshStatefulSet.Spec.Service, selector: deploy.Selector, template: &deploy.Template}
+	case "MeshDaemonSet":
+		meshDaemonSet := &meshv1beta1.MeshDaemonSet{}
+		err := json.Unmarshal(req.Object.Raw, meshDaemonSet)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "unmarshal json %s", req.String())
+		}
+		deploy := &meshDaemonSet.Spec.Deploy
+		workload = &meshWorkload{service: &meshDaemonSet.Spec.Service, selector: deploy.Selector, template: &deploy.Template}
+	default:
+		return "", nil, errors.Errorf("unsupported kind %s", req.Kind.Kind)
+	}
+
+	serviceName := workload.service.Name
+	if serviceName == "" {
+		serviceName = req.Name
+	}
+
+	return serviceName, workload.validate(field.NewPath("spec")), nil
+}
+
+func (w *meshWorkload) validate(specPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	deployPath := specPath.Child("deploy")
+	selectorPath := deployPath.Child("selector")
+	templatePath := deployPath.Child("template")
+
+	if len(w.template.Spec.Containers) == 0 {
+		errs = append(errs, field.Required(templatePath.Child("spec", "containers"), ""))
+	}
+
+	if name := w.service.AppContainerName; name != "" {
+		errs = append(errs, validateAppContainer(&w.template.Spec, name,
+			specPath.Child("service", "appContainerName"))...)
+	}
+
+	if w.selector == nil {
+		return append(errs, field.Required(selectorPath, ""))
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(w.selector)
+	if err != nil {
+		return append(errs, field.Invalid(selectorPath, w.selector, err.Error()))
+	}
+	if selector.Empty() {
+		return append(errs, field.Invalid(selectorPath, w.selector, "empty selector is invalid"))
+	}
+
+	// NOTE: The operator labels the pods with the matchLabels of the selector
+	// if the template has no labels, see the labels issue in the controllers.
+	templateLabels := w.template.Labels
+	if len(templateLabels) == 0 {
+		templateLabels = w.selector.MatchLabels
+	}
+	if !selector.Matches(labels.Set(templateLabels)) {
+		errs = append(errs, field.Invalid(templatePath.Child("metadata", "labels"),
+			w.template.Labels, "`selector` does not match template `labels`"))
+	}
+
+	return errs
+}
+
+// validateAppContainer validates the app container exists in the pod spec.
+func validateAppContainer(podSpec *corev1.PodSpec, name string, fldPath *field.Path) field.ErrorList {
+	names := []string{}
+	for _, container := range podSpec.Containers {
+		if container.Name == name {
+			return nil
+		}
+		names = append(names, container.Name)
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, name,
+		fmt.Sprintf("no such container in the pod, the containers are [%s]", strings.Join(names, ", ")))}
+}
+
+func deniedResp(errs field.ErrorList) admission.Response {
+	return admission.Denied(errs.ToAggregate().Error())
+}
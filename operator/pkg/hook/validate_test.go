@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+	"github.com/megaease/easemesh/mesh-operator/pkg/base"
+	"github.com/megaease/easemesh/mesh-operator/pkg/controlplane"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+type fakeServiceGetter struct {
+	services map[string]*controlplane.Service
+	err      error
+}
+
+var _ MeshServiceGetter = &fakeServiceGetter{}
+
+func (f *fakeServiceGetter) GetService(ctx context.Context, name string) (*controlplane.Service, error) {
+	return f.services[name], f.err
+}
+
+var _ = Describe("ValidateHook", func() {
+	podTemplate := func(labels map[string]string, containers ...string) corev1.PodTemplateSpec {
+		template := corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		}
+		for _, container := range containers {
+			template.Spec.Containers = append(template.Spec.Containers, corev1.Container{Name: container, Image: container})
+		}
+		return template
+	}
+
+	deployment := func(annotations map[string]string, containers ...string) runtime.Object {
+		return &v1.Deployment{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "vets",
+				Annotations: annotations,
+			},
+			Spec: v1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "vets"}},
+				Template: podTemplate(map[string]string{"app": "vets"}, containers...),
+			},
+		}
+	}
+
+	meshDeployment := func(appContainerName string, selector, templateLabels map[string]string) runtime.Object {
+		return &meshv1beta1.MeshDeployment{
+			TypeMeta: metav1.TypeMeta{APIVersion: "mesh.megaease.com/v1beta1", Kind: "MeshDeployment"},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "vets",
+			},
+			Spec: meshv1beta1.MeshDeploymentSpec{
+				Service: meshv1beta1.ServiceSpec{Name: "vets-service", AppContainerName: appContainerName},
+				Deploy: meshv1beta1.DeploySpec{DeploymentSpec: v1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: selector},
+					Template: podTemplate(templateLabels, "vets"),
+				}},
+			},
+		}
+	}
+
+	request := func(object runtime.Object) admission.Request {
+		raw, err := json.Marshal(object)
+		Expect(err).NotTo(HaveOccurred())
+
+		kind := object.GetObjectKind().GroupVersionKind()
+		return admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "validate-uid",
+				Kind:      metav1.GroupVersionKind{Group: kind.Group, Version: kind.Version, Kind: kind.Kind},
+				Namespace: "default",
+				Name:      "vets",
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		}
+	}
+
+	newHook := func(controlPlane MeshServiceGetter) *ValidateHook {
+		return NewValidateHook(&base.Runtime{
+			Name: "test-runtime-name",
+			Log:  logr.Discard(),
+		}, controlPlane)
+	}
+
+	table.DescribeTable("denies the invalid objects",
+		func(object runtime.Object, reason string) {
+			resp := newHook(nil).Admission.Handle(context.Background(), request(object))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(ContainSubstring(reason))
+		},
+		table.Entry("with the invalid application port",
+			deployment(map[string]string{
+				annotationServiceNameKey:     "vets-service",
+				annotationApplicationPortKey: "http",
+			}, "vets"),
+			fmt.Sprintf("metadata.annotations[%s]: Invalid value: \"http\"", annotationApplicationPortKey)),
+		table.Entry("with the zero application port",
+			deployment(map[string]string{
+				annotationServiceNameKey:     "vets-service",
+				annotationApplicationPortKey: "0",
+			}, "vets"),
+			"must be a port number between 1 and 65535"),
+		table.Entry("with the invalid service labels",
+			deployment(map[string]string{
+				annotationServiceNameKey: "vets-service",
+				annotationServiceLabels:  "version=beta,canary",
+			}, "vets"),
+			`invalid label "canary", want key=value`),
+		table.Entry("with the missing app container",
+			deployment(map[string]string{
+				annotationServiceNameKey:      "vets-service",
+				annotationAppContainerNameKey: "app",
+			}, "vets", "logger"),
+			"no such container in the pod, the containers are [vets, logger]"),
+		table.Entry("with the missing app container of the mesh deployment",
+			meshDeployment("app", map[string]string{"app": "vets"}, map[string]string{"app": "vets"}),
+			"spec.service.appContainerName: Invalid value: \"app\""),
+		table.Entry("with the selector mismatching the template",
+			meshDeployment("", map[string]string{"app": "vets"}, map[string]string{"app": "visits"}),
+			"`selector` does not match template `labels`"),
+	)
+
+	It("allows the valid objects", func() {
+		object := deployment(map[string]string{
+			annotationServiceNameKey:      "vets-service",
+			annotationApplicationPortKey:  "8080",
+			annotationServiceLabels:       "version=beta",
+			annotationAppContainerNameKey: "vets",
+		}, "vets")
+
+		resp := newHook(nil).Admission.Handle(context.Background(), request(object))
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(resp.Warnings).To(BeEmpty())
+	})
+
+	table.DescribeTable("warns the missing mesh service",
+		func(controlPlane *fakeServiceGetter, warnings []string) {
+			object := meshDeployment("vets", map[string]string{"app": "vets"}, map[string]string{"app": "vets"})
+
+			resp := newHook(controlPlane).Admission.Handle(context.Background(), request(object))
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Warnings).To(Equal(warnings))
+		},
+		table.Entry("if the control plane hasn't the service",
+			&fakeServiceGetter{services: map[string]*controlplane.Service{}},
+			[]string{"mesh service vets-service not found in the control plane, " +
+				"the sidecars can't serve until it's created by emctl apply"}),
+		table.Entry("unless the control plane has the service",
+			&fakeServiceGetter{services: map[string]*controlplane.Service{"vets-service": {}}},
+			nil),
+		table.Entry("unless the control plane is unavailable",
+			&fakeServiceGetter{err: fmt.Errorf("connection refused")},
+			nil),
+	)
+})
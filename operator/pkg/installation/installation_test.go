@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package installation
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+
+	"gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const manifests = `
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: easemesh
+---
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: easemesh-control-plane-public
+  namespace: easemesh
+spec:
+  ports:
+  - name: peer-port
+    port: 2380
+  - name: admin-port
+    port: 2381
+`
+
+var _ = Describe("Installation", func() {
+	It("converts the spec to the spec file of emctl", func() {
+		spec := &meshv1beta1.EaseMeshInstallationSpec{
+			MeshNamespace:                 "mesh",
+			EasegressControlPlaneReplicas: 1,
+			MeshControlPlaneNodeSelectors: map[string]string{"mesh": "true"},
+			AddOns:                        []string{"shadowservice"},
+		}
+
+		specFile, err := SpecFile(spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		fields := map[string]interface{}{}
+		Expect(yaml.Unmarshal(specFile, fields)).To(Succeed())
+		Expect(fields).To(HaveLen(3))
+		Expect(fields).To(HaveKeyWithValue("easegresscontrolplanereplicas", 1))
+		Expect(fields).To(HaveKey("meshcontrolplanenodeselectors"))
+		Expect(fields).To(HaveKey("addons"))
+	})
+
+	It("maps every field of the spec to the install flags of emctl", func() {
+		// NOTE: The install flags of emctl are parsed from its source, since the
+		// operator can't import emctl.
+		file, err := parser.ParseFile(token.NewFileSet(), "../../../emctl/cmd/client/command/flags/flags.go", nil, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		installFields := map[string]bool{}
+		ast.Inspect(file, func(n ast.Node) bool {
+			typeSpec, ok := n.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != "Install" {
+				return true
+			}
+			for _, field := range typeSpec.Type.(*ast.StructType).Fields.List {
+				for _, name := range field.Names {
+					installFields[strings.ToLower(name.Name)] = true
+				}
+			}
+			return false
+		})
+		Expect(installFields).NotTo(BeEmpty())
+
+		specType := reflect.TypeOf(meshv1beta1.EaseMeshInstallationSpec{})
+		for i := 0; i < specType.NumField(); i++ {
+			name := strings.Split(specType.Field(i).Tag.Get("json"), ",")[0]
+			if name == "meshNamespace" {
+				continue
+			}
+			Expect(specFileKeys).To(HaveKey(name))
+			Expect(installFields).To(HaveKey(specFileKeys[name]))
+		}
+	})
+
+	It("rejects the fields unknown to the spec file of emctl", func() {
+		delete(specFileKeys, "addOns")
+		defer func() { specFileKeys["addOns"] = "addons" }()
+
+		_, err := SpecFile(&meshv1beta1.EaseMeshInstallationSpec{AddOns: []string{"shadowservice"}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("decodes the manifests and finds the admin URL", func() {
+		objects, err := DecodeManifests(strings.NewReader(manifests))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects).To(HaveLen(2))
+
+		url, err := AdminURL("easemesh", objects)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(url).To(Equal("http://easemesh-control-plane-public.easemesh:2381"))
+
+		_, err = AdminURL("easemesh", objects[:1])
+		Expect(err).To(HaveOccurred())
+	})
+})
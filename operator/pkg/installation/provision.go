@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package installation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// ControlPlaneStatefulSetName is the name of the statefulset of the control plane.
+	ControlPlaneStatefulSetName = "easemesh-control-plane"
+	// ControlPlanePublicServiceName is the name of the public service of the control plane.
+	ControlPlanePublicServiceName = "easemesh-control-plane-public"
+
+	controlPlaneAdminPortName = "admin-port"
+	meshControllerName        = "easemesh-controller"
+	meshControllerKind        = "MeshController"
+	meshControllerAPIPort     = 13009
+	objectsURL                = "/apis/v2/objects"
+)
+
+// meshControllerConfig is the config of the EaseMesh controller, the same as the one
+// provisioned by `emctl install`.
+type meshControllerConfig struct {
+	Name              string `yaml:"name"`
+	Kind              string `yaml:"kind"`
+	RegistryType      string `yaml:"registryType"`
+	HeartbeatInterval string `yaml:"heartbeatInterval"`
+	IngressPort       int32  `yaml:"ingressPort"`
+	APIPort           int    `yaml:"apiPort"`
+}
+
+// AdminURL returns the admin URL of the control plane according to its rendered public service.
+func AdminURL(namespace string, objects []*unstructured.Unstructured) (string, error) {
+	for _, obj := range objects {
+		if obj.GetKind() != "Service" || obj.GetName() != ControlPlanePublicServiceName {
+			continue
+		}
+
+		ports, _, err := unstructured.NestedSlice(obj.Object, "spec", "ports")
+		if err != nil {
+			return "", errors.Wrapf(err, "get ports of service %s", obj.GetName())
+		}
+		for _, p := range ports {
+			port, ok := p.(map[string]interface{})
+			if !ok || port["name"] != controlPlaneAdminPortName {
+				continue
+			}
+			number, _, err := unstructured.NestedInt64(port, "port")
+			if err != nil {
+				return "", errors.Wrapf(err, "get admin port of service %s", obj.GetName())
+			}
+			return fmt.Sprintf("http://%s.%s:%d", ControlPlanePublicServiceName, namespace, number), nil
+		}
+	}
+
+	return "", errors.Errorf("service %s with port %s not found in the rendered objects",
+		ControlPlanePublicServiceName, controlPlaneAdminPortName)
+}
+
+// ProvisionMeshController creates the EaseMesh controller in the control plane,
+// it's fine if the controller exists already.
+func ProvisionMeshController(ctx context.Context, adminURL string, spec *meshv1beta1.EaseMeshInstallationSpec) error {
+	config := &meshControllerConfig{
+		Name:              meshControllerName,
+		Kind:              meshControllerKind,
+		RegistryType:      spec.EaseMeshRegistryType,
+		HeartbeatInterval: strconv.Itoa(spec.HeartbeatInterval) + "s",
+		IngressPort:       spec.MeshIngressServicePort,
+		APIPort:           meshControllerAPIPort,
+	}
+	body, err := yaml.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "marshal mesh controller config")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, adminURL+objectsURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Content-Type", "text/vnd.yaml")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "call control plane %s", adminURL)
+	}
+	defer resp.Body.Close()
+
+	// NOTE: 409 represents the mesh controller exists already.
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusConflict {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("control plane %s returned status code %d, body: %s",
+			adminURL, resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
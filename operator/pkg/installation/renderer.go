@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package installation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	meshv1beta1 "github.com/megaease/easemesh/mesh-operator/pkg/api/v1beta1"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// DefaultEmctlPath is the path of emctl in the image of the operator.
+const DefaultEmctlPath = "/usr/local/bin/emctl"
+
+type (
+	// Renderer renders the Kubernetes objects of the installation spec.
+	Renderer interface {
+		Render(ctx context.Context, spec *meshv1beta1.EaseMeshInstallationSpec) ([]*unstructured.Unstructured, error)
+	}
+
+	// EmctlRenderer renders the objects by `emctl install --render`, so the installation
+	// shares the spec builders with emctl.
+	//
+	// NOTE: The builders can't be imported by the operator until it moves to the Kubernetes
+	// libraries of emctl, which requires controller-runtime v0.10 at least.
+	EmctlRenderer struct {
+		Path string
+	}
+)
+
+var _ Renderer = &EmctlRenderer{}
+
+// Render implements Renderer.
+func (r *EmctlRenderer) Render(ctx context.Context, spec *meshv1beta1.EaseMeshInstallationSpec) ([]*unstructured.Unstructured, error) {
+	specFile, err := SpecFile(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile("", "easemesh-installation-*.yaml")
+	if err != nil {
+		return nil, errors.Wrap(err, "create spec file")
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(specFile)
+	f.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "write spec file")
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, r.Path, "install", "--render", "--output", "-",
+		"--file", f.Name(), "--mesh-namespace", spec.MeshNamespace)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err = cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "render by %s: %s", r.Path, strings.TrimSpace(stderr.String()))
+	}
+
+	return DecodeManifests(stdout)
+}
+
+// specFileKeys maps the fields of the spec to the keys of the spec file of `emctl install`,
+// which are the lower-cased field names of its install flags. The keys are pinned by the
+// test against the install flags of emctl, so renaming any of them fails the test.
+var specFileKeys = map[string]string{
+	"imageRegistryURL":                      "imageregistryurl",
+	"imagePullPolicy":                       "imagepullpolicy",
+	"easegressImage":                        "easegressimage",
+	"easegressControlPlaneReplicas":         "easegresscontrolplanereplicas",
+	"egClientPort":                          "egclientport",
+	"egAdminPort":                           "egadminport",
+	"egPeerPort":                            "egpeerport",
+	"egServicePeerPort":                     "egservicepeerport",
+	"egServiceAdminPort":                    "egserviceadminport",
+	"meshControlPlaneStorageClassName":      "meshcontrolplanestorageclassname",
+	"meshControlPlanePersistVolumeName":     "meshcontrolplanepersistvolumename",
+	"meshControlPlanePersistVolumeHostPath": "meshcontrolplanepersistvolumehostpath",
+	"meshControlPlanePersistVolumeCapacity": "meshcontrolplanepersistvolumecapacity",
+	"meshControlPlaneNodeSelectors":         "meshcontrolplanenodeselectors",
+	"meshIngressReplicas":                   "meshingressreplicas",
+	"meshIngressServicePort":                "meshingressserviceport",
+	"addOns":                                "addons",
+	"shadowServiceControllerImage":          "shadowservicecontrollerimage",
+	"easeMeshRegistryType":                  "easemeshregistrytype",
+	"heartbeatInterval":                     "heartbeatinterval",
+	"easeMeshOperatorImage":                 "easemeshoperatorimage",
+	"easeMeshOperatorReplicas":              "easemeshoperatorreplicas",
+	"rbacProxyImage":                        "rbacproxyimage",
+	"sidecarImage":                          "sidecarimage",
+	"agentInitializerImage":                 "agentinitializerimage",
+	"webhookCertMode":                       "webhookcertmode",
+	"components":                            "components",
+}
+
+// SpecFile converts the spec to the spec file of `emctl install`, it fails if any field
+// of the spec is unknown to the spec file, instead of being ignored by emctl silently.
+func SpecFile(spec *meshv1beta1.EaseMeshInstallationSpec) ([]byte, error) {
+	buff, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal spec")
+	}
+
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(buff, &fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal spec")
+	}
+
+	// NOTE: The mesh namespace is passed by the flag, it's not a top-level key of the spec file.
+	delete(fields, "meshNamespace")
+
+	specFile := map[string]interface{}{}
+	for k, v := range fields {
+		key, exists := specFileKeys[k]
+		if !exists {
+			return nil, errors.Errorf("field %s is not supported by the spec file of emctl", k)
+		}
+		specFile[key] = v
+	}
+
+	return yaml.Marshal(specFile)
+}
+
+// DecodeManifests decodes the multi-document manifests, the empty documents are skipped.
+func DecodeManifests(r io.Reader) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+
+	var objects []*unstructured.Unstructured
+	for {
+		raw := json.RawMessage{}
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "decode manifests")
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		// NOTE: Unmarshalling by Unstructured keeps the integers as int64.
+		obj := &unstructured.Unstructured{}
+		err = obj.UnmarshalJSON(raw)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode object")
+		}
+		if obj.GetName() == "" {
+			return nil, errors.Errorf("invalid %s without name", obj.GetKind())
+		}
+
+		objects = append(objects, obj)
+	}
+}
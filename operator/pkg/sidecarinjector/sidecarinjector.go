@@ -24,6 +24,7 @@ import (
 
 	"github.com/megaease/easemesh/mesh-operator/pkg/base"
 	"github.com/megaease/easemesh/mesh-operator/pkg/util/labelstool"
+	"github.com/megaease/easemesh/mesh-operator/pkg/version"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -48,16 +49,40 @@ var (
 
 	// Init container stuff.
 	initContainerName      = "initializer"
-	initContainerImageName = func(customImage string, spec *meshControllerSpec) string {
+	initContainerImageName = func(customImage string, spec *meshControllerSpec) (string, error) {
 		if customImage != "" {
-			return customImage
+			return customImage, nil
 		}
 
 		if spec.AgentInitializerImageName != "" {
-			return spec.AgentInitializerImageName
+			return spec.AgentInitializerImageName, nil
 		}
 
-		return "megaease/easeagent-initializer:latest"
+		return version.CurrentImage(version.ComponentAgentInitializer)
+	}
+
+	// NOTE: The node labels of topology are copied to the pod by the locality webhook
+	// of the operator when it's bound to the node, which are exposed to the init container
+	// by the downward API, and become the labels of the service instance for the locality load balance.
+	initContainerZoneEnvName   = "MESH_LOCALITY_ZONE"
+	initContainerRegionEnvName = "MESH_LOCALITY_REGION"
+	initContainerEnvs          = []corev1.EnvVar{
+		{
+			Name: initContainerZoneEnvName,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: fmt.Sprintf("metadata.labels['%s']", corev1.LabelTopologyZone),
+				},
+			},
+		},
+		{
+			Name: initContainerRegionEnvName,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: fmt.Sprintf("metadata.labels['%s']", corev1.LabelTopologyRegion),
+				},
+			},
+		},
 	}
 
 	initContainerAgentVolumeName        = "agent-volume"
@@ -98,14 +123,16 @@ var (
 
 	// Sidecar container stuff.
 	sidecarContainerName      = "easemesh-sidecar"
-	sidecarContainerImageName = func(customImage string, spec *meshControllerSpec) string {
+	sidecarContainerImageName = func(customImage string, spec *meshControllerSpec) (string, error) {
 		if customImage != "" {
-			return customImage
+			return customImage, nil
 		}
+
 		if spec.SidecarImageName != "" {
-			return spec.SidecarImageName
+			return spec.SidecarImageName, nil
 		}
-		return "megaease/easegress:server-sidecar"
+
+		return version.CurrentImage(version.ComponentSidecar)
 	}
 
 	sidecarContainerVolumeName      = initContainerSidecarVolumeName
@@ -131,6 +158,16 @@ var (
 		},
 	}
 
+	// NOTE: The sidecar registers the value of HOSTNAME as the instance ID,
+	// so the pod name is stable as the instance ID even if the hostname of the pod
+	// is overridden by spec.hostname.
+	sidecarContainerInstanceIDEnvName  = "HOSTNAME"
+	sidecarContainerInstanceIDEnvValue = &corev1.EnvVarSource{
+		FieldRef: &corev1.ObjectFieldSelector{
+			FieldPath: "metadata.name",
+		},
+	}
+
 	sidecarContainerIngressPortName          = "sidecar-ingress"
 	sidecarContainerIngressPortContainerPort = int32(13001)
 	sidecarContainerEgressPortName           = "sidecar-egress"
@@ -160,10 +197,21 @@ var (
 	}
 )
 
+// StatefulSetInstanceID returns the instance ID registered by the sidecar of the pod of
+// the ordinal of the StatefulSet with StableInstanceID, which is the name of the pod since
+// the sidecar config written by the init container leaves the instance ID to HOSTNAME.
+func StatefulSetInstanceID(statefulSetName string, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", statefulSetName, ordinal)
+}
+
 func initContainerCommand(service *MeshService) []string {
 	const cmdTemplate = `set -e
 cp -r /easeagent-volume/* %s
 
+labels='%s'
+if [ -n "$%s" ]; then labels="${labels:+$labels,}%s=$%s"; fi
+if [ -n "$%s" ]; then labels="${labels:+$labels,}%s=$%s"; fi
+
 echo 'name: %s
 cluster-name: easemesh-control-plane
 cluster-role: secondary
@@ -173,18 +221,21 @@ cluster:
 labels:
   mesh-alive-probe: %s
   mesh-application-port: %d
-  mesh-service-labels: %s
+  mesh-service-labels: '"$labels"'
   mesh-service-name: %s
 ' > %s`
 
 	cmd := fmt.Sprintf(cmdTemplate,
 		initContainerAgentVolumeMountPath,
 
+		labelstool.Marshal(service.Labels),
+		initContainerZoneEnvName, corev1.LabelTopologyZone, initContainerZoneEnvName,
+		initContainerRegionEnvName, corev1.LabelTopologyRegion, initContainerRegionEnvName,
+
 		service.Name,
 
 		service.AliveProbeURL,
 		service.ApplicationPort,
-		labelstool.Marshal(service.Labels),
 		service.Name,
 
 		initContainerSidecarConfigPath)
@@ -225,6 +276,10 @@ type (
 
 		// SidecarImage could overlap the default image of the sidecar
 		SidecarImage string
+
+		// StableInstanceID registers the pod name as the instance ID,
+		// which is stable for the pods of StatefulSet such as <name>-<ordinal>.
+		StableInstanceID bool
 	}
 )
 
@@ -247,8 +302,14 @@ func (m *SidecarInjector) Inject() error {
 	}
 
 	m.injectVolumes(volumes...)
-	m.injectInitContainer()
-	m.injectSidecarContainer()
+	err = m.injectInitContainer()
+	if err != nil {
+		return errors.Wrap(err, "inject init container")
+	}
+	err = m.injectSidecarContainer()
+	if err != nil {
+		return errors.Wrap(err, "inject sidecar container")
+	}
 
 	err = m.adaptAppContainerSpec()
 	if err != nil {
@@ -258,6 +319,26 @@ func (m *SidecarInjector) Inject() error {
 	return nil
 }
 
+// SidecarImage returns the image of the injected sidecar of the pod,
+// it returns empty string if the pod is not injected.
+func SidecarImage(pod *corev1.PodSpec) string {
+	container, exists := findContainer(pod.Containers, sidecarContainerName)
+	if !exists {
+		return ""
+	}
+	return container.Image
+}
+
+// InitializerImage returns the image of the injected init container of the pod,
+// it returns empty string if the pod is not injected.
+func InitializerImage(pod *corev1.PodSpec) string {
+	container, exists := findContainer(pod.InitContainers, initContainerName)
+	if !exists {
+		return ""
+	}
+	return container.Image
+}
+
 func (m *SidecarInjector) setupMeshService() error {
 	if len(m.pod.Containers) == 0 {
 		return fmt.Errorf("empty containers")
@@ -318,16 +399,24 @@ func (m *SidecarInjector) injectVolumes(volumes ...corev1.Volume) {
 	}
 }
 
-func (m *SidecarInjector) injectInitContainer() {
+func (m *SidecarInjector) injectInitContainer() error {
+	image, err := initContainerImageName(m.meshService.InitContainerImage, m.dynamicSpec.spec())
+	if err != nil {
+		return err
+	}
+
 	initContainer := corev1.Container{
 		Name:            initContainerName,
-		Image:           m.completeImageURL(initContainerImageName(m.meshService.InitContainerImage, m.dynamicSpec.spec())),
+		Image:           m.completeImageURL(image),
 		ImagePullPolicy: corev1.PullPolicy(m.dynamicSpec.spec().ImagePullPolicy),
 		Command:         initContainerCommand(m.meshService),
+		Env:             initContainerEnvs,
 		VolumeMounts:    initContainerVolumeMounts,
 	}
 
 	m.pod.InitContainers = injectContainers(m.pod.InitContainers, initContainer)
+
+	return nil
 }
 
 func (m *SidecarInjector) adaptAppContainerSpec() error {
@@ -358,18 +447,33 @@ func (m *SidecarInjector) adaptAppContainerSpec() error {
 	return nil
 }
 
-func (m *SidecarInjector) injectSidecarContainer() {
+func (m *SidecarInjector) injectSidecarContainer() error {
+	image, err := sidecarContainerImageName(m.meshService.SidecarImage, m.dynamicSpec.spec())
+	if err != nil {
+		return err
+	}
+
+	envs := sidecarContainerEnvs
+	if m.meshService.StableInstanceID {
+		envs = injectEnvVars(append([]corev1.EnvVar{}, envs...), corev1.EnvVar{
+			Name:      sidecarContainerInstanceIDEnvName,
+			ValueFrom: sidecarContainerInstanceIDEnvValue,
+		})
+	}
+
 	sidecarContainer := corev1.Container{
 		Name:            sidecarContainerName,
-		Image:           m.completeImageURL(sidecarContainerImageName(m.meshService.SidecarImage, m.dynamicSpec.spec())),
+		Image:           m.completeImageURL(image),
 		ImagePullPolicy: corev1.PullPolicy(m.dynamicSpec.spec().ImagePullPolicy),
 		Command:         sidecarContainerCmd,
 		VolumeMounts:    sidecarContainerVolumeMounts,
-		Env:             sidecarContainerEnvs,
+		Env:             envs,
 		Ports:           sidecarContainerPorts,
 	}
 
 	m.pod.Containers = injectContainers(m.pod.Containers, sidecarContainer)
+
+	return nil
 }
 
 func (m *SidecarInjector) completeImageURL(imageName string) string {
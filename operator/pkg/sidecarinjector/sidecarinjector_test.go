@@ -19,11 +19,13 @@ package sidecarinjector
 
 import (
 	_ "embed"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/megaease/easemesh/mesh-operator/pkg/base"
 
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
 
 	. "github.com/onsi/ginkgo"
@@ -73,4 +75,74 @@ var _ = Describe("SidecarInjector", func() {
 
 		Expect(originalDeploy.Spec.Template.Spec).To(Equal(wantDeploy.Spec.Template.Spec))
 	})
+
+	It("reports injected images", func() {
+		originalDeploy := &v1.Deployment{}
+		wantDeploy := &v1.Deployment{}
+
+		Expect(yaml.Unmarshal([]byte(originalDeployStr), originalDeploy)).To(Succeed())
+		Expect(yaml.Unmarshal([]byte(wantDeployStr), wantDeploy)).To(Succeed())
+
+		Expect(SidecarImage(&originalDeploy.Spec.Template.Spec)).To(BeEmpty())
+		Expect(InitializerImage(&originalDeploy.Spec.Template.Spec)).To(BeEmpty())
+
+		Expect(SidecarImage(&wantDeploy.Spec.Template.Spec)).To(Equal("megaease/easegress:easemesh"))
+		Expect(InitializerImage(&wantDeploy.Spec.Template.Spec)).To(Equal("megaease/easeagent-initializer:latest"))
+	})
+
+	It("registers the pod name as the instance ID", func() {
+		originalDeploy := &v1.Deployment{}
+		Expect(yaml.Unmarshal([]byte(originalDeployStr), originalDeploy)).To(Succeed())
+
+		baseRuntime := &base.Runtime{
+			Name:            "test-runtime-name",
+			ImagePullPolicy: "IfNotPresent",
+			Log:             logr.Discard(),
+		}
+
+		service := &MeshService{
+			Name:             "vets-service",
+			AppContainerName: "vets-service",
+			StableInstanceID: true,
+		}
+
+		pod := &originalDeploy.Spec.Template.Spec
+		Expect(New(baseRuntime, service, pod).Inject()).To(Succeed())
+
+		sidecar, exists := findContainer(pod.Containers, sidecarContainerName)
+		Expect(exists).To(BeTrue())
+		Expect(sidecar.Env).To(ContainElement(corev1.EnvVar{
+			Name: "HOSTNAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		}))
+		Expect(sidecarContainerEnvs).To(HaveLen(1))
+	})
+
+	It("leaves the instance ID to HOSTNAME in the sidecar config", func() {
+		service := &MeshService{
+			Name:   "vets-service",
+			Labels: map[string]string{"version": "v1"},
+		}
+
+		// NOTE: The config is echoed by the shell, the labels are expanded by it.
+		cmd := initContainerCommand(service)[2]
+		begin := strings.Index(cmd, "echo '") + len("echo '")
+		end := strings.LastIndex(cmd, "' > "+initContainerSidecarConfigPath)
+		Expect(begin).To(BeNumerically(">", len("echo '")))
+		Expect(end).To(BeNumerically(">", begin))
+		config := strings.ReplaceAll(cmd[begin:end], `'"$labels"'`, "version=v1")
+
+		spec := map[string]interface{}{}
+		Expect(yaml.Unmarshal([]byte(config), &spec)).To(Succeed())
+		Expect(spec).To(HaveKeyWithValue("name", "vets-service"))
+		Expect(spec).To(HaveLen(6))
+		for _, key := range []string{"name", "cluster-name", "cluster-role", "cluster-request-timeout", "cluster", "labels"} {
+			Expect(spec).To(HaveKey(key))
+		}
+
+		// NOTE: StatefulSets name their pods by the ordinals.
+		Expect(StatefulSetInstanceID("vets-service", 2)).To(Equal("vets-service-2"))
+	})
 })
@@ -49,7 +49,7 @@ func Unmarshal(s string) (map[string]string, error) {
 	for _, kv := range kvs {
 		label := strings.Split(kv, "=")
 		if len(label) != 2 {
-			return nil, fmt.Errorf("invalid labels")
+			return nil, fmt.Errorf("invalid label %q, want key=value", kv)
 		}
 		result[label[0]] = label[1]
 	}
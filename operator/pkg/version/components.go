@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2021, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package version
+
+import (
+	_ "embed" // for the manifest of the components
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// The names of the components injected by the operator.
+const (
+	ComponentSidecar          = "sidecar"
+	ComponentAgentInitializer = "agent-initializer"
+)
+
+// NOTE: The manifest is generated from the one of emctl by `make components-sync`.
+//
+//go:embed components.yaml
+var componentsYAML []byte
+
+type (
+	// ComponentsManifest maps every release to the images of its components.
+	ComponentsManifest struct {
+		Releases []*ComponentsRelease `yaml:"releases"`
+	}
+
+	// ComponentsRelease is the components of a release.
+	ComponentsRelease struct {
+		Release    string       `yaml:"release"`
+		Components []*Component `yaml:"components"`
+	}
+
+	// Component is the image of a component pinned by its digest.
+	Component struct {
+		Name   string `yaml:"name"`
+		Image  string `yaml:"image"`
+		Digest string `yaml:"digest"`
+	}
+)
+
+// ParseComponentsManifest parses the manifest of the components.
+func ParseComponentsManifest(buff []byte) (*ComponentsManifest, error) {
+	manifest := &ComponentsManifest{}
+	err := yaml.Unmarshal(buff, manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal components manifest")
+	}
+	if len(manifest.Releases) == 0 {
+		return nil, errors.Errorf("no release in components manifest")
+	}
+
+	return manifest, nil
+}
+
+// CurrentComponents returns the components of the release of the operator, the builds
+// without a known release use the latest one.
+func CurrentComponents() (*ComponentsRelease, error) {
+	manifest, err := ParseComponentsManifest(componentsYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	release := strings.TrimPrefix(RELEASE, "v")
+	for _, r := range manifest.Releases {
+		if strings.TrimPrefix(r.Release, "v") == release {
+			return r, nil
+		}
+	}
+
+	return manifest.Releases[len(manifest.Releases)-1], nil
+}
+
+// CurrentImage returns the pinned image of the component of the release of the operator.
+func CurrentImage(name string) (string, error) {
+	r, err := CurrentComponents()
+	if err != nil {
+		return "", err
+	}
+	return r.Image(name)
+}
+
+// Image returns the pinned image of the component, it fails if the component
+// is not in the release.
+func (r *ComponentsRelease) Image(name string) (string, error) {
+	for _, c := range r.Components {
+		if c.Name == name {
+			return c.Reference(), nil
+		}
+	}
+	return "", errors.Errorf("component %s not found in release %s", name, r.Release)
+}
+
+// Reference returns the image pinned by the digest, the image with the tag
+// only if the digest has not been resolved.
+func (c *Component) Reference() string {
+	if c.Digest == "" {
+		return c.Image
+	}
+	return c.Image + "@" + c.Digest
+}